@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// command 管理子命令，直接操作数据库，不启动 HTTP 服务
+type command struct {
+	Group string // 一级命令，如 backup
+	Name  string // 二级命令，为空表示一级命令本身即可执行
+	Usage string // 参数说明
+	Brief string
+	Run   func(ctx context.Context, fs *flag.FlagSet, args []string) error
+}
+
+var commands []*command
+
+func registerCommand(cmd *command) {
+	commands = append(commands, cmd)
+}
+
+// findCommand 按 group name 匹配，返回命令和剩余参数
+func findCommand(args []string) (*command, []string) {
+	for _, cmd := range commands {
+		if cmd.Group != args[0] {
+			continue
+		}
+
+		if cmd.Name == "" {
+			return cmd, args[1:]
+		}
+
+		if len(args) > 1 && cmd.Name == args[1] {
+			return cmd, args[2:]
+		}
+	}
+
+	return nil, nil
+}
+
+// runCommand 执行子命令，返回进程退出码
+func runCommand(ctx context.Context, args []string) int {
+	cmd, rest := findCommand(args)
+	if cmd == nil {
+		if args[0] != "help" {
+			fmt.Fprintf(os.Stderr, "未知命令：%s\n\n", strings.Join(args, " "))
+		}
+
+		printUsage()
+
+		return 2
+	}
+
+	if err := cmd.Run(ctx, cmd.newFlagSet(), rest); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 2
+		}
+
+		fmt.Fprintln(os.Stderr, "错误：", err)
+
+		return 1
+	}
+
+	return 0
+}
+
+// newFlagSet 子命令自己的参数，全局参数（如 -config）需写在子命令之前
+func (c *command) newFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(c.fullName(), flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "用法：%s %s\n%s\n", c.fullName(), c.Usage, c.Brief)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// parseFlags 解析参数，允许参数写在位置参数之后（如 scan 1 -deep），返回位置参数
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+func (c *command) fullName() string {
+	return strings.TrimSpace(c.Group + " " + c.Name)
+}
+
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "用法：%s [-config etc/config.yaml] <命令> [参数]\n\n不带命令时启动服务。可用命令：\n", os.Args[0])
+
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %s %s\n      %s\n", cmd.fullName(), cmd.Usage, cmd.Brief)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/xxcheng123/cloudpan189-share/configs"
+	"github.com/xxcheng123/cloudpan189-share/internal/backup"
+)
+
+func init() {
+	registerCommand(&command{
+		Group: "backup",
+		Name:  "export",
+		Usage: "-o <文件> -passphrase <口令>",
+		Brief: "导出配置归档",
+		Run:   backupExport,
+	})
+
+	registerCommand(&command{
+		Group: "backup",
+		Name:  "import",
+		Usage: "-i <文件> -passphrase <口令> [-mode merge|replace] [-dry-run]",
+		Brief: "导入配置归档并扫描挂载点",
+		Run:   backupImport,
+	})
+
+	registerCommand(&command{
+		Group: "backup",
+		Name:  "snapshot",
+		Usage: "[-dir <目录>]",
+		Brief: "立即备份数据库",
+		Run:   backupSnapshot,
+	})
+}
+
+func backupExport(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	output := fs.String("o", "", "输出文件，为空时输出到标准输出")
+	passphrase := fs.String("passphrase", os.Getenv("SHARE_BACKUP_PASSPHRASE"), "加密口令，默认取环境变量 SHARE_BACKUP_PASSPHRASE")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	archive, err := backup.Export(ctx, configs.DB(), *passphrase)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(append(data, '\n'))
+
+		return err
+	}
+
+	// 归档中含加密后的令牌，仅允许当前用户读取
+	if err = os.WriteFile(*output, data, 0o600); err != nil {
+		return err
+	}
+
+	fmt.Printf("已导出：挂载点 %d 个，云盘令牌 %d 个，用户 %d 个 -> %s\n",
+		len(archive.Mounts), len(archive.CloudTokens), len(archive.Users), *output)
+
+	return nil
+}
+
+func backupImport(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	input := fs.String("i", "", "归档文件")
+	passphrase := fs.String("passphrase", os.Getenv("SHARE_BACKUP_PASSPHRASE"), "加密口令，默认取环境变量 SHARE_BACKUP_PASSPHRASE")
+	mode := fs.String("mode", backup.ModeMerge, "导入模式：merge 只新增，replace 以归档为准覆盖并删除多余项")
+	dryRun := fs.Bool("dry-run", false, "只输出变更报告，不写入")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *input == "" {
+		return errors.New("请使用 -i 指定归档文件")
+	}
+
+	file, err := os.Open(*input)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	archive := new(backup.Archive)
+	if err = json.NewDecoder(file).Decode(archive); err != nil {
+		return errors.Wrap(err, "归档文件格式错误")
+	}
+
+	// 导入后需要扫描新挂载点、删除多余挂载点，由总线完成
+	if !*dryRun {
+		startBus()
+	}
+
+	report, err := backup.Import(ctx, configs.DB(), archive, backup.ImportOptions{
+		Passphrase: *passphrase,
+		Mode:       *mode,
+		DryRun:     *dryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	printImportReport(report)
+
+	if *dryRun {
+		return nil
+	}
+
+	fmt.Println("等待挂载点扫描完成……")
+
+	return waitBus(ctx)
+}
+
+func printImportReport(report *backup.ImportReport) {
+	title := "导入完成"
+	if report.DryRun {
+		title = "预览（未写入）"
+	}
+
+	fmt.Printf("%s，模式：%s\n", title, report.Mode)
+
+	for _, change := range report.Changes {
+		line := fmt.Sprintf("  %-8s %-12s %s", change.Op, change.Kind, change.Name)
+		if change.Reason != "" {
+			line += "（" + change.Reason + "）"
+		}
+
+		fmt.Println(line)
+	}
+
+	for _, warning := range report.Warnings {
+		fmt.Println("  警告：", warning)
+	}
+}
+
+func backupSnapshot(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	dir := fs.String("dir", configs.GetConfig().BackupDir, "备份目录，默认取配置中的 backupDir")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *dir == "" {
+		return errors.New("未配置备份目录，请使用 -dir 指定")
+	}
+
+	snapshot, err := backup.TakeSnapshot(ctx, configs.DB(), *dir)
+	if err != nil {
+		return err
+	}
+
+	if _, err = backup.RotateSnapshots(*dir, configs.GetConfig().BackupKeep); err != nil {
+		return err
+	}
+
+	fmt.Printf("已备份：%s（%d 字节）\n", snapshot.Name, snapshot.Size)
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/xxcheng123/cloudpan189-share/internal/bus"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/search"
+)
+
+// cliUsername 命令行操作在审计日志中的操作人
+const cliUsername = "cli"
+
+// invoke 在进程内调用服务的处理函数，复用其参数校验和业务逻辑；非 200 响应转为错误
+func invoke(ctx context.Context, h gin.HandlerFunc, method string, query url.Values, body any, out any) error {
+	var reader = new(bytes.Buffer)
+	if body != nil {
+		if err := json.NewEncoder(reader).Encode(body); err != nil {
+			return err
+		}
+	}
+
+	req := httptest.NewRequestWithContext(ctx, method, "/?"+query.Encode(), reader)
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = "127.0.0.1:0"
+
+	// 命令行中不需要 gin 的调试输出
+	gin.SetMode(gin.ReleaseMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Set("user_id", int64(0))
+	c.Set("username", cliUsername)
+	c.Set("permissions", models.PermissionAdmin)
+
+	h(c)
+
+	if w.Code != http.StatusOK {
+		// 各服务的错误字段不统一，msg 和 message 都有
+		var resp struct {
+			Msg     string `json:"msg"`
+			Message string `json:"message"`
+		}
+
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+
+		if resp.Msg == "" {
+			resp.Msg = resp.Message
+		}
+
+		return errors.Errorf("%d %s", w.Code, resp.Msg)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.Unmarshal(w.Body.Bytes(), out)
+}
+
+// startBus 启动总线，扫描、删除和重建 strm 等任务都由总线执行
+func startBus() {
+	bus.Init()
+	search.Init()
+}
+
+// waitBus 等待总线任务全部结束后关闭总线，命令行在退出前调用
+func waitBus(ctx context.Context) error {
+	if err := bus.Wait(ctx); err != nil {
+		return err
+	}
+
+	return bus.Shutdown(context.Background())
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/xxcheng123/cloudpan189-share/configs"
+	"github.com/xxcheng123/cloudpan189-share/internal/services/advancedops"
+)
+
+func init() {
+	registerCommand(&command{
+		Group: "strm",
+		Name:  "rebuild",
+		Brief: "重建全部 strm 文件",
+		Run:   strmRebuild,
+	})
+
+	registerCommand(&command{
+		Group: "db",
+		Name:  "vacuum",
+		Brief: "整理数据库，回收已删除数据占用的空间",
+		Run:   dbVacuum,
+	})
+
+	registerCommand(&command{
+		Group: "config",
+		Name:  "print",
+		Brief: "输出生效的配置，令牌类字段会被隐藏",
+		Run:   configPrint,
+	})
+}
+
+func strmRebuild(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	startBus()
+
+	if err := invoke(ctx, advancedops.NewService(configs.DB(), configs.Logger()).RebuildStrm(), http.MethodPost, nil, nil, nil); err != nil {
+		return err
+	}
+
+	fmt.Println("等待重建完成……")
+
+	if err := waitBus(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("重建 strm 文件完成")
+
+	return nil
+}
+
+func dbVacuum(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	dbFile := configs.GetConfig().DBFile
+
+	before, err := os.Stat(dbFile)
+	if err != nil {
+		return err
+	}
+
+	if err = configs.DB().WithContext(ctx).Exec("VACUUM").Error; err != nil {
+		return err
+	}
+
+	after, err := os.Stat(dbFile)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("整理完成：%d -> %d 字节\n", before.Size(), after.Size())
+
+	return nil
+}
+
+func configPrint(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	config := *configs.GetConfig()
+	if config.MetricsToken != "" {
+		config.MetricsToken = "******"
+	}
+
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(os.Stdout, string(data))
+
+	return err
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/xxcheng123/cloudpan189-share/configs"
+	"github.com/xxcheng123/cloudpan189-share/internal/bus"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/services/storage"
+)
+
+func init() {
+	registerCommand(&command{
+		Group: "mount",
+		Name:  "list",
+		Brief: "列出挂载点",
+		Run:   mountList,
+	})
+
+	registerCommand(&command{
+		Group: "mount",
+		Name:  "add",
+		Usage: "<本地路径> -protocol <subscribe|share|person|family|subscribe_share> [参数]",
+		Brief: "新增挂载点并扫描",
+		Run:   mountAdd,
+	})
+
+	registerCommand(&command{
+		Group: "mount",
+		Name:  "remove",
+		Usage: "<挂载点ID>",
+		Brief: "删除挂载点及其文件",
+		Run:   mountRemove,
+	})
+
+	registerCommand(&command{
+		Group: "scan",
+		Usage: "<挂载点ID> [-deep]",
+		Brief: "扫描挂载点，等待扫描完成后退出",
+		Run:   scanMount,
+	})
+}
+
+func mountList(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var resp struct {
+		Data []*storage.FileItem `json:"data"`
+	}
+
+	if err := invoke(ctx, storage.NewService(configs.DB(), configs.Logger()).List(), http.MethodGet, url.Values{
+		"noPaginate": {"true"},
+	}, nil, &resp); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\t路径\t协议\t健康状态")
+
+	for _, item := range resp.Data {
+		state := "-"
+		if item.Health != nil {
+			state = item.Health.State
+		}
+
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", item.ID, item.LocalPath, item.OsType, state)
+	}
+
+	return w.Flush()
+}
+
+func mountAdd(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	var (
+		protocol        = fs.String("protocol", "", "挂载协议")
+		subscribeUser   = fs.String("subscribe-user", "", "订阅用户，subscribe / subscribe_share 必填")
+		shareCode       = fs.String("share-code", "", "分享码，share / subscribe_share 必填")
+		shareAccessCode = fs.String("access-code", "", "分享访问码")
+		cloudToken      = fs.Int64("token", 0, "云盘令牌ID，person / family 必填")
+		fileId          = fs.String("file-id", "", "云盘目录ID，person / family 必填")
+		familyId        = fs.String("family-id", "", "家庭云ID，family 必填")
+	)
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 {
+		fs.Usage()
+
+		return flag.ErrHelp
+	}
+
+	startBus()
+
+	var resp struct {
+		ID int64 `json:"id"`
+	}
+
+	if err = invoke(ctx, storage.NewService(configs.DB(), configs.Logger()).Add(), http.MethodPost, nil, map[string]any{
+		"localPath":       positional[0],
+		"protocol":        *protocol,
+		"subscribeUser":   *subscribeUser,
+		"shareCode":       *shareCode,
+		"shareAccessCode": *shareAccessCode,
+		"cloudToken":      *cloudToken,
+		"fileId":          *fileId,
+		"familyId":        *familyId,
+	}, &resp); err != nil {
+		return err
+	}
+
+	fmt.Printf("已新增挂载点 %d：%s\n", resp.ID, positional[0])
+
+	fmt.Println("等待扫描完成……")
+
+	return waitBus(ctx)
+}
+
+func mountRemove(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	id, err := mountIdArg(fs, positional)
+	if err != nil {
+		return err
+	}
+
+	startBus()
+
+	if err = invoke(ctx, storage.NewService(configs.DB(), configs.Logger()).Delete(), http.MethodPost, nil, map[string]any{
+		"id": id,
+	}, nil); err != nil {
+		return err
+	}
+
+	if err = waitBus(ctx); err != nil {
+		return err
+	}
+
+	fmt.Printf("已删除挂载点 %d\n", id)
+
+	return nil
+}
+
+func scanMount(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	deep := fs.Bool("deep", false, "深度扫描，重新比对所有子目录")
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	id, err := mountIdArg(fs, positional)
+	if err != nil {
+		return err
+	}
+
+	file := new(models.VirtualFile)
+	if err = configs.DB().WithContext(ctx).Where("id = ?", id).First(file).Error; err != nil {
+		return errors.Wrap(err, "查询挂载点失败")
+	}
+
+	if file.IsTop != 1 {
+		return errors.Errorf("%d 不是挂载点", id)
+	}
+
+	startBus()
+
+	if err = bus.PublishVirtualFileRefresh(ctx, id, *deep, models.ScanTriggerCLI); err != nil {
+		return err
+	}
+
+	fmt.Printf("开始扫描挂载点 %d：%s\n", id, file.Name)
+
+	if err = waitBus(ctx); err != nil {
+		return err
+	}
+
+	// 扫描结果记录在 scan_run 中
+	run := new(models.ScanRun)
+	if err = configs.DB().WithContext(ctx).Where("file_id = ?", id).Order("id DESC").First(run).Error; err == nil {
+		fmt.Printf("扫描结束：%s，新增 %d，更新 %d，删除 %d，错误 %d\n",
+			run.Status, run.Created, run.Updated, run.Deleted, run.ErrorCount)
+	}
+
+	return nil
+}
+
+func mountIdArg(fs *flag.FlagSet, positional []string) (int64, error) {
+	if len(positional) != 1 {
+		fs.Usage()
+
+		return 0, flag.ErrHelp
+	}
+
+	id, err := strconv.ParseInt(positional[0], 10, 64)
+	if err != nil || id <= 0 {
+		return 0, errors.Errorf("挂载点ID不合法：%s", positional[0])
+	}
+
+	return id, nil
+}
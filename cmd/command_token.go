@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/xxcheng123/cloudpan189-interface/client"
+	"github.com/xxcheng123/cloudpan189-share/configs"
+	"github.com/xxcheng123/cloudpan189-share/internal/jobs"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/services/cloudtoken"
+)
+
+func init() {
+	registerCommand(&command{
+		Group: "token",
+		Name:  "list",
+		Brief: "列出云盘令牌",
+		Run:   tokenList,
+	})
+
+	registerCommand(&command{
+		Group: "token",
+		Name:  "test",
+		Usage: "[令牌ID...]",
+		Brief: "校验云盘令牌并更新状态，不指定时校验全部",
+		Run:   tokenTest,
+	})
+}
+
+func tokenStatusName(status int8) string {
+	if status == models.CloudTokenStatusNormal {
+		return "正常"
+	}
+
+	return "登录失败"
+}
+
+func tokenList(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var tokens []*models.CloudToken
+	if err := invoke(ctx, cloudtoken.NewService(configs.DB(), configs.Logger()).List(), http.MethodGet, nil, nil, &tokens); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\t名称\t账号\t状态\t过期时间")
+
+	for _, token := range tokens {
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", token.ID, token.Name, token.Username,
+			tokenStatusName(token.Status), client.NewAuthToken(token.AccessToken, token.ExpiresIn).ExpireTime())
+	}
+
+	return w.Flush()
+}
+
+func tokenTest(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]int64, 0, len(positional))
+	for _, v := range positional {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return errors.Errorf("令牌ID不合法：%s", v)
+		}
+
+		ids = append(ids, id)
+	}
+
+	query := configs.DB().WithContext(ctx).Order("id")
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+
+	var tokens = make([]*models.CloudToken, 0)
+	if err = query.Find(&tokens).Error; err != nil {
+		return err
+	}
+
+	if len(tokens) == 0 {
+		return errors.New("没有可校验的令牌")
+	}
+
+	failed := 0
+	for _, token := range tokens {
+		if err = jobs.CheckCloudToken(ctx, configs.DB(), configs.Logger(), token); err != nil {
+			failed++
+			fmt.Printf("%d %s：失败，%s\n", token.ID, token.Name, err.Error())
+
+			continue
+		}
+
+		fmt.Printf("%d %s：正常\n", token.ID, token.Name)
+	}
+
+	if failed > 0 {
+		return errors.Errorf("%d 个令牌校验失败", failed)
+	}
+
+	return nil
+}
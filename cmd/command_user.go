@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/xxcheng123/cloudpan189-share/configs"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/services/user"
+)
+
+func init() {
+	registerCommand(&command{
+		Group: "user",
+		Name:  "list",
+		Brief: "列出用户",
+		Run:   userList,
+	})
+
+	registerCommand(&command{
+		Group: "user",
+		Name:  "reset-password",
+		Usage: "<用户名> [-password <新密码>]",
+		Brief: "重置用户密码，不指定时随机生成，已登录的会话随之失效",
+		Run:   userResetPassword,
+	})
+
+	registerCommand(&command{
+		Group: "user",
+		Name:  "reset-2fa",
+		Usage: "<用户名>",
+		Brief: "解除用户的两步验证，用于丢失验证器的情况，已登录的会话随之失效",
+		Run:   userResetTwoFactor,
+	})
+}
+
+func userList(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var resp struct {
+		Data []struct {
+			models.User
+			GroupName string `json:"groupName"`
+		} `json:"data"`
+	}
+
+	if err := invoke(ctx, user.NewService(configs.DB(), configs.Logger()).List(), http.MethodGet, url.Values{
+		"noPaginate": {"true"},
+	}, nil, &resp); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\t用户名\t状态\t权限\t用户组")
+
+	for _, u := range resp.Data {
+		status := "正常"
+		if u.Status != 1 {
+			status = "禁用"
+		}
+
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", u.ID, u.Username, status, permissionNames(u.Permissions), u.GroupName)
+	}
+
+	return w.Flush()
+}
+
+func permissionNames(p uint8) string {
+	switch {
+	case p&models.PermissionAdmin != 0:
+		return "管理员"
+	case p&models.PermissionDavRead != 0:
+		return "普通用户+WebDAV"
+	default:
+		return "普通用户"
+	}
+}
+
+func userResetPassword(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	password := fs.String("password", "", "新密码，6-20 位，为空时随机生成")
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 {
+		fs.Usage()
+
+		return flag.ErrHelp
+	}
+
+	u := new(models.User)
+	if err = configs.DB().WithContext(ctx).Where("username = ?", positional[0]).First(u).Error; err != nil {
+		return errors.Wrapf(err, "查询用户 %s 失败", positional[0])
+	}
+
+	generated := *password == ""
+	if generated {
+		*password = randomPassword(12)
+	}
+
+	if err = invoke(ctx, user.NewService(configs.DB(), configs.Logger()).ModifyPass(), http.MethodPost, nil, map[string]any{
+		"id":       u.ID,
+		"password": *password,
+	}, nil); err != nil {
+		return err
+	}
+
+	if generated {
+		fmt.Printf("用户 %s 的密码已重置为：%s\n", u.Username, *password)
+	} else {
+		fmt.Printf("用户 %s 的密码已重置\n", u.Username)
+	}
+
+	return nil
+}
+
+func userResetTwoFactor(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 {
+		fs.Usage()
+
+		return flag.ErrHelp
+	}
+
+	u := new(models.User)
+	if err = configs.DB().WithContext(ctx).Where("username = ?", positional[0]).First(u).Error; err != nil {
+		return errors.Wrapf(err, "查询用户 %s 失败", positional[0])
+	}
+
+	if err = invoke(ctx, user.NewService(configs.DB(), configs.Logger()).TwoFactorReset(), http.MethodPost, nil, map[string]any{
+		"id": u.ID,
+	}, nil); err != nil {
+		return err
+	}
+
+	fmt.Printf("用户 %s 的两步验证已解除\n", u.Username)
+
+	return nil
+}
+
+func randomPassword(n int) string {
+	const letters = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+
+	for i := range b {
+		b[i] = letters[int(b[i])%len(letters)]
+	}
+
+	return string(b)
+}
@@ -5,6 +5,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 	"text/template"
 
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
@@ -194,8 +195,118 @@ func (s *service) Get() gin.HandlerFunc {
 }
 `
 
+const settingPatchTemplate = `// Code generated by cmd/generate_setting/main.go. DO NOT EDIT.
+
+package setting
+
+import (
+	"slices"
+
+	"github.com/samber/lo"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/shared"
+	"gorm.io/gorm"
+)
+
+// patchRequest 字段为空表示不修改
+type patchRequest struct {
+{{- range .All }}
+	{{ .MethodSuffix }} *{{ .GoType }} ` + "`" + `json:"{{ .JsonTag }}" binding:"{{ .Binding }}"` + "`" + `
+{{- end }}
+}
+
+// settingFields 设置项键名对应的请求字段名
+var settingFields = map[string]string{
+{{- range .All }}
+	"{{ .Key }}": "{{ .JsonTag }}",
+{{- end }}
+}
+
+// settingValues 当前生效的设置值，键为设置项键名
+func settingValues() map[string]any {
+	return map[string]any{
+{{- range .All }}
+		"{{ .Key }}": {{ .SharedRef }},
+{{- end }}
+	}
+}
+
+// normalize 列表去重
+func (r *patchRequest) normalize() {
+{{- range .All }}
+{{- if eq .Type "json" }}
+	if r.{{ .MethodSuffix }} != nil {
+		v := lo.Uniq(*r.{{ .MethodSuffix }})
+		r.{{ .MethodSuffix }} = &v
+	}
+{{- end }}
+{{- end }}
+}
+
+// changes 返回与当前值不同的设置项
+func (r *patchRequest) changes() []settingChange {
+	var list []settingChange
+{{- range .All }}
+
+	if r.{{ .MethodSuffix }} != nil && {{ if eq .Type "json" }}!slices.Equal({{ .SharedRef }}, *r.{{ .MethodSuffix }}){{ else }}{{ .SharedRef }} != *r.{{ .MethodSuffix }}{{ end }} {
+		list = append(list, settingChange{
+			Key:             "{{ .Key }}",
+			Before:          {{ .SharedRef }},
+			After:           *r.{{ .MethodSuffix }},
+			RestartRequired: {{ .RestartRequired }},
+		})
+	}
+{{- end }}
+
+	return list
+}
+
+// save 在事务中写入发生变化的设置项
+func (r *patchRequest) save(tx *gorm.DB, settingId int64, changes []settingChange) error {
+	var (
+		columns = make(map[string]any)
+		dict    = new(models.SettingDict)
+	)
+
+	for _, c := range changes {
+		switch c.Key {
+{{- range .All }}
+{{- if eq .Source "setting" }}
+		case "{{ .Key }}":
+			columns["{{ .Key }}"] = *r.{{ .MethodSuffix }}
+{{- else }}
+		case models.SettingDictKey{{ .MethodSuffix }}:
+			if err := dict.Set{{ .MethodSuffix }}(tx, *r.{{ .MethodSuffix }}).Error; err != nil {
+				return err
+			}
+{{- end }}
+{{- end }}
+		}
+	}
+
+	if len(columns) == 0 {
+		return nil
+	}
+
+	return tx.Model(&models.Setting{}).Where("id = ?", settingId).Updates(columns).Error
+}
+
+// apply 将发生变化的设置项写入 shared
+func (r *patchRequest) apply(changes []settingChange) {
+	for _, c := range changes {
+		switch c.Key {
+{{- range .All }}
+		case "{{ .Key }}":
+			{{ .SharedRef }} = *r.{{ .MethodSuffix }}
+{{- end }}
+		}
+	}
+}
+`
+
 type TemplateData struct {
-	Items []TemplateItem
+	Items []TemplateItem // setting_dicts 中的项
+	All   []TemplateItem // 全部设置项
 }
 
 type TemplateItem struct {
@@ -205,6 +316,11 @@ type TemplateItem struct {
 	MethodSuffix string
 	GoType       string
 	JsonTag      string
+
+	Source          string
+	Binding         string // patch 请求的校验规则
+	SharedRef       string // shared 中对应的变量
+	RestartRequired bool
 }
 
 func main() {
@@ -212,10 +328,22 @@ func main() {
 	var templateData TemplateData
 	for _, item := range models.SettingItems {
 		templateItem := TemplateItem{
-			Key:          item.Key,
-			Type:         item.Type,
-			DefaultValue: item.DefaultValue,
-			MethodSuffix: item.MethodSuffix,
+			Key:             item.Key,
+			Type:            item.Type,
+			DefaultValue:    item.DefaultValue,
+			MethodSuffix:    item.MethodSuffix,
+			Source:          item.Source,
+			RestartRequired: item.RestartRequired,
+		}
+
+		if templateItem.Source == "" {
+			templateItem.Source = models.SettingSourceDict
+		}
+
+		if templateItem.Source == models.SettingSourceRow {
+			templateItem.SharedRef = "shared.Setting." + item.MethodSuffix
+		} else {
+			templateItem.SharedRef = "shared." + item.MethodSuffix
 		}
 
 		// 设置 Go 类型和 JSON 标签
@@ -234,8 +362,14 @@ func main() {
 
 		// 生成 JSON 标签（将 MethodSuffix 转换为 camelCase）
 		templateItem.JsonTag = toCamelCase(item.MethodSuffix)
+		templateItem.Binding = bindingRule(item)
 
-		templateData.Items = append(templateData.Items, templateItem)
+		templateData.All = append(templateData.All, templateItem)
+
+		// setting 表的列由 models.Setting 定义，不生成字典方法
+		if templateItem.Source == models.SettingSourceDict {
+			templateData.Items = append(templateData.Items, templateItem)
+		}
 	}
 
 	// 解析模板
@@ -314,6 +448,44 @@ func main() {
 	}
 
 	fmt.Println("Generated internal/services/setting/service_get_generated.go")
+
+	// 生成 setting patch 文件
+	patchTmpl, err := template.New("setting_patch").Parse(settingPatchTemplate)
+	if err != nil {
+		panic(err)
+	}
+
+	patchFile, err := os.Create("internal/services/setting/service_patch_generated.go")
+	if err != nil {
+		panic(err)
+	}
+	defer patchFile.Close()
+
+	// 执行 setting patch 模板
+	if err := patchTmpl.Execute(patchFile, templateData); err != nil {
+		panic(err)
+	}
+
+	fmt.Println("Generated internal/services/setting/service_patch_generated.go")
+}
+
+// bindingRule 生成 patch 请求字段的校验规则，字段为指针，未传时跳过
+func bindingRule(item models.SettingItem) string {
+	rules := []string{"omitempty"}
+
+	if item.Validate != "" {
+		rules = append(rules, item.Validate)
+	}
+
+	if len(item.Options) > 0 {
+		if item.Type == "json" {
+			rules = append(rules, "dive")
+		}
+
+		rules = append(rules, "oneof="+strings.Join(item.Options, " "))
+	}
+
+	return strings.Join(rules, ",")
 }
 
 // toCamelCase 将 PascalCase 转换为 camelCase
@@ -2,37 +2,154 @@ package main
 
 import (
 	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/xxcheng123/cloudpan189-share/internal/bus"
 
 	"github.com/xxcheng123/cloudpan189-share/configs"
+	"github.com/xxcheng123/cloudpan189-share/internal/alert"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
+	"github.com/xxcheng123/cloudpan189-share/internal/authguard"
+	"github.com/xxcheng123/cloudpan189-share/internal/changefeed"
 	"github.com/xxcheng123/cloudpan189-share/internal/jobs"
+	"github.com/xxcheng123/cloudpan189-share/internal/metrics"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/trace"
 	"github.com/xxcheng123/cloudpan189-share/internal/router"
+	"github.com/xxcheng123/cloudpan189-share/internal/search"
+	"github.com/xxcheng123/cloudpan189-share/internal/traffic"
 	"go.uber.org/zap"
 )
 
+var configPath = flag.String("config", configs.DefaultConfigPath, "config path")
+
 func main() {
+	flag.Parse()
+
+	if err := configs.Load(*configPath); err != nil {
+		log.Fatalf("加载配置失败: %v", err)
+	}
+
 	configs.Init()
 
 	defer configs.Logger().Sync()
 
+	// 带子命令时执行管理命令后退出，不启动服务
+	if args := flag.Args(); len(args) > 0 {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		code := runCommand(ctx, args)
+		stop()
+
+		closeDB()
+		_ = configs.Logger().Sync()
+
+		os.Exit(code)
+	}
+
+	metrics.Init()
+
+	if endpoint := configs.GetConfig().OtlpEndpoint; endpoint != "" {
+		trace.StartExporter(endpoint, "cloudpan189-share", configs.Logger().With(zap.String("module", "trace")))
+	}
+
 	bus.Init()
 
+	search.Init()
+
+	traffic.Init()
+
+	audit.Init()
+
+	authguard.Init()
+
+	alert.Init()
+
+	changefeed.Init()
+
+	// 收到退出信号后依次停止 HTTP、定时任务和总线
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	configs.Watch(ctx)
+
 	scanJob := jobs.NewScanFileJob(configs.DB(), configs.Logger())
-	if err := scanJob.Start(context.Background()); err != nil {
+	if err := scanJob.Start(ctx); err != nil {
 		panic(err)
 	}
 
-	defer scanJob.Stop()
-
 	autoLoginJob := jobs.NewAutoLoginJob(configs.DB(), configs.Logger())
-	if err := autoLoginJob.Start(context.Background()); err != nil {
+	if err := autoLoginJob.Start(ctx); err != nil {
+		panic(err)
+	}
+
+	tokenHealthJob := jobs.NewTokenHealthJob(configs.DB(), configs.Logger())
+	if err := tokenHealthJob.Start(ctx); err != nil {
 		panic(err)
 	}
 
-	defer autoLoginJob.Stop()
+	dbBackupJob := jobs.NewDBBackupJob(configs.DB(), configs.Logger())
+	if err := dbBackupJob.Start(ctx); err != nil {
+		panic(err)
+	}
+
+	serverErr := router.StartHTTPServer(ctx)
+	if serverErr != nil {
+		configs.Logger().Error("start http server error", zap.Error(serverErr))
+	}
+
+	shutdown(scanJob, autoLoginJob, tokenHealthJob, dbBackupJob)
+
+	// 端口占用、证书错误等导致服务异常退出时返回非 0，便于进程管理器识别
+	if serverErr != nil {
+		_ = configs.Logger().Sync()
+
+		os.Exit(1)
+	}
+}
+
+// shutdown 先停止定时任务，再等待总线任务退出并关闭数据库，最长等待 shutdownTimeout
+func shutdown(jobList ...jobs.Job) {
+	logger := configs.Logger()
+
+	ctx, cancel := context.WithTimeout(context.Background(), configs.GetConfig().GetShutdownTimeout())
+	defer cancel()
+
+	// 定时任务可能正在访问数据库或发布总线任务，需在总线退出、数据库关闭之前结束
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		for _, job := range jobList {
+			job.Stop()
+		}
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		logger.Warn("wait jobs timeout", zap.Error(ctx.Err()))
+	}
+
+	if err := bus.Shutdown(ctx); err != nil {
+		logger.Warn("wait bus tasks timeout", zap.Error(err))
+	}
+
+	trace.StopExporter(ctx)
+
+	traffic.Flush(ctx)
+
+	closeDB()
+
+	logger.Info("shutdown complete")
+}
 
-	if err := router.StartHTTPServer(); err != nil {
-		configs.Logger().Error("start http server error", zap.Error(err))
+func closeDB() {
+	if sqlDB, err := configs.DB().DB(); err == nil {
+		if err = sqlDB.Close(); err != nil {
+			configs.Logger().Error("close db error", zap.Error(err))
+		}
 	}
 }
@@ -1,9 +1,15 @@
 package configs
 
 import (
+	"fmt"
+	"net"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
+// Config 启动配置，每个字段都可以用 SHARE_ 开头的环境变量覆盖，如 dbFile 对应 SHARE_DB_FILE；
+// 标记 reload:"true" 的字段在收到 SIGHUP 或配置文件变化时立即生效，其余字段需重启
 type Config struct {
 	Port    int    `json:"port,default=12395"`
 	DBFile  string `json:"dbFile,default=data/share.db"`
@@ -11,6 +17,123 @@ type Config struct {
 	// Deprecated: use MediaDir instead.
 	FileDir  string `json:"fileDir,default=datadir"`
 	MediaDir string `json:"mediaDir,default=media_dir"`
+	// MetricsToken 访问 /metrics 需携带的令牌，为空时不校验
+	MetricsToken string `json:"metricsToken,optional" reload:"true"`
+	// ShutdownTimeout 收到退出信号后，HTTP 请求和总线任务各自最长等待的秒数
+	ShutdownTimeout int `json:"shutdownTimeout,default=30" reload:"true"`
+	// OtlpEndpoint OTLP/HTTP 采集器地址，如 http://127.0.0.1:4318，为空时不导出 trace
+	OtlpEndpoint string `json:"otlpEndpoint,optional"`
+	// BackupDir 数据库定时备份目录，为空时不备份
+	BackupDir string `json:"backupDir,optional" reload:"true"`
+	// BackupIntervalHours 定时备份间隔小时数
+	BackupIntervalHours int `json:"backupIntervalHours,default=24" reload:"true"`
+	// BackupKeep 保留的备份个数，0 表示不清理
+	BackupKeep int `json:"backupKeep,default=7" reload:"true"`
+	// Listen 监听地址，如 127.0.0.1:12395，为空时监听所有地址的 port 端口
+	Listen string `json:"listen,optional"`
+	// TLSCert TLSKey 证书和私钥文件，都配置时以 HTTPS 提供服务，文件更新后自动重新加载
+	TLSCert string `json:"tlsCert,optional"`
+	TLSKey  string `json:"tlsKey,optional"`
+	// TLSClientCA 签发客户端证书的 CA 文件，配置后 WebDAV 要求客户端出示该 CA 签发的证书
+	TLSClientCA string `json:"tlsClientCA,optional"`
+	// UnixSocket 额外监听的 Unix socket 路径，供本机反向代理使用，不走 TLS
+	UnixSocket string `json:"unixSocket,optional"`
+	// UnixSocketTrustProxy 把 Unix socket 的对端当作可信代理，采信其 X-Forwarded-* 请求头，
+	// 并由其负责 WebDAV 客户端证书校验；只应在 socket 仅对反向代理开放时开启
+	UnixSocketTrustProxy bool `json:"unixSocketTrustProxy,optional"`
+	// TrustedProxies 可信反向代理的 IP 或网段，只采信这些来源的 X-Forwarded-For 等请求头，为空时只信任本机
+	TrustedProxies []string `json:"trustedProxies,optional"`
+	// BasePath 部署在子路径下时的 URL 前缀，如 /share
+	BasePath string `json:"basePath,optional"`
+	// CorsOrigins 允许跨域访问的来源，如 https://a.com，* 表示全部，为空时不允许跨域
+	CorsOrigins []string `json:"corsOrigins,optional" reload:"true"`
+	// LogLevel 日志级别：debug、info、warn、error
+	LogLevel string `json:"logLevel,default=info,options=debug|info|warn|error" reload:"true"`
+	// LoginWindowMinutes 统计登录失败次数的时间窗口，网页登录和 WebDAV 认证共用
+	LoginWindowMinutes int `json:"loginWindowMinutes,default=15" reload:"true"`
+	// LoginMaxFailures 窗口内同一用户名允许的失败次数，超过后锁定该用户名，0 表示不限制
+	LoginMaxFailures int `json:"loginMaxFailures,default=5" reload:"true"`
+	// LoginMaxFailuresPerIP 窗口内同一 IP 允许的失败次数，超过后锁定该 IP，0 表示不限制
+	LoginMaxFailuresPerIP int `json:"loginMaxFailuresPerIP,default=20" reload:"true"`
+	// LoginLockMinutes 锁定时长
+	LoginLockMinutes int `json:"loginLockMinutes,default=15" reload:"true"`
+}
+
+// GetShutdownTimeout 优雅退出的最长等待时间
+func (c *Config) GetShutdownTimeout() time.Duration {
+	if c.ShutdownTimeout <= 0 {
+		return time.Second * 30
+	}
+
+	return time.Duration(c.ShutdownTimeout) * time.Second
+}
+
+// GetListen HTTP 服务的监听地址
+func (c *Config) GetListen() string {
+	if c.Listen != "" {
+		return c.Listen
+	}
+
+	return fmt.Sprintf(":%d", c.Port)
+}
+
+// EnableTLS 是否以 HTTPS 提供服务
+func (c *Config) EnableTLS() bool {
+	return c.TLSCert != "" && c.TLSKey != ""
+}
+
+// EnableDavMTLS WebDAV 是否要求客户端证书
+func (c *Config) EnableDavMTLS() bool {
+	return c.EnableTLS() && c.TLSClientCA != ""
+}
+
+// UnixSocketRemoteIP Unix socket 上的连接没有对端地址，以这个不可路由的地址（RFC 6666 丢弃前缀）标记，
+// 与本机 TCP 连接区分，不会落入默认的可信代理
+const UnixSocketRemoteIP = "100::1"
+
+// GetTrustedProxies 可信代理，未配置时只信任本机；开启 UnixSocketTrustProxy 时加上 Unix socket 的标记地址
+func (c *Config) GetTrustedProxies() []string {
+	proxies := c.TrustedProxies
+	if len(proxies) == 0 {
+		proxies = []string{"127.0.0.1", "::1"}
+	}
+
+	if c.UnixSocket != "" && c.UnixSocketTrustProxy {
+		proxies = append(proxies[:len(proxies):len(proxies)], UnixSocketRemoteIP)
+	}
+
+	return proxies
+}
+
+// IsTrustedProxy ip 是否为可信代理
+func (c *Config) IsTrustedProxy(ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+
+	for _, item := range c.GetTrustedProxies() {
+		if strings.Contains(item, "/") {
+			if _, ipNet, err := net.ParseCIDR(item); err == nil && ipNet.Contains(addr) {
+				return true
+			}
+		} else if other := net.ParseIP(item); other != nil && other.Equal(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AllowOrigin 是否允许来自 origin 的跨域请求
+func (c *Config) AllowOrigin(origin string) bool {
+	for _, item := range c.CorsOrigins {
+		if item == "*" || strings.EqualFold(item, origin) {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (c *Config) MediaJoinPath(paths ...string) string {
@@ -19,3 +142,17 @@ func (c *Config) MediaJoinPath(paths ...string) string {
 
 	return filepath.Join(tp...)
 }
+
+// normalize 统一格式：BasePath 以 / 开头且不以 / 结尾，根路径为空
+func (c *Config) normalize() {
+	c.BasePath = strings.Trim(strings.TrimSpace(c.BasePath), "/")
+	if c.BasePath != "" {
+		c.BasePath = "/" + c.BasePath
+	}
+
+	for i, origin := range c.CorsOrigins {
+		c.CorsOrigins[i] = strings.TrimRight(strings.TrimSpace(origin), "/")
+	}
+
+	c.LogLevel = strings.ToLower(c.LogLevel)
+}
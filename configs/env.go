@@ -0,0 +1,95 @@
+package configs
+
+import (
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// envPrefix 覆盖配置项的环境变量前缀
+const envPrefix = "SHARE_"
+
+// envName 配置项对应的环境变量名，如 dbFile -> SHARE_DB_FILE
+func envName(key string) string {
+	var sb strings.Builder
+
+	sb.WriteString(envPrefix)
+
+	runes := []rune(key)
+	for i, r := range runes {
+		// 连续大写视为一个单词，如 tlsCert -> TLS_CERT、baseURL -> BASE_URL
+		if i > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) ||
+			(i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			sb.WriteByte('_')
+		}
+
+		sb.WriteRune(unicode.ToUpper(r))
+	}
+
+	return sb.String()
+}
+
+// jsonKey 字段在配置文件中的名称
+func jsonKey(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+	return name
+}
+
+// applyEnv 用 SHARE_* 环境变量覆盖配置，列表类型以逗号分隔
+func applyEnv(cfg *Config) error {
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		key := jsonKey(t.Field(i))
+		if key == "" || key == "-" {
+			continue
+		}
+
+		name := envName(key)
+
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		field := v.Field(i)
+
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Int, reflect.Int64:
+			n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if err != nil {
+				return errors.Errorf("环境变量 %s 不是整数：%s", name, value)
+			}
+
+			field.SetInt(n)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(strings.TrimSpace(value))
+			if err != nil {
+				return errors.Errorf("环境变量 %s 不是布尔值：%s", name, value)
+			}
+
+			field.SetBool(b)
+		case reflect.Slice:
+			list := make([]string, 0)
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					list = append(list, item)
+				}
+			}
+
+			field.Set(reflect.ValueOf(list))
+		default:
+			return errors.Errorf("环境变量 %s 对应的配置类型不支持：%s", name, field.Kind())
+		}
+	}
+
+	return nil
+}
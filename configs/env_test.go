@@ -0,0 +1,120 @@
+package configs
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"port", "SHARE_PORT"},
+		{"dbFile", "SHARE_DB_FILE"},
+		{"tlsCert", "SHARE_TLS_CERT"},
+		{"tlsClientCA", "SHARE_TLS_CLIENT_CA"},
+		{"baseURL", "SHARE_BASE_URL"},
+		{"corsOrigins", "SHARE_CORS_ORIGINS"},
+		{"loginMaxFailuresPerIP", "SHARE_LOGIN_MAX_FAILURES_PER_IP"},
+	}
+
+	for _, tt := range tests {
+		if got := envName(tt.key); got != tt.want {
+			t.Errorf("envName(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv("SHARE_PORT", " 8080 ")
+	t.Setenv("SHARE_DB_FILE", "/data/share.db")
+	t.Setenv("SHARE_UNIX_SOCKET_TRUST_PROXY", "true")
+	t.Setenv("SHARE_CORS_ORIGINS", "https://a.com, ,https://b.com")
+	t.Setenv("SHARE_TRUSTED_PROXIES", "")
+
+	cfg := &Config{
+		Port:           12395,
+		LogFile:        "logs/share.log",
+		TrustedProxies: []string{"10.0.0.1"},
+	}
+
+	if err := applyEnv(cfg); err != nil {
+		t.Fatalf("applyEnv() error = %v", err)
+	}
+
+	if cfg.Port != 8080 {
+		t.Errorf("Port = %d, want 8080", cfg.Port)
+	}
+
+	if cfg.DBFile != "/data/share.db" {
+		t.Errorf("DBFile = %q", cfg.DBFile)
+	}
+
+	if cfg.LogFile != "logs/share.log" {
+		t.Errorf("LogFile without env should keep %q, got %q", "logs/share.log", cfg.LogFile)
+	}
+
+	if !cfg.UnixSocketTrustProxy {
+		t.Error("UnixSocketTrustProxy = false, want true")
+	}
+
+	if want := []string{"https://a.com", "https://b.com"}; !reflect.DeepEqual(cfg.CorsOrigins, want) {
+		t.Errorf("CorsOrigins = %v, want %v", cfg.CorsOrigins, want)
+	}
+
+	// 设置为空字符串表示清空列表
+	if len(cfg.TrustedProxies) != 0 {
+		t.Errorf("TrustedProxies = %v, want empty", cfg.TrustedProxies)
+	}
+}
+
+func TestApplyEnvInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"SHARE_PORT", "abc"},
+		{"SHARE_SHUTDOWN_TIMEOUT", "1.5"},
+		{"SHARE_UNIX_SOCKET_TRUST_PROXY", "maybe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.name, tt.value)
+
+			if err := applyEnv(new(Config)); err == nil {
+				t.Errorf("applyEnv() with %s=%q should fail", tt.name, tt.value)
+			}
+		})
+	}
+}
+
+func TestLoadConfigDefaultsAndEnv(t *testing.T) {
+	t.Setenv("SHARE_BASE_PATH", "share/")
+	t.Setenv("SHARE_LOG_LEVEL", "DEBUG")
+
+	cfg, err := loadConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+
+	if cfg.Port != 12395 || cfg.DBFile != "data/share.db" {
+		t.Errorf("defaults not applied: port=%d dbFile=%q", cfg.Port, cfg.DBFile)
+	}
+
+	if cfg.BasePath != "/share" {
+		t.Errorf("BasePath = %q, want /share", cfg.BasePath)
+	}
+
+	if cfg.LogLevel != "debug" {
+		t.Errorf("LogLevel = %q, want debug", cfg.LogLevel)
+	}
+
+	t.Setenv("SHARE_LOG_LEVEL", "verbose")
+
+	if _, err = loadConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("loadConfig() with invalid log level should fail")
+	}
+}
@@ -4,7 +4,6 @@ package configs
 
 import (
 	"crypto/md5"
-	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -18,7 +17,6 @@ import (
 	logger2 "github.com/xxcheng123/cloudpan189-share/internal/pkgs/logger"
 	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/utils"
 	"github.com/xxcheng123/cloudpan189-share/internal/shared"
-	"github.com/zeromicro/go-zero/core/conf"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -30,27 +28,36 @@ var (
 	GitSummary string
 )
 
-var c = new(Config)
+// DefaultConfigPath 未通过 -config 指定时使用的配置文件
+const DefaultConfigPath = "etc/config.yaml"
 
-var configPath string
+var configPath = DefaultConfigPath
 
-func init() {
-	flag.StringVar(&configPath, "config", "etc/config.yaml", "config path")
-}
-
-func init() {
-	flag.Parse()
-
-	conf.MustLoad(configPath, c)
+// Load 加载 .env 和配置文件，需在 Init 之前调用
+func Load(path string) error {
+	configPath = path
 
+	// .env 中也可以写 SHARE_* 覆盖配置，需先于配置加载
 	envFiles := []string{".env", ".env.local", ".env.example"}
 	for _, envFile := range envFiles {
 		_ = godotenv.Load(envFile)
 	}
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	current.Store(cfg)
+
+	return nil
 }
 
 func Init() {
-	var err error
+	var (
+		c   = GetConfig()
+		err error
+	)
 
 	// 先判断数据库目录是否存在
 	dbDir := filepath.Dir(c.DBFile)
@@ -116,31 +123,33 @@ func Init() {
 		new(models.Group2File),
 		new(models.SettingDict),
 		new(models.MediaFile),
+		new(models.TrafficLimit),
+		new(models.TrafficUsage),
+		new(models.Notifier),
+		new(models.DownloadSource),
+		new(models.FileEvent),
+		new(models.Webhook),
+		new(models.ScanRun),
+		new(models.AuditLog),
+		new(models.LoginFailure),
+		new(models.IPRule),
+		new(models.Session),
+		new(models.ShareLink),
 	); err != nil {
 		panic(err)
 	}
 
 	{
+		setLogLevel(c.LogLevel)
+
 		var options = []logger2.Option{
 			logger2.WithTimeLayout(time.DateTime),
 			logger2.WithFileRotationP(c.LogFile),
 			logger2.WithOutputInConsole(),
+			logger2.WithAtomicLevel(logLevel),
 			// logger2.WithField("build_info", fmt.Sprintf("[buildDate:%s]&&[commit:%s]&&[gitSummary:%s]&&[gitBranch:%s]", BuildDate, Commit, GitSummary, GitBranch)),
 		}
 
-		if logLevel := os.Getenv("LOG_LEVEL"); logLevel != "" {
-			switch logLevel {
-			case "debug":
-				options = append(options, logger2.WithDebugLevel())
-			case "warn":
-				options = append(options, logger2.WithWarnLevel())
-			case "error":
-				options = append(options, logger2.WithErrorLevel())
-			default:
-				options = append(options, logger2.WithInfoLevel())
-			}
-		}
-
 		logger, err = logger2.NewJSONLogger(options...)
 		if err != nil {
 			panic(err)
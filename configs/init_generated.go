@@ -23,6 +23,20 @@ func LoadSettingDicts(dicts []*models.SettingDict) {
 			shared.LinkFileAutoDelete = dict.Value.Bool()
 		case models.SettingDictKeyStrmBaseURL:
 			shared.StrmBaseURL = dict.Value.Value()
+		case models.SettingDictKeyTokenHealthCheckMinutes:
+			shared.TokenHealthCheckMinutes = dict.Value.Int()
+		case models.SettingDictKeyTokenExpireWarnDays:
+			shared.TokenExpireWarnDays = dict.Value.Int()
+		case models.SettingDictKeyMountUnhealthyActions:
+			shared.MountUnhealthyActions = dict.Value.StringSlice()
+		case models.SettingDictKeyMirrorAutoDetect:
+			shared.MirrorAutoDetect = dict.Value.Bool()
+		case models.SettingDictKeyDuplicateHideTargets:
+			shared.DuplicateHideTargets = dict.Value.StringSlice()
+		case models.SettingDictKeyFileEventRetentionDays:
+			shared.FileEventRetentionDays = dict.Value.Int()
+		case models.SettingDictKeyAdminRequireTwoFactor:
+			shared.AdminRequireTwoFactor = dict.Value.Bool()
 		}
 	}
 }
@@ -0,0 +1,162 @@
+package configs
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/zeromicro/go-zero/core/conf"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 配置文件变化的检查间隔
+const watchInterval = 3 * time.Second
+
+var (
+	current  atomic.Pointer[Config]
+	logLevel = zap.NewAtomicLevel()
+)
+
+func init() {
+	// 调用 Load 之前使用默认配置，GetConfig 不会返回 nil
+	cfg := new(Config)
+	_ = conf.LoadFromJsonBytes([]byte("{}"), cfg)
+	cfg.normalize()
+
+	current.Store(cfg)
+}
+
+// loadConfig 读取配置文件并应用环境变量；文件不存在时只使用默认值和环境变量
+func loadConfig(path string) (*Config, error) {
+	cfg := new(Config)
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err = conf.LoadFromJsonBytes([]byte("{}"), cfg); err != nil {
+			return nil, err
+		}
+	} else if err = conf.Load(path, cfg); err != nil {
+		return nil, err
+	}
+
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+
+	// 兼容旧的 LOG_LEVEL 环境变量
+	if level := os.Getenv("LOG_LEVEL"); level != "" && os.Getenv(envName("logLevel")) == "" {
+		if _, err := zapcore.ParseLevel(level); err == nil {
+			cfg.LogLevel = level
+		}
+	}
+
+	cfg.normalize()
+
+	if _, err := zapcore.ParseLevel(cfg.LogLevel); err != nil {
+		return nil, errors.Errorf("日志级别不合法：%s", cfg.LogLevel)
+	}
+
+	return cfg, nil
+}
+
+// Reload 重新加载配置，只有标记 reload 的字段生效，其余字段保持原值并提示需重启
+func Reload() error {
+	next, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	var (
+		old       = current.Load()
+		ov        = reflect.ValueOf(old).Elem()
+		nv        = reflect.ValueOf(next).Elem()
+		t         = nv.Type()
+		changed   []string
+		needStart []string
+	)
+
+	for i := 0; i < t.NumField(); i++ {
+		if reflect.DeepEqual(ov.Field(i).Interface(), nv.Field(i).Interface()) {
+			continue
+		}
+
+		key := jsonKey(t.Field(i))
+
+		if t.Field(i).Tag.Get("reload") == "true" {
+			changed = append(changed, key)
+
+			continue
+		}
+
+		needStart = append(needStart, key)
+		nv.Field(i).Set(ov.Field(i))
+	}
+
+	current.Store(next)
+	setLogLevel(next.LogLevel)
+
+	if len(changed) > 0 {
+		logger.Info("配置已重新加载", zap.Strings("changed", changed))
+	}
+
+	if len(needStart) > 0 {
+		logger.Warn("以下配置需重启后生效", zap.Strings("fields", needStart))
+	}
+
+	return nil
+}
+
+func setLogLevel(level string) {
+	if l, err := zapcore.ParseLevel(level); err == nil {
+		logLevel.SetLevel(l)
+	}
+}
+
+// Watch 收到 SIGHUP 或配置文件修改时间变化时重新加载配置，ctx 结束后退出
+func Watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hup)
+
+		ticker := time.NewTicker(watchInterval)
+		defer ticker.Stop()
+
+		modTime := fileModTime(configPath)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				logger.Info("收到 SIGHUP，重新加载配置")
+			case <-ticker.C:
+				mt := fileModTime(configPath)
+				if mt.Equal(modTime) {
+					continue
+				}
+
+				modTime = mt
+			}
+
+			if err := Reload(); err != nil {
+				logger.Error("重新加载配置失败，继续使用原配置", zap.Error(err))
+			}
+		}
+	}()
+}
+
+func fileModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
@@ -18,6 +18,7 @@ func Logger() *zap.Logger {
 	return logger
 }
 
+// GetConfig 当前生效的配置，重新加载后返回新的实例，调用方不要修改
 func GetConfig() *Config {
-	return c
+	return current.Load()
 }
@@ -1,4 +1,5 @@
 import api from './index'
+import { basePath } from '@/utils/basePath'
 
 // 高级操作相关接口类型定义
 
@@ -81,6 +82,21 @@ export interface BusDetailInfo {
 // 获取总线详情响应
 export interface BusDetailResponse extends BusDetailInfo {}
 
+// 实时事件凭证
+export interface StreamTicketResponse {
+  ticket: string
+  expiresIn: number
+}
+
+// 实时事件消息，事件名与 topic 相同
+export interface StreamMessage<T = any> {
+  id: number
+  topic: string
+  type: string
+  time: number
+  data: T
+}
+
 // 高级操作 API
 export const advancedOpsApi = {
   // 重建 STRM 文件
@@ -98,4 +114,16 @@ export const advancedOpsApi = {
     return api.get('/advanced_ops/bus_detail')
   },
 
+  // 获取建立实时事件连接的短期凭证，EventSource 无法携带 Authorization 头
+  getStreamTicket: (): Promise<StreamTicketResponse> => {
+    return api.post('/event_stream/ticket')
+  },
+
+  // 实时事件地址，topics 为空时订阅全部
+  eventStreamURL: (ticket: string, topics: string[] = []): string => {
+    const params = new URLSearchParams({ ticket })
+    if (topics.length) params.set('topics', topics.join(','))
+    return `${basePath}/api/event_stream?${params.toString()}`
+  },
+
 }
@@ -1,10 +1,11 @@
 import axios, { AxiosInstance, InternalAxiosRequestConfig, AxiosResponse } from 'axios'
 import { useAuthStore } from '@/stores/auth'
 import router from '@/router'
+import { basePath } from '@/utils/basePath'
 
 // 创建axios实例
 const api: AxiosInstance = axios.create({
-  baseURL: '/api',
+  baseURL: basePath + '/api',
   timeout: 10000,
   headers: {
     'Content-Type': 'application/json'
@@ -32,7 +33,8 @@ api.interceptors.response.use(
     return response.data
   },
   (error) => {
-    if (error.response?.status === 401) {
+    // 分享页的 401 表示需要提取码，与登录状态无关
+    if (error.response?.status === 401 && !error.config?.url?.startsWith('/share/')) {
       const authStore = useAuthStore()
       authStore.logout()
       // 只有在不是根路径和登录页时才跳转，避免初始化时的循环跳转
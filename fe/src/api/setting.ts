@@ -94,9 +94,44 @@ export interface ModifyStrmBaseURLRequest {
   strmBaseURL: string
 }
 
-// 修改操作的通用响应
-export interface ModifyResponse {
-  rowsAffected: number
+// 设置分组
+export interface SettingGroup {
+  key: string
+  label: string
+}
+
+// 设置项描述
+export interface SettingSchemaItem {
+  key: string
+  field: string // PATCH /setting 中的字段名
+  type: 'int' | 'int64' | 'bool' | 'string' | 'json'
+  group: string
+  label: string
+  description: string
+  validate: string // 校验规则，gin binding 语法
+  options: string[] | null
+  restartRequired: boolean
+  default: unknown
+  value: unknown
+}
+
+export interface SettingSchema {
+  groups: SettingGroup[]
+  items: SettingSchemaItem[]
+}
+
+// 批量修改设置请求，只修改传入的字段
+export type PatchSettingRequest = Partial<Omit<Setting, 'id' | 'runTimes' | 'createdAt' | 'updatedAt' | 'initialized'>>
+
+// 批量修改设置响应
+export interface PatchSettingResponse {
+  changed: string[] // 发生变化的设置项键名
+  restartRequired: boolean
+}
+
+// 批量修改设置，以下单项修改均通过该接口完成
+const patchSetting = (data: PatchSettingRequest): Promise<PatchSettingResponse> => {
+  return api.patch('/setting', data)
 }
 
 // 设置API
@@ -106,9 +141,17 @@ export const settingApi = {
     return api.get('/setting/get')
   },
 
+  // 获取设置项描述及当前值
+  getSchema: (): Promise<SettingSchema> => {
+    return api.get('/setting/schema')
+  },
+
+  // 批量修改设置
+  patchSetting,
+
   // 修改网站名称
-  modifyName: (data: ModifyNameRequest): Promise<void> => {
-    return api.post('/setting/modify_name', data)
+  modifyName: (data: ModifyNameRequest): Promise<PatchSettingResponse> => {
+    return patchSetting({ title: data.name })
   },
 
   // 刷新密钥
@@ -117,68 +160,68 @@ export const settingApi = {
   },
 
   // 切换认证状态
-  toggleAuth: (data: ToggleAuthRequest): Promise<void> => {
-    return api.post('/setting/toggle_auth', data)
+  toggleAuth: (data: ToggleAuthRequest): Promise<PatchSettingResponse> => {
+    return patchSetting({ enableAuth: !data.disable })
   },
 
   // 切换本地代理状态
-  toggleLocalProxy: (data: ToggleLocalProxyRequest): Promise<void> => {
-    return api.post('/setting/toggle_local_proxy', data)
+  toggleLocalProxy: (data: ToggleLocalProxyRequest): Promise<PatchSettingResponse> => {
+    return patchSetting({ localProxy: !data.disable })
   },
 
   // 切换多线程流加速状态
-  toggleMultipleStream: (data: ToggleMultipleStreamRequest): Promise<void> => {
-    return api.post('/setting/toggle_multiple_stream', data)
+  toggleMultipleStream: (data: ToggleMultipleStreamRequest): Promise<PatchSettingResponse> => {
+    return patchSetting({ multipleStream: !data.disable })
   },
 
   // 修改基础URL
-  modifyBaseURL: (data: ModifyBaseURLRequest): Promise<void> => {
-    return api.post('/setting/modify_base_url', data)
+  modifyBaseURL: (data: ModifyBaseURLRequest): Promise<PatchSettingResponse> => {
+    return patchSetting({ baseURL: data.baseURL })
   },
 
   // 切换挂载文件自动刷新状态
-  toggleEnableTopFileAutoRefresh: (data: ToggleEnableTopFileAutoRefreshRequest): Promise<void> => {
-    return api.post('/setting/toggle_enable_top_file_auto_refresh', data)
+  toggleEnableTopFileAutoRefresh: (data: ToggleEnableTopFileAutoRefreshRequest): Promise<PatchSettingResponse> => {
+    return patchSetting({ enableTopFileAutoRefresh: !data.disable })
   },
 
   // 修改任务线程数
-  modifyJobThreadCount: (data: ModifyJobThreadCountRequest): Promise<void> => {
-    return api.post('/setting/modify_job_thread_count', data)
+  modifyJobThreadCount: (data: ModifyJobThreadCountRequest): Promise<PatchSettingResponse> => {
+    return patchSetting({ jobThreadCount: data.threadCount })
   },
 
   // 修改自动刷新间隔
-  modifyAutoRefreshMinutes: (data: ModifyAutoRefreshMinutesRequest): Promise<void> => {
-    return api.post('/setting/modify_auto_refresh_minutes', data)
+  modifyAutoRefreshMinutes: (data: ModifyAutoRefreshMinutesRequest): Promise<PatchSettingResponse> => {
+    return patchSetting({ autoRefreshMinutes: data.autoRefreshMinutes })
   },
 
   // 修改多线程流线程数
-  modifyMultipleStreamThreadCount: (data: ModifyMultipleStreamThreadCountRequest): Promise<ModifyResponse> => {
-    return api.post('/setting/modify_multiple_stream_thread_count', data)
+  modifyMultipleStreamThreadCount: (data: ModifyMultipleStreamThreadCountRequest): Promise<PatchSettingResponse> => {
+    return patchSetting(data)
   },
 
   // 修改多线程流块大小
-  modifyMultipleStreamChunkSize: (data: ModifyMultipleStreamChunkSizeRequest): Promise<ModifyResponse> => {
-    return api.post('/setting/modify_multiple_stream_chunk_size', data)
+  modifyMultipleStreamChunkSize: (data: ModifyMultipleStreamChunkSizeRequest): Promise<PatchSettingResponse> => {
+    return patchSetting(data)
   },
 
   // 切换STRM文件启用状态
-  toggleStrmFileEnable: (data: ToggleStrmFileEnableRequest): Promise<ModifyResponse> => {
-    return api.post('/setting/toggle_strm_file_enable', data)
+  toggleStrmFileEnable: (data: ToggleStrmFileEnableRequest): Promise<PatchSettingResponse> => {
+    return patchSetting(data)
   },
 
   // 修改STRM支持文件扩展名列表
-  modifyStrmSupportFileExtList: (data: ModifyStrmSupportFileExtListRequest): Promise<ModifyResponse> => {
-    return api.post('/setting/modify_strm_support_file_ext_list', data)
+  modifyStrmSupportFileExtList: (data: ModifyStrmSupportFileExtListRequest): Promise<PatchSettingResponse> => {
+    return patchSetting(data)
   },
 
   // 切换关联文件自动删除
-  toggleLinkFileAutoDelete: (data: ToggleLinkFileAutoDeleteRequest): Promise<ModifyResponse> => {
-    return api.post('/setting/toggle_link_file_auto_delete', data)
+  toggleLinkFileAutoDelete: (data: ToggleLinkFileAutoDeleteRequest): Promise<PatchSettingResponse> => {
+    return patchSetting(data)
   },
 
   // 修改STRM基础URL
-  modifyStrmBaseURL: (data: ModifyStrmBaseURLRequest): Promise<ModifyResponse> => {
-    return api.post('/setting/modify_strm_base_url', data)
+  modifyStrmBaseURL: (data: ModifyStrmBaseURLRequest): Promise<PatchSettingResponse> => {
+    return patchSetting(data)
   },
 
   // 初始化系统
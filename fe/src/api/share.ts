@@ -0,0 +1,124 @@
+import api from './index'
+
+export type ShareLinkStatus = 'active' | 'expired' | 'exhausted'
+
+// 分享链接
+export interface ShareLink {
+  id: number
+  code: string
+  userId: number
+  fileId: number
+  name: string
+  hasPassword: boolean // 提取码只保存哈希，创建后无法再查看
+  expiresAt: string | null
+  maxDownloads: number
+  allowPreview: boolean
+  allowDownload: boolean
+  viewCount: number
+  previewCount: number
+  downloadCount: number
+  lastAccessAt: string | null
+  createdAt: string
+  updatedAt: string
+  url: string
+  status: ShareLinkStatus
+  username?: string
+}
+
+// 新建时传 fileId，修改时传 id
+export interface SaveShareLinkRequest {
+  id?: number
+  fileId?: number
+  password?: string // 修改时不传保持不变，空字符串表示取消提取码
+  expiresAt: string | null
+  maxDownloads: number
+  allowPreview: boolean
+  allowDownload: boolean
+}
+
+export interface ShareLinkListRequest {
+  all?: boolean
+  fileId?: number
+  currentPage?: number
+  pageSize?: number
+}
+
+export interface ShareLinkListResponse {
+  total: number
+  currentPage: number
+  pageSize: number
+  data: ShareLink[]
+}
+
+// 访问者看到的分享信息
+export interface ShareInfo {
+  code: string
+  name: string
+  isFolder: boolean
+  size: number
+  ownerName: string
+  expiresAt: string | null
+  allowPreview: boolean
+  allowDownload: boolean
+  passwordRequired: boolean
+  remainingDownloads: number // -1 表示不限
+  status: ShareLinkStatus
+  createdAt: string
+}
+
+export interface ShareFile {
+  name: string
+  path: string
+  isFolder: boolean
+  size: number
+  modifyDate: string
+}
+
+export interface ShareBrowseResponse {
+  file: ShareFile
+  children: ShareFile[]
+}
+
+// 分享内文件的预览、下载地址，浏览器直接打开，不经过 axios
+const shareFileURL = (code: string, action: 'preview' | 'download', path: string, token: string): string => {
+  const params = new URLSearchParams({ path })
+  if (token) {
+    params.set('token', token)
+  }
+  return `${api.defaults.baseURL}/share/${encodeURIComponent(code)}/${action}?${params.toString()}`
+}
+
+export const shareApi = {
+  // 我的分享，管理员传 all 查看全部
+  getList: (params: ShareLinkListRequest): Promise<ShareLinkListResponse> => {
+    return api.get('/share_link/list', { params })
+  },
+
+  // 新建或修改分享
+  save: (data: SaveShareLinkRequest): Promise<ShareLink> => {
+    return api.post('/share_link/save', data)
+  },
+
+  // 取消分享
+  delete: (id: number): Promise<void> => {
+    return api.post('/share_link/delete', { id })
+  },
+
+  // 以下为匿名访问接口
+  getInfo: (code: string, token: string): Promise<ShareInfo> => {
+    return api.get(`/share/${encodeURIComponent(code)}`, { params: { token } })
+  },
+
+  // 校验提取码，返回访问 token
+  verify: (code: string, password: string): Promise<{ token: string, expiresIn: number }> => {
+    return api.post(`/share/${encodeURIComponent(code)}/verify`, { password })
+  },
+
+  browse: (code: string, path: string, token: string): Promise<ShareBrowseResponse> => {
+    return api.get(`/share/${encodeURIComponent(code)}/browse`, { params: { path, token } })
+  },
+
+  previewURL: (code: string, path: string, token: string): string => shareFileURL(code, 'preview', path, token),
+
+  downloadURL: (code: string, path: string, token: string): string => shareFileURL(code, 'download', path, token),
+}
@@ -137,14 +137,23 @@ export interface SearchRequest {
   keyword: string
   pid?: number
   global?: boolean
+  ext?: string
+  type?: 'file' | 'folder'
+  minSize?: number
+  maxSize?: number
+  modifiedAfter?: string
+  modifiedBefore?: string
+  mountId?: number
+  cursor?: number
   pageSize: number
-  currentPage: number
+  currentPage?: number
 }
 
 export interface SearchResponse {
   total: number
   currentPage: number
   pageSize: number
+  nextCursor: number
   data: SearchItem[]
 }
 
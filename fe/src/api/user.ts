@@ -12,6 +12,32 @@ export interface LoginResponse {
   tokenType: string
   expiresIn: number
   user: User
+  recoveryCodes?: string[] // 登录时完成两步验证绑定才会返回
+}
+
+// 密码验证通过后需要完成两步验证
+export interface TwoFactorChallenge {
+  twoFactorRequired: boolean
+  setupRequired: boolean
+  challengeToken: string
+  expiresIn: number
+}
+
+export interface LoginTwoFactorRequest {
+  challengeToken: string
+  code?: string
+  recoveryCode?: string
+}
+
+export interface TwoFactorSetupResponse {
+  secret: string
+  uri: string
+}
+
+export interface TwoFactorDisableRequest {
+  password: string
+  code?: string
+  recoveryCode?: string
 }
 
 export interface User {
@@ -24,6 +50,7 @@ export interface User {
   updatedAt: string
   groupName?: string
   groupId: number
+  totpEnabled: boolean
 }
 
 export interface RefreshTokenRequest {
@@ -65,6 +92,36 @@ export interface UserListResponse {
   data: User[]
 }
 
+// 登录会话，每次登录创建一个，刷新Token使用后轮换
+export interface Session {
+  id: number
+  userId: number
+  device: string
+  userAgent: string
+  ip: string
+  lastSeenAt: string
+  expiresAt: string
+  revokedAt: string | null
+  revokeReason: string // logout、revoked、reuse_detected、credential_changed
+  createdAt: string
+  current?: boolean // 是否为当前会话，仅在自己的会话列表中返回
+  username?: string // 仅在管理员的会话列表中返回
+}
+
+export interface SessionListRequest {
+  userId?: number
+  onlyActive?: boolean
+  currentPage?: number
+  pageSize?: number
+}
+
+export interface SessionListResponse {
+  total: number
+  currentPage: number
+  pageSize: number
+  data: Session[]
+}
+
 export interface BindGroupRequest {
   userId: number
   groupId: number // 0 表示默认用户组
@@ -80,10 +137,20 @@ export interface BindGroupResponse {
 // 用户API
 export const userApi = {
   // 登录
-  login: (data: LoginRequest): Promise<LoginResponse> => {
+  login: (data: LoginRequest): Promise<LoginResponse | TwoFactorChallenge> => {
     return api.post('/user/login', data)
   },
 
+  // 登录第二步：验证码或恢复码
+  loginTwoFactor: (data: LoginTwoFactorRequest): Promise<LoginResponse> => {
+    return api.post('/user/login/two_factor', data)
+  },
+
+  // 登录时被要求启用两步验证，获取绑定密钥
+  loginTwoFactorSetup: (challengeToken: string): Promise<TwoFactorSetupResponse> => {
+    return api.post('/user/login/two_factor/setup', { challengeToken })
+  },
+
   // 刷新token
   refreshToken: (data: RefreshTokenRequest): Promise<LoginResponse> => {
     return api.post('/user/refresh_token', data)
@@ -127,4 +194,49 @@ export const userApi = {
   bindGroup: (data: BindGroupRequest): Promise<BindGroupResponse> => {
     return api.post('/user/bind_group', data)
   },
+
+  // 生成两步验证密钥，验证通过后才启用
+  twoFactorSetup: (): Promise<TwoFactorSetupResponse> => {
+    return api.post('/user/two_factor/setup')
+  },
+
+  // 启用两步验证，返回恢复码
+  twoFactorEnable: (code: string): Promise<{ recoveryCodes: string[] }> => {
+    return api.post('/user/two_factor/enable', { code })
+  },
+
+  // 关闭两步验证
+  twoFactorDisable: (data: TwoFactorDisableRequest): Promise<void> => {
+    return api.post('/user/two_factor/disable', data)
+  },
+
+  // 重新生成恢复码
+  twoFactorRecoveryCodes: (code: string): Promise<{ recoveryCodes: string[] }> => {
+    return api.post('/user/two_factor/recovery_codes', { code })
+  },
+
+  // 退出登录，注销当前会话
+  logout: (): Promise<void> => {
+    return api.post('/user/logout')
+  },
+
+  // 当前用户的有效会话
+  getSessions: (): Promise<Session[]> => {
+    return api.get('/user/sessions')
+  },
+
+  // 注销会话
+  revokeSession: (id: number): Promise<void> => {
+    return api.post('/user/session/revoke', { id })
+  },
+
+  // 管理员查看所有会话
+  getSessionList: (params: SessionListRequest): Promise<SessionListResponse> => {
+    return api.get('/user/session/list', { params })
+  },
+
+  // 管理员重置用户的两步验证
+  twoFactorReset: (id: number): Promise<void> => {
+    return api.post('/user/two_factor/reset', { id })
+  },
 }
\ No newline at end of file
@@ -1,9 +1,10 @@
 import { createRouter, createWebHistory } from 'vue-router'
 import { useAuthStore } from '@/stores/auth'
 import { useSettingStore } from '@/stores/setting'
+import { basePath } from '@/utils/basePath'
 
 const router = createRouter({
-  history: createWebHistory(),
+  history: createWebHistory(basePath + '/'),
   routes: [
     {
       path: '/',
@@ -33,6 +34,15 @@ const router = createRouter({
         title: '登录'
       }
     },
+    {
+      path: '/@share/:code',
+      name: 'ShareView',
+      component: () => import('@/views/ShareView.vue'),
+      meta: {
+        requiresAuth: false,
+        title: '分享'
+      }
+    },
     {
       path: '/@admin',
       name: 'Admin',
@@ -100,6 +110,15 @@ const router = createRouter({
             title: '系统设置'
           }
         },
+        {
+          path: 'share_links',
+          name: 'ShareLinks',
+          component: () => import('@/views/ShareLinks.vue'),
+          meta: {
+            requiresAuth: true,
+            title: '我的分享'
+          }
+        },
         {
           path: 'profile',
           name: 'Profile',
@@ -1,6 +1,6 @@
 import { defineStore } from 'pinia'
 import { ref, computed } from 'vue'
-import { userApi, type User, type LoginRequest } from '@/api/user'
+import { userApi, type User, type LoginRequest, type LoginResponse, type LoginTwoFactorRequest } from '@/api/user'
 import { isAdmin } from '@/utils/permissions'
 
 export const useAuthStore = defineStore('auth', () => {
@@ -17,20 +17,29 @@ export const useAuthStore = defineStore('auth', () => {
     return isAdmin(user.value.permissions)
   })
 
-  // 登录
+  // 保存登录结果
+  const setSession = (response: LoginResponse) => {
+    token.value = response.accessToken
+    refreshToken.value = response.refreshToken
+    user.value = response.user
+
+    // 保存到localStorage
+    localStorage.setItem('token', response.accessToken)
+    localStorage.setItem('refreshToken', response.refreshToken)
+  }
+
+  // 登录，需要两步验证时返回验证令牌，由页面继续完成第二步
   const login = async (loginData: LoginRequest) => {
     loading.value = true
     try {
       const response = await userApi.login(loginData)
-      
-      token.value = response.accessToken
-      refreshToken.value = response.refreshToken
-      user.value = response.user
-      
-      // 保存到localStorage
-      localStorage.setItem('token', response.accessToken)
-      localStorage.setItem('refreshToken', response.refreshToken)
-      
+
+      if ('twoFactorRequired' in response) {
+        return response
+      }
+
+      setSession(response)
+
       return response
     } catch (error) {
       throw error
@@ -39,6 +48,20 @@ export const useAuthStore = defineStore('auth', () => {
     }
   }
 
+  // 两步验证
+  const loginTwoFactor = async (data: LoginTwoFactorRequest) => {
+    loading.value = true
+    try {
+      const response = await userApi.loginTwoFactor(data)
+
+      setSession(response)
+
+      return response
+    } finally {
+      loading.value = false
+    }
+  }
+
   // 刷新token
   const refresh = async () => {
     if (!refreshToken.value) {
@@ -47,14 +70,9 @@ export const useAuthStore = defineStore('auth', () => {
     
     try {
       const response = await userApi.refreshToken({ refreshToken: refreshToken.value })
-      
-      token.value = response.accessToken
-      refreshToken.value = response.refreshToken
-      user.value = response.user
-      
-      localStorage.setItem('token', response.accessToken)
-      localStorage.setItem('refreshToken', response.refreshToken)
-      
+
+      setSession(response)
+
       return response
     } catch (error) {
       logout()
@@ -84,6 +102,19 @@ export const useAuthStore = defineStore('auth', () => {
     localStorage.removeItem('refreshToken')
   }
 
+  // 退出登录，先注销服务端会话，失败时也清除本地状态
+  const signOut = async () => {
+    if (token.value) {
+      try {
+        await userApi.logout()
+      } catch (error) {
+        console.error('注销会话失败:', error)
+      }
+    }
+
+    logout()
+  }
+
   // 初始化 - 如果有token则获取用户信息
   const initialize = async () => {
     if (token.value) {
@@ -110,9 +141,11 @@ export const useAuthStore = defineStore('auth', () => {
     
     // 方法
     login,
+    loginTwoFactor,
     refresh,
     fetchUserInfo,
     logout,
+    signOut,
     initialize
   }
 })
\ No newline at end of file
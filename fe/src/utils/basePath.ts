@@ -0,0 +1,11 @@
+declare global {
+  interface Window {
+    __BASE_PATH__?: string
+  }
+}
+
+// 部署子路径，由服务端注入到 index.html，根路径部署时为空字符串
+export const basePath: string = (window.__BASE_PATH__ || '').replace(/\/+$/, '')
+
+// 当前站点的访问地址（含子路径）
+export const siteURL = (): string => window.location.origin + basePath
@@ -0,0 +1,27 @@
+import type { ShareLink } from '@/api/share'
+import { toast } from '@/utils/toast'
+
+// 复制分享链接，传入提取码时一并附上；提取码只在创建时可见
+export const copyShare = async (link: ShareLink, password = '') => {
+  const text = password ? `链接：${link.url} 提取码：${password}` : link.url
+
+  try {
+    if (navigator.clipboard && window.isSecureContext) {
+      await navigator.clipboard.writeText(text)
+    } else {
+      // 非 HTTPS 页面没有剪贴板 API，使用传统方法
+      const textArea = document.createElement('textarea')
+      textArea.value = text
+      textArea.style.position = 'fixed'
+      textArea.style.left = '-999999px'
+      document.body.appendChild(textArea)
+      textArea.select()
+      document.execCommand('copy')
+      document.body.removeChild(textArea)
+    }
+    toast.success('分享链接已复制到剪贴板')
+  } catch (error) {
+    console.error('复制失败:', error)
+    toast.error('复制失败，请手动复制')
+  }
+}
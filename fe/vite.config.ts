@@ -3,6 +3,8 @@ import vue from '@vitejs/plugin-vue'
 import { resolve } from 'path'
 
 export default defineConfig({
+  // 资源使用相对路径，便于部署在反向代理的子路径下
+  base: './',
   plugins: [vue()],
   resolve: {
     alias: {
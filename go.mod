@@ -27,6 +27,7 @@ require (
 	gopkg.in/natefinch/lumberjack.v2 v2.2.1
 	gorm.io/datatypes v1.2.6
 	gorm.io/gorm v1.30.1
+	resty.dev/v3 v3.0.0-beta.3
 )
 
 require (
@@ -76,5 +77,4 @@ require (
 	modernc.org/mathutil v1.5.0 // indirect
 	modernc.org/memory v1.5.0 // indirect
 	modernc.org/sqlite v1.23.1 // indirect
-	resty.dev/v3 v3.0.0-beta.3 // indirect
 )
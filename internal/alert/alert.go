@@ -0,0 +1,74 @@
+package alert
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/xxcheng123/cloudpan189-share/configs"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/notify"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var onceLoad sync.Once
+
+var singletonAlerter *alerter
+
+type alerter struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+func Init() {
+	onceLoad.Do(func() {
+		singletonAlerter = &alerter{
+			db:     configs.DB(),
+			logger: configs.Logger().With(zap.String("module", "alert")),
+		}
+	})
+}
+
+// Send 向所有启用的通知渠道发送告警，单个渠道失败只记录日志
+func Send(ctx context.Context, level notify.Level, title, content string) {
+	if singletonAlerter == nil {
+		return
+	}
+
+	singletonAlerter.send(ctx, &notify.Message{
+		Title:   title,
+		Content: content,
+		Level:   level,
+		Time:    time.Now(),
+	})
+}
+
+func (a *alerter) send(ctx context.Context, msg *notify.Message) {
+	var list = make([]*models.Notifier, 0)
+	if err := a.db.WithContext(ctx).Where("enabled = ?", true).Find(&list).Error; err != nil {
+		a.logger.Error("查询通知渠道失败", zap.Error(err))
+
+		return
+	}
+
+	for _, n := range list {
+		notifier, err := notify.New(n.Type, n.Config)
+		if err != nil {
+			a.logger.Error("通知渠道配置错误",
+				zap.Int64("notifierId", n.ID),
+				zap.String("name", n.Name),
+				zap.Error(err))
+
+			continue
+		}
+
+		if err = notifier.Send(ctx, msg); err != nil {
+			a.logger.Error("发送通知失败",
+				zap.Int64("notifierId", n.ID),
+				zap.String("name", n.Name),
+				zap.String("title", msg.Title),
+				zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Package audit 记录管理员对配置、挂载、用户、用户组、云盘令牌、备份和访问控制的变更
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/configs"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/trace"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 目标类型
+const (
+	TargetSetting    = "setting"
+	TargetStorage    = "storage"
+	TargetUser       = "user"
+	TargetUserGroup  = "user_group"
+	TargetCloudToken = "cloud_token"
+	TargetBackup     = "backup"
+	TargetIPRule     = "ip_rule"
+	TargetLoginLock  = "login_lock"
+	TargetSession    = "session"
+	TargetShareLink  = "share_link"
+)
+
+// Entry 一次操作，Before/After 会序列化为 JSON，调用方需自行去掉密码、令牌等敏感字段
+type Entry struct {
+	Action     models.AuditAction
+	TargetType string
+	TargetId   any
+	TargetName string
+	Before     any
+	After      any
+}
+
+type recorder struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+var (
+	onceLoad  sync.Once
+	singleton *recorder
+)
+
+// Init 初始化审计模块
+func Init() {
+	onceLoad.Do(func() {
+		singleton = &recorder{
+			db:     configs.DB(),
+			logger: configs.Logger().With(zap.String("module", "audit")),
+		}
+	})
+}
+
+// Record 记录一次操作，操作人取自认证中间件写入的 user_id/username；写入失败只记日志，不影响业务
+func Record(ctx *gin.Context, entry Entry) {
+	if singleton == nil {
+		return
+	}
+
+	log := &models.AuditLog{
+		UserId:     ctx.GetInt64("user_id"),
+		Username:   ctx.GetString("username"),
+		IP:         ctx.ClientIP(),
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetName: entry.TargetName,
+		Before:     marshal(entry.Before),
+		After:      marshal(entry.After),
+		RequestId:  trace.ID(ctx),
+	}
+
+	if entry.TargetId != nil {
+		log.TargetId = fmt.Sprint(entry.TargetId)
+	}
+
+	// 请求结束后 gin.Context 会被复用，这里同步写入
+	if err := singleton.db.WithContext(context.WithoutCancel(ctx.Request.Context())).Create(log).Error; err != nil {
+		trace.Logger(ctx, singleton.logger).Error("写入审计日志失败",
+			zap.String("action", entry.Action),
+			zap.String("target_id", log.TargetId),
+			zap.Error(err))
+	}
+}
+
+func marshal(v any) []byte {
+	if v == nil {
+		return nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+
+	return b
+}
@@ -0,0 +1,146 @@
+package authguard
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+)
+
+type rule struct {
+	network *net.IPNet
+	action  models.IPRuleAction
+	scope   models.IPRuleScope
+}
+
+// ParseCIDR 解析单个 IP 或网段，单个 IP 视为 /32 或 /128
+func ParseCIDR(s string) (*net.IPNet, error) {
+	s = strings.TrimSpace(s)
+
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, errors.Errorf("无效的 IP：%s", s)
+		}
+
+		bits := 128
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+
+	_, network, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, errors.Errorf("无效的网段：%s", s)
+	}
+
+	return network, nil
+}
+
+func compile(items []*models.IPRule) []*rule {
+	rules := make([]*rule, 0, len(items))
+
+	for _, item := range items {
+		network, err := ParseCIDR(item.CIDR)
+		if err != nil {
+			continue
+		}
+
+		rules = append(rules, &rule{
+			network: network,
+			action:  item.Action,
+			scope:   item.Scope,
+		})
+	}
+
+	return rules
+}
+
+// allowed deny 优先；该范围下存在 allow 规则时，只放行命中的 IP
+func allowed(rules []*rule, ip net.IP, scope models.IPRuleScope) bool {
+	var (
+		hasAllow bool
+		inAllow  bool
+	)
+
+	for _, r := range rules {
+		if r.scope != models.IPRuleScopeAll && r.scope != scope {
+			continue
+		}
+
+		matched := ip != nil && r.network.Contains(ip)
+
+		switch r.action {
+		case models.IPRuleDeny:
+			if matched {
+				return false
+			}
+		case models.IPRuleAllow:
+			hasAllow = true
+			inAllow = inAllow || matched
+		}
+	}
+
+	return !hasAllow || inAllow
+}
+
+// Allowed 按给定的规则判断 ip 能否访问 scope，用于保存规则前检查是否会把自己拦在外面
+func Allowed(items []*models.IPRule, ip string, scope models.IPRuleScope) bool {
+	return allowed(compile(items), net.ParseIP(ip), scope)
+}
+
+func (g *guard) reloadRules(ctx context.Context) error {
+	var items = make([]*models.IPRule, 0)
+	if err := g.db.WithContext(ctx).Order("id").Find(&items).Error; err != nil {
+		return err
+	}
+
+	rules := compile(items)
+
+	g.rulesMu.Lock()
+	g.rules = rules
+	g.rulesMu.Unlock()
+
+	return nil
+}
+
+// ReloadRules 规则变更后重新加载到内存
+func ReloadRules(ctx context.Context) error {
+	if singleton == nil {
+		return nil
+	}
+
+	return singleton.reloadRules(ctx)
+}
+
+// IPFilter 按 IP 规则拒绝请求，scope 为 api 或 dav
+func IPFilter(scope models.IPRuleScope) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if singleton == nil {
+			ctx.Next()
+
+			return
+		}
+
+		singleton.rulesMu.RLock()
+		rules := singleton.rules
+		singleton.rulesMu.RUnlock()
+
+		if len(rules) > 0 && !allowed(rules, net.ParseIP(ctx.ClientIP()), scope) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"code": http.StatusForbidden,
+				"msg":  "当前 IP 禁止访问",
+			})
+
+			return
+		}
+
+		ctx.Next()
+	}
+}
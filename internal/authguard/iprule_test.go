@@ -0,0 +1,84 @@
+package authguard
+
+import (
+	"testing"
+
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+)
+
+func TestParseCIDR(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"192.168.1.10", "192.168.1.10/32", false},
+		{" 10.0.0.0/8 ", "10.0.0.0/8", false},
+		{"192.168.1.10/24", "192.168.1.0/24", false},
+		{"::1", "::1/128", false},
+		{"fd00::/8", "fd00::/8", false},
+		{"", "", true},
+		{"example.com", "", true},
+		{"10.0.0.0/33", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			network, err := ParseCIDR(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseCIDR(%q) = %v, want error", tt.in, network)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseCIDR(%q) error = %v", tt.in, err)
+			}
+
+			if got := network.String(); got != tt.want {
+				t.Errorf("ParseCIDR(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	var (
+		allowLAN = &models.IPRule{CIDR: "192.168.0.0/16", Action: models.IPRuleAllow, Scope: models.IPRuleScopeAll}
+		denyHost = &models.IPRule{CIDR: "192.168.1.10", Action: models.IPRuleDeny, Scope: models.IPRuleScopeAll}
+		denyDav  = &models.IPRule{CIDR: "10.0.0.0/8", Action: models.IPRuleDeny, Scope: models.IPRuleScopeDav}
+		allowAPI = &models.IPRule{CIDR: "172.16.0.1", Action: models.IPRuleAllow, Scope: models.IPRuleScopeAPI}
+		invalid  = &models.IPRule{CIDR: "not-an-ip", Action: models.IPRuleDeny, Scope: models.IPRuleScopeAll}
+	)
+
+	tests := []struct {
+		name  string
+		rules []*models.IPRule
+		ip    string
+		scope models.IPRuleScope
+		want  bool
+	}{
+		{"no rules", nil, "1.2.3.4", models.IPRuleScopeAPI, true},
+		{"in allow list", []*models.IPRule{allowLAN}, "192.168.3.4", models.IPRuleScopeAPI, true},
+		{"outside allow list", []*models.IPRule{allowLAN}, "8.8.8.8", models.IPRuleScopeAPI, false},
+		{"deny wins over allow", []*models.IPRule{allowLAN, denyHost}, "192.168.1.10", models.IPRuleScopeDav, false},
+		{"deny only matching host", []*models.IPRule{allowLAN, denyHost}, "192.168.1.11", models.IPRuleScopeDav, true},
+		{"scoped deny applies to its scope", []*models.IPRule{denyDav}, "10.1.2.3", models.IPRuleScopeDav, false},
+		{"scoped deny ignored elsewhere", []*models.IPRule{denyDav}, "10.1.2.3", models.IPRuleScopeAPI, true},
+		{"scoped allow list", []*models.IPRule{allowAPI}, "172.16.0.2", models.IPRuleScopeAPI, false},
+		{"scoped allow list ignored elsewhere", []*models.IPRule{allowAPI}, "172.16.0.2", models.IPRuleScopeDav, true},
+		{"invalid rule skipped", []*models.IPRule{invalid}, "1.2.3.4", models.IPRuleScopeAPI, true},
+		{"unparsable ip with allow list", []*models.IPRule{allowLAN}, "", models.IPRuleScopeAPI, false},
+		{"unparsable ip with deny only", []*models.IPRule{denyHost}, "", models.IPRuleScopeAPI, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Allowed(tt.rules, tt.ip, tt.scope); got != tt.want {
+				t.Errorf("Allowed(%q, %q) = %v, want %v", tt.ip, tt.scope, got, tt.want)
+			}
+		})
+	}
+}
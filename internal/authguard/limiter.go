@@ -0,0 +1,186 @@
+package authguard
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xxcheng123/cloudpan189-share/configs"
+)
+
+const (
+	keyPrefixIP   = "ip:"
+	keyPrefixUser = "user:"
+)
+
+// counter 滑动窗口内的失败时间点，达到上限后在 lockedUntil 之前拒绝认证
+type counter struct {
+	failures    []time.Time
+	lockedUntil time.Time
+}
+
+// Lock 一个被锁定的 IP 或用户名
+type Lock struct {
+	Type        string    `json:"type"` // ip、username
+	Value       string    `json:"value"`
+	LockedUntil time.Time `json:"lockedUntil"`
+}
+
+func ipKey(ip string) string {
+	return keyPrefixIP + ip
+}
+
+// userKey 用户名不区分大小写，防止变换大小写绕过限制
+func userKey(username string) string {
+	return keyPrefixUser + strings.ToLower(username)
+}
+
+func window() time.Duration {
+	minutes := configs.GetConfig().LoginWindowMinutes
+	if minutes <= 0 {
+		minutes = 15
+	}
+
+	return time.Duration(minutes) * time.Minute
+}
+
+func lockDuration() time.Duration {
+	minutes := configs.GetConfig().LoginLockMinutes
+	if minutes <= 0 {
+		minutes = 15
+	}
+
+	return time.Duration(minutes) * time.Minute
+}
+
+func (g *guard) locked(now time.Time, keys ...string) (time.Duration, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var wait time.Duration
+
+	for _, key := range keys {
+		c, ok := g.counters[key]
+		if !ok {
+			continue
+		}
+
+		if d := c.lockedUntil.Sub(now); d > wait {
+			wait = d
+		}
+	}
+
+	return wait, wait > 0
+}
+
+func (g *guard) fail(now time.Time, key string, max int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	c, ok := g.counters[key]
+	if !ok {
+		c = new(counter)
+		g.counters[key] = c
+	}
+
+	c.failures = append(trim(c.failures, now.Add(-window())), now)
+
+	if max > 0 && len(c.failures) >= max {
+		c.lockedUntil = now.Add(lockDuration())
+		// 解锁后重新计数
+		c.failures = nil
+	}
+}
+
+func (g *guard) reset(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.counters, key)
+}
+
+// sweep 删除窗口外且未锁定的计数，避免大量不同 IP 的尝试占用内存
+func (g *guard) sweep(now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	since := now.Add(-window())
+
+	for key, c := range g.counters {
+		c.failures = trim(c.failures, since)
+		if len(c.failures) == 0 && !c.lockedUntil.After(now) {
+			delete(g.counters, key)
+		}
+	}
+}
+
+// trim 去掉 since 之前的时间点，failures 按时间递增
+func trim(failures []time.Time, since time.Time) []time.Time {
+	i := sort.Search(len(failures), func(i int) bool {
+		return failures[i].After(since)
+	})
+
+	return failures[i:]
+}
+
+// Locks 当前锁定中的 IP 和用户名
+func Locks() []*Lock {
+	locks := make([]*Lock, 0)
+
+	if singleton == nil {
+		return locks
+	}
+
+	singleton.mu.Lock()
+	defer singleton.mu.Unlock()
+
+	now := time.Now()
+
+	for key, c := range singleton.counters {
+		if !c.lockedUntil.After(now) {
+			continue
+		}
+
+		lock := &Lock{
+			LockedUntil: c.lockedUntil,
+		}
+
+		if ip, ok := strings.CutPrefix(key, keyPrefixIP); ok {
+			lock.Type, lock.Value = "ip", ip
+		} else {
+			lock.Type, lock.Value = "username", strings.TrimPrefix(key, keyPrefixUser)
+		}
+
+		locks = append(locks, lock)
+	}
+
+	sort.Slice(locks, func(i, j int) bool {
+		return locks[i].LockedUntil.After(locks[j].LockedUntil)
+	})
+
+	return locks
+}
+
+// Unlock 手动解除锁定并清空失败次数，typ 为 ip 或 username，返回是否存在该锁定
+func Unlock(typ, value string) bool {
+	if singleton == nil {
+		return false
+	}
+
+	key := ipKey(value)
+	if typ == "username" {
+		key = userKey(value)
+	}
+
+	singleton.mu.Lock()
+	defer singleton.mu.Unlock()
+
+	c, ok := singleton.counters[key]
+	if !ok {
+		return false
+	}
+
+	delete(singleton.counters, key)
+
+	return c.lockedUntil.After(time.Now())
+}
@@ -0,0 +1,186 @@
+package authguard
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestGuard() *guard {
+	return &guard{counters: map[string]*counter{}}
+}
+
+func TestGuardLock(t *testing.T) {
+	const max = 3
+
+	var (
+		g   = newTestGuard()
+		now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		key = ipKey("1.2.3.4")
+	)
+
+	for i := 0; i < max-1; i++ {
+		g.fail(now.Add(time.Duration(i)*time.Second), key, max)
+	}
+
+	if _, ok := g.locked(now, key); ok {
+		t.Fatalf("locked after %d failures, max is %d", max-1, max)
+	}
+
+	g.fail(now.Add(time.Minute), key, max)
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		keys     []string
+		wantWait time.Duration
+		wantOK   bool
+	}{
+		{"just locked", now.Add(time.Minute), []string{key}, lockDuration(), true},
+		{"half way", now.Add(time.Minute + lockDuration()/2), []string{key}, lockDuration() / 2, true},
+		{"any key locked", now.Add(time.Minute), []string{userKey("admin"), key}, lockDuration(), true},
+		{"other key", now.Add(time.Minute), []string{ipKey("5.6.7.8")}, 0, false},
+		{"expired", now.Add(time.Minute + lockDuration()), []string{key}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wait, ok := g.locked(tt.at, tt.keys...)
+			if ok != tt.wantOK || wait != tt.wantWait {
+				t.Errorf("locked() = (%v, %v), want (%v, %v)", wait, ok, tt.wantWait, tt.wantOK)
+			}
+		})
+	}
+
+	g.reset(key)
+
+	if _, ok := g.locked(now.Add(time.Minute), key); ok {
+		t.Error("still locked after reset")
+	}
+}
+
+func TestGuardFailWindow(t *testing.T) {
+	var (
+		g   = newTestGuard()
+		now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		key = userKey("Admin")
+	)
+
+	g.fail(now, key, 2)
+
+	// 窗口外的失败不再计数
+	later := now.Add(window() + time.Second)
+	g.fail(later, key, 2)
+
+	if _, ok := g.locked(later, key); ok {
+		t.Fatal("failures outside the window should not lock")
+	}
+
+	g.fail(later.Add(time.Second), userKey("ADMIN"), 2)
+
+	if _, ok := g.locked(later.Add(time.Second), userKey("admin")); !ok {
+		t.Error("username should be case insensitive")
+	}
+}
+
+func TestGuardFailWithoutMax(t *testing.T) {
+	var (
+		g   = newTestGuard()
+		now = time.Now()
+		key = ipKey("1.2.3.4")
+	)
+
+	for i := 0; i < 100; i++ {
+		g.fail(now, key, 0)
+	}
+
+	if _, ok := g.locked(now, key); ok {
+		t.Error("max <= 0 should never lock")
+	}
+}
+
+func TestGuardSweep(t *testing.T) {
+	var (
+		g      = newTestGuard()
+		now    = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		stale  = ipKey("1.1.1.1")
+		recent = ipKey("2.2.2.2")
+		locked = ipKey("3.3.3.3")
+	)
+
+	g.fail(now.Add(-window()-time.Second), stale, 5)
+	g.fail(now.Add(-time.Second), recent, 5)
+	g.fail(now.Add(-time.Second), locked, 1)
+
+	g.sweep(now)
+
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{stale, false},
+		{recent, true},
+		{locked, true},
+	}
+
+	for _, tt := range tests {
+		if _, ok := g.counters[tt.key]; ok != tt.want {
+			t.Errorf("counter %s kept = %v, want %v", tt.key, ok, tt.want)
+		}
+	}
+
+	g.sweep(now.Add(lockDuration()))
+
+	if len(g.counters) != 0 {
+		t.Errorf("counters after lock expired = %d, want 0", len(g.counters))
+	}
+}
+
+func TestLocksAndUnlock(t *testing.T) {
+	old := singleton
+	t.Cleanup(func() {
+		singleton = old
+	})
+
+	singleton = newTestGuard()
+
+	now := time.Now()
+	singleton.fail(now, ipKey("1.2.3.4"), 1)
+	singleton.fail(now, userKey("Admin"), 1)
+	singleton.fail(now, userKey("guest"), 5)
+
+	locks := Locks()
+	if len(locks) != 2 {
+		t.Fatalf("Locks() = %d items, want 2", len(locks))
+	}
+
+	got := map[string]string{}
+	for _, lock := range locks {
+		got[lock.Type] = lock.Value
+	}
+
+	if got["ip"] != "1.2.3.4" || got["username"] != "admin" {
+		t.Errorf("Locks() = %v", got)
+	}
+
+	tests := []struct {
+		typ   string
+		value string
+		want  bool
+	}{
+		{"username", "ADMIN", true},
+		{"username", "admin", false},
+		{"username", "guest", false},
+		{"ip", "1.2.3.4", true},
+		{"ip", "9.9.9.9", false},
+	}
+
+	for _, tt := range tests {
+		if ok := Unlock(tt.typ, tt.value); ok != tt.want {
+			t.Errorf("Unlock(%q, %q) = %v, want %v", tt.typ, tt.value, ok, tt.want)
+		}
+	}
+
+	if locks = Locks(); len(locks) != 0 {
+		t.Errorf("Locks() after unlock = %d items, want 0", len(locks))
+	}
+}
@@ -0,0 +1,86 @@
+// Package backup 配置归档的导出导入，以及 SQLite 数据库的定时在线备份
+//
+// 归档只包含迁移一个站点所需的配置：挂载点、云盘令牌、用户、用户组及其文件绑定、系统设置，
+// 不包含扫描得到的文件树，导入后由扫描重新生成。令牌和用户密码使用导出时的口令加密。
+package backup
+
+import (
+	"time"
+
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+)
+
+// ArchiveVersion 归档格式版本，字段不兼容变更时递增
+const ArchiveVersion = 1
+
+// Archive 配置归档
+type Archive struct {
+	Version      int             `json:"version"`
+	ExportedAt   time.Time       `json:"exportedAt"`
+	Crypto       Crypto          `json:"crypto"`
+	Setting      *models.Setting `json:"setting"` // 不含 SaltKey
+	SettingDicts []SettingDict   `json:"settingDicts"`
+	CloudTokens  []CloudToken    `json:"cloudTokens"`
+	UserGroups   []UserGroup     `json:"userGroups"`
+	Users        []User          `json:"users"`
+	Mounts       []Mount         `json:"mounts"`
+	GroupFiles   []GroupFile     `json:"groupFiles"`
+}
+
+type SettingDict struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Type  string `json:"type"`
+}
+
+// CloudToken 云盘令牌，Secret 为加密后的 cloudTokenSecret
+type CloudToken struct {
+	Id        int64  `json:"id"` // 导出时的ID，挂载点的 cloud_token / cloud_token_pool 引用该值
+	Name      string `json:"name"`
+	ExpiresIn int64  `json:"expiresIn"`
+	Status    int8   `json:"status"`
+	LoginType int8   `json:"loginType"`
+	Username  string `json:"username"`
+	Secret    string `json:"secret"`
+}
+
+type cloudTokenSecret struct {
+	AccessToken string         `json:"accessToken"`
+	Password    string         `json:"password"`
+	Addition    map[string]any `json:"addition"`
+}
+
+type UserGroup struct {
+	Name string `json:"name"`
+}
+
+// User 用户，Secret 为加密后的密码摘要
+type User struct {
+	Username    string `json:"username"`
+	Status      int8   `json:"status"`
+	Permissions uint8  `json:"permissions"`
+	Group       string `json:"group"` // 用户组名称，为空表示未分组
+	TOTPEnabled bool   `json:"totpEnabled,omitempty"`
+	Secret      string `json:"secret"`
+}
+
+type userSecret struct {
+	Password      string `json:"password"`
+	TOTPSecret    string `json:"totpSecret,omitempty"`
+	RecoveryCodes string `json:"recoveryCodes,omitempty"`
+}
+
+// Mount 挂载点，Path 为逐级名称，最后一级为挂载点本身
+type Mount struct {
+	Path       []string       `json:"path"`
+	OsType     string         `json:"osType"`
+	Addition   map[string]any `json:"addition"`
+	CreateDate string         `json:"createDate"`
+	ModifyDate string         `json:"modifyDate"`
+}
+
+// GroupFile 用户组可访问的文件，文件按路径引用
+type GroupFile struct {
+	Group string   `json:"group"`
+	Path  []string `json:"path"`
+}
@@ -0,0 +1,203 @@
+package backup
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/xxcheng123/cloudpan189-share/internal/consts"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"gorm.io/gorm"
+)
+
+// 挂载点运行时状态，导出时去掉，导入后由扫描重新生成
+var runtimeAdditionKeys = []string{
+	consts.FileAdditionKeyHealthState,
+	consts.FileAdditionKeyHealthError,
+	consts.FileAdditionKeyHealthErrorAt,
+	consts.FileAdditionKeyHealthCheckedAt,
+	consts.FileAdditionKeyHidden,
+}
+
+// Export 导出配置归档，passphrase 用于加密令牌和用户密码
+func Export(ctx context.Context, db *gorm.DB, passphrase string) (*Archive, error) {
+	s, c, err := newCrypto(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	db = db.WithContext(ctx)
+
+	archive := &Archive{
+		Version:    ArchiveVersion,
+		ExportedAt: time.Now(),
+		Crypto:     c,
+		Setting:    new(models.Setting),
+	}
+
+	if err = db.First(archive.Setting).Error; err != nil {
+		return nil, err
+	}
+
+	var dicts = make([]*models.SettingDict, 0)
+	if err = db.Order("id").Find(&dicts).Error; err != nil {
+		return nil, err
+	}
+
+	for _, dict := range dicts {
+		archive.SettingDicts = append(archive.SettingDicts, SettingDict{
+			Key:   dict.Key,
+			Value: dict.Value.Value(),
+			Type:  dict.Type,
+		})
+	}
+
+	var tokens = make([]*models.CloudToken, 0)
+	if err = db.Order("id").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+
+	for _, token := range tokens {
+		secret, err := s.seal(cloudTokenSecret{
+			AccessToken: token.AccessToken,
+			Password:    token.Password,
+			Addition:    token.Addition,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		archive.CloudTokens = append(archive.CloudTokens, CloudToken{
+			Id:        token.ID,
+			Name:      token.Name,
+			ExpiresIn: token.ExpiresIn,
+			Status:    token.Status,
+			LoginType: token.LoginType,
+			Username:  token.Username,
+			Secret:    secret,
+		})
+	}
+
+	var groups = make([]*models.UserGroup, 0)
+	if err = db.Order("id").Find(&groups).Error; err != nil {
+		return nil, err
+	}
+
+	groupNames := make(map[int64]string, len(groups))
+	for _, group := range groups {
+		groupNames[group.ID] = group.Name
+		archive.UserGroups = append(archive.UserGroups, UserGroup{Name: group.Name})
+	}
+
+	var users = make([]*models.User, 0)
+	if err = db.Order("id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		secret, err := s.seal(userSecret{
+			Password:      user.Password,
+			TOTPSecret:    user.TOTPSecret,
+			RecoveryCodes: user.RecoveryCodes,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		archive.Users = append(archive.Users, User{
+			Username:    user.Username,
+			Status:      user.Status,
+			Permissions: user.Permissions,
+			Group:       groupNames[user.GroupID],
+			TOTPEnabled: user.TOTPEnabled,
+			Secret:      secret,
+		})
+	}
+
+	var (
+		tops    = make([]*models.VirtualFile, 0)
+		parents = make(map[int64]*models.VirtualFile)
+	)
+
+	if err = db.Where("is_top = ?", 1).Order("id").Find(&tops).Error; err != nil {
+		return nil, err
+	}
+
+	for _, top := range tops {
+		path, err := filePath(db, top, parents)
+		if err != nil {
+			return nil, err
+		}
+
+		addition := make(map[string]any, len(top.Addition))
+		for k, v := range top.Addition {
+			addition[k] = v
+		}
+
+		for _, k := range runtimeAdditionKeys {
+			delete(addition, k)
+		}
+
+		archive.Mounts = append(archive.Mounts, Mount{
+			Path:       path,
+			OsType:     top.OsType,
+			Addition:   addition,
+			CreateDate: top.CreateDate,
+			ModifyDate: top.ModifyDate,
+		})
+	}
+
+	var bindings = make([]*models.Group2File, 0)
+	if err = db.Order("id").Find(&bindings).Error; err != nil {
+		return nil, err
+	}
+
+	for _, binding := range bindings {
+		group, ok := groupNames[binding.GroupId]
+		if !ok {
+			continue
+		}
+
+		file := new(models.VirtualFile)
+		if err = db.Select("id", "parent_id", "name").Where("id = ?", binding.FileId).First(file).Error; err != nil {
+			// 绑定的文件已被删除
+			continue
+		}
+
+		path, err := filePath(db, file, parents)
+		if err != nil {
+			return nil, err
+		}
+
+		archive.GroupFiles = append(archive.GroupFiles, GroupFile{
+			Group: group,
+			Path:  path,
+		})
+	}
+
+	return archive, nil
+}
+
+// filePath 文件从根开始的逐级名称
+func filePath(db *gorm.DB, file *models.VirtualFile, parents map[int64]*models.VirtualFile) ([]string, error) {
+	var names = []string{file.Name}
+
+	for pid := file.ParentId; pid > 0; {
+		parent, ok := parents[pid]
+		if !ok {
+			parent = new(models.VirtualFile)
+			if err := db.Select("id", "parent_id", "name").Where("id = ?", pid).First(parent).Error; err != nil {
+				return nil, err
+			}
+
+			parents[pid] = parent
+		}
+
+		names = append(names, parent.Name)
+		pid = parent.ParentId
+	}
+
+	slices.Reverse(names)
+
+	return names, nil
+}
@@ -0,0 +1,789 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"github.com/xxcheng123/cloudpan189-share/configs"
+	"github.com/xxcheng123/cloudpan189-share/internal/bus"
+	"github.com/xxcheng123/cloudpan189-share/internal/consts"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/utils"
+	"github.com/xxcheng123/cloudpan189-share/internal/search"
+	"github.com/xxcheng123/cloudpan189-share/internal/shared"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Mode 导入模式
+type Mode = string
+
+const (
+	ModeMerge   Mode = "merge"   // 保留现有数据，只新增归档中没有的项，不修改设置
+	ModeReplace Mode = "replace" // 以归档为准：覆盖同名项和设置，删除归档中没有的项
+)
+
+// 变更对象
+const (
+	KindSetting    = "setting"
+	KindCloudToken = "cloud_token"
+	KindUserGroup  = "user_group"
+	KindUser       = "user"
+	KindMount      = "mount"
+	KindGroupFile  = "group_file"
+)
+
+// 变更操作
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+	OpSkip   = "skip"
+)
+
+var errDryRun = errors.New("dry run")
+
+type ImportOptions struct {
+	Passphrase string
+	Mode       Mode
+	DryRun     bool // 只生成报告，不写入
+}
+
+type Change struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Op     string `json:"op"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type ImportReport struct {
+	Mode     Mode                      `json:"mode"`
+	DryRun   bool                      `json:"dryRun"`
+	Summary  map[string]map[string]int `json:"summary"` // 对象 -> 操作 -> 数量
+	Changes  []Change                  `json:"changes"`
+	Warnings []string                  `json:"warnings"`
+}
+
+func (r *ImportReport) add(kind, name, op, reason string) {
+	r.Changes = append(r.Changes, Change{Kind: kind, Name: name, Op: op, Reason: reason})
+
+	if r.Summary[kind] == nil {
+		r.Summary[kind] = make(map[string]int)
+	}
+
+	r.Summary[kind][op]++
+}
+
+func (r *ImportReport) warn(msg string) {
+	r.Warnings = append(r.Warnings, msg)
+}
+
+type importer struct {
+	tx      *gorm.DB
+	archive *Archive
+	sealer  *sealer
+	opts    ImportOptions
+	report  *ImportReport
+
+	groupIds map[string]int64 // 用户组名称 -> ID
+	tokenIds map[int64]int64  // 归档中的令牌ID -> 导入后的ID
+
+	created []*models.VirtualFile // 新建的目录和挂载点，提交后建立索引
+	refresh []int64               // 新建或修改的挂载点，提交后扫描
+	removed []int64               // 替换模式下归档中没有的挂载点，提交后删除
+}
+
+// Import 导入配置归档，全部写入在一个事务中完成；挂载点的扫描和删除在提交后交给总线
+func Import(ctx context.Context, db *gorm.DB, archive *Archive, opts ImportOptions) (*ImportReport, error) {
+	if archive.Version <= 0 || archive.Version > ArchiveVersion {
+		return nil, errors.Errorf("不支持的归档版本：%d", archive.Version)
+	}
+
+	switch opts.Mode {
+	case "":
+		opts.Mode = ModeMerge
+	case ModeMerge, ModeReplace:
+	default:
+		return nil, errors.Errorf("不支持的导入模式：%s", opts.Mode)
+	}
+
+	s, err := openCrypto(opts.Passphrase, archive.Crypto)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Mode == ModeReplace && !lo.SomeBy(archive.Users, func(u User) bool {
+		return u.Permissions&models.PermissionAdmin != 0
+	}) {
+		return nil, errors.New("归档中没有管理员用户，替换后将无法登录")
+	}
+
+	im := &importer{
+		archive: archive,
+		sealer:  s,
+		opts:    opts,
+		report: &ImportReport{
+			Mode:     opts.Mode,
+			DryRun:   opts.DryRun,
+			Summary:  make(map[string]map[string]int),
+			Changes:  make([]Change, 0),
+			Warnings: make([]string, 0),
+		},
+		groupIds: make(map[string]int64),
+		tokenIds: make(map[int64]int64),
+	}
+
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		im.tx = tx
+
+		for _, step := range []func() error{
+			im.settings,
+			im.userGroups,
+			im.cloudTokens,
+			im.users,
+			im.mounts,
+			im.groupFiles,
+		} {
+			if err := step(); err != nil {
+				return err
+			}
+		}
+
+		if opts.DryRun {
+			return errDryRun
+		}
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+
+	if !opts.DryRun {
+		im.afterCommit(ctx, db)
+	}
+
+	return im.report, nil
+}
+
+// settingColumns setting 表中可导入的列
+func settingColumns() []string {
+	columns := []string{"initialized"}
+	for _, item := range models.SettingItems {
+		if item.Source == models.SettingSourceRow {
+			columns = append(columns, item.Key)
+		}
+	}
+
+	return columns
+}
+
+func (im *importer) settings() error {
+	current := new(models.Setting)
+	if err := im.tx.First(current).Error; err != nil {
+		return err
+	}
+
+	// 尚未初始化的站点在合并模式下也使用归档中的设置
+	if im.archive.Setting != nil && (im.opts.Mode == ModeReplace || !current.Initialized) {
+		values := *im.archive.Setting
+		values.ID = current.ID
+
+		if err := im.tx.Model(current).Select(settingColumns()).Updates(&values).Error; err != nil {
+			return err
+		}
+
+		im.report.add(KindSetting, "setting", OpUpdate, "")
+	}
+
+	dictKeys := make(map[string]bool)
+	for _, item := range models.SettingItems {
+		if item.Source != models.SettingSourceRow {
+			dictKeys[item.Key] = true
+		}
+	}
+
+	for _, item := range im.archive.SettingDicts {
+		if !dictKeys[item.Key] {
+			im.report.warn("忽略未知设置项：" + item.Key)
+
+			continue
+		}
+
+		dict := new(models.SettingDict)
+		err := im.tx.Where("key = ?", item.Key).First(dict).Error
+
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err = im.tx.Create(&models.SettingDict{
+				Key:   item.Key,
+				Value: models.SettingDictValue(item.Value),
+				Type:  item.Type,
+			}).Error; err != nil {
+				return err
+			}
+
+			im.report.add(KindSetting, item.Key, OpCreate, "")
+		case err != nil:
+			return err
+		case dict.Value.Value() == item.Value:
+		case im.opts.Mode == ModeMerge:
+			im.report.add(KindSetting, item.Key, OpSkip, "已存在")
+		default:
+			if err = im.tx.Model(dict).Update("value", item.Value).Error; err != nil {
+				return err
+			}
+
+			im.report.add(KindSetting, item.Key, OpUpdate, "")
+		}
+	}
+
+	return nil
+}
+
+func (im *importer) userGroups() error {
+	var existing = make([]*models.UserGroup, 0)
+	if err := im.tx.Find(&existing).Error; err != nil {
+		return err
+	}
+
+	names := lo.SliceToMap(im.archive.UserGroups, func(g UserGroup) (string, bool) {
+		return g.Name, true
+	})
+
+	for _, group := range existing {
+		if im.opts.Mode == ModeReplace && !names[group.Name] {
+			if err := im.tx.Delete(group).Error; err != nil {
+				return err
+			}
+
+			im.report.add(KindUserGroup, group.Name, OpDelete, "")
+
+			continue
+		}
+
+		im.groupIds[group.Name] = group.ID
+	}
+
+	for _, item := range im.archive.UserGroups {
+		if _, ok := im.groupIds[item.Name]; ok {
+			im.report.add(KindUserGroup, item.Name, OpSkip, "已存在")
+
+			continue
+		}
+
+		group := &models.UserGroup{Name: item.Name}
+		if err := im.tx.Create(group).Error; err != nil {
+			return err
+		}
+
+		im.groupIds[group.Name] = group.ID
+		im.report.add(KindUserGroup, item.Name, OpCreate, "")
+	}
+
+	return nil
+}
+
+func (im *importer) cloudTokens() error {
+	var existing = make([]*models.CloudToken, 0)
+	if err := im.tx.Order("id").Find(&existing).Error; err != nil {
+		return err
+	}
+
+	names := lo.SliceToMap(im.archive.CloudTokens, func(t CloudToken) (string, bool) {
+		return t.Name, true
+	})
+
+	var (
+		byName = make(map[string]*models.CloudToken)
+		used   = make(map[int64]bool)
+	)
+
+	for _, token := range existing {
+		if im.opts.Mode == ModeReplace && !names[token.Name] {
+			if err := im.tx.Delete(token).Error; err != nil {
+				return err
+			}
+
+			im.report.add(KindCloudToken, token.Name, OpDelete, "")
+
+			continue
+		}
+
+		if _, ok := byName[token.Name]; !ok {
+			byName[token.Name] = token
+		}
+	}
+
+	for _, item := range im.archive.CloudTokens {
+		var secret cloudTokenSecret
+		if err := im.sealer.open(item.Secret, &secret); err != nil {
+			return errors.Wrapf(err, "解密令牌 %s 失败", item.Name)
+		}
+
+		// 同名的令牌按顺序一一对应，多出来的新建
+		token, ok := byName[item.Name]
+		if ok && used[token.ID] {
+			token, ok = nil, false
+		}
+
+		if ok && im.opts.Mode == ModeMerge {
+			used[token.ID] = true
+			im.tokenIds[item.Id] = token.ID
+			im.report.add(KindCloudToken, item.Name, OpSkip, "已存在")
+
+			continue
+		}
+
+		if !ok {
+			token = new(models.CloudToken)
+		}
+
+		token.Name = item.Name
+		token.AccessToken = secret.AccessToken
+		token.ExpiresIn = item.ExpiresIn
+		token.Status = item.Status
+		token.LoginType = item.LoginType
+		token.Username = item.Username
+		token.Password = secret.Password
+		token.Addition = secret.Addition
+
+		if token.Addition == nil {
+			token.Addition = make(datatypes.JSONMap)
+		}
+
+		if err := im.tx.Save(token).Error; err != nil {
+			return err
+		}
+
+		used[token.ID] = true
+		im.tokenIds[item.Id] = token.ID
+		im.report.add(KindCloudToken, item.Name, lo.Ternary(ok, OpUpdate, OpCreate), "")
+	}
+
+	return nil
+}
+
+func (im *importer) users() error {
+	var existing = make([]*models.User, 0)
+	if err := im.tx.Find(&existing).Error; err != nil {
+		return err
+	}
+
+	names := lo.SliceToMap(im.archive.Users, func(u User) (string, bool) {
+		return u.Username, true
+	})
+
+	byName := make(map[string]*models.User)
+	for _, user := range existing {
+		if im.opts.Mode == ModeReplace && !names[user.Username] {
+			if err := im.tx.Delete(user).Error; err != nil {
+				return err
+			}
+
+			// 会话和分享链接不随备份导出，删除用户时一并删除，避免新用户复用 ID 后沿用
+			if err := im.tx.Where("user_id = ?", user.ID).Delete(new(models.Session)).Error; err != nil {
+				return err
+			}
+
+			if err := im.tx.Where("user_id = ?", user.ID).Delete(new(models.ShareLink)).Error; err != nil {
+				return err
+			}
+
+			im.report.add(KindUser, user.Username, OpDelete, "")
+
+			continue
+		}
+
+		byName[user.Username] = user
+	}
+
+	for _, item := range im.archive.Users {
+		var secret userSecret
+		if err := im.sealer.open(item.Secret, &secret); err != nil {
+			return errors.Wrapf(err, "解密用户 %s 失败", item.Username)
+		}
+
+		user, ok := byName[item.Username]
+		if ok && im.opts.Mode == ModeMerge {
+			im.report.add(KindUser, item.Username, OpSkip, "已存在")
+
+			continue
+		}
+
+		var groupId int64
+		if item.Group != "" {
+			if groupId, ok = im.groupIds[item.Group]; !ok {
+				im.report.warn("用户 " + item.Username + " 所属的用户组 " + item.Group + " 不存在，已设为未分组")
+			}
+		}
+
+		if user == nil {
+			user = &models.User{Username: item.Username, Version: 1}
+		} else if user.Password != secret.Password {
+			// 密码变化时使已签发的登录令牌失效
+			user.Version++
+
+			if err := im.tx.Model(new(models.Session)).
+				Where("user_id = ? AND revoked_at IS NULL", user.ID).
+				Updates(map[string]any{
+					"revoked_at":    time.Now(),
+					"revoke_reason": models.SessionRevokeCredential,
+				}).Error; err != nil {
+				return err
+			}
+		}
+
+		user.Password = secret.Password
+		user.TOTPEnabled = item.TOTPEnabled && secret.TOTPSecret != ""
+		user.TOTPSecret = secret.TOTPSecret
+		user.RecoveryCodes = secret.RecoveryCodes
+		user.Status = item.Status
+		user.Permissions = item.Permissions
+		user.GroupID = groupId
+
+		exists := user.ID != 0
+		if err := im.tx.Save(user).Error; err != nil {
+			return err
+		}
+
+		im.report.add(KindUser, item.Username, lo.Ternary(exists, OpUpdate, OpCreate), "")
+	}
+
+	return nil
+}
+
+func pathKey(path []string) string {
+	return strings.Join(path, "\x00")
+}
+
+func pathName(path []string) string {
+	return "/" + strings.Join(path, "/")
+}
+
+func (im *importer) mounts() error {
+	var (
+		tops    = make([]*models.VirtualFile, 0)
+		parents = make(map[int64]*models.VirtualFile)
+		byPath  = make(map[string]*models.VirtualFile)
+	)
+
+	if err := im.tx.Where("is_top = ?", 1).Find(&tops).Error; err != nil {
+		return err
+	}
+
+	for _, top := range tops {
+		path, err := filePath(im.tx, top, parents)
+		if err != nil {
+			return err
+		}
+
+		byPath[pathKey(path)] = top
+	}
+
+	wanted := make(map[string]bool, len(im.archive.Mounts))
+
+	for _, item := range im.archive.Mounts {
+		name := pathName(item.Path)
+
+		if len(item.Path) == 0 || lo.Contains(item.Path, "") {
+			im.report.warn("忽略路径不合法的挂载点：" + name)
+
+			continue
+		}
+
+		wanted[pathKey(item.Path)] = true
+		addition := im.remapAddition(name, item.Addition)
+
+		if top, ok := byPath[pathKey(item.Path)]; ok {
+			if im.opts.Mode == ModeMerge {
+				im.report.add(KindMount, name, OpSkip, "已存在")
+
+				continue
+			}
+
+			if err := im.tx.Model(top).Updates(map[string]any{
+				"os_type":  item.OsType,
+				"addition": addition,
+			}).Error; err != nil {
+				return err
+			}
+
+			im.refresh = append(im.refresh, top.ID)
+			im.report.add(KindMount, name, OpUpdate, "")
+
+			continue
+		}
+
+		pid, err := im.ensureFolders(item.Path[:len(item.Path)-1])
+		if err != nil {
+			return err
+		}
+
+		if pid < 0 {
+			im.report.add(KindMount, name, OpSkip, "上级路径中存在同名的文件或挂载点")
+
+			continue
+		}
+
+		var count int64
+		if err = im.tx.Model(&models.VirtualFile{}).Where("parent_id = ? AND name = ?", pid, item.Path[len(item.Path)-1]).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count > 0 {
+			im.report.add(KindMount, name, OpSkip, "路径已被其他目录占用")
+
+			continue
+		}
+
+		now := time.Now()
+		top := &models.VirtualFile{
+			ParentId:   pid,
+			Name:       item.Path[len(item.Path)-1],
+			IsTop:      1,
+			IsFolder:   1,
+			OsType:     item.OsType,
+			CreateDate: lo.Ternary(item.CreateDate != "", item.CreateDate, now.Format(time.DateTime)),
+			ModifyDate: lo.Ternary(item.ModifyDate != "", item.ModifyDate, now.Format(time.DateTime)),
+			Rev:        now.Format("20060102150405"),
+			Addition:   addition,
+		}
+
+		if err = im.tx.Create(top).Error; err != nil {
+			return err
+		}
+
+		im.created = append(im.created, top)
+		im.refresh = append(im.refresh, top.ID)
+		im.report.add(KindMount, name, OpCreate, "")
+	}
+
+	if im.opts.Mode == ModeReplace {
+		for key, top := range byPath {
+			if wanted[key] {
+				continue
+			}
+
+			im.removed = append(im.removed, top.ID)
+			im.report.add(KindMount, pathName(strings.Split(key, "\x00")), OpDelete, "")
+		}
+	}
+
+	return nil
+}
+
+// remapAddition 把挂载点引用的令牌ID换成导入后的ID，引用不到的令牌会被去掉
+func (im *importer) remapAddition(name string, src map[string]any) datatypes.JSONMap {
+	addition := make(datatypes.JSONMap, len(src))
+	for k, v := range src {
+		addition[k] = v
+	}
+
+	if v, ok := addition[consts.FileAdditionKeyCloudToken]; ok {
+		oldId, _ := utils.Int64(v)
+		if newId, ok := im.tokenIds[oldId]; ok {
+			addition[consts.FileAdditionKeyCloudToken] = newId
+		} else {
+			delete(addition, consts.FileAdditionKeyCloudToken)
+			im.report.warn("挂载点 " + name + " 引用的令牌不在归档中，已解除绑定")
+		}
+	}
+
+	if v, ok := addition[consts.FileAdditionKeyCloudTokenPool]; ok {
+		var pool = make([]int64, 0)
+
+		if list, ok := v.([]any); ok {
+			for _, item := range list {
+				oldId, _ := utils.Int64(item)
+				if newId, ok := im.tokenIds[oldId]; ok {
+					pool = append(pool, newId)
+				}
+			}
+		}
+
+		addition[consts.FileAdditionKeyCloudTokenPool] = pool
+	}
+
+	return addition
+}
+
+// ensureFolders 逐级查找或创建目录，返回最后一级的ID；路径被文件或挂载点占用时返回 -1
+func (im *importer) ensureFolders(names []string) (int64, error) {
+	var pid int64
+
+	for _, name := range names {
+		folder := new(models.VirtualFile)
+		err := im.tx.Where("parent_id = ? AND name = ?", pid, name).First(folder).Error
+
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			now := time.Now()
+			folder = &models.VirtualFile{
+				ParentId:   pid,
+				Name:       name,
+				IsFolder:   1,
+				OsType:     models.OsTypeFolder,
+				CreateDate: now.Format(time.DateTime),
+				ModifyDate: now.Format(time.DateTime),
+				Rev:        now.Format("20060102150405"),
+				Addition:   datatypes.JSONMap{},
+			}
+
+			if err = im.tx.Create(folder).Error; err != nil {
+				return 0, err
+			}
+
+			im.created = append(im.created, folder)
+		case err != nil:
+			return 0, err
+		case folder.IsTop == 1 || folder.OsType != models.OsTypeFolder:
+			return -1, nil
+		}
+
+		pid = folder.ID
+	}
+
+	return pid, nil
+}
+
+// findFile 按逐级名称查找文件
+func (im *importer) findFile(path []string) (int64, error) {
+	var pid int64
+
+	for _, name := range path {
+		file := new(models.VirtualFile)
+		if err := im.tx.Select("id").Where("parent_id = ? AND name = ?", pid, name).First(file).Error; err != nil {
+			return 0, err
+		}
+
+		pid = file.ID
+	}
+
+	return pid, nil
+}
+
+func (im *importer) groupFiles() error {
+	type binding struct {
+		groupId int64
+		fileId  int64
+	}
+
+	wanted := make(map[binding]bool)
+
+	for _, item := range im.archive.GroupFiles {
+		name := item.Group + ":" + pathName(item.Path)
+
+		groupId, ok := im.groupIds[item.Group]
+		if !ok {
+			im.report.add(KindGroupFile, name, OpSkip, "用户组不存在")
+
+			continue
+		}
+
+		fileId, err := im.findFile(item.Path)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// 挂载点下的文件需扫描后才存在
+			im.report.add(KindGroupFile, name, OpSkip, "文件不存在")
+
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		wanted[binding{groupId, fileId}] = true
+
+		var count int64
+		if err = im.tx.Model(&models.Group2File{}).Where("group_id = ? AND file_id = ?", groupId, fileId).Count(&count).Error; err != nil {
+			return err
+		}
+
+		if count > 0 {
+			im.report.add(KindGroupFile, name, OpSkip, "已存在")
+
+			continue
+		}
+
+		if err = im.tx.Create(&models.Group2File{GroupId: groupId, FileId: fileId}).Error; err != nil {
+			return err
+		}
+
+		im.report.add(KindGroupFile, name, OpCreate, "")
+	}
+
+	if im.opts.Mode != ModeReplace {
+		return nil
+	}
+
+	var existing = make([]*models.Group2File, 0)
+	if err := im.tx.Find(&existing).Error; err != nil {
+		return err
+	}
+
+	for _, item := range existing {
+		if wanted[binding{item.GroupId, item.FileId}] {
+			continue
+		}
+
+		if err := im.tx.Delete(item).Error; err != nil {
+			return err
+		}
+
+		im.report.add(KindGroupFile, fmt.Sprintf("%d:%d", item.GroupId, item.FileId), OpDelete, "")
+	}
+
+	return nil
+}
+
+// afterCommit 重新加载设置，为新建的目录建立索引，并把挂载点的扫描和删除交给总线
+func (im *importer) afterCommit(ctx context.Context, db *gorm.DB) {
+	if err := ReloadSettings(ctx, db); err != nil {
+		im.report.warn("重新加载设置失败，重启后生效：" + err.Error())
+	}
+
+	search.Index(ctx, im.created...)
+
+	if len(im.refresh) == 0 && len(im.removed) == 0 {
+		return
+	}
+
+	if !bus.Running() {
+		im.report.warn("任务总线未运行，挂载点的扫描和删除将在服务启动后手动执行")
+
+		return
+	}
+
+	for _, id := range im.removed {
+		if err := bus.PublishVirtualFileDelete(ctx, id); err != nil {
+			im.report.warn("提交挂载点删除任务失败：" + err.Error())
+		}
+	}
+
+	for _, id := range im.refresh {
+		if err := bus.PublishVirtualFileRefresh(ctx, id, false, models.ScanTriggerAPI); err != nil {
+			im.report.warn("提交挂载点扫描任务失败：" + err.Error())
+		}
+	}
+}
+
+// ReloadSettings 从数据库重新加载设置到 shared
+func ReloadSettings(ctx context.Context, db *gorm.DB) error {
+	setting := new(models.Setting)
+	if err := db.WithContext(ctx).First(setting).Error; err != nil {
+		return err
+	}
+
+	var dicts = make([]*models.SettingDict, 0)
+	if err := db.WithContext(ctx).Find(&dicts).Error; err != nil {
+		return err
+	}
+
+	configs.LoadSettingDicts(dicts)
+	shared.Setting = setting
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	kdfName       = "pbkdf2-sha256"
+	kdfIterations = 600000
+	checkPlain    = "cloudpan189-share"
+)
+
+var ErrBadPassphrase = errors.New("口令错误")
+
+// Crypto 归档中敏感字段的加密参数，Check 用于导入前校验口令
+type Crypto struct {
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       string `json:"salt"`
+	Check      string `json:"check"`
+}
+
+// sealer 使用口令派生的密钥做 AES-256-GCM 加密，输出 base64(nonce || 密文)
+type sealer struct {
+	aead cipher.AEAD
+}
+
+// newCrypto 生成新的加密参数
+func newCrypto(passphrase string) (*sealer, Crypto, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, Crypto{}, err
+	}
+
+	c := Crypto{
+		KDF:        kdfName,
+		Iterations: kdfIterations,
+		Salt:       base64.StdEncoding.EncodeToString(salt),
+	}
+
+	s, err := newSealer(passphrase, c)
+	if err != nil {
+		return nil, Crypto{}, err
+	}
+
+	if c.Check, err = s.seal(checkPlain); err != nil {
+		return nil, Crypto{}, err
+	}
+
+	return s, c, nil
+}
+
+// openCrypto 按归档中的参数派生密钥并校验口令
+func openCrypto(passphrase string, c Crypto) (*sealer, error) {
+	if c.KDF != kdfName {
+		return nil, errors.Errorf("不支持的密钥派生算法：%s", c.KDF)
+	}
+
+	s, err := newSealer(passphrase, c)
+	if err != nil {
+		return nil, err
+	}
+
+	var check string
+	if err = s.open(c.Check, &check); err != nil || check != checkPlain {
+		return nil, ErrBadPassphrase
+	}
+
+	return s, nil
+}
+
+func newSealer(passphrase string, c Crypto) (*sealer, error) {
+	if passphrase == "" {
+		return nil, errors.New("口令不能为空")
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(c.Salt)
+	if err != nil {
+		return nil, errors.Wrap(err, "解析 salt 失败")
+	}
+
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, c.Iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sealer{aead: aead}, nil
+}
+
+func (s *sealer) seal(v any) (string, error) {
+	plain, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(s.aead.Seal(nonce, nonce, plain, nil)), nil
+}
+
+func (s *sealer) open(data string, v any) error {
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return err
+	}
+
+	if len(raw) < s.aead.NonceSize() {
+		return errors.New("密文长度不足")
+	}
+
+	nonce, ciphertext := raw[:s.aead.NonceSize()], raw[s.aead.NonceSize():]
+
+	plain, err := s.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(plain, v)
+}
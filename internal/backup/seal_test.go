@@ -0,0 +1,120 @@
+package backup
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestSealRoundTrip(t *testing.T) {
+	s, c, err := newCrypto("correct horse")
+	if err != nil {
+		t.Fatalf("newCrypto() error = %v", err)
+	}
+
+	if c.KDF != kdfName || c.Iterations != kdfIterations || c.Salt == "" || c.Check == "" {
+		t.Fatalf("unexpected crypto params: %+v", c)
+	}
+
+	tests := []struct {
+		name string
+		in   any
+		out  any
+	}{
+		{"user", &userSecret{Password: "5f4dcc3b", TOTPSecret: "JBSWY3DPEHPK3PXP", RecoveryCodes: "a,b"}, new(userSecret)},
+		{"cloud token", &cloudTokenSecret{AccessToken: "token", Password: "p@ss", Addition: map[string]any{"k": "v"}}, new(cloudTokenSecret)},
+		{"empty", &userSecret{}, new(userSecret)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := s.seal(tt.in)
+			if err != nil {
+				t.Fatalf("seal() error = %v", err)
+			}
+
+			// 重新派生密钥，模拟导入时只拿到归档中的参数
+			opened, err := openCrypto("correct horse", c)
+			if err != nil {
+				t.Fatalf("openCrypto() error = %v", err)
+			}
+
+			if err = opened.open(data, tt.out); err != nil {
+				t.Fatalf("open() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(tt.in, tt.out) {
+				t.Errorf("open() = %+v, want %+v", tt.out, tt.in)
+			}
+		})
+	}
+}
+
+func TestSealRandomNonce(t *testing.T) {
+	s, _, err := newCrypto("passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, _ := s.seal("same")
+	b, _ := s.seal("same")
+
+	if a == b {
+		t.Error("sealing the same value twice should use different nonces")
+	}
+}
+
+func TestOpenCryptoRejects(t *testing.T) {
+	_, c, err := newCrypto("passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = openCrypto("wrong", c); !errors.Is(err, ErrBadPassphrase) {
+		t.Errorf("wrong passphrase: err = %v, want ErrBadPassphrase", err)
+	}
+
+	if _, err = openCrypto("", c); err == nil {
+		t.Error("empty passphrase should fail")
+	}
+
+	other := c
+	other.KDF = "scrypt"
+	if _, err = openCrypto("passphrase", other); err == nil || errors.Is(err, ErrBadPassphrase) {
+		t.Errorf("unknown kdf: err = %v", err)
+	}
+}
+
+func TestOpenTampered(t *testing.T) {
+	s, _, err := newCrypto("passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := s.seal(&userSecret{Password: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := base64.StdEncoding.DecodeString(data)
+	raw[len(raw)-1] ^= 0xff
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"modified ciphertext", base64.StdEncoding.EncodeToString(raw)},
+		{"truncated", base64.StdEncoding.EncodeToString(raw[:4])},
+		{"not base64", "%%%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.open(tt.data, new(userSecret)); err == nil {
+				t.Error("open() should fail")
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package backup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const (
+	snapshotPrefix = "share-"
+	snapshotSuffix = ".db"
+	snapshotLayout = "20060102-150405"
+)
+
+// Snapshot 数据库快照文件
+type Snapshot struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// TakeSnapshot 使用 VACUUM INTO 做在线备份，不阻塞其他连接的读写；先写临时文件再改名，避免留下不完整的快照
+func TakeSnapshot(ctx context.Context, db *gorm.DB, dir string) (*Snapshot, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrap(err, "创建备份目录失败")
+	}
+
+	var (
+		name = snapshotPrefix + time.Now().Format(snapshotLayout) + snapshotSuffix
+		dst  = filepath.Join(dir, name)
+		tmp  = dst + ".tmp"
+	)
+
+	// VACUUM INTO 要求目标文件不存在
+	_ = os.Remove(tmp)
+
+	if err := db.WithContext(ctx).Exec("VACUUM INTO ?", tmp).Error; err != nil {
+		_ = os.Remove(tmp)
+
+		return nil, errors.Wrap(err, "备份数据库失败")
+	}
+
+	if err := os.Rename(tmp, dst); err != nil {
+		_ = os.Remove(tmp)
+
+		return nil, errors.Wrap(err, "保存备份文件失败")
+	}
+
+	info, err := os.Stat(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Snapshot{
+		Name:    name,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}, nil
+}
+
+// ListSnapshots 列出备份目录中的快照，按时间倒序
+func ListSnapshots(dir string) ([]*Snapshot, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*Snapshot{}, nil
+		}
+
+		return nil, err
+	}
+
+	list := make([]*Snapshot, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, snapshotPrefix) || !strings.HasSuffix(name, snapshotSuffix) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		list = append(list, &Snapshot{
+			Name:    name,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+
+	// 文件名中的时间可直接按字典序比较
+	slices.SortFunc(list, func(a, b *Snapshot) int {
+		return strings.Compare(b.Name, a.Name)
+	})
+
+	return list, nil
+}
+
+// RotateSnapshots 只保留最新的 keep 个快照，keep <= 0 时不清理
+func RotateSnapshots(dir string, keep int) ([]string, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+
+	list, err := ListSnapshots(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, item := range list[min(keep, len(list)):] {
+		if err = os.Remove(filepath.Join(dir, item.Name)); err != nil {
+			return removed, err
+		}
+
+		removed = append(removed, item.Name)
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,85 @@
+// Package baseurl 生成对外访问的地址：部署在子路径或反向代理之后时，下载链接、WebDAV href 和订阅地址都需要带上对外的前缀
+package baseurl
+
+import (
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/configs"
+	"github.com/xxcheng123/cloudpan189-share/internal/shared"
+)
+
+// BasePath 配置的路由前缀，如 /share，根路径为空
+func BasePath() string {
+	return configs.GetConfig().BasePath
+}
+
+// trusted 请求是否直接来自可信代理，只有这时才采信 X-Forwarded-* 请求头
+func trusted(ctx *gin.Context) bool {
+	return configs.GetConfig().IsTrustedProxy(ctx.RemoteIP())
+}
+
+// forwarded 取请求头中的第一个值，多级代理时为最外层
+func forwarded(ctx *gin.Context, key string) string {
+	v, _, _ := strings.Cut(ctx.GetHeader(key), ",")
+
+	return strings.TrimSpace(v)
+}
+
+// ForwardedPrefix 可信代理转发前剥离的路径前缀（X-Forwarded-Prefix），不含 basePath
+func ForwardedPrefix(ctx *gin.Context) string {
+	if !trusted(ctx) {
+		return ""
+	}
+
+	v := forwarded(ctx, "X-Forwarded-Prefix")
+	if !strings.HasPrefix(v, "/") || strings.ContainsAny(v, "\"'<>\\ ?#") {
+		return ""
+	}
+
+	return strings.TrimSuffix(path.Clean(v), "/")
+}
+
+// Prefix 对外的路径前缀：X-Forwarded-Prefix 加上配置的 basePath
+func Prefix(ctx *gin.Context) string {
+	return ForwardedPrefix(ctx) + BasePath()
+}
+
+// Resolve 对外访问的根地址（含路径前缀，不以 / 结尾）；优先使用设置中的 BaseURL，
+// 未设置时按请求地址推断，可信代理转发的请求使用 X-Forwarded-Proto/Host/Prefix
+func Resolve(ctx *gin.Context) string {
+	if shared.Setting != nil && shared.Setting.BaseURL != "" {
+		return strings.TrimRight(shared.Setting.BaseURL, "/")
+	}
+
+	var (
+		scheme = "http"
+		host   = ctx.Request.Host
+	)
+
+	if ctx.Request.TLS != nil {
+		scheme = "https"
+	}
+
+	if trusted(ctx) {
+		if v := forwarded(ctx, "X-Forwarded-Proto"); v == "http" || v == "https" {
+			scheme = v
+		}
+
+		if v := forwarded(ctx, "X-Forwarded-Host"); v != "" && !strings.ContainsAny(v, "/\\@ ") {
+			host = v
+		}
+	}
+
+	return scheme + "://" + host + Prefix(ctx)
+}
+
+// Static 不依赖请求的根地址，用于 strm 等离线生成的链接；baseURL 为空时返回只含路径前缀的相对地址
+func Static(baseURL string) string {
+	if baseURL != "" {
+		return strings.TrimRight(baseURL, "/")
+	}
+
+	return BasePath()
+}
@@ -13,9 +13,11 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/samber/lo"
+	"github.com/xxcheng123/cloudpan189-share/internal/changefeed"
 	"github.com/xxcheng123/cloudpan189-share/internal/consts"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/utils"
+	"github.com/xxcheng123/cloudpan189-share/internal/search"
 	"github.com/xxcheng123/cloudpan189-share/internal/shared"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -23,12 +25,21 @@ import (
 
 type virtualFileWalkFunc func(ctx context.Context, file *models.VirtualFile, childrenFiles []*models.VirtualFile) (nextWalkFiles []*models.VirtualFile)
 
-func (w *busWorker) scanVirtualFile(ctx context.Context, rootId int64, deep bool) error {
+func (w *busWorker) scanVirtualFile(ctx context.Context, rootId int64, deep bool, trigger models.ScanTrigger) (err error) {
+	ctx, run := w.startScanRun(ctx, rootId, deep, trigger)
+
 	var scanErrors []error
 	var mu sync.Mutex
 
+	// 记录挂载点根目录的列表结果，用于判断挂载点健康状态；子目录的错误（如扫描中途被删除）不影响挂载点状态
+	var (
+		rootListed  bool
+		rootListErr error
+	)
+
 	fss := &FileScanStat{
 		FileId:       rootId,
+		RunId:        run.run.ID,
 		ScannedCount: 0,
 		WaitCount:    0,
 	}
@@ -36,7 +47,14 @@ func (w *busWorker) scanVirtualFile(ctx context.Context, rootId int64, deep bool
 	w.fileScanStat.Store(rootId, fss)
 	defer w.fileScanStat.Delete(rootId)
 
-	err := w.walkVirtualFile(ctx, rootId, func(ctx context.Context, file *models.VirtualFile, oldFiles []*models.VirtualFile) (nextWalkFiles []*models.VirtualFile) {
+	defer func() {
+		w.finishScanRun(ctx, run, err)
+		w.publishScanProgress(run, fss, "", true)
+	}()
+
+	w.publishScanProgress(run, fss, "", true)
+
+	err = w.walkVirtualFile(ctx, rootId, func(ctx context.Context, file *models.VirtualFile, oldFiles []*models.VirtualFile) (nextWalkFiles []*models.VirtualFile) {
 		var (
 			newFiles = make([]*models.VirtualFile, 0)
 			err      error
@@ -57,17 +75,30 @@ func (w *busWorker) scanVirtualFile(ctx context.Context, rootId int64, deep bool
 			return nil
 		}
 
+		run.addFolder()
+
+		if file.ID == rootId && file.IsTop == 1 {
+			mu.Lock()
+			rootListed = true
+			if err != nil {
+				rootListErr = fmt.Errorf("获取文件列表失败 [%s]: %w", file.Name, err)
+			}
+			mu.Unlock()
+		}
+
 		if err != nil {
-			w.logger.Error("获取文件列表失败", zap.Error(err))
+			w.log(ctx).Error("获取文件列表失败", zap.Error(err))
+			run.addError(file, "list", err)
 			mu.Lock()
 			scanErrors = append(scanErrors, fmt.Errorf("获取文件列表失败 [%s]: %w", file.Name, err))
 			mu.Unlock()
 			return nil
 		}
 
-		fss.WaitCount += int64(len(newFiles))
+		atomic.AddInt64(&fss.WaitCount, int64(len(newFiles)))
 		defer func() {
-			fss.ScannedCount += int64(len(newFiles))
+			atomic.AddInt64(&fss.ScannedCount, int64(len(newFiles)))
+			w.publishScanProgress(run, fss, file.Name, false)
 		}()
 
 		// 数据准备
@@ -95,6 +126,8 @@ func (w *busWorker) scanVirtualFile(ctx context.Context, rootId int64, deep bool
 			filesToDelete []*models.VirtualFile
 			// 找出需要更新的文件
 			filesToUpdateMap = map[int64]map[string]any{}
+			// 更新后的文件，用于记录变更，避免逐个回查数据库
+			updatedFileMap = map[int64]*models.VirtualFile{}
 			// 需要深度扫描的文件
 			filesToDeep []*models.VirtualFile
 		)
@@ -104,7 +137,7 @@ func (w *busWorker) scanVirtualFile(ctx context.Context, rootId int64, deep bool
 			if oldFile, exists := oldFileMap[name]; exists {
 				// 文件存在，检查是否需要更新（通过Rev比较）
 				if oldFile.Rev != newFile.Rev {
-					w.logger.Debug("文件存在差异 - rev changed",
+					w.log(ctx).Debug("文件存在差异 - rev changed",
 						zap.String("parent", file.Name),
 						zap.String("file_name", name),
 						zap.String("old_rev", oldFile.Rev),
@@ -119,11 +152,19 @@ func (w *busWorker) scanVirtualFile(ctx context.Context, rootId int64, deep bool
 					}
 
 					filesToUpdateMap[oldFile.ID] = mp
+
+					updatedFile := *oldFile
+					updatedFile.Name = newFile.Name
+					updatedFile.Rev = newFile.Rev
+					updatedFile.Size = newFile.Size
+					updatedFile.Hash = strings.ToLower(newFile.Hash)
+					updatedFile.ModifyDate = newFile.ModifyDate
+					updatedFileMap[oldFile.ID] = &updatedFile
 				} else if oldFile.IsFolder == 1 && deep {
 					filesToDeep = append(filesToDeep, oldFile)
 				}
 			} else {
-				w.logger.Debug("发现新文件",
+				w.log(ctx).Debug("发现新文件",
 					zap.String("parent", file.Name),
 					zap.String("file_name", name),
 					zap.String("rev", newFile.Rev))
@@ -139,7 +180,7 @@ func (w *busWorker) scanVirtualFile(ctx context.Context, rootId int64, deep bool
 				dbFile.IsTop != 1 &&
 				// 允许自动删除的文件类型
 				lo.IndexOf(allowAutoDelOsTypes, dbFile.OsType) > -1 {
-				w.logger.Debug("文件不存在 - 删除",
+				w.log(ctx).Debug("文件不存在 - 删除",
 					zap.String("parent", file.Name),
 					zap.String("file_name", name),
 					zap.Int64("file_id", dbFile.ID),
@@ -159,15 +200,16 @@ func (w *busWorker) scanVirtualFile(ctx context.Context, rootId int64, deep bool
 
 			count, err = w.batchCreateVirtualFile(ctx, file.ID, filesToCreate)
 			if err != nil {
-				w.logger.Error("批量创建子文件失败",
+				w.log(ctx).Error("批量创建子文件失败",
 					zap.Error(err),
 					zap.Int64("count", count),
 					zap.Int64("file_id", file.ID),
 					zap.String("file_name", file.Name))
 
 				errs = append(errs, fmt.Errorf("批量创建子文件失败: %w", err))
+				run.addError(file, "create", err)
 			} else {
-				w.logger.Info("批量创建子文件成功",
+				w.log(ctx).Info("批量创建子文件成功",
 					zap.Int64("count", count),
 					zap.Int64("file_id", file.ID),
 					zap.String("file_name", file.Name))
@@ -175,36 +217,46 @@ func (w *busWorker) scanVirtualFile(ctx context.Context, rootId int64, deep bool
 		}
 
 		// 更新文件
+		updated := make([]*models.VirtualFile, 0, len(filesToUpdateMap))
 		for id, item := range filesToUpdateMap {
 			if err = w.updateVirtualFile(ctx, id, item); err != nil {
-				w.logger.Error("更新文件失败",
+				w.log(ctx).Error("更新文件失败",
 					zap.Error(err),
 					zap.String("file_name", item["name"].(string)),
 					zap.Int64("file_id", id))
 
 				errs = append(errs, fmt.Errorf("更新文件失败: %w", err))
+				run.addError(file, "update", fmt.Errorf("%s: %w", item["name"], err))
 			} else {
-				w.logger.Debug("更新文件成功",
+				w.log(ctx).Debug("更新文件成功",
 					zap.String("file_name", item["name"].(string)),
 				)
+
+				updated = append(updated, updatedFileMap[id])
 			}
 		}
 
+		changefeed.Record(ctx, models.FileEventUpdate, updated...)
+
+		deleted := new(deletedFiles)
 		for _, item := range filesToDelete {
-			if err = w.deleteVirtualFile(ctx, item.ID); err != nil {
-				w.logger.Error("删除文件失败",
+			if err = w.deleteVirtualFileTree(ctx, item.ID, deleted); err != nil {
+				w.log(ctx).Error("删除文件失败",
 					zap.Error(err),
 					zap.String("file_name", item.Name),
 					zap.Int64("file_id", item.ID))
 
 				errs = append(errs, fmt.Errorf("删除文件失败: %w", err))
+				run.addError(file, "delete", fmt.Errorf("%s: %w", item.Name, err))
 			} else {
-				w.logger.Debug("删除文件成功",
+				w.log(ctx).Debug("删除文件成功",
 					zap.String("file_name", item.Name),
 				)
 			}
 		}
 
+		changefeed.Record(ctx, models.FileEventDelete, deleted.list()...)
+
 		// 收集当前文件处理过程中的错误
 		if len(errs) > 0 {
 			mu.Lock()
@@ -217,6 +269,10 @@ func (w *busWorker) scanVirtualFile(ctx context.Context, rootId int64, deep bool
 		return nextWalkFiles
 	})
 
+	if rootListed {
+		w.updateMountHealth(ctx, rootId, rootListErr)
+	}
+
 	// 返回收集到的错误
 	if err != nil {
 		return err
@@ -259,7 +315,7 @@ func (w *busWorker) walkVirtualFile(ctx context.Context, rootId int64, walkFunc
 		}
 	}
 
-	w.logger.Debug("开始处理文件", zap.String("file_name", file.Name))
+	w.log(ctx).Debug("开始处理文件", zap.String("file_name", file.Name))
 
 	// 判断是否还要继续
 	if nextFiles := walkFunc(ctx, file, children); len(nextFiles) > 0 {
@@ -314,7 +370,7 @@ func (w *busWorker) walkVirtualFile(ctx context.Context, rootId int64, walkFunc
 }
 
 func (w *busWorker) batchCreateVirtualFile(ctx context.Context, parentId int64, files []*models.VirtualFile) (int64, error) {
-	w.logger.Debug("批量创建文件", zap.Int64("parent_id", parentId), zap.Int("file_count", len(files)))
+	w.log(ctx).Debug("批量创建文件", zap.Int64("parent_id", parentId), zap.Int("file_count", len(files)))
 
 	// 检查 pid
 	if parentId <= 0 {
@@ -334,16 +390,52 @@ func (w *busWorker) batchCreateVirtualFile(ctx context.Context, parentId int64,
 		_ = w.createVirtualFileHook(ctx, file)
 	}
 
+	if result.Error == nil {
+		scanRunFromContext(ctx).addCreated(result.RowsAffected)
+		search.Index(ctx, files...)
+		changefeed.Record(ctx, models.FileEventCreate, files...)
+	}
+
 	return result.RowsAffected, result.Error
 }
 
+// deletedFiles 收集递归删除中成功删除的文件，删除结束后一次性记录变更
+type deletedFiles struct {
+	mu    sync.Mutex
+	files []*models.VirtualFile
+}
+
+func (d *deletedFiles) add(file *models.VirtualFile) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.files = append(d.files, file)
+}
+
+func (d *deletedFiles) list() []*models.VirtualFile {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.files
+}
+
 func (w *busWorker) deleteVirtualFile(ctx context.Context, id int64) error {
-	w.logger.Debug("删除文件", zap.Int64("file_id", id))
+	deleted := new(deletedFiles)
+	err := w.deleteVirtualFileTree(ctx, id, deleted)
+
+	// 部分子文件删除失败时，已经删除的文件同样记录
+	changefeed.Record(ctx, models.FileEventDelete, deleted.list()...)
+
+	return err
+}
+
+func (w *busWorker) deleteVirtualFileTree(ctx context.Context, id int64, deleted *deletedFiles) error {
+	w.log(ctx).Debug("删除文件", zap.Int64("file_id", id))
 
 	file := new(models.VirtualFile)
 	if err := w.getDB(ctx).Where("id = ?", id).First(file).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			w.logger.Warn("文件不存在，跳过删除", zap.Int64("id", id))
+			w.log(ctx).Warn("文件不存在，跳过删除", zap.Int64("id", id))
 
 			return nil
 		}
@@ -367,8 +459,8 @@ func (w *busWorker) deleteVirtualFile(ctx context.Context, id int64) error {
 		if threadCount == 1 || len(children) <= 1 {
 			var errs []error
 			for _, child := range children {
-				if err := w.deleteVirtualFile(ctx, child.ID); err != nil {
-					w.logger.Error("删除子文件失败",
+				if err := w.deleteVirtualFileTree(ctx, child.ID, deleted); err != nil {
+					w.log(ctx).Error("删除子文件失败",
 						zap.Int64("parent_id", id),
 						zap.Int64("child_id", child.ID),
 						zap.Error(err))
@@ -393,8 +485,8 @@ func (w *busWorker) deleteVirtualFile(ctx context.Context, id int64) error {
 					semaphore <- struct{}{}
 					defer func() { <-semaphore }()
 
-					if err := w.deleteVirtualFile(ctx, childFile.ID); err != nil {
-						w.logger.Error("删除子文件失败",
+					if err := w.deleteVirtualFileTree(ctx, childFile.ID, deleted); err != nil {
+						w.log(ctx).Error("删除子文件失败",
 							zap.Int64("parent_id", id),
 							zap.Int64("child_id", childFile.ID),
 							zap.Error(err))
@@ -424,21 +516,34 @@ func (w *busWorker) deleteVirtualFile(ctx context.Context, id int64) error {
 	// hook
 	_ = w.deleteVirtualFileHook(ctx, file.ID)
 
-	return w.withLock(ctx, func(db *gorm.DB) *gorm.DB {
+	result := w.withLock(ctx, func(db *gorm.DB) *gorm.DB {
 		return db.Where("id", id).Delete(&models.VirtualFile{})
-	}).Error
+	})
+
+	if result.Error == nil {
+		scanRunFromContext(ctx).addDeleted(result.RowsAffected)
+		deleted.add(file)
+	}
+
+	return result.Error
 }
 
 func (w *busWorker) updateVirtualFile(ctx context.Context, id int64, mp map[string]any) error {
-	w.logger.Debug("更新文件", zap.Int64("file_id", id), zap.Any("data", mp))
+	w.log(ctx).Debug("更新文件", zap.Int64("file_id", id), zap.Any("data", mp))
 
-	return w.withLock(ctx, func(db *gorm.DB) *gorm.DB {
+	if err := w.withLock(ctx, func(db *gorm.DB) *gorm.DB {
 		return db.Model(&models.VirtualFile{}).Where("id", id).Updates(mp)
-	}).Error
+	}).Error; err != nil {
+		return err
+	}
+
+	scanRunFromContext(ctx).addUpdated(1)
+
+	return nil
 }
 
 func (w *busWorker) deleteVirtualFileHook(ctx context.Context, fileId int64) error {
-	w.logger.Debug("删除文件", zap.Int64("file_id", fileId))
+	w.log(ctx).Debug("删除文件", zap.Int64("file_id", fileId))
 
 	if fileId == 0 {
 		return errors.New("file.ID is invalid")
@@ -453,7 +558,7 @@ func (w *busWorker) deleteVirtualFileHook(ctx context.Context, fileId int64) err
 
 		// 删除媒体文件后自动清理空文件夹（目前会删除所有的空文件夹）
 		if _, err := w.clearEmptyDirs(ctx); err != nil {
-			w.logger.Warn("清理空文件夹失败", zap.Int64("file_id", fileId), zap.Error(err))
+			w.log(ctx).Warn("清理空文件夹失败", zap.Int64("file_id", fileId), zap.Error(err))
 			// 不将此错误加入到errs中，因为这不应该阻止删除操作
 		}
 	}
@@ -479,6 +584,10 @@ func (w *busWorker) createVirtualFileHook(ctx context.Context, file *models.Virt
 			goto strmOver
 		}
 
+		if w.isHiddenDuplicate(ctx, file) {
+			goto strmOver
+		}
+
 		filePath, err := w.calFilePath(ctx, file.ID)
 		if err != nil {
 			errs = append(errs, err)
@@ -495,7 +604,7 @@ strmOver:
 	return errors2.Join(errs...)
 }
 
-func (w *busWorker) scanTopVirtualFiles(ctx context.Context) error {
+func (w *busWorker) scanTopVirtualFiles(ctx context.Context, trigger models.ScanTrigger) error {
 	// 读取所有顶层文件
 	var topFiles = make([]*models.VirtualFile, 0)
 	if err := w.getDB(ctx).Where("is_top = 1").Find(&topFiles).Error; err != nil {
@@ -509,7 +618,7 @@ func (w *busWorker) scanTopVirtualFiles(ctx context.Context) error {
 		if f.IsTop == 1 && f.Addition != nil {
 			if disableAutoScanValue, exists := f.Addition[consts.FileAdditionKeyDisableAutoScan]; exists {
 				if disableAutoScan, err := utils.Bool(disableAutoScanValue); err == nil && disableAutoScan {
-					w.logger.Info("跳过自动扫描，文件已设置禁用自动扫描队列标志",
+					w.log(ctx).Info("跳过自动扫描，文件已设置禁用自动扫描队列标志",
 						zap.Int64("file_id", f.ID),
 						zap.String("file_name", f.Name))
 
@@ -518,7 +627,7 @@ func (w *busWorker) scanTopVirtualFiles(ctx context.Context) error {
 			}
 		}
 
-		_ = w.scanVirtualFile(ctx, f.ID, false)
+		_ = w.scanVirtualFile(ctx, f.ID, false, trigger)
 	}
 
 	if len(errs) > 0 {
@@ -542,6 +651,10 @@ func (w *busWorker) buildMediaFile(ctx context.Context, fileId int64) (int64, er
 			return nil
 		}
 
+		if w.isHiddenDuplicate(ctx, file) {
+			return nil
+		}
+
 		filePath, err := w.calFilePath(ctx, file.ID)
 		if err != nil {
 			return nil
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 
 	"github.com/xxcheng123/cloudpan189-share/configs"
+	"github.com/xxcheng123/cloudpan189-share/internal/duplicate"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/utils"
 	"go.uber.org/zap"
@@ -71,7 +72,7 @@ func (w *busWorker) clearEmptyDirs(ctx context.Context) (int64, error) {
 
 	// 检查媒体目录是否存在
 	if _, err := os.Stat(mediaDir); os.IsNotExist(err) {
-		w.logger.Info("媒体目录不存在，跳过清理", zap.String("media_dir", mediaDir))
+		w.log(ctx).Info("媒体目录不存在，跳过清理", zap.String("media_dir", mediaDir))
 		return 0, nil
 	} else if err != nil {
 		return 0, fmt.Errorf("检查媒体目录失败: %v", err)
@@ -84,7 +85,7 @@ func (w *busWorker) clearEmptyDirs(ctx context.Context) (int64, error) {
 		return deletedCount, err
 	}
 
-	w.logger.Info("清理空文件夹完成",
+	w.log(ctx).Info("清理空文件夹完成",
 		zap.String("media_dir", mediaDir),
 		zap.Int64("deleted_count", deletedCount),
 	)
@@ -135,9 +136,9 @@ func (w *busWorker) clearEmptyDirsRecursive(dirPath string, deletedCount *int64)
 func (w *busWorker) clearAllMediaFiles(ctx context.Context, mediaTypes ...models.MediaType) (count int64, err error) {
 	defer func() {
 		if err != nil {
-			w.logger.Error("清理所有媒体文件失败", zap.Error(err))
+			w.log(ctx).Error("清理所有媒体文件失败", zap.Error(err))
 		} else {
-			w.logger.Info("清理所有媒体文件成功", zap.Int64("count", count))
+			w.log(ctx).Info("清理所有媒体文件成功", zap.Int64("count", count))
 
 			// 清理所有空目录
 			_, _ = w.clearEmptyDirs(ctx)
@@ -198,10 +199,48 @@ func (w *busWorker) write(path string, content []byte) error {
 		return fmt.Errorf("创建目录失败 %s: %v", dir, err)
 	}
 
-	// 写入文件
-	if err := os.WriteFile(fullPath, content, 0644); err != nil {
+	// 先写临时文件再重命名，进程中途退出也不会留下写了一半的文件
+	tmp, err := os.CreateTemp(dir, ".strm-*.tmp")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败 %s: %v", dir, err)
+	}
+
+	tmpPath := tmp.Name()
+
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Chmod(tmpPath, 0644)
+	}
+
+	if err == nil {
+		err = os.Rename(tmpPath, fullPath)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmpPath)
+
 		return fmt.Errorf("写入文件失败 %s: %v", fullPath, err)
 	}
 
 	return nil
 }
+
+// isHiddenDuplicate 开启后，重复文件只为保留的那一份生成 strm
+func (w *busWorker) isHiddenDuplicate(ctx context.Context, file *models.VirtualFile) bool {
+	if !duplicate.HideEnabled(models.DuplicateHideTargetStrm) || !duplicate.Candidate(file) {
+		return false
+	}
+
+	hidden, err := duplicate.IsHidden(ctx, w.getDB(ctx), file)
+	if err != nil {
+		w.log(ctx).Warn("检查重复文件失败", zap.Int64("fileId", file.ID), zap.Error(err))
+
+		return false
+	}
+
+	return hidden
+}
@@ -0,0 +1,246 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/xxcheng123/cloudpan189-interface/client"
+	"github.com/xxcheng123/cloudpan189-share/internal/consts"
+	"github.com/xxcheng123/cloudpan189-share/internal/metrics"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/cloudbatch"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/utils"
+	"github.com/xxcheng123/cloudpan189-share/internal/tokenpool"
+	"go.uber.org/zap"
+)
+
+const (
+	shareSavePollInterval = time.Second * 2
+	shareSaveTimeout      = time.Minute * 30
+	// shareSaveMaxCheckFailures 网络异常、限流等可重试错误连续出现的上限，超过后放弃等待
+	shareSaveMaxCheckFailures = 10
+	// shareSaveTargetMaxDepth 向上查找已挂载祖先目录的最大层数
+	shareSaveTargetMaxDepth = 32
+)
+
+var shareSaveSeq int64
+
+// ShareSaveStat 转存任务进度，进度字段由任务协程原子写入，读取时使用 snapshot
+type ShareSaveStat struct {
+	ID             int64     `json:"id"`
+	FileId         int64     `json:"fileId"`
+	FileName       string    `json:"fileName"`
+	CloudTokenId   int64     `json:"cloudTokenId"`
+	TargetFolderId string    `json:"targetFolderId"`
+	TaskId         string    `json:"taskId"`
+	TaskStatus     int32     `json:"taskStatus"`
+	SubTaskCount   int64     `json:"subTaskCount"`
+	SuccessedCount int64     `json:"successedCount"`
+	FailedCount    int64     `json:"failedCount"`
+	SkipCount      int64     `json:"skipCount"`
+	StartTime      time.Time `json:"startTime"`
+}
+
+func (s *ShareSaveStat) update(status *cloudbatch.TaskStatus) {
+	atomic.StoreInt32(&s.TaskStatus, int32(status.TaskStatus))
+	atomic.StoreInt64(&s.SubTaskCount, status.SubTaskCount)
+	atomic.StoreInt64(&s.SuccessedCount, status.SuccessedCount)
+	atomic.StoreInt64(&s.FailedCount, status.FailedCount)
+	atomic.StoreInt64(&s.SkipCount, status.SkipCount)
+}
+
+// snapshot 复制当前进度，供接口序列化
+func (s *ShareSaveStat) snapshot() *ShareSaveStat {
+	return &ShareSaveStat{
+		ID:             s.ID,
+		FileId:         s.FileId,
+		FileName:       s.FileName,
+		CloudTokenId:   s.CloudTokenId,
+		TargetFolderId: s.TargetFolderId,
+		TaskId:         s.TaskId,
+		TaskStatus:     atomic.LoadInt32(&s.TaskStatus),
+		SubTaskCount:   atomic.LoadInt64(&s.SubTaskCount),
+		SuccessedCount: atomic.LoadInt64(&s.SuccessedCount),
+		FailedCount:    atomic.LoadInt64(&s.FailedCount),
+		SkipCount:      atomic.LoadInt64(&s.SkipCount),
+		StartTime:      s.StartTime,
+	}
+}
+
+func (w *busWorker) saveShare(ctx context.Context, req TopicShareSaveRequest) error {
+	file := new(models.VirtualFile)
+	if err := w.getDB(ctx).Where("id = ?", req.FileId).First(file).Error; err != nil {
+		return err
+	}
+
+	shareId, err := utils.GetInt64(file.Addition, consts.FileAdditionKeyShareId)
+	if err != nil {
+		return errors.New("当前文件不属于分享，无法转存")
+	}
+
+	fileId := utils.GetString(file.Addition, consts.FileAdditionKeyFileId)
+	if fileId == "" {
+		return errors.New("no file_id")
+	}
+
+	cloudToken := new(models.CloudToken)
+	if err = w.getDB(ctx).Where("id = ?", req.CloudTokenId).First(cloudToken).Error; err != nil {
+		return errors.Wrap(err, "查询令牌失败")
+	}
+
+	token := client.NewAuthToken(cloudToken.AccessToken, cloudToken.ExpiresIn)
+
+	taskId, err := cloudbatch.CreateShareSaveTask(ctx, token, shareId, req.FamilyId, req.TargetFolderId, []cloudbatch.TaskInfo{
+		{
+			FileId:   fileId,
+			FileName: file.Name,
+			IsFolder: int(file.IsFolder),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "创建转存任务失败")
+	}
+
+	stat := &ShareSaveStat{
+		ID:             atomic.AddInt64(&shareSaveSeq, 1),
+		FileId:         file.ID,
+		FileName:       file.Name,
+		CloudTokenId:   req.CloudTokenId,
+		TargetFolderId: req.TargetFolderId,
+		TaskId:         taskId,
+		TaskStatus:     cloudbatch.TaskStatusRunning,
+		StartTime:      time.Now(),
+	}
+
+	w.shareSaveStat.Store(stat.ID, stat)
+	defer w.shareSaveStat.Delete(stat.ID)
+
+	w.log(ctx).Info("转存任务已创建",
+		zap.Int64("fileId", file.ID),
+		zap.String("taskId", taskId),
+		zap.String("targetFolderId", req.TargetFolderId))
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, shareSaveTimeout)
+	defer cancel()
+
+	var checkFailures int
+
+	for {
+		select {
+		case <-timeoutCtx.Done():
+			return errors.Wrap(timeoutCtx.Err(), "等待转存任务完成超时")
+		case <-time.After(shareSavePollInterval):
+		}
+
+		status, err := cloudbatch.CheckTask(timeoutCtx, token, cloudbatch.TaskTypeShareSave, taskId)
+		if err != nil {
+			if !checkTaskRetryable(err) {
+				return errors.Wrap(err, "查询转存任务进度失败")
+			}
+
+			if checkFailures++; checkFailures >= shareSaveMaxCheckFailures {
+				return errors.Wrapf(err, "查询转存任务进度连续失败 %d 次", checkFailures)
+			}
+
+			w.log(ctx).Warn("查询转存任务进度失败", zap.String("taskId", taskId), zap.Error(err))
+
+			continue
+		}
+
+		checkFailures = 0
+
+		stat.update(status)
+
+		switch status.TaskStatus {
+		case cloudbatch.TaskStatusSuccess:
+			w.log(ctx).Info("转存任务完成",
+				zap.String("taskId", taskId),
+				zap.Int64("successedCount", status.SuccessedCount),
+				zap.Int64("failedCount", status.FailedCount))
+
+			w.refreshSaveTarget(ctx, req, token)
+
+			return nil
+		case cloudbatch.TaskStatusConflict:
+			return fmt.Errorf("转存任务存在同名文件冲突: %s", taskId)
+		case cloudbatch.TaskStatusRunning, cloudbatch.TaskStatusPending:
+		default:
+			return fmt.Errorf("转存任务异常结束，状态: %d，任务: %s", status.TaskStatus, taskId)
+		}
+	}
+}
+
+// checkTaskRetryable 查询任务进度的错误是否值得继续轮询：令牌失效或云盘拒绝请求时重试也不会成功
+func checkTaskRetryable(err error) bool {
+	if errors.Is(err, cloudbatch.ErrTokenExpired) {
+		return false
+	}
+
+	switch tokenpool.Classify(err) {
+	case tokenpool.ErrorKindExpired:
+		return false
+	case tokenpool.ErrorKindThrottled:
+		return true
+	}
+
+	// 云盘返回的其他业务错误（如任务不存在）不会自行恢复，网络错误则可以重试
+	var respErr *client.RespErr
+
+	return !errors.As(err, &respErr)
+}
+
+// refreshSaveTarget 刷新转存目标目录所在的挂载。目标目录可能位于挂载目录下的任意层级，也可能还没有被扫描到，
+// 此时沿云盘目录向上查找最近一个已在虚拟目录中的祖先目录，深度扫描该目录
+func (w *busWorker) refreshSaveTarget(ctx context.Context, req TopicShareSaveRequest, token client.AuthToken) {
+	osType := models.OsTypeCloudFolder
+	if req.FamilyId != "" {
+		osType = models.OsTypeCloudFamilyFolder
+	}
+
+	folderId := req.TargetFolderId
+
+	for depth := 0; depth < shareSaveTargetMaxDepth && folderId != ""; depth++ {
+		var ids []int64
+		if err := w.getDB(ctx).Model(&models.VirtualFile{}).
+			Where("os_type = ? AND is_folder = 1", osType).
+			Where("CAST(json_extract(addition, '$.file_id') AS TEXT) = ?", folderId).
+			Pluck("id", &ids).Error; err != nil {
+			w.log(ctx).Error("查询转存目标挂载失败", zap.Error(err))
+
+			return
+		}
+
+		if len(ids) > 0 {
+			for _, id := range ids {
+				if err := w.scanVirtualFile(ctx, id, true, models.ScanTriggerAPI); err != nil {
+					w.log(ctx).Error("刷新转存目标失败", zap.Int64("fileId", id), zap.Error(err))
+				}
+			}
+
+			return
+		}
+
+		// 家庭云没有查询上级目录的接口，只能匹配已扫描到的目录
+		if req.FamilyId != "" {
+			break
+		}
+
+		info, err := metrics.NewClient().WithToken(token).GetFolderInfo(ctx, client.String(folderId))
+		if err != nil {
+			w.log(ctx).Warn("查询转存目标上级目录失败", zap.String("folderId", folderId), zap.Error(err))
+
+			return
+		}
+
+		if string(info.ParentId) == folderId {
+			break
+		}
+
+		folderId = string(info.ParentId)
+	}
+
+	w.log(ctx).Info("转存目标目录不在任何挂载中，跳过刷新", zap.String("targetFolderId", req.TargetFolderId))
+}
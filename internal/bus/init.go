@@ -1,11 +1,14 @@
 package bus
 
 import (
+	"context"
 	"sync"
+	"time"
 
-	"github.com/xxcheng123/cloudpan189-interface/client"
 	"github.com/xxcheng123/cloudpan189-share/configs"
+	"github.com/xxcheng123/cloudpan189-share/internal/metrics"
 	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/eventbus"
+	"github.com/xxcheng123/cloudpan189-share/internal/stream"
 	"go.uber.org/zap"
 )
 
@@ -21,10 +24,87 @@ func Init() {
 			bus: eventbus.NewWithConfig(&eventbus.Config{
 				BufferSize:     8,
 				MaxConcurrency: 0,
+				OnTaskChange: func(task eventbus.TaskInfo) {
+					stream.Publish(stream.TopicBus, task.Status, task)
+
+					if task.Status == "completed" || task.Status == "failed" {
+						metrics.RecordBusTask(task.Topic, task.Status)
+					}
+				},
 			}),
-			client: client.New(),
+			client: metrics.NewClient(),
 		}
 
+		singletonBusWork.registerMetrics()
+		singletonBusWork.markInterruptedScanRuns()
 		singletonBusWork.doSubscribe()
 	})
 }
+
+// Running 总线是否在运行
+func Running() bool {
+	return singletonBusWork != nil && !singletonBusWork.bus.Closed()
+}
+
+// Shutdown 取消正在执行的任务并等待其退出，超出 ctx 时限直接返回
+func Shutdown(ctx context.Context) error {
+	if singletonBusWork == nil {
+		return nil
+	}
+
+	return singletonBusWork.bus.Shutdown(ctx)
+}
+
+// Wait 等待队列中和正在运行的任务全部结束，任务执行中产生的新任务也会等待；供命令行在退出前使用
+func Wait(ctx context.Context) error {
+	if singletonBusWork == nil {
+		return nil
+	}
+
+	// 任务之间投递下一个任务时可能短暂为空，连续两次空闲才算结束
+	idle := 0
+	for idle < 2 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+
+		stats := singletonBusWork.bus.GetStats()
+		if stats.RunningCount+stats.PendingCount+stats.QueueLength == 0 {
+			idle++
+		} else {
+			idle = 0
+		}
+	}
+
+	return nil
+}
+
+// registerMetrics 暴露总线队列状态，按主题统计运行中和等待中的任务
+func (w *busWorker) registerMetrics() {
+	countByTopic := func(tasks []eventbus.TaskInfo) map[string]float64 {
+		mp := make(map[string]float64)
+		for _, task := range tasks {
+			mp[task.Topic]++
+		}
+
+		return mp
+	}
+
+	metrics.RegisterGauge("share_bus_queue_length", "总线队列中等待调度的任务数", "", func() map[string]float64 {
+		return map[string]float64{"": float64(w.bus.GetStats().QueueLength)}
+	})
+
+	metrics.RegisterGauge("share_bus_running_tasks", "正在运行的总线任务数", "topic", func() map[string]float64 {
+		return countByTopic(w.bus.GetRunningTasks())
+	})
+
+	metrics.RegisterGauge("share_bus_pending_tasks", "等待中的总线任务数", "topic", func() map[string]float64 {
+		return countByTopic(w.bus.GetPendingTasks())
+	})
+
+	metrics.RegisterGauge("share_bus_completed_tasks", "启动以来已完成的总线任务数", "", func() map[string]float64 {
+		return map[string]float64{"": float64(w.bus.GetStats().CompletedCount)}
+	})
+}
@@ -0,0 +1,180 @@
+package bus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"github.com/xxcheng123/cloudpan189-interface/client"
+	"github.com/xxcheng123/cloudpan189-share/internal/alert"
+	"github.com/xxcheng123/cloudpan189-share/internal/consts"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/notify"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/utils"
+	"github.com/xxcheng123/cloudpan189-share/internal/shared"
+	"github.com/xxcheng123/cloudpan189-share/internal/tokenpool"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 天翼云盘分享相关的错误码，只用于判断挂载点根目录的列表错误，FileNotFound 表示分享的根文件夹已不存在
+var (
+	shareCancelledCodes = []string{"ShareNotFound", "ShareInfoNotFound", "ShareExpiredError", "ShareCancelled", "FileNotFound"}
+	shareAuditCodes     = []string{"ShareAuditWaiting", "ShareAuditNotPass", "ShareAuditing"}
+	shareAuthCodes      = []string{"ShareAccessCodeError", "AccessCodeError", "ShareNeedAccessCode"}
+)
+
+// MountHealth 挂载点健康状态
+type MountHealth struct {
+	State       models.MountHealthState `json:"state"`
+	LastError   string                  `json:"lastError"`
+	LastErrorAt int64                   `json:"lastErrorAt"`
+	CheckedAt   int64                   `json:"checkedAt"`
+	Hidden      bool                    `json:"hidden"`
+}
+
+// FindMountHealth 读取挂载点的健康状态，从未扫描过时返回 nil
+func FindMountHealth(file *models.VirtualFile) *MountHealth {
+	state := utils.GetString(file.Addition, consts.FileAdditionKeyHealthState)
+	if state == "" {
+		return nil
+	}
+
+	h := &MountHealth{
+		State:     state,
+		LastError: utils.GetString(file.Addition, consts.FileAdditionKeyHealthError),
+	}
+
+	h.LastErrorAt, _ = utils.GetInt64(file.Addition, consts.FileAdditionKeyHealthErrorAt)
+	h.CheckedAt, _ = utils.GetInt64(file.Addition, consts.FileAdditionKeyHealthCheckedAt)
+	h.Hidden, _ = utils.Bool(file.Addition[consts.FileAdditionKeyHidden])
+
+	return h
+}
+
+// classifyMountError 根据扫描错误判断挂载点健康状态
+func classifyMountError(err error) models.MountHealthState {
+	if err == nil {
+		return models.MountHealthOk
+	}
+
+	switch tokenpool.Classify(err) {
+	case tokenpool.ErrorKindExpired:
+		return models.MountHealthAuthFailed
+	case tokenpool.ErrorKindThrottled:
+		return models.MountHealthRateLimited
+	}
+
+	var respErr *client.RespErr
+	if errors.As(err, &respErr) {
+		codes := []string{respErr.Code, respErr.ErrorCode, respErr.Error_}
+		if v, ok := respErr.ResCode.(string); ok {
+			codes = append(codes, v)
+		}
+
+		for _, code := range codes {
+			switch {
+			case code == "":
+			case containsFold(shareCancelledCodes, code):
+				return models.MountHealthShareCancelled
+			case containsFold(shareAuditCodes, code):
+				return models.MountHealthAuditPending
+			case containsFold(shareAuthCodes, code):
+				return models.MountHealthAuthFailed
+			}
+		}
+	}
+
+	// cloud_token 未绑定或已删除
+	if strings.Contains(err.Error(), "cloud_token") {
+		return models.MountHealthAuthFailed
+	}
+
+	return models.MountHealthError
+}
+
+func containsFold(list []string, s string) bool {
+	return lo.ContainsBy(list, func(item string) bool {
+		return strings.EqualFold(item, s)
+	})
+}
+
+// updateMountHealth 扫描结束后更新所属挂载点的健康状态，并按设置执行隐藏/告警
+func (w *busWorker) updateMountHealth(ctx context.Context, fileId int64, scanErr error) {
+	top, err := w.findTopFile(ctx, fileId)
+	if err != nil {
+		w.log(ctx).Warn("查找挂载点失败", zap.Int64("fileId", fileId), zap.Error(err))
+
+		return
+	}
+
+	var (
+		now       = time.Now()
+		state     = classifyMountError(scanErr)
+		prevState = utils.GetString(top.Addition, consts.FileAdditionKeyHealthState)
+		actions   = shared.MountUnhealthyActions
+	)
+
+	// 只更新健康状态相关的键，避免覆盖扫描期间其他地方对 addition 的修改（如令牌池、令牌换绑）
+	var (
+		expr = "json_set(COALESCE(addition, '{}'), '$." + consts.FileAdditionKeyHealthState + "', ?, '$." + consts.FileAdditionKeyHealthCheckedAt + "', ?"
+		args = []any{state, now.Unix()}
+	)
+
+	if scanErr != nil {
+		expr += ", '$." + consts.FileAdditionKeyHealthError + "', ?, '$." + consts.FileAdditionKeyHealthErrorAt + "', ?"
+		args = append(args, scanErr.Error(), now.Unix())
+	}
+
+	expr += ")"
+
+	if state != models.MountHealthOk && lo.Contains(actions, models.MountUnhealthyActionHide) {
+		expr = "json_set(" + expr + ", '$." + consts.FileAdditionKeyHidden + "', json('true'))"
+	} else {
+		expr = "json_remove(" + expr + ", '$." + consts.FileAdditionKeyHidden + "')"
+	}
+
+	if err = w.withLock(ctx, func(db *gorm.DB) *gorm.DB {
+		return db.Model(&models.VirtualFile{}).Where("id = ?", top.ID).Update("addition", gorm.Expr(expr, args...))
+	}).Error; err != nil {
+		w.log(ctx).Error("更新挂载点健康状态失败", zap.Int64("fileId", top.ID), zap.Error(err))
+
+		return
+	}
+
+	if state == prevState || !lo.Contains(actions, models.MountUnhealthyActionAlert) {
+		return
+	}
+
+	if state != models.MountHealthOk {
+		alert.Send(ctx, notify.LevelError, "挂载点异常",
+			fmt.Sprintf("挂载点「%s」(ID:%d) 状态变为 %s：%s", top.Name, top.ID, state, scanErr.Error()))
+	} else if prevState != "" {
+		alert.Send(ctx, notify.LevelInfo, "挂载点恢复",
+			fmt.Sprintf("挂载点「%s」(ID:%d) 已恢复正常", top.Name, top.ID))
+	}
+}
+
+func (w *busWorker) findTopFile(ctx context.Context, fileId int64) (*models.VirtualFile, error) {
+	file := new(models.VirtualFile)
+
+	for {
+		if err := w.getDB(ctx).Where("id = ?", fileId).First(file).Error; err != nil {
+			return nil, err
+		}
+
+		if file.IsTop == 1 {
+			return file, nil
+		}
+
+		if file.ParentId == 0 {
+			return nil, FileNotFound
+		}
+
+		fileId = file.ParentId
+		file = new(models.VirtualFile)
+	}
+}
@@ -0,0 +1,248 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xxcheng123/cloudpan189-share/internal/metrics"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/stream"
+	"go.uber.org/zap"
+)
+
+const (
+	// 每次运行最多保存的错误条数，超出只计数
+	scanRunMaxErrors = 100
+	// 每个挂载点保留的运行记录数
+	scanRunKeepPerFile = 100
+	// 扫描进度推送的最小间隔
+	scanProgressInterval = time.Millisecond * 500
+)
+
+type scanRunCtxKey struct{}
+
+// scanRunTracker 记录一次扫描运行中的统计，扫描过程中并发更新
+type scanRunTracker struct {
+	run *models.ScanRun
+
+	folders  atomic.Int64
+	created  atomic.Int64
+	updated  atomic.Int64
+	deleted  atomic.Int64
+	apiCalls atomic.Int64
+
+	lastPublish atomic.Int64 // 上次推送进度的时间，毫秒
+
+	mu         sync.Mutex
+	errors     []models.ScanRunError
+	errorCount int64
+}
+
+// scanRunFromContext 获取当前扫描的统计，不在扫描中时返回 nil，nil 上的方法均为空操作
+func scanRunFromContext(ctx context.Context) *scanRunTracker {
+	t, _ := ctx.Value(scanRunCtxKey{}).(*scanRunTracker)
+
+	return t
+}
+
+// countScanAPICall 记录一次云盘接口调用
+func countScanAPICall(ctx context.Context) {
+	if t := scanRunFromContext(ctx); t != nil {
+		t.apiCalls.Add(1)
+	}
+}
+
+func (t *scanRunTracker) addFolder() {
+	if t != nil {
+		t.folders.Add(1)
+	}
+}
+
+func (t *scanRunTracker) addCreated(n int64) {
+	if t != nil {
+		t.created.Add(n)
+	}
+}
+
+func (t *scanRunTracker) addUpdated(n int64) {
+	if t != nil {
+		t.updated.Add(n)
+	}
+}
+
+func (t *scanRunTracker) addDeleted(n int64) {
+	if t != nil {
+		t.deleted.Add(n)
+	}
+}
+
+func (t *scanRunTracker) addError(folder *models.VirtualFile, stage string, err error) {
+	if t == nil || err == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.errorCount++
+	if len(t.errors) >= scanRunMaxErrors {
+		return
+	}
+
+	t.errors = append(t.errors, models.ScanRunError{
+		FolderId:   folder.ID,
+		FolderName: folder.Name,
+		Stage:      stage,
+		Message:    err.Error(),
+	})
+}
+
+// startScanRun 创建运行记录，返回携带统计的 ctx
+func (w *busWorker) startScanRun(ctx context.Context, rootId int64, deep bool, trigger models.ScanTrigger) (context.Context, *scanRunTracker) {
+	if trigger == "" {
+		trigger = models.ScanTriggerManual
+	}
+
+	run := &models.ScanRun{
+		FileId:    rootId,
+		Trigger:   trigger,
+		Deep:      deep,
+		Status:    models.ScanRunRunning,
+		StartedAt: time.Now(),
+	}
+
+	if rootId != 0 {
+		root := new(models.VirtualFile)
+		if err := w.getDB(ctx).Select("id", "name").Where("id = ?", rootId).First(root).Error; err == nil {
+			run.Name = root.Name
+		}
+	}
+
+	if err := w.getDB(ctx).Create(run).Error; err != nil {
+		w.log(ctx).Error("创建扫描运行记录失败", zap.Int64("file_id", rootId), zap.Error(err))
+	}
+
+	t := &scanRunTracker{run: run}
+
+	return context.WithValue(ctx, scanRunCtxKey{}, t), t
+}
+
+// finishScanRun 写入统计结果，err 不为空表示扫描中断
+func (w *busWorker) finishScanRun(ctx context.Context, t *scanRunTracker, err error) {
+	run := t.run
+
+	t.mu.Lock()
+	run.Errors = t.errors
+	run.ErrorCount = t.errorCount
+	t.mu.Unlock()
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		// 服务关闭时取消
+		run.Status = models.ScanRunInterrupted
+	case err != nil:
+		run.Status = models.ScanRunFailed
+	case run.ErrorCount > 0:
+		run.Status = models.ScanRunPartial
+	default:
+		run.Status = models.ScanRunSuccess
+	}
+
+	// 中断的原因不一定落在某个目录上，单独记一条
+	if err != nil && run.ErrorCount == 0 {
+		run.ErrorCount = 1
+		run.Errors = append(run.Errors, models.ScanRunError{
+			FolderId:   run.FileId,
+			FolderName: run.Name,
+			Stage:      "walk",
+			Message:    err.Error(),
+		})
+	}
+
+	now := time.Now()
+	run.FinishedAt = &now
+	run.Duration = now.Sub(run.StartedAt).Milliseconds()
+	run.Folders = t.folders.Load()
+	run.Created = t.created.Load()
+	run.Updated = t.updated.Load()
+	run.Deleted = t.deleted.Load()
+	run.ApiCalls = t.apiCalls.Load()
+
+	metrics.RecordScan(run.FileId, run.Status, now.Sub(run.StartedAt), run.ErrorCount)
+
+	if run.ID == 0 {
+		return
+	}
+
+	// 扫描可能因 ctx 取消而结束，记录仍需落库
+	db := w.db.WithContext(context.WithoutCancel(ctx))
+
+	if err := db.Save(run).Error; err != nil {
+		w.log(ctx).Error("保存扫描运行记录失败", zap.Int64("run_id", run.ID), zap.Error(err))
+
+		return
+	}
+
+	if err := db.Where("file_id = ?", run.FileId).
+		Where("id NOT IN (?)", db.Model(&models.ScanRun{}).Select("id").Where("file_id = ?", run.FileId).Order("id DESC").Limit(scanRunKeepPerFile)).
+		Delete(&models.ScanRun{}).Error; err != nil {
+		w.log(ctx).Warn("清理扫描运行记录失败", zap.Int64("file_id", run.FileId), zap.Error(err))
+	}
+}
+
+// markInterruptedScanRuns 启动时将上次未结束的运行标记为中断
+func (w *busWorker) markInterruptedScanRuns() {
+	if err := w.db.Model(&models.ScanRun{}).
+		Where("status = ?", models.ScanRunRunning).
+		Update("status", models.ScanRunInterrupted).Error; err != nil {
+		w.logger.Error("标记中断的扫描运行记录失败", zap.Error(err))
+	}
+}
+
+// ScanProgress 推送给前端的扫描进度
+type ScanProgress struct {
+	FileId       int64                `json:"fileId"`
+	RunId        int64                `json:"runId"`
+	Name         string               `json:"name"`
+	Status       models.ScanRunStatus `json:"status"`
+	Folder       string               `json:"folder"` // 刚处理完的目录
+	WaitCount    int64                `json:"waitCount"`
+	ScannedCount int64                `json:"scannedCount"`
+	Created      int64                `json:"created"`
+	Updated      int64                `json:"updated"`
+	Deleted      int64                `json:"deleted"`
+	ErrorCount   int64                `json:"errorCount"`
+}
+
+// publishScanProgress 推送扫描进度，目录处理很快时按间隔节流，force 用于开始和结束
+func (w *busWorker) publishScanProgress(t *scanRunTracker, fss *FileScanStat, folder string, force bool) {
+	now := time.Now().UnixMilli()
+	last := t.lastPublish.Load()
+
+	if !force && (now-last < scanProgressInterval.Milliseconds() || !t.lastPublish.CompareAndSwap(last, now)) {
+		return
+	}
+
+	t.lastPublish.Store(now)
+
+	t.mu.Lock()
+	errorCount := t.errorCount
+	t.mu.Unlock()
+
+	stream.Publish(stream.TopicScan, "progress", &ScanProgress{
+		FileId:       fss.FileId,
+		RunId:        t.run.ID,
+		Name:         t.run.Name,
+		Status:       t.run.Status,
+		Folder:       folder,
+		WaitCount:    atomic.LoadInt64(&fss.WaitCount),
+		ScannedCount: atomic.LoadInt64(&fss.ScannedCount),
+		Created:      t.created.Load(),
+		Updated:      t.updated.Load(),
+		Deleted:      t.deleted.Load(),
+		ErrorCount:   errorCount,
+	})
+}
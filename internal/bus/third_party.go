@@ -12,6 +12,7 @@ import (
 
 	"github.com/xxcheng123/cloudpan189-interface/client"
 	"github.com/xxcheng123/cloudpan189-share/internal/consts"
+	"github.com/xxcheng123/cloudpan189-share/internal/metrics"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/utils"
 )
@@ -30,6 +31,7 @@ func (w *busWorker) getSubscribeUserFiles(ctx context.Context, f *models.Virtual
 		files          = make([]*models.VirtualFile, 0)
 	)
 
+	countScanAPICall(ctx)
 	resp, err := w.client.GetUpResourceShare(ctx, userId, pageNum, pageSize)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get first page: %w", err)
@@ -73,6 +75,7 @@ func (w *busWorker) getSubscribeUserFiles(ctx context.Context, f *models.Virtual
 			go func(pageNum int64, index int) {
 				defer wg.Done()
 
+				countScanAPICall(ctx)
 				subResp, subErr := w.client.GetUpResourceShare(ctx, userId, pageNum, pageSize)
 				if subErr != nil {
 					mu.Lock()
@@ -146,6 +149,7 @@ func (w *busWorker) getSubscribeShareFiles(ctx context.Context, f *models.Virtua
 		files      = make([]*models.VirtualFile, 0)
 	)
 
+	countScanAPICall(ctx)
 	resp, err := w.client.ListShareDir(ctx, shareId, client.String(fileId), func(req *client.ListShareFileRequest) {
 		req.PageNum = pageNum
 		req.PageSize = pageSize
@@ -211,6 +215,7 @@ func (w *busWorker) getSubscribeShareFiles(ctx context.Context, f *models.Virtua
 			go func(pageNum int64, index int) {
 				defer wg.Done()
 
+				countScanAPICall(ctx)
 				subResp, subErr := w.client.ListShareDir(ctx, shareId, client.String(fileId), func(req *client.ListShareFileRequest) {
 					req.PageNum = int(pageNum)
 					req.PageSize = pageSize
@@ -327,6 +332,7 @@ func (w *busWorker) getShareFiles(ctx context.Context, f *models.VirtualFile) ([
 		}
 	)
 
+	countScanAPICall(ctx)
 	resp, err := w.client.ListShareDir(ctx, shareId, client.String(fileId), func(req *client.ListShareFileRequest) {
 		req.PageNum = pageNum
 		req.PageSize = pageSize
@@ -393,6 +399,7 @@ func (w *busWorker) getShareFiles(ctx context.Context, f *models.VirtualFile) ([
 			go func(pageNum int64, index int) {
 				defer wg.Done()
 
+				countScanAPICall(ctx)
 				subResp, subErr := w.client.ListShareDir(ctx, shareId, client.String(fileId), func(req *client.ListShareFileRequest) {
 					req.PageNum = int(pageNum)
 					req.PageSize = pageSize
@@ -479,13 +486,13 @@ func (w *busWorker) getCloudFiles(ctx context.Context, f *models.VirtualFile) ([
 	cloudToken := new(models.CloudToken)
 
 	if err = w.getDB(ctx).Where("id = ?", tokenId).First(cloudToken).Error; err != nil {
-		w.logger.Error("获取云盘信息失败", zap.Int64("id", tokenId))
+		w.log(ctx).Error("获取云盘信息失败", zap.Int64("id", tokenId))
 
 		return nil, err
 	}
 
 	cc := client.NewAuthToken(cloudToken.AccessToken, cloudToken.ExpiresIn)
-	ct := client.New().WithToken(cc)
+	ct := metrics.NewClient().WithToken(cc)
 
 	var (
 		pageNum  = 1
@@ -496,6 +503,7 @@ func (w *busWorker) getCloudFiles(ctx context.Context, f *models.VirtualFile) ([
 		}
 	)
 
+	countScanAPICall(ctx)
 	resp, err := ct.ListFiles(ctx, fileId, func(req *client.ListFilesRequest) {
 		req.PageNum = pageNum
 		req.PageSize = pageSize
@@ -559,6 +567,7 @@ func (w *busWorker) getCloudFiles(ctx context.Context, f *models.VirtualFile) ([
 			go func(pageNum int64, index int) {
 				defer wg.Done()
 
+				countScanAPICall(ctx)
 				subResp, subErr := ct.ListFiles(ctx, fileId, func(req *client.ListFilesRequest) {
 					req.PageNum = int(pageNum)
 					req.PageSize = pageSize
@@ -649,13 +658,13 @@ func (w *busWorker) getCloudFamilyFiles(ctx context.Context, f *models.VirtualFi
 	cloudToken := new(models.CloudToken)
 
 	if err = w.getDB(ctx).Where("id = ?", tokenId).First(cloudToken).Error; err != nil {
-		w.logger.Error("获取云盘信息失败", zap.Int64("id", tokenId))
+		w.log(ctx).Error("获取云盘信息失败", zap.Int64("id", tokenId))
 
 		return nil, err
 	}
 
 	cc := client.NewAuthToken(cloudToken.AccessToken, cloudToken.ExpiresIn)
-	ct := client.New().WithToken(cc)
+	ct := metrics.NewClient().WithToken(cc)
 
 	var (
 		pageNum  = 1
@@ -668,6 +677,7 @@ func (w *busWorker) getCloudFamilyFiles(ctx context.Context, f *models.VirtualFi
 		}
 	)
 
+	countScanAPICall(ctx)
 	resp, err := ct.FamilyListFiles(ctx, familyId, fileId, func(req *client.FamilyListFilesRequest) {
 		req.PageNum = pageNum
 		req.PageSize = pageSize
@@ -731,6 +741,7 @@ func (w *busWorker) getCloudFamilyFiles(ctx context.Context, f *models.VirtualFi
 			go func(pageNum int64, index int) {
 				defer wg.Done()
 
+				countScanAPICall(ctx)
 				subResp, subErr := ct.FamilyListFiles(ctx, familyId, fileId, func(req *client.FamilyListFilesRequest) {
 					req.PageNum = int(pageNum)
 					req.PageSize = pageSize
@@ -18,11 +18,18 @@ const (
 	TopicMediaDeleteLinkFile = "topic::media::delete::link::file"
 	TopicMediaClearEmptyDir  = "topic::media::clear::empty::dir"
 	TopicMediaClearAllMedia  = "topic::media::clear::all::media"
+
+	TopicShareSave = "topic::share::save"
 )
 
 type TopicFileRefreshFileRequest struct {
-	FileId int64 `json:"fileId"`
-	Deep   bool  `json:"deep"`
+	FileId  int64              `json:"fileId"`
+	Deep    bool               `json:"deep"`
+	Trigger models.ScanTrigger `json:"trigger"`
+}
+
+type TopicFileScanTopRequest struct {
+	Trigger models.ScanTrigger `json:"trigger"`
 }
 
 type TopicFileDeleteRequest struct {
@@ -48,3 +55,10 @@ type TopicMediaAddStrmFileRequest struct {
 type TopicMediaClearAllMediaRequest struct {
 	MediaTypes []models.MediaType `json:"mediaTypes"`
 }
+
+type TopicShareSaveRequest struct {
+	FileId         int64  `json:"fileId"`         // 分享中的节点
+	CloudTokenId   int64  `json:"cloudTokenId"`   // 转存使用的令牌
+	FamilyId       string `json:"familyId"`       // 为空时转存到个人云
+	TargetFolderId string `json:"targetFolderId"` // 目标目录的云盘ID
+}
@@ -30,7 +30,7 @@ func (w *busWorker) doSubscribeTopicFileRefreshFile() eventbus.Subscription {
 			deep   = req.Deep
 		)
 
-		return w.scanVirtualFile(ctx, fileId, deep)
+		return w.scanVirtualFile(ctx, fileId, deep, req.Trigger)
 	})
 }
 
@@ -47,7 +47,12 @@ func (w *busWorker) doSubscribeTopicFileDeleteFile() eventbus.Subscription {
 
 func (w *busWorker) doSubscribeTopicScanTop() eventbus.Subscription {
 	return w.bus.Subscribe(TopicFileScanTop, func(ctx context.Context, data interface{}) error {
-		return w.scanTopVirtualFiles(ctx)
+		req, ok := data.(TopicFileScanTopRequest)
+		if !ok {
+			return ErrRequestDataFormat
+		}
+
+		return w.scanTopVirtualFiles(ctx, req.Trigger)
 	})
 }
 
@@ -65,26 +70,27 @@ func (w *busWorker) doSubscribeTopicRebuildMediaFile() eventbus.Subscription {
 
 		_, err := w.clearAllMediaFiles(ctx, mediaReq.MediaTypes...)
 		if err != nil {
-			w.logger.Error("删除旧媒体文件失败", zap.Error(err))
+			w.log(ctx).Error("删除旧媒体文件失败", zap.Error(err))
 		}
 
 		count, err := w.buildMediaFile(ctx, 0)
 		if err != nil {
-			w.logger.Error("重建媒体文件失败", zap.Error(err))
+			w.log(ctx).Error("重建媒体文件失败", zap.Error(err))
 
 			return err
 		}
 
-		w.logger.Info("重建媒体文件完成", zap.Int64("count", count))
+		w.log(ctx).Info("重建媒体文件完成", zap.Int64("count", count))
 
 		return nil
 	})
 }
 
-func PublishVirtualFileRefresh(ctx context.Context, fileId int64, deep bool) error {
+func PublishVirtualFileRefresh(ctx context.Context, fileId int64, deep bool, trigger models.ScanTrigger) error {
 	return singletonBusWork.bus.Publish(ctx, TopicFileRefreshFile, TopicFileRefreshFileRequest{
-		FileId: fileId,
-		Deep:   deep,
+		FileId:  fileId,
+		Deep:    deep,
+		Trigger: trigger,
 	})
 }
 
@@ -94,8 +100,10 @@ func PublishVirtualFileDelete(ctx context.Context, fileId int64) error {
 	})
 }
 
-func PublishVirtualFileScanTop(ctx context.Context) error {
-	return singletonBusWork.bus.Publish(ctx, TopicFileScanTop, nil)
+func PublishVirtualFileScanTop(ctx context.Context, trigger models.ScanTrigger) error {
+	return singletonBusWork.bus.Publish(ctx, TopicFileScanTop, TopicFileScanTopRequest{
+		Trigger: trigger,
+	})
 }
 
 func PublishRebuildMediaFile(ctx context.Context, mediaTypes ...models.MediaType) error {
@@ -42,7 +42,7 @@ func (w *busWorker) doSubscribeTopicMediaClearEmptyDir() eventbus.Subscription {
 			return err
 		}
 
-		w.logger.Debug("清理空文件夹完成", zap.Int64("count", count))
+		w.log(ctx).Debug("清理空文件夹完成", zap.Int64("count", count))
 
 		return nil
 	})
@@ -60,7 +60,7 @@ func (w *busWorker) doSubscribeTopicMediaClearAllMedia() eventbus.Subscription {
 			return err
 		}
 
-		w.logger.Debug("清理所有媒体文件完成", zap.Int64("count", count))
+		w.log(ctx).Debug("清理所有媒体文件完成", zap.Int64("count", count))
 
 		return nil
 	})
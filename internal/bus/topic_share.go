@@ -0,0 +1,23 @@
+package bus
+
+import (
+	"context"
+
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/eventbus"
+)
+
+func (w *busWorker) doSubscribeTopicShareSave() eventbus.Subscription {
+	return w.bus.Subscribe(TopicShareSave, func(ctx context.Context, data interface{}) error {
+		req, ok := data.(TopicShareSaveRequest)
+		if !ok {
+			return ErrRequestDataFormat
+		}
+
+		return w.saveShare(ctx, req)
+	})
+}
+
+// PublishShareSave 将分享中的文件或文件夹转存到令牌对应的个人云/家庭云目录
+func PublishShareSave(ctx context.Context, req TopicShareSaveRequest) error {
+	return singletonBusWork.bus.Publish(ctx, TopicShareSave, req)
+}
@@ -5,6 +5,7 @@ import (
 	"net/url"
 
 	"github.com/google/uuid"
+	"github.com/xxcheng123/cloudpan189-share/internal/baseurl"
 	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/enc"
 	"github.com/xxcheng123/cloudpan189-share/internal/shared"
 )
@@ -17,6 +18,8 @@ func generateDownloadURLWithNeverExpire(fid int64) string {
 		baseURL = shared.Setting.BaseURL
 	}
 
+	baseURL = baseurl.Static(baseURL)
+
 	values := enc.Enc(url.Values{
 		"id":        []string{fmt.Sprintf("%d", fid)},
 		"random":    []string{uuid.NewString()},
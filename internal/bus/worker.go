@@ -9,6 +9,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/xxcheng123/cloudpan189-interface/client"
 	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/eventbus"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/trace"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -28,11 +29,13 @@ type busWorker struct {
 
 	dbLock sync.Mutex
 
-	fileScanStat xsync.Map[int64, *FileScanStat]
+	fileScanStat  xsync.Map[int64, *FileScanStat]
+	shareSaveStat xsync.Map[int64, *ShareSaveStat]
 }
 
 type FileScanStat struct {
 	FileId       int64 `json:"fileId"`
+	RunId        int64 `json:"runId"`
 	WaitCount    int64 `json:"waitCount"`
 	ScannedCount int64 `json:"scannedCount"`
 }
@@ -44,6 +47,12 @@ func (w *busWorker) withLock(ctx context.Context, fn func(db *gorm.DB) *gorm.DB)
 
 	return fn(w.db.WithContext(ctx))
 }
+
+// log 附带当前任务的 request_id，同一次操作触发的日志可一起检索
+func (w *busWorker) log(ctx context.Context) *zap.Logger {
+	return trace.Logger(ctx, w.logger)
+}
+
 func (w *busWorker) getDB(ctx context.Context) *gorm.DB {
 	return w.db.WithContext(ctx)
 }
@@ -58,6 +67,8 @@ func (w *busWorker) doSubscribe() {
 	w.doSubscribeTopicMediaClearAllMedia()
 	w.doSubscribeTopicAddStrmFile()
 	w.doSubscribeTopicDeleteLinkVirtualFile()
+
+	w.doSubscribeTopicShareSave()
 }
 
 func Status() eventbus.BusStats {
@@ -69,19 +80,40 @@ func Status() eventbus.BusStats {
 }
 
 type DetailInfo struct {
-	RunningTasks []eventbus.TaskInfo `json:"runningTasks"`
-	PendingTasks []eventbus.TaskInfo `json:"pendingTasks"`
-	Stats        eventbus.BusStats   `json:"stats"`
+	RunningTasks   []eventbus.TaskInfo `json:"runningTasks"`
+	PendingTasks   []eventbus.TaskInfo `json:"pendingTasks"`
+	Stats          eventbus.BusStats   `json:"stats"`
+	ShareSaveTasks []*ShareSaveStat    `json:"shareSaveTasks"`
 }
 
 func Detail() DetailInfo {
+	shareSaveTasks := make([]*ShareSaveStat, 0)
+	singletonBusWork.shareSaveStat.Range(func(_ int64, v *ShareSaveStat) bool {
+		shareSaveTasks = append(shareSaveTasks, v.snapshot())
+
+		return true
+	})
+
 	return DetailInfo{
-		RunningTasks: singletonBusWork.bus.GetRunningTasks(),
-		PendingTasks: singletonBusWork.bus.GetPendingTasks(),
-		Stats:        singletonBusWork.bus.GetStats(),
+		RunningTasks:   singletonBusWork.bus.GetRunningTasks(),
+		PendingTasks:   singletonBusWork.bus.GetPendingTasks(),
+		Stats:          singletonBusWork.bus.GetStats(),
+		ShareSaveTasks: shareSaveTasks,
 	}
 }
 
+// ScanFileStats 所有正在扫描的统计
+func ScanFileStats() []*FileScanStat {
+	list := make([]*FileScanStat, 0)
+	singletonBusWork.fileScanStat.Range(func(_ int64, v *FileScanStat) bool {
+		list = append(list, v)
+
+		return true
+	})
+
+	return list
+}
+
 func FindScanFileStat(fileId int64) *FileScanStat {
 	v, ok := singletonBusWork.fileScanStat.Load(fileId)
 	if !ok {
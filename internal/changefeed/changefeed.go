@@ -0,0 +1,112 @@
+// Package changefeed 记录扫描产生的文件变更，并推送到配置的 Webhook
+package changefeed
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bytedance/gopkg/util/gopool"
+	"github.com/xxcheng123/cloudpan189-share/configs"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/search"
+	"github.com/xxcheng123/cloudpan189-share/internal/shared"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const cleanupInterval = time.Hour
+
+var onceLoad sync.Once
+
+var singletonFeed *feed
+
+type feed struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+func Init() {
+	onceLoad.Do(func() {
+		singletonFeed = &feed{
+			db:     configs.DB(),
+			logger: configs.Logger().With(zap.String("module", "changefeed")),
+		}
+
+		gopool.Go(singletonFeed.cleanupLoop)
+	})
+}
+
+// Record 记录文件变更并异步触发 Webhook，strm 虚拟文件不记录
+func Record(ctx context.Context, action models.FileEventAction, files ...*models.VirtualFile) {
+	if singletonFeed == nil || len(files) == 0 {
+		return
+	}
+
+	singletonFeed.record(ctx, action, files)
+}
+
+func (f *feed) record(ctx context.Context, action models.FileEventAction, files []*models.VirtualFile) {
+	var (
+		events  = make([]*models.FileEvent, 0, len(files))
+		parents = make(map[int64]*models.VirtualFile)
+	)
+
+	// 同批的文件夹可以作为路径中的父级，删除事件记录时它们已经不在数据库中
+	for _, file := range files {
+		if file.IsFolder == 1 {
+			parents[file.ID] = file
+		}
+	}
+
+	for _, file := range files {
+		if file.OsType == models.OsTypeStrmFile {
+			continue
+		}
+
+		fullPath, topId, err := search.ResolvePath(ctx, f.db, file, parents)
+		if err != nil {
+			f.logger.Warn("计算变更文件路径失败", zap.Int64("fileId", file.ID), zap.Error(err))
+		}
+
+		events = append(events, &models.FileEvent{
+			FileId:   file.ID,
+			TopId:    topId,
+			Action:   action,
+			Name:     file.Name,
+			Path:     fullPath,
+			Size:     file.Size,
+			IsFolder: file.IsFolder,
+			Hash:     file.Hash,
+		})
+	}
+
+	if len(events) == 0 {
+		return
+	}
+
+	if err := f.db.WithContext(ctx).CreateInBatches(events, 500).Error; err != nil {
+		f.logger.Error("记录文件变更失败", zap.String("action", action), zap.Int("count", len(events)), zap.Error(err))
+
+		return
+	}
+
+	gopool.Go(func() {
+		f.dispatch(context.Background(), action, events)
+	})
+}
+
+func (f *feed) cleanupLoop() {
+	for {
+		if days := shared.FileEventRetentionDays; days > 0 {
+			result := f.db.Where("created_at < ?", time.Now().AddDate(0, 0, -days)).Delete(&models.FileEvent{})
+			if result.Error != nil {
+				f.logger.Error("清理文件变更记录失败", zap.Error(result.Error))
+			} else if result.RowsAffected > 0 {
+				f.logger.Info("清理文件变更记录", zap.Int64("count", result.RowsAffected))
+			}
+		}
+
+		time.Sleep(cleanupInterval)
+	}
+}
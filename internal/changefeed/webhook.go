@@ -0,0 +1,191 @@
+package changefeed
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+)
+
+// 请求头
+const (
+	HeaderEvent     = "X-Share-Event"
+	HeaderDelivery  = "X-Share-Delivery"
+	HeaderTimestamp = "X-Share-Timestamp"
+	HeaderSignature = "X-Share-Signature" // sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))
+)
+
+// 失败后的重试间隔，第一次立即发送
+var retryDelays = []time.Duration{0, time.Second * 5, time.Second * 30, time.Minute * 2}
+
+var httpClient = &http.Client{
+	Timeout: time.Second * 10,
+}
+
+// Payload Webhook 请求体，同一次扫描中同类变更合并为一次推送
+type Payload struct {
+	Delivery  string              `json:"delivery"`
+	Action    string              `json:"action"`
+	Timestamp int64               `json:"timestamp"`
+	Events    []*models.FileEvent `json:"events"`
+}
+
+func (f *feed) dispatch(ctx context.Context, action models.FileEventAction, events []*models.FileEvent) {
+	var hooks = make([]*models.Webhook, 0)
+	if err := f.db.WithContext(ctx).Where("enabled = ?", true).Find(&hooks).Error; err != nil {
+		f.logger.Error("查询 Webhook 失败", zap.Error(err))
+
+		return
+	}
+
+	for _, hook := range hooks {
+		matched := lo.Filter(events, func(e *models.FileEvent, _ int) bool {
+			return Match(hook, action, e)
+		})
+
+		if len(matched) == 0 {
+			continue
+		}
+
+		payload := &Payload{
+			Delivery:  uuid.NewString(),
+			Action:    action,
+			Timestamp: time.Now().Unix(),
+			Events:    matched,
+		}
+
+		if err := f.deliverWithRetry(ctx, hook, payload); err != nil {
+			f.logger.Error("Webhook 推送失败",
+				zap.Int64("webhookId", hook.ID),
+				zap.String("delivery", payload.Delivery),
+				zap.Error(err))
+		}
+	}
+}
+
+// Match 判断变更是否满足 Webhook 的过滤条件
+func Match(hook *models.Webhook, action models.FileEventAction, e *models.FileEvent) bool {
+	actions := []string(hook.Actions)
+	if len(actions) == 0 {
+		actions = []string{models.FileEventCreate}
+	}
+
+	if !lo.Contains(actions, action) {
+		return false
+	}
+
+	if e.IsFolder == 1 && !hook.IncludeFolders {
+		return false
+	}
+
+	if len(hook.MountIds) > 0 && !lo.Contains(hook.MountIds, e.TopId) {
+		return false
+	}
+
+	if hook.MinSize > 0 && e.Size < hook.MinSize {
+		return false
+	}
+
+	if len(hook.Exts) > 0 {
+		ext := strings.TrimPrefix(strings.ToLower(path.Ext(e.Name)), ".")
+		if !lo.ContainsBy(hook.Exts, func(item string) bool {
+			return strings.TrimPrefix(strings.ToLower(item), ".") == ext
+		}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f *feed) deliverWithRetry(ctx context.Context, hook *models.Webhook, payload *Payload) error {
+	var err error
+
+	for i, delay := range retryDelays {
+		if delay > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		var retryable bool
+		if retryable, err = Deliver(ctx, hook, payload); err == nil {
+			return nil
+		}
+
+		f.logger.Warn("Webhook 推送失败，稍后重试",
+			zap.Int64("webhookId", hook.ID),
+			zap.String("delivery", payload.Delivery),
+			zap.Int("attempt", i+1),
+			zap.Error(err))
+
+		if !retryable {
+			return err
+		}
+	}
+
+	return err
+}
+
+// Deliver 发送一次 Webhook 请求，返回失败时是否值得重试
+func Deliver(ctx context.Context, hook *models.Webhook, payload *Payload) (bool, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	timestamp := strconv.FormatInt(payload.Timestamp, 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, payload.Action)
+	req.Header.Set(HeaderDelivery, payload.Delivery)
+	req.Header.Set(HeaderTimestamp, timestamp)
+
+	if hook.Secret != "" {
+		req.Header.Set(HeaderSignature, "sha256="+Sign(hook.Secret, timestamp, body))
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	err = errors.Errorf("unexpected status code: %d", resp.StatusCode)
+
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
+
+// Sign 计算签名，接收方用同样的方式校验
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,37 @@
+package changefeed
+
+import "testing"
+
+func TestSign(t *testing.T) {
+	// 期望值由 openssl 独立计算：printf '%s' "<timestamp>.<body>" | openssl dgst -sha256 -hmac "<secret>"
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      []byte
+		want      string
+	}{
+		{
+			name:      "json body",
+			secret:    "s3cret",
+			timestamp: "1760000000",
+			body:      []byte(`{"action":"create"}`),
+			want:      "654fb0b8d21afbb52587070ac1a21ed67bd2c5688ea929d504d9895d79b9af0f",
+		},
+		{
+			name:      "empty body",
+			secret:    "s3cret",
+			timestamp: "1760000000",
+			body:      nil,
+			want:      "66f579a1117f936821e40d39dd644353a5a3fe6a4bc1be1e5dbcf9088af254cf",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, tt.timestamp, tt.body); got != tt.want {
+				t.Errorf("Sign() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
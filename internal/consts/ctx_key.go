@@ -10,4 +10,7 @@ const (
 	CtxKeyGroupFileSet = "x_group_file_set"
 
 	CtxKeyFilename = "x_filename"
+
+	// CtxKeyServeInline 仅允许在线播放、查看，输出文件时强制 inline，不作为附件下载
+	CtxKeyServeInline = "x_serve_inline"
 )
@@ -5,6 +5,10 @@ const (
 	FileAdditionKeyFilePath = "file_path"
 	// FileAdditionKeyCloudToken 文件使用哪个令牌ID下载
 	FileAdditionKeyCloudToken = "cloud_token"
+	// FileAdditionKeyCloudTokenPool 备用令牌ID列表，与 cloud_token 组成令牌池（仅is_top=1时生效）
+	FileAdditionKeyCloudTokenPool = "cloud_token_pool"
+	// FileAdditionKeyCloudTokenStrategy 令牌池选择策略 round_robin / lru
+	FileAdditionKeyCloudTokenStrategy = "cloud_token_strategy"
 	// FileAdditionKeyFileId 云盘文件ID
 	FileAdditionKeyFileId = "file_id"
 	// FileAdditionKeyShareId 分享ID
@@ -25,4 +29,16 @@ const (
 	FileAdditionKeyDisableAutoScan = "disable_auto_scan"
 	// FileAdditionKeyFamilyId 家庭ID
 	FileAdditionKeyFamilyId = "family_id"
+	// FileAdditionKeyHealthState 挂载健康状态（仅is_top=1时生效）
+	FileAdditionKeyHealthState = "health_state"
+	// FileAdditionKeyHealthError 最近一次扫描错误
+	FileAdditionKeyHealthError = "health_error"
+	// FileAdditionKeyHealthErrorAt 最近一次扫描错误时间
+	FileAdditionKeyHealthErrorAt = "health_error_at"
+	// FileAdditionKeyHealthCheckedAt 最近一次更新健康状态的时间
+	FileAdditionKeyHealthCheckedAt = "health_checked_at"
+	// FileAdditionKeyHidden 挂载异常时被隐藏（仅is_top=1时生效）
+	FileAdditionKeyHidden = "hidden"
+	// FileAdditionKeyMirrorGroup 镜像组名称，同组挂载点内相同 Hash+Size 的文件互为备用源（仅is_top=1时生效）
+	FileAdditionKeyMirrorGroup = "mirror_group"
 )
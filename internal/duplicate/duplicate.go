@@ -0,0 +1,176 @@
+// Package duplicate 根据 Hash+Size 识别跨挂载点的重复文件
+//
+// 同一组重复文件中，在调用方可见的副本里保留 ID 最小（最早入库）的一个，其余视为重复。
+package duplicate
+
+import (
+	"context"
+
+	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"github.com/xxcheng123/cloudpan189-share/internal/consts"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/utils"
+	"github.com/xxcheng123/cloudpan189-share/internal/shared"
+	"gorm.io/gorm"
+)
+
+// CloudFileOsTypes 参与重复检测的文件类型
+var CloudFileOsTypes = []string{models.OsTypeFile, models.OsTypeCloudFamilyFile}
+
+type key struct {
+	hash string
+	size int64
+}
+
+// HideEnabled 是否在指定场景中隐藏重复文件
+func HideEnabled(target string) bool {
+	return lo.Contains(shared.DuplicateHideTargets, target)
+}
+
+// Candidate 文件是否可能参与重复检测
+func Candidate(file *models.VirtualFile) bool {
+	return file.IsFolder == 0 && file.Hash != "" && file.Size > 0 && lo.Contains(CloudFileOsTypes, file.OsType)
+}
+
+// FindHidden 返回 files 中应被隐藏的重复文件ID
+//
+// 保留的那一份只在调用方可见的副本中选择：groupFileSet 不为 nil 时，副本所在挂载点须在用户组授权范围内，
+// 因异常被隐藏的挂载点中的副本不参与选择，健康挂载中的副本优先。调用方能看到的唯一副本不会被隐藏。
+func FindHidden(ctx context.Context, db *gorm.DB, files []*models.VirtualFile, groupFileSet mapset.Set[int64]) (map[int64]struct{}, error) {
+	var hidden = make(map[int64]struct{})
+
+	hashes := lo.Uniq(lo.FilterMap(files, func(file *models.VirtualFile, _ int) (string, bool) {
+		return file.Hash, Candidate(file)
+	}))
+	if len(hashes) == 0 {
+		return hidden, nil
+	}
+
+	var copies []*models.VirtualFile
+	if err := db.WithContext(ctx).Model(&models.VirtualFile{}).
+		Select("id, parent_id, hash, size").
+		Where("hash IN ?", hashes).
+		Where("is_folder = 0 AND size > 0").
+		Where("os_type IN ?", CloudFileOsTypes).
+		Order("id ASC").
+		Find(&copies).Error; err != nil {
+		return nil, err
+	}
+
+	var (
+		r = &visibilityResolver{
+			db:           db.WithContext(ctx),
+			groupFileSet: groupFileSet,
+			states:       make(map[int64]visibility),
+		}
+		// 每组重复文件保留的副本及其可见性
+		keep      = make(map[key]int64)
+		keepState = make(map[key]visibility)
+	)
+
+	for _, c := range copies {
+		k := key{c.Hash, c.Size}
+		if keepState[k] == visibleHealthy {
+			continue
+		}
+
+		state, err := r.resolve(c.ParentId)
+		if err != nil {
+			return nil, err
+		}
+
+		if state > keepState[k] {
+			keep[k] = c.ID
+			keepState[k] = state
+		}
+	}
+
+	for _, file := range files {
+		if !Candidate(file) {
+			continue
+		}
+
+		if id, ok := keep[key{file.Hash, file.Size}]; ok && id != file.ID {
+			hidden[file.ID] = struct{}{}
+		}
+	}
+
+	return hidden, nil
+}
+
+// visibility 副本对调用方的可见程度，值越大越优先保留
+type visibility int
+
+const (
+	invisible        visibility = iota // 挂载点被隐藏或不在用户组授权范围内
+	visibleUnhealthy                   // 可见，但挂载点健康状态异常
+	visibleHealthy
+)
+
+// visibilityResolver 沿父目录向上检查所有顶层挂载点，结果按目录缓存
+type visibilityResolver struct {
+	db           *gorm.DB
+	groupFileSet mapset.Set[int64]
+	states       map[int64]visibility
+}
+
+func (r *visibilityResolver) resolve(folderId int64) (visibility, error) {
+	if folderId == 0 {
+		return visibleHealthy, nil
+	}
+
+	if state, ok := r.states[folderId]; ok {
+		return state, nil
+	}
+
+	folder := new(models.VirtualFile)
+	if err := r.db.Select("id, parent_id, is_top, addition").Where("id = ?", folderId).First(folder).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			r.states[folderId] = invisible
+
+			return invisible, nil
+		}
+
+		return invisible, err
+	}
+
+	state := visibleHealthy
+	if folder.IsTop == 1 {
+		hiddenMount, _ := utils.Bool(folder.Addition[consts.FileAdditionKeyHidden])
+		healthState := utils.GetString(folder.Addition, consts.FileAdditionKeyHealthState)
+
+		switch {
+		case hiddenMount, r.groupFileSet != nil && !r.groupFileSet.Contains(folder.ID):
+			state = invisible
+		case healthState != "" && healthState != models.MountHealthOk:
+			state = visibleUnhealthy
+		}
+	}
+
+	if state != invisible {
+		parentState, err := r.resolve(folder.ParentId)
+		if err != nil {
+			return invisible, err
+		}
+
+		state = min(state, parentState)
+	}
+
+	r.states[folderId] = state
+
+	return state, nil
+}
+
+// IsHidden 单个文件是否为应被隐藏的重复文件，不限用户组
+func IsHidden(ctx context.Context, db *gorm.DB, file *models.VirtualFile) (bool, error) {
+	hidden, err := FindHidden(ctx, db, []*models.VirtualFile{file}, nil)
+	if err != nil {
+		return false, err
+	}
+
+	_, ok := hidden[file.ID]
+
+	return ok, nil
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/tickstep/cloudpan189-api/cloudpan"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/utils"
+	"github.com/xxcheng123/cloudpan189-share/internal/stream"
+	"github.com/xxcheng123/cloudpan189-share/internal/tokenpool"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -21,6 +23,7 @@ type AutoLoginJob struct {
 	logger *zap.Logger
 	ctx    context.Context
 	cancel context.CancelFunc
+	done   chan struct{}
 }
 
 func NewAutoLoginJob(db *gorm.DB, logger *zap.Logger) Job {
@@ -38,8 +41,11 @@ func (s *AutoLoginJob) Start(ctx context.Context) error {
 	defer s.mu.Unlock()
 
 	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
 
 	gopool.Go(func() {
+		defer close(s.done)
+
 		for {
 			select {
 			case <-s.ctx.Done():
@@ -74,6 +80,10 @@ func (s *AutoLoginJob) Start(ctx context.Context) error {
 
 			// 执行刷新
 			for _, token := range tokens {
+				if s.ctx.Err() != nil {
+					break
+				}
+
 				loginResult, loginErr := cloudpan.AppLogin(token.Username, token.Password)
 
 				updateMap := make(map[string]interface{})
@@ -99,7 +109,18 @@ func (s *AutoLoginJob) Start(ctx context.Context) error {
 
 				if err := s.db.WithContext(ctx).Model(&models.CloudToken{}).Where("id = ?", token.ID).Updates(updateMap).Error; err != nil {
 					s.logger.Error("update cloud token error", zap.Error(err))
+				} else if loginErr == nil {
+					tokenpool.Reset(token.ID)
 				}
+
+				stream.Publish(stream.TopicToken, "login", &stream.TokenLogin{
+					TokenId:   token.ID,
+					Name:      token.Name,
+					LoginType: token.LoginType,
+					Auto:      true,
+					Success:   loginErr == nil,
+					Message:   utils.String(token.Addition[models.CloudTokenAdditionAutoLoginResultKey]),
+				})
 			}
 		}
 	})
@@ -107,8 +128,10 @@ func (s *AutoLoginJob) Start(ctx context.Context) error {
 	return nil
 }
 
+// Stop 停止任务并等待正在进行的一轮结束
 func (s *AutoLoginJob) Stop() {
 	if s.cancel != nil {
 		s.cancel()
+		<-s.done
 	}
 }
@@ -0,0 +1,114 @@
+package jobs
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/bytedance/gopkg/util/gopool"
+	"github.com/xxcheng123/cloudpan189-share/configs"
+	"github.com/xxcheng123/cloudpan189-share/internal/backup"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// DBBackupJob 按 backupDir/backupIntervalHours 定时备份数据库并清理旧备份
+type DBBackupJob struct {
+	db     *gorm.DB
+	mu     sync.Mutex
+	logger *zap.Logger
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewDBBackupJob(db *gorm.DB, logger *zap.Logger) Job {
+	return &DBBackupJob{
+		db:     db,
+		logger: logger.With(zap.String("job", "db_backup")),
+	}
+}
+
+func (s *DBBackupJob) Start(ctx context.Context) error {
+	if !s.mu.TryLock() {
+		return ErrJobRunning
+	}
+
+	defer s.mu.Unlock()
+
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+
+	gopool.Go(func() {
+		defer close(s.done)
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				s.logger.Info("db backup job stopped")
+
+				return
+			case <-time.After(time.Minute):
+			}
+
+			config := configs.GetConfig()
+			if config.BackupDir == "" || config.BackupIntervalHours <= 0 {
+				continue
+			}
+
+			// 以最新一个备份的时间判断是否到期，重启后不会立即重复备份
+			list, err := backup.ListSnapshots(config.BackupDir)
+			if err != nil {
+				s.logger.Error("list snapshots error", zap.Error(err))
+
+				continue
+			}
+
+			interval := time.Duration(config.BackupIntervalHours) * time.Hour
+			if len(list) > 0 && time.Since(list[0].ModTime) < interval {
+				continue
+			}
+
+			s.doJob(s.ctx, config)
+		}
+	})
+
+	return nil
+}
+
+func (s *DBBackupJob) doJob(ctx context.Context, config *configs.Config) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("数据库备份发生异常",
+				zap.Any("panic", r),
+				zap.String("stack", string(debug.Stack())))
+		}
+	}()
+
+	snapshot, err := backup.TakeSnapshot(ctx, s.db, config.BackupDir)
+	if err != nil {
+		s.logger.Error("数据库备份失败", zap.Error(err))
+
+		return
+	}
+
+	s.logger.Info("数据库备份完成", zap.String("name", snapshot.Name), zap.Int64("size", snapshot.Size))
+
+	removed, err := backup.RotateSnapshots(config.BackupDir, config.BackupKeep)
+	if err != nil {
+		s.logger.Error("清理旧备份失败", zap.Error(err))
+	}
+
+	if len(removed) > 0 {
+		s.logger.Info("已清理旧备份", zap.Strings("names", removed))
+	}
+}
+
+// Stop 停止任务并等待正在进行的备份结束
+func (s *DBBackupJob) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+		<-s.done
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/xxcheng123/cloudpan189-share/internal/bus"
+	"github.com/xxcheng123/cloudpan189-share/internal/metrics"
 
 	"github.com/bytedance/gopkg/util/gopool"
 	"github.com/xxcheng123/cloudpan189-interface/client"
@@ -26,13 +27,14 @@ type ScanFileJob struct {
 	logger  *zap.Logger
 	ctx     context.Context
 	cancel  context.CancelFunc
+	done    chan struct{}
 }
 
 func NewScanFileJob(db *gorm.DB, logger *zap.Logger) Job {
 	return &ScanFileJob{
 		db:     db,
 		logger: logger.With(zap.String("job", "scan_file")),
-		client: client.New(),
+		client: metrics.NewClient(),
 	}
 }
 
@@ -46,8 +48,11 @@ func (s *ScanFileJob) Start(ctx context.Context) error {
 	defer s.mu.Unlock()
 
 	s.running = true
+	s.done = make(chan struct{})
 
 	gopool.Go(func() {
+		defer close(s.done)
+
 		for {
 			if !s.doJob(ctx) {
 				break
@@ -93,7 +98,7 @@ func (s *ScanFileJob) doJob(ctx context.Context) bool {
 		}
 
 		s.logger.Info("开始定时扫描文件")
-		if err := bus.PublishVirtualFileScanTop(ctx); err != nil {
+		if err := bus.PublishVirtualFileScanTop(ctx, models.ScanTriggerSchedule); err != nil {
 			s.logger.Error("定时扫描文件失败", zap.Error(err))
 		}
 	}
@@ -101,6 +106,7 @@ func (s *ScanFileJob) doJob(ctx context.Context) bool {
 	return true
 }
 
+// Stop 停止任务并等待调度循环退出
 func (s *ScanFileJob) Stop() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -108,5 +114,6 @@ func (s *ScanFileJob) Stop() {
 	if s.running {
 		s.cancel()
 		s.running = false
+		<-s.done
 	}
 }
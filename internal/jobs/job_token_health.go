@@ -0,0 +1,200 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/bytedance/gopkg/util/gopool"
+	"github.com/xxcheng123/cloudpan189-interface/client"
+	"github.com/xxcheng123/cloudpan189-share/internal/alert"
+	"github.com/xxcheng123/cloudpan189-share/internal/metrics"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/notify"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/utils"
+	"github.com/xxcheng123/cloudpan189-share/internal/shared"
+	"github.com/xxcheng123/cloudpan189-share/internal/tokenpool"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// TokenHealthJob 定时校验所有云盘令牌，更新状态并在失效或即将过期时发送告警
+type TokenHealthJob struct {
+	db        *gorm.DB
+	mu        sync.Mutex
+	logger    *zap.Logger
+	ctx       context.Context
+	cancel    context.CancelFunc
+	done      chan struct{}
+	lastCheck time.Time
+}
+
+func NewTokenHealthJob(db *gorm.DB, logger *zap.Logger) Job {
+	return &TokenHealthJob{
+		db:     db,
+		logger: logger.With(zap.String("job", "token_health")),
+	}
+}
+
+func (s *TokenHealthJob) Start(ctx context.Context) error {
+	if !s.mu.TryLock() {
+		return ErrJobRunning
+	}
+
+	defer s.mu.Unlock()
+
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+
+	gopool.Go(func() {
+		defer close(s.done)
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				s.logger.Info("token health job stopped")
+
+				return
+			case <-time.After(time.Minute):
+			}
+
+			// 间隔可在设置中动态调整，0 表示关闭
+			minutes := shared.TokenHealthCheckMinutes
+			if minutes <= 0 || time.Since(s.lastCheck) < time.Duration(minutes)*time.Minute {
+				continue
+			}
+
+			s.lastCheck = time.Now()
+			s.doJob(s.ctx)
+		}
+	})
+
+	return nil
+}
+
+func (s *TokenHealthJob) doJob(ctx context.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("令牌健康检查发生异常",
+				zap.Any("panic", r),
+				zap.String("stack", string(debug.Stack())))
+		}
+	}()
+
+	var tokens = make([]*models.CloudToken, 0)
+	if err := s.db.WithContext(ctx).Find(&tokens).Error; err != nil {
+		s.logger.Error("query cloud token error", zap.Error(err))
+
+		return
+	}
+
+	s.logger.Info("token health job started", zap.Int("count", len(tokens)))
+
+	for _, token := range tokens {
+		if ctx.Err() != nil {
+			return
+		}
+
+		_ = s.checkToken(ctx, token)
+	}
+}
+
+// CheckCloudToken 立即校验一个令牌，更新状态并按需告警，返回校验错误；供命令行使用
+func CheckCloudToken(ctx context.Context, db *gorm.DB, logger *zap.Logger, token *models.CloudToken) error {
+	job := &TokenHealthJob{
+		db:     db,
+		logger: logger.With(zap.String("job", "token_health")),
+	}
+
+	return job.checkToken(ctx, token)
+}
+
+func (s *TokenHealthJob) checkToken(ctx context.Context, token *models.CloudToken) error {
+	var (
+		now       = time.Now()
+		authToken = client.NewAuthToken(token.AccessToken, token.ExpiresIn)
+		status    = models.CloudTokenStatusNormal
+		result    string
+	)
+
+	_, err := metrics.NewClient().WithToken(authToken).GetUserInfo(ctx)
+	tokenpool.Report(token.ID, err)
+
+	if err != nil {
+		// 超时、网络异常或限流不能说明令牌失效，保持原状态等下一轮再校验
+		if tokenpool.Classify(err) != tokenpool.ErrorKindExpired {
+			s.logger.Warn("cloud token check skipped", zap.Int64("tokenId", token.ID), zap.Error(err))
+
+			return err
+		}
+
+		status = models.CloudTokenStatusLoginFailed
+		result = fmt.Sprintf("%s，令牌校验失败。%s", now.Format(time.DateTime), err.Error())
+
+		s.logger.Warn("cloud token check failed", zap.Int64("tokenId", token.ID), zap.Error(err))
+	} else {
+		result = fmt.Sprintf("%s，令牌校验成功。", now.Format(time.DateTime))
+	}
+
+	// 只在状态由正常变为失败时告警，避免每轮重复发送
+	if status != models.CloudTokenStatusNormal && token.Status == models.CloudTokenStatusNormal {
+		alert.Send(ctx, notify.LevelError, "云盘令牌失效",
+			fmt.Sprintf("令牌「%s」(ID:%d) 校验失败：%s", token.Name, token.ID, err.Error()))
+	} else if status == models.CloudTokenStatusNormal && token.Status != models.CloudTokenStatusNormal {
+		alert.Send(ctx, notify.LevelInfo, "云盘令牌恢复",
+			fmt.Sprintf("令牌「%s」(ID:%d) 已恢复正常", token.Name, token.ID))
+	}
+
+	// 即将过期提醒，每个令牌每天最多一次
+	var (
+		warnDays = shared.TokenExpireWarnDays
+		today    = now.Format(time.DateOnly)
+		alertDay string
+	)
+	if status == models.CloudTokenStatusNormal && warnDays > 0 &&
+		authToken.ExpireDuration() < time.Duration(warnDays)*24*time.Hour &&
+		utils.GetString(token.Addition, models.CloudTokenAdditionExpireAlertDay) != today {
+		content := fmt.Sprintf("令牌「%s」(ID:%d) 将于 %s 过期", token.Name, token.ID, authToken.ExpireTime())
+		if token.LoginType == models.LoginTypePassword {
+			content += "，系统会尝试自动重新登录"
+		} else {
+			content += "，请重新扫码登录"
+		}
+
+		alert.Send(ctx, notify.LevelWarn, "云盘令牌即将过期", content)
+
+		alertDay = today
+	}
+
+	// 只更新健康检查相关的键；状态以检查开始时的状态和令牌为条件，避免覆盖检查期间自动登录或令牌池写入的新状态
+	var (
+		expr = "json_set(COALESCE(addition, '{}'), '$." + models.CloudTokenAdditionHealthCheckResult + "', ?, '$." + models.CloudTokenAdditionHealthCheckAt + "', ?"
+		args = []any{result, now.Unix()}
+	)
+
+	if alertDay != "" {
+		expr += ", '$." + models.CloudTokenAdditionExpireAlertDay + "', ?"
+		args = append(args, alertDay)
+	}
+
+	expr += ")"
+
+	if updateErr := s.db.WithContext(ctx).Model(&models.CloudToken{}).Where("id = ?", token.ID).Updates(map[string]interface{}{
+		"status":   gorm.Expr("CASE WHEN status = ? AND access_token = ? THEN ? ELSE status END", token.Status, token.AccessToken, status),
+		"addition": gorm.Expr(expr, args...),
+	}).Error; updateErr != nil {
+		s.logger.Error("update cloud token error", zap.Error(updateErr))
+	}
+
+	return err
+}
+
+// Stop 停止任务并等待正在进行的检查结束
+func (s *TokenHealthJob) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+		<-s.done
+	}
+}
@@ -0,0 +1,205 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/xxcheng123/cloudpan189-interface/client"
+	"github.com/xxcheng123/cloudpan189-share/configs"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/trace"
+	"go.uber.org/zap"
+	"resty.dev/v3"
+)
+
+// NewClient 创建记录接口耗时和错误码的天翼云盘客户端，用法与 client.New() 相同
+func NewClient() client.Client {
+	return &instrumentedClient{inner: client.New()}
+}
+
+type instrumentedClient struct {
+	inner client.Client
+}
+
+// startCloudAPI 开始一次接口调用，返回的 done 记录耗时和错误码，并以当前请求的 request_id 输出日志
+func startCloudAPI(ctx context.Context, api string) (context.Context, func(err error)) {
+	start := time.Now()
+	ctx, span := trace.Start(ctx, "cloud "+api, trace.SpanKindClient)
+
+	return ctx, func(err error) {
+		code := cloudErrorCode(err)
+
+		cloudAPIDuration.observe(time.Since(start).Seconds(), api)
+		cloudAPIRequests.inc(api, code)
+
+		span.SetAttr("cloud.api", api)
+		span.SetAttr("cloud.code", code)
+		span.End(err)
+
+		logger := trace.Logger(ctx, configs.Logger()).With(
+			zap.String("module", "cloud_api"),
+			zap.String("api", api),
+			zap.String("code", code),
+			zap.Duration("latency", time.Since(start)),
+		)
+
+		if err != nil {
+			logger.Warn("cloud api failed", zap.Error(err))
+		} else {
+			logger.Debug("cloud api")
+		}
+	}
+}
+
+// cloudErrorCode 取接口返回的错误码，非接口错误时为 error
+func cloudErrorCode(err error) string {
+	if err == nil {
+		return "ok"
+	}
+
+	var respErr *client.RespErr
+	if !errors.As(err, &respErr) {
+		return "error"
+	}
+
+	for _, code := range []string{respErr.Code, respErr.ErrorCode, respErr.Error_} {
+		if code != "" {
+			return code
+		}
+	}
+
+	if v, ok := respErr.ResCode.(string); ok && v != "" {
+		return v
+	}
+
+	return "unknown"
+}
+
+func (c *instrumentedClient) WithDebug(flags ...bool) client.Client {
+	c.inner = c.inner.WithDebug(flags...)
+
+	return c
+}
+
+func (c *instrumentedClient) WithToken(token client.AuthToken) client.Client {
+	c.inner = c.inner.WithToken(token)
+
+	return c
+}
+
+func (c *instrumentedClient) WithForceWithToken(flags ...bool) client.Client {
+	c.inner = c.inner.WithForceWithToken(flags...)
+
+	return c
+}
+
+func (c *instrumentedClient) WithClient(httpClient *resty.Client) client.Client {
+	c.inner = c.inner.WithClient(httpClient)
+
+	return c
+}
+
+func (c *instrumentedClient) GetShareInfo(ctx context.Context, shareCode string, opts ...client.GetShareInfoOption) (resp *client.GetShareInfoResponse, err error) {
+	ctx, done := startCloudAPI(ctx, "GetShareInfo")
+	defer func() { done(err) }()
+
+	return c.inner.GetShareInfo(ctx, shareCode, opts...)
+}
+
+func (c *instrumentedClient) GetFileDownload(ctx context.Context, fileId client.String, opts ...client.GetFileDownloadOption) (resp *client.GetFileDownloadResponse, err error) {
+	ctx, done := startCloudAPI(ctx, "GetFileDownload")
+	defer func() { done(err) }()
+
+	return c.inner.GetFileDownload(ctx, fileId, opts...)
+}
+
+func (c *instrumentedClient) GetFileInfo(ctx context.Context, fileId client.String, opts ...client.GetFileFileOption) (resp *client.GetFileInfoResponse, err error) {
+	ctx, done := startCloudAPI(ctx, "GetFileInfo")
+	defer func() { done(err) }()
+
+	return c.inner.GetFileInfo(ctx, fileId, opts...)
+}
+
+func (c *instrumentedClient) GetFolderInfo(ctx context.Context, folder client.String, opts ...client.GetFolderFileOption) (resp *client.GetFolderInfoResponse, err error) {
+	ctx, done := startCloudAPI(ctx, "GetFolderInfo")
+	defer func() { done(err) }()
+
+	return c.inner.GetFolderInfo(ctx, folder, opts...)
+}
+
+func (c *instrumentedClient) GetNewVLCVideoPlayURL(ctx context.Context, fileId client.String, opts ...client.WithGetNewVLCVideoPlayURLRequestOption) (resp *client.GetNewVLCVideoPlayURLResponse, err error) {
+	ctx, done := startCloudAPI(ctx, "GetNewVLCVideoPlayURL")
+	defer func() { done(err) }()
+
+	return c.inner.GetNewVLCVideoPlayURL(ctx, fileId, opts...)
+}
+
+func (c *instrumentedClient) GetUpResourceShare(ctx context.Context, upUserId string, pageNum int64, pageSize int64, opts ...client.GetUpResourceShareRequestOption) (resp *client.GetUpResourceShareResponse, err error) {
+	ctx, done := startCloudAPI(ctx, "GetUpResourceShare")
+	defer func() { done(err) }()
+
+	return c.inner.GetUpResourceShare(ctx, upUserId, pageNum, pageSize, opts...)
+}
+
+func (c *instrumentedClient) GetUserInfo(ctx context.Context) (resp *client.GetUserInfoResponse, err error) {
+	ctx, done := startCloudAPI(ctx, "GetUserInfo")
+	defer func() { done(err) }()
+
+	return c.inner.GetUserInfo(ctx)
+}
+
+func (c *instrumentedClient) GetUserPrivileges(ctx context.Context) (resp *client.GetUserPrivilegesResponse, err error) {
+	ctx, done := startCloudAPI(ctx, "GetUserPrivileges")
+	defer func() { done(err) }()
+
+	return c.inner.GetUserPrivileges(ctx)
+}
+
+func (c *instrumentedClient) ListResourceShareDir(ctx context.Context, upUserId string, shareId int64, fileId client.String, opts ...client.WithListResourceShareDirRequestOption) (resp *client.ListFilesResponse, err error) {
+	ctx, done := startCloudAPI(ctx, "ListResourceShareDir")
+	defer func() { done(err) }()
+
+	return c.inner.ListResourceShareDir(ctx, upUserId, shareId, fileId, opts...)
+}
+
+func (c *instrumentedClient) ListShareDir(ctx context.Context, shareId int64, fileId client.String, opts ...client.WithListShareFileRequestOption) (resp *client.ListFilesResponse, err error) {
+	ctx, done := startCloudAPI(ctx, "ListShareDir")
+	defer func() { done(err) }()
+
+	return c.inner.ListShareDir(ctx, shareId, fileId, opts...)
+}
+
+func (c *instrumentedClient) ListFiles(ctx context.Context, folderId client.String, opts ...client.WithListFilesRequestOption) (resp *client.ListFilesResponse, err error) {
+	ctx, done := startCloudAPI(ctx, "ListFiles")
+	defer func() { done(err) }()
+
+	return c.inner.ListFiles(ctx, folderId, opts...)
+}
+
+func (c *instrumentedClient) GetFamilyList(ctx context.Context) (resp *client.GetFamilyListResponse, err error) {
+	ctx, done := startCloudAPI(ctx, "GetFamilyList")
+	defer func() { done(err) }()
+
+	return c.inner.GetFamilyList(ctx)
+}
+
+func (c *instrumentedClient) FamilyListFiles(ctx context.Context, familyId client.String, folderId client.String, opts ...client.WithFamilyListFilesRequestOption) (resp *client.ListFilesResponse, err error) {
+	ctx, done := startCloudAPI(ctx, "FamilyListFiles")
+	defer func() { done(err) }()
+
+	return c.inner.FamilyListFiles(ctx, familyId, folderId, opts...)
+}
+
+func (c *instrumentedClient) FamilyGetFileDownload(ctx context.Context, familyId client.String, fileId client.String, opts ...client.FamilyGetFileDownloadOption) (resp *client.FamilyGetFileDownloadResponse, err error) {
+	ctx, done := startCloudAPI(ctx, "FamilyGetFileDownload")
+	defer func() { done(err) }()
+
+	return c.inner.FamilyGetFileDownload(ctx, familyId, fileId, opts...)
+}
+
+func (c *instrumentedClient) SubscribeGetUser(ctx context.Context, userId string) (resp *client.SubscribeGetUserResponse, err error) {
+	ctx, done := startCloudAPI(ctx, "SubscribeGetUser")
+	defer func() { done(err) }()
+
+	return c.inner.SubscribeGetUser(ctx, userId)
+}
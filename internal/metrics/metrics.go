@@ -0,0 +1,185 @@
+// Package metrics 以 Prometheus 文本格式暴露运行指标
+package metrics
+
+import (
+	"crypto/subtle"
+	errors2 "errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	"github.com/xxcheng123/cloudpan189-share/configs"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 扫描耗时分桶，秒
+var scanBuckets = []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600}
+
+// WebDAV 客户端在该时间内有请求即视为活跃会话
+const davSessionTTL = time.Minute * 5
+
+var (
+	busTasks = newCounterVec("share_bus_tasks_total", "已结束的总线任务数", "topic", "status")
+
+	scanDuration = newHistogramVec("share_scan_duration_seconds", "挂载点扫描耗时", scanBuckets, "mount_id")
+	scanRuns     = newCounterVec("share_scan_runs_total", "挂载点扫描次数", "mount_id", "status")
+	scanErrors   = newCounterVec("share_scan_errors_total", "挂载点扫描中出错的目录数", "mount_id")
+
+	downloads     = newCounterVec("share_downloads_total", "下载请求数", "transfer_type")
+	downloadBytes = newCounterVec("share_download_bytes_total", "经本服务传输的下载字节数，重定向不计", "transfer_type")
+	urlCache      = newCounterVec("share_download_url_cache_total", "下载链接缓存（file::url::）命中情况", "result")
+
+	cloudAPIDuration = newHistogramVec("share_cloud_api_duration_seconds", "天翼云盘接口耗时", defaultBuckets, "api")
+	cloudAPIRequests = newCounterVec("share_cloud_api_requests_total", "天翼云盘接口请求数，code 为 ok 或错误码", "api", "code")
+
+	dbDuration = newHistogramVec("share_db_query_duration_seconds", "数据库操作耗时", defaultBuckets, "operation")
+	dbErrors   = newCounterVec("share_db_errors_total", "数据库操作错误数，不含记录不存在", "operation")
+
+	davActiveRequests atomic.Int64
+	davSessions       = cache.New(davSessionTTL, time.Minute)
+)
+
+func init() {
+	newGaugeFunc("share_webdav_active_requests", "正在处理的 WebDAV 请求数", "", func() map[string]float64 {
+		return map[string]float64{"": float64(davActiveRequests.Load())}
+	})
+
+	newGaugeFunc("share_webdav_active_sessions", "最近 5 分钟内有请求的 WebDAV 客户端数（用户+IP）", "", func() map[string]float64 {
+		return map[string]float64{"": float64(davSessions.ItemCount())}
+	})
+}
+
+var onceLoad sync.Once
+
+// Init 注册数据库耗时统计
+func Init() {
+	onceLoad.Do(func() {
+		if err := registerGormCallbacks(configs.DB()); err != nil {
+			configs.Logger().Error("注册数据库指标失败", zap.String("module", "metrics"), zap.Error(err))
+		}
+	})
+}
+
+// RegisterGauge 注册抓取时计算的指标，供其他模块暴露内部状态；label 为空时 fn 只返回 "" 一个键
+func RegisterGauge(name, help, label string, fn func() map[string]float64) {
+	newGaugeFunc(name, help, label, fn)
+}
+
+// RecordBusTask 记录总线任务结束
+func RecordBusTask(topic, status string) {
+	busTasks.inc(topic, status)
+}
+
+// RecordScan 记录一次扫描结束
+func RecordScan(mountId int64, status string, duration time.Duration, errorCount int64) {
+	id := strconv.FormatInt(mountId, 10)
+
+	scanDuration.observe(duration.Seconds(), id)
+	scanRuns.inc(id, status)
+
+	if errorCount > 0 {
+		scanErrors.add(float64(errorCount), id)
+	}
+}
+
+// RecordDownload 记录一次下载，transferType 为 redirect/local_proxy/multi_stream/local_file
+func RecordDownload(transferType string, bytes int64) {
+	downloads.inc(transferType)
+
+	if bytes > 0 {
+		downloadBytes.add(float64(bytes), transferType)
+	}
+}
+
+// RecordURLCache 记录下载链接缓存是否命中
+func RecordURLCache(hit bool) {
+	if hit {
+		urlCache.inc("hit")
+	} else {
+		urlCache.inc("miss")
+	}
+}
+
+// DavMiddleware 统计 WebDAV 活跃请求与会话，需放在认证之后
+func DavMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		davActiveRequests.Add(1)
+		defer davActiveRequests.Add(-1)
+
+		davSessions.SetDefault(ctx.GetString("username")+"|"+ctx.ClientIP(), struct{}{})
+
+		ctx.Next()
+	}
+}
+
+// Handler 输出全部指标，配置了 metricsToken 时需通过 Authorization: Bearer 或 token 参数携带
+func Handler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if token := configs.GetConfig().MetricsToken; token != "" {
+			got := ctx.Query("token")
+			if auth := ctx.GetHeader("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+				got = strings.TrimPrefix(auth, "Bearer ")
+			}
+
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				ctx.String(http.StatusUnauthorized, "unauthorized")
+
+				return
+			}
+		}
+
+		ctx.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		ctx.Status(http.StatusOK)
+
+		defaultRegistry.write(ctx.Writer)
+	}
+}
+
+const gormStartKey = "metrics:start"
+
+func registerGormCallbacks(db *gorm.DB) error {
+	before := func(tx *gorm.DB) {
+		tx.InstanceSet(gormStartKey, time.Now())
+	}
+
+	after := func(operation string) func(tx *gorm.DB) {
+		return func(tx *gorm.DB) {
+			v, ok := tx.InstanceGet(gormStartKey)
+			if !ok {
+				return
+			}
+
+			if start, ok := v.(time.Time); ok {
+				dbDuration.observe(time.Since(start).Seconds(), operation)
+			}
+
+			if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+				dbErrors.inc(operation)
+			}
+		}
+	}
+
+	cb := db.Callback()
+
+	return errors2.Join(
+		cb.Create().Before("gorm:create").Register("metrics:before_create", before),
+		cb.Create().After("gorm:create").Register("metrics:after_create", after("create")),
+		cb.Query().Before("gorm:query").Register("metrics:before_query", before),
+		cb.Query().After("gorm:query").Register("metrics:after_query", after("query")),
+		cb.Update().Before("gorm:update").Register("metrics:before_update", before),
+		cb.Update().After("gorm:update").Register("metrics:after_update", after("update")),
+		cb.Delete().Before("gorm:delete").Register("metrics:before_delete", before),
+		cb.Delete().After("gorm:delete").Register("metrics:after_delete", after("delete")),
+		cb.Row().Before("gorm:row").Register("metrics:before_row", before),
+		cb.Row().After("gorm:row").Register("metrics:after_row", after("row")),
+		cb.Raw().Before("gorm:raw").Register("metrics:before_raw", before),
+		cb.Raw().After("gorm:raw").Register("metrics:after_raw", after("raw")),
+	)
+}
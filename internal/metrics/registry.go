@@ -0,0 +1,262 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 不引入 prometheus 客户端，按文本格式 0.0.4 手写输出，只实现用到的 counter/gauge/histogram
+
+// defaultBuckets 秒
+var defaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+type collector interface {
+	write(w io.Writer)
+}
+
+type registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+var defaultRegistry = &registry{}
+
+func (r *registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.collectors = append(r.collectors, c)
+}
+
+func (r *registry) write(w io.Writer) {
+	r.mu.Lock()
+	list := append([]collector(nil), r.collectors...)
+	r.mu.Unlock()
+
+	for _, c := range list {
+		c.write(w)
+	}
+}
+
+type desc struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (d *desc) header(w io.Writer, typ string) {
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, d.help, d.name, typ)
+}
+
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s 需要 %d 个标签，实际 %d 个", d.name, len(d.labels), len(values)))
+	}
+
+	return strings.Join(values, "\xff")
+}
+
+func (d *desc) labelPairs(values []string, extra ...string) string {
+	pairs := make([]string, 0, len(values)+1)
+	for i, v := range values {
+		pairs = append(pairs, d.labels[i]+`="`+escapeLabel(v)+`"`)
+	}
+
+	pairs = append(pairs, extra...)
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(v)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// counterVec 只增不减的计数
+type counterVec struct {
+	desc
+	mu     sync.Mutex
+	values map[string]float64
+	labels map[string][]string
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	c := &counterVec{
+		desc:   desc{name: name, help: help, labels: labels},
+		values: make(map[string]float64),
+		labels: make(map[string][]string),
+	}
+
+	defaultRegistry.register(c)
+
+	return c
+}
+
+func (c *counterVec) add(v float64, labelValues ...string) {
+	k := c.key(labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.labels[k]; !ok {
+		c.labels[k] = append([]string(nil), labelValues...)
+	}
+
+	c.values[k] += v
+}
+
+func (c *counterVec) inc(labelValues ...string) {
+	c.add(1, labelValues...)
+}
+
+func (c *counterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.header(w, "counter")
+
+	for _, k := range sortedKeys(c.values) {
+		_, _ = fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelPairs(c.labels[k]), formatFloat(c.values[k]))
+	}
+}
+
+// gaugeFunc 抓取时计算的瞬时值，fn 返回 标签值 -> 数值
+type gaugeFunc struct {
+	desc
+	fn func() map[string]float64
+}
+
+// newGaugeFunc label 为空时 fn 返回的 map 只应有一个 "" 键
+func newGaugeFunc(name, help, label string, fn func() map[string]float64) *gaugeFunc {
+	g := &gaugeFunc{
+		desc: desc{name: name, help: help},
+		fn:   fn,
+	}
+
+	if label != "" {
+		g.desc.labels = []string{label}
+	}
+
+	defaultRegistry.register(g)
+
+	return g
+}
+
+func (g *gaugeFunc) write(w io.Writer) {
+	values := g.fn()
+
+	g.header(w, "gauge")
+
+	for _, k := range sortedKeys(values) {
+		var lv []string
+		if len(g.desc.labels) > 0 {
+			lv = []string{k}
+		}
+
+		_, _ = fmt.Fprintf(w, "%s%s %s\n", g.name, g.labelPairs(lv), formatFloat(values[k]))
+	}
+}
+
+type histogram struct {
+	labels []string
+	counts []uint64 // 与 buckets 对应，非累计
+	sum    float64
+	count  uint64
+}
+
+// histogramVec 分布统计
+type histogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogram
+}
+
+func newHistogramVec(name, help string, buckets []float64, labels ...string) *histogramVec {
+	h := &histogramVec{
+		desc:    desc{name: name, help: help, labels: labels},
+		buckets: buckets,
+		values:  make(map[string]*histogram),
+	}
+
+	defaultRegistry.register(h)
+
+	return h
+}
+
+func (h *histogramVec) observe(v float64, labelValues ...string) {
+	k := h.key(labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	item, ok := h.values[k]
+	if !ok {
+		item = &histogram{
+			labels: append([]string(nil), labelValues...),
+			counts: make([]uint64, len(h.buckets)),
+		}
+		h.values[k] = item
+	}
+
+	for i, upper := range h.buckets {
+		if v <= upper {
+			item.counts[i]++
+
+			break
+		}
+	}
+
+	item.sum += v
+	item.count++
+}
+
+func (h *histogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.header(w, "histogram")
+
+	for _, k := range sortedKeys(h.values) {
+		item := h.values[k]
+
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += item.counts[i]
+			_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(item.labels, `le="`+formatFloat(upper)+`"`), cumulative)
+		}
+
+		_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelPairs(item.labels, `le="+Inf"`), item.count)
+		_, _ = fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelPairs(item.labels), formatFloat(item.sum))
+		_, _ = fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelPairs(item.labels), item.count)
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// DownloadSource 按 文件/实际来源/天 汇总的下载来源记录，来源与请求文件不同时说明走了镜像回退
+type DownloadSource struct {
+	ID           int64     `gorm:"primaryKey" json:"id"`
+	FileId       int64     `gorm:"column:file_id;type:bigint;not null;default:0;uniqueIndex:idx_file_source_day" json:"fileId"`
+	SourceFileId int64     `gorm:"column:source_file_id;type:bigint;not null;default:0;uniqueIndex:idx_file_source_day" json:"sourceFileId"`
+	Day          string    `gorm:"column:day;type:varchar(10);not null;uniqueIndex:idx_file_source_day;index:idx_source_day" json:"day"` // 2006-01-02
+	Requests     int64     `gorm:"column:requests;type:bigint;not null;default:0" json:"requests"`
+	CreatedAt    time.Time `gorm:"column:created_at;autoCreateTime;type:datetime;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt    time.Time `gorm:"column:updated_at;autoUpdateTime;type:datetime;default:CURRENT_TIMESTAMP;on update:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (d *DownloadSource) TableName() string {
+	return "download_sources"
+}
@@ -22,15 +22,42 @@ const (
 	OsTypeCloudFamilyFile   = "cloud_family_file"
 )
 
+// TokenPoolOsTypes 可以配置备用令牌池的挂载类型；分享类挂载的文件任何令牌都能访问，个人云和家庭云只有所有者的令牌可以
+var TokenPoolOsTypes = []OsType{OsTypeSubscribe, OsTypeSubscribeShare, OsTypeShare}
+
+// MountHealthState 挂载点健康状态
+type MountHealthState = string
+
+const (
+	MountHealthOk             MountHealthState = "ok"
+	MountHealthAuthFailed     MountHealthState = "auth_failed"     // 令牌失效或访问码错误
+	MountHealthShareCancelled MountHealthState = "share_cancelled" // 分享已取消或不存在
+	MountHealthAuditPending   MountHealthState = "audit_pending"   // 分享审核中或审核未通过
+	MountHealthRateLimited    MountHealthState = "rate_limited"    // 被限流
+	MountHealthError          MountHealthState = "error"           // 其他错误
+)
+
+// 挂载点异常时的处理动作
+const (
+	MountUnhealthyActionHide  = "hide"
+	MountUnhealthyActionAlert = "alert"
+)
+
+// 隐藏重复文件的场景
+const (
+	DuplicateHideTargetWebDAV = "webdav" // WebDAV 目录列表
+	DuplicateHideTargetStrm   = "strm"   // strm 文件生成
+)
+
 type VirtualFile struct {
 	ID         int64             `gorm:"primaryKey" json:"id"`
 	ParentId   int64             `gorm:"column:parent_id;type:bigint(20);not null;default:0;uniqueIndex:parent_name_unique" json:"parentId"`
 	LinkId     int64             `gorm:"column:link_id;type:bigint(20);default:0;index:link_id_index" json:"linkId"` // 关联id，用于strm文件，当文件被删除后，实现关联的 strm 文件快速删除
 	Name       string            `gorm:"column:name;type:varchar(1024);not null;uniqueIndex:parent_name_unique" json:"name"`
 	IsTop      int8              `gorm:"column:is_top;type:tinyint(1);default:0" json:"isTop"` // 是否最顶层文件夹
-	Size       int64             `gorm:"column:size;type:bigint(20);default:0" json:"size"`
+	Size       int64             `gorm:"column:size;type:bigint(20);default:0;index:hash_size_index" json:"size"`
 	IsFolder   int8              `gorm:"column:is_folder;type:tinyint(1);default:0" json:"isFolder"`
-	Hash       string            `gorm:"column:hash;type:varchar(64);default:''" json:"hash"`
+	Hash       string            `gorm:"column:hash;type:varchar(64);default:'';index:hash_size_index" json:"hash"`
 	CreateDate string            `gorm:"column:create_date;type:varchar(20);default:CURRENT_TIMESTAMP" json:"createDate"`
 	ModifyDate string            `gorm:"column:modify_date;type:varchar(20);default:CURRENT_TIMESTAMP" json:"modifyDate"`
 	OsType     OsType            `gorm:"column:os_type;type:varchar(20);default:'folder'" json:"osType"` // 读取文件的方式
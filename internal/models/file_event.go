@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// FileEventAction 文件变更类型
+type FileEventAction = string
+
+const (
+	FileEventCreate FileEventAction = "create"
+	FileEventUpdate FileEventAction = "update"
+	FileEventDelete FileEventAction = "delete"
+)
+
+// FileEvent 扫描过程中产生的文件变更记录
+type FileEvent struct {
+	ID        int64           `gorm:"primaryKey" json:"id"`
+	FileId    int64           `gorm:"column:file_id;type:bigint;not null;index:idx_file_id" json:"fileId"`
+	TopId     int64           `gorm:"column:top_id;type:bigint;not null;default:0;index:idx_top_action" json:"topId"` // 所属挂载点
+	Action    FileEventAction `gorm:"column:action;type:varchar(20);not null;index:idx_top_action" json:"action"`
+	Name      string          `gorm:"column:name;type:varchar(1024);not null" json:"name"`
+	Path      string          `gorm:"column:path;type:varchar(4096);not null;default:''" json:"path"`
+	Size      int64           `gorm:"column:size;type:bigint;not null;default:0" json:"size"`
+	IsFolder  int8            `gorm:"column:is_folder;type:tinyint(1);default:0" json:"isFolder"`
+	Hash      string          `gorm:"column:hash;type:varchar(64);default:''" json:"hash"`
+	CreatedAt time.Time       `gorm:"column:created_at;autoCreateTime;type:datetime;default:CURRENT_TIMESTAMP;index:idx_created_at" json:"createdAt"`
+}
+
+func (e *FileEvent) TableName() string {
+	return "file_events"
+}
+
+// Webhook 文件变更回调，请求体使用 HMAC-SHA256 签名
+type Webhook struct {
+	ID             int64                       `gorm:"primaryKey" json:"id"`
+	Name           string                      `gorm:"column:name;type:varchar(255);not null" json:"name"`
+	URL            string                      `gorm:"column:url;type:varchar(1024);not null" json:"url"`
+	Secret         string                      `gorm:"column:secret;type:varchar(255);not null;default:''" json:"secret"`
+	Enabled        bool                        `gorm:"column:enabled;type:tinyint(1);default:1" json:"enabled"`
+	Actions        datatypes.JSONSlice[string] `gorm:"column:actions;type:json" json:"actions"`    // 为空时只推送 create
+	MountIds       datatypes.JSONSlice[int64]  `gorm:"column:mount_ids;type:json" json:"mountIds"` // 为空时不限挂载点
+	Exts           datatypes.JSONSlice[string] `gorm:"column:exts;type:json" json:"exts"`          // 为空时不限扩展名
+	MinSize        int64                       `gorm:"column:min_size;type:bigint;not null;default:0" json:"minSize"`
+	IncludeFolders bool                        `gorm:"column:include_folders;type:tinyint(1);default:0" json:"includeFolders"`
+	CreatedAt      time.Time                   `gorm:"column:created_at;autoCreateTime;type:datetime;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt      time.Time                   `gorm:"column:updated_at;autoUpdateTime;type:datetime;default:CURRENT_TIMESTAMP;on update:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (w *Webhook) TableName() string {
+	return "webhooks"
+}
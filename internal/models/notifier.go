@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Notifier 告警通知渠道
+type Notifier struct {
+	ID        int64             `gorm:"primaryKey" json:"id"`
+	Name      string            `gorm:"column:name;type:varchar(255);not null" json:"name"`
+	Type      string            `gorm:"column:type;type:varchar(20);not null" json:"type"` // webhook / smtp / telegram
+	Enabled   bool              `gorm:"column:enabled;type:tinyint(1);default:1" json:"enabled"`
+	Config    datatypes.JSONMap `gorm:"column:config;type:json;default:'{}'" json:"config"` // 渠道参数，不同类型字段不同
+	CreatedAt time.Time         `gorm:"column:created_at;autoCreateTime;type:datetime;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt time.Time         `gorm:"column:updated_at;autoUpdateTime;type:datetime;default:CURRENT_TIMESTAMP;on update:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (n *Notifier) TableName() string {
+	return "notifiers"
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// ScanTrigger 扫描触发方式
+type ScanTrigger = string
+
+const (
+	ScanTriggerManual   ScanTrigger = "manual"   // 管理员手动刷新
+	ScanTriggerSchedule ScanTrigger = "schedule" // 定时任务
+	ScanTriggerAPI      ScanTrigger = "api"      // 其他接口顺带触发，如新增挂载、转存
+	ScanTriggerCLI      ScanTrigger = "cli"      // 命令行
+)
+
+// ScanRunStatus 扫描运行状态
+type ScanRunStatus = string
+
+const (
+	ScanRunRunning     ScanRunStatus = "running"
+	ScanRunSuccess     ScanRunStatus = "success"
+	ScanRunPartial     ScanRunStatus = "partial"     // 完成但部分目录出错
+	ScanRunFailed      ScanRunStatus = "failed"      // 扫描中断
+	ScanRunInterrupted ScanRunStatus = "interrupted" // 服务重启时仍在运行
+)
+
+// ScanRunError 扫描中单个目录的错误
+type ScanRunError struct {
+	FolderId   int64  `json:"folderId"`
+	FolderName string `json:"folderName"`
+	Stage      string `json:"stage"` // list/create/update/delete
+	Message    string `json:"message"`
+}
+
+// ScanRun 一次挂载点扫描的运行记录
+type ScanRun struct {
+	ID         int64                             `gorm:"primaryKey" json:"id"`
+	FileId     int64                             `gorm:"column:file_id;type:bigint;not null;index:idx_file_status" json:"fileId"` // 扫描的根目录，通常是挂载点
+	Name       string                            `gorm:"column:name;type:varchar(1024);not null;default:''" json:"name"`
+	Trigger    ScanTrigger                       `gorm:"column:trigger_type;type:varchar(20);not null;index:idx_trigger_type" json:"trigger"`
+	Deep       bool                              `gorm:"column:deep;type:tinyint(1);default:0" json:"deep"`
+	Status     ScanRunStatus                     `gorm:"column:status;type:varchar(20);not null;index:idx_file_status" json:"status"`
+	Folders    int64                             `gorm:"column:folders;type:bigint;not null;default:0" json:"folders"` // 列出的目录数
+	Created    int64                             `gorm:"column:created;type:bigint;not null;default:0" json:"created"`
+	Updated    int64                             `gorm:"column:updated;type:bigint;not null;default:0" json:"updated"`
+	Deleted    int64                             `gorm:"column:deleted;type:bigint;not null;default:0" json:"deleted"`
+	ApiCalls   int64                             `gorm:"column:api_calls;type:bigint;not null;default:0" json:"apiCalls"`
+	ErrorCount int64                             `gorm:"column:error_count;type:bigint;not null;default:0" json:"errorCount"`
+	Errors     datatypes.JSONSlice[ScanRunError] `gorm:"column:errors;type:json" json:"errors"` // 只保留前若干条
+	StartedAt  time.Time                         `gorm:"column:started_at;type:datetime;not null;index:idx_started_at" json:"startedAt"`
+	FinishedAt *time.Time                        `gorm:"column:finished_at;type:datetime" json:"finishedAt"`
+	Duration   int64                             `gorm:"column:duration;type:bigint;not null;default:0" json:"duration"` // 毫秒
+}
+
+func (r *ScanRun) TableName() string {
+	return "scan_runs"
+}
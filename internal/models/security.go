@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// LoginFailureSource 认证入口
+type LoginFailureSource = string
+
+const (
+	LoginFailureSourceLogin LoginFailureSource = "login" // 网页登录
+	LoginFailureSourceDav   LoginFailureSource = "dav"   // WebDAV Basic 认证
+	LoginFailureSourceShare LoginFailureSource = "share" // 分享链接提取码，username 为 share:分享码
+)
+
+// LoginFailure 一次失败的登录或 WebDAV 认证，不记录密码
+type LoginFailure struct {
+	ID        int64              `gorm:"primaryKey" json:"id"`
+	Username  string             `gorm:"column:username;type:varchar(255);not null;default:'';index:idx_login_failure_username" json:"username"`
+	IP        string             `gorm:"column:ip;type:varchar(64);not null;default:'';index:idx_login_failure_ip" json:"ip"`
+	Source    LoginFailureSource `gorm:"column:source;type:varchar(16);not null;default:''" json:"source"`
+	Reason    string             `gorm:"column:reason;type:varchar(32);not null;default:''" json:"reason"` // user_not_found、bad_password、bad_code
+	UserAgent string             `gorm:"column:user_agent;type:varchar(512);not null;default:''" json:"userAgent"`
+	CreatedAt time.Time          `gorm:"column:created_at;autoCreateTime;type:datetime;default:CURRENT_TIMESTAMP;index:idx_login_failure_created_at" json:"createdAt"`
+}
+
+func (l *LoginFailure) TableName() string {
+	return "login_failures"
+}
+
+type IPRuleAction = string
+
+const (
+	IPRuleAllow IPRuleAction = "allow"
+	IPRuleDeny  IPRuleAction = "deny"
+)
+
+type IPRuleScope = string
+
+const (
+	IPRuleScopeAll IPRuleScope = "all"
+	IPRuleScopeAPI IPRuleScope = "api" // /api 下的接口
+	IPRuleScopeDav IPRuleScope = "dav" // /dav
+)
+
+// IPRule IP 访问规则：命中 deny 的请求直接拒绝；某一范围配置了 allow 规则后，只有命中的 IP 可以访问
+type IPRule struct {
+	ID        int64        `gorm:"primaryKey" json:"id"`
+	CIDR      string       `gorm:"column:cidr;type:varchar(64);not null" json:"cidr"` // 单个 IP 或网段
+	Action    IPRuleAction `gorm:"column:action;type:varchar(16);not null" json:"action"`
+	Scope     IPRuleScope  `gorm:"column:scope;type:varchar(16);not null;default:'all'" json:"scope"`
+	Remark    string       `gorm:"column:remark;type:varchar(255);not null;default:''" json:"remark"`
+	CreatedAt time.Time    `gorm:"column:created_at;autoCreateTime;type:datetime;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt time.Time    `gorm:"column:updated_at;autoUpdateTime;type:datetime;default:CURRENT_TIMESTAMP;on update:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (r *IPRule) TableName() string {
+	return "ip_rules"
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// SessionRevokeReason 会话失效原因
+type SessionRevokeReason = string
+
+const (
+	SessionRevokeLogout     SessionRevokeReason = "logout"             // 用户退出登录
+	SessionRevokeManual     SessionRevokeReason = "revoked"            // 在会话列表中注销
+	SessionRevokeReuse      SessionRevokeReason = "reuse_detected"     // 已轮换的刷新 Token 被再次使用，可能已泄露
+	SessionRevokeCredential SessionRevokeReason = "credential_changed" // 修改密码、解除两步验证等使全部会话失效的操作
+)
+
+// Session 一次网页登录，刷新 Token 每次使用后轮换，TokenID 为当前有效的刷新 Token ID
+type Session struct {
+	ID           int64               `gorm:"primaryKey" json:"id"`
+	UserID       int64               `gorm:"column:user_id;not null;index:idx_session_user_id" json:"userId"`
+	TokenID      string              `gorm:"column:token_id;type:varchar(64);not null;uniqueIndex:uk_session_token_id" json:"-"`
+	Device       string              `gorm:"column:device;type:varchar(128);not null;default:''" json:"device"` // 由 User-Agent 识别的浏览器和系统
+	UserAgent    string              `gorm:"column:user_agent;type:varchar(512);not null;default:''" json:"userAgent"`
+	IP           string              `gorm:"column:ip;type:varchar(64);not null;default:''" json:"ip"` // 最近一次使用的 IP
+	LastSeenAt   time.Time           `gorm:"column:last_seen_at;type:datetime" json:"lastSeenAt"`
+	ExpiresAt    time.Time           `gorm:"column:expires_at;type:datetime;index:idx_session_expires_at" json:"expiresAt"`
+	RevokedAt    *time.Time          `gorm:"column:revoked_at;type:datetime" json:"revokedAt"`
+	RevokeReason SessionRevokeReason `gorm:"column:revoke_reason;type:varchar(32);not null;default:''" json:"revokeReason"`
+	CreatedAt    time.Time           `gorm:"column:created_at;autoCreateTime;type:datetime;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt    time.Time           `gorm:"column:updated_at;autoUpdateTime;type:datetime;default:CURRENT_TIMESTAMP;on update:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (s *Session) TableName() string {
+	return "sessions"
+}
@@ -1,32 +1,151 @@
 package models
 
+// 设置项的存储位置
+const (
+	SettingSourceDict = "dict"    // setting_dicts 表，一项一行
+	SettingSourceRow  = "setting" // setting 表的列
+)
+
+// SettingGroup 设置分组，用于前端按组展示
+type SettingGroup struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+}
+
+// SettingGroups 设置分组，按展示顺序排列
+var SettingGroups = []SettingGroup{
+	{Key: "general", Label: "基础设置"},
+	{Key: "scan", Label: "扫描刷新"},
+	{Key: "download", Label: "下载加速"},
+	{Key: "strm", Label: "STRM"},
+	{Key: "token", Label: "令牌检查"},
+	{Key: "mount", Label: "挂载与文件"},
+	{Key: "security", Label: "安全"},
+}
+
 // SettingItem 定义设置项的配置
 type SettingItem struct {
-	Key          string      // 设置项的键名
-	Type         string      // 数据类型：int, int64, bool, string, json
-	DefaultValue interface{} // 默认值
-	MethodSuffix string      // 方法名后缀，如 MultipleStreamThreadCount
+	Key             string      // 设置项的键名，setting 表的项为列名
+	Type            string      // 数据类型：int, int64, bool, string, json
+	DefaultValue    interface{} // 默认值
+	MethodSuffix    string      // 方法名后缀，如 MultipleStreamThreadCount；setting 表的项为字段名
+	Source          string      // 存储位置，为空时为 SettingSourceDict
+	Group           string      // 所属分组，对应 SettingGroups
+	Label           string      // 名称
+	Description     string      // 说明
+	Validate        string      // 校验规则，gin binding 语法，不含 omitempty
+	Options         []string    // 可选值，json 类型表示每个元素的可选值
+	RestartRequired bool        // 修改后需重启才生效
 }
 
 // SettingItems 所有设置项的配置
 var SettingItems = []SettingItem{
+	{
+		Key:          "title",
+		Type:         "string",
+		DefaultValue: "",
+		MethodSuffix: "Title",
+		Source:       SettingSourceRow,
+		Group:        "general",
+		Label:        "网站名称",
+		Validate:     "min=1,max=32",
+	},
+	{
+		Key:          "base_url",
+		Type:         "string",
+		DefaultValue: "",
+		MethodSuffix: "BaseURL",
+		Source:       SettingSourceRow,
+		Group:        "general",
+		Label:        "基础URL",
+		Description:  "对外访问地址，部署在子路径时需包含子路径，用于生成下载链接；留空时按请求地址推算",
+		Validate:     "eq=|url,max=255",
+	},
+	{
+		Key:          "enable_auth",
+		Type:         "bool",
+		DefaultValue: true,
+		MethodSuffix: "EnableAuth",
+		Source:       SettingSourceRow,
+		Group:        "general",
+		Label:        "启用鉴权",
+		Description:  "关闭后游客可直接访问文件",
+	},
+	{
+		Key:          "enable_top_file_auto_refresh",
+		Type:         "bool",
+		DefaultValue: true,
+		MethodSuffix: "EnableTopFileAutoRefresh",
+		Source:       SettingSourceRow,
+		Group:        "scan",
+		Label:        "挂载文件自动刷新",
+	},
+	{
+		Key:          "auto_refresh_minutes",
+		Type:         "int",
+		DefaultValue: 10,
+		MethodSuffix: "AutoRefreshMinutes",
+		Source:       SettingSourceRow,
+		Group:        "scan",
+		Label:        "自动刷新间隔（分钟）",
+		Validate:     "min=5,max=120",
+	},
+	{
+		Key:          "job_thread_count",
+		Type:         "int",
+		DefaultValue: 1,
+		MethodSuffix: "JobThreadCount",
+		Source:       SettingSourceRow,
+		Group:        "scan",
+		Label:        "任务线程数",
+		Validate:     "min=1,max=8",
+	},
+	{
+		Key:          "local_proxy",
+		Type:         "bool",
+		DefaultValue: false,
+		MethodSuffix: "LocalProxy",
+		Source:       SettingSourceRow,
+		Group:        "download",
+		Label:        "本地代理",
+		Description:  "下载经本服务中转，而不是重定向到云盘地址",
+	},
+	{
+		Key:          "multiple_stream",
+		Type:         "bool",
+		DefaultValue: false,
+		MethodSuffix: "MultipleStream",
+		Source:       SettingSourceRow,
+		Group:        "download",
+		Label:        "多线程流加速",
+		Description:  "仅在本地代理时生效",
+	},
 	{
 		Key:          "multiple_stream_thread_count",
 		Type:         "int",
 		DefaultValue: 6,
 		MethodSuffix: "MultipleStreamThreadCount",
+		Group:        "download",
+		Label:        "多线程流线程数",
+		Validate:     "min=1,max=64",
 	},
 	{
 		Key:          "multiple_stream_chunk_size",
 		Type:         "int64",
 		DefaultValue: int64(1024 * 1024 * 4),
 		MethodSuffix: "MultipleStreamChunkSize",
+		Group:        "download",
+		Label:        "多线程流块大小（字节）",
+		Description:  "512KB - 32MB",
+		Validate:     "min=524288,max=33554432",
 	},
 	{
 		Key:          "strm_file_enable",
 		Type:         "bool",
 		DefaultValue: false,
 		MethodSuffix: "StrmFileEnable",
+		Group:        "strm",
+		Label:        "生成 STRM 文件",
 	},
 	{
 		Key:  "strm_support_file_ext_list",
@@ -39,17 +158,91 @@ var SettingItems = []SettingItem{
 			"qt", "amv", "mpv", "m1v", "svi", "viv", "fli", "flc",
 		},
 		MethodSuffix: "StrmSupportFileExtList",
+		Group:        "strm",
+		Label:        "STRM 支持的文件扩展名",
+		Validate:     "dive,required",
 	},
 	{
 		Key:          "link_file_auto_delete",
 		Type:         "bool",
 		DefaultValue: true,
 		MethodSuffix: "LinkFileAutoDelete",
+		Group:        "strm",
+		Label:        "关联文件自动删除",
 	},
 	{
 		Key:          "strm_base_url",
 		Type:         "string",
 		DefaultValue: "",
 		MethodSuffix: "StrmBaseURL",
+		Group:        "strm",
+		Label:        "STRM 基础URL",
+		Description:  "为空时使用基础URL",
+	},
+	{
+		Key:          "token_health_check_minutes",
+		Type:         "int",
+		DefaultValue: 30,
+		MethodSuffix: "TokenHealthCheckMinutes",
+		Group:        "token",
+		Label:        "令牌健康检查间隔（分钟）",
+		Description:  "0 表示关闭",
+		Validate:     "min=0,max=1440",
+	},
+	{
+		Key:          "token_expire_warn_days",
+		Type:         "int",
+		DefaultValue: 3,
+		MethodSuffix: "TokenExpireWarnDays",
+		Group:        "token",
+		Label:        "令牌过期提醒（天）",
+		Description:  "0 表示不提醒",
+		Validate:     "min=0,max=30",
+	},
+	{
+		Key:          "mount_unhealthy_actions",
+		Type:         "json",
+		DefaultValue: []string{"alert"},
+		MethodSuffix: "MountUnhealthyActions",
+		Group:        "mount",
+		Label:        "挂载失效处理",
+		Options:      []string{MountUnhealthyActionHide, MountUnhealthyActionAlert},
+	},
+	{
+		Key:          "mirror_auto_detect",
+		Type:         "bool",
+		DefaultValue: false,
+		MethodSuffix: "MirrorAutoDetect",
+		Group:        "mount",
+		Label:        "自动识别镜像文件",
+		Description:  "按文件哈希在所有挂载点中查找镜像，关闭时只在同一镜像组内查找",
+	},
+	{
+		Key:          "duplicate_hide_targets",
+		Type:         "json",
+		DefaultValue: []string{},
+		MethodSuffix: "DuplicateHideTargets",
+		Group:        "mount",
+		Label:        "重复文件隐藏场景",
+		Options:      []string{DuplicateHideTargetWebDAV, DuplicateHideTargetStrm},
+	},
+	{
+		Key:          "file_event_retention_days",
+		Type:         "int",
+		DefaultValue: 30,
+		MethodSuffix: "FileEventRetentionDays",
+		Group:        "mount",
+		Label:        "文件变更记录保留天数",
+		Description:  "0 表示永久保留",
+		Validate:     "min=0,max=3650",
+	},
+	{
+		Key:          "admin_require_two_factor",
+		Type:         "bool",
+		DefaultValue: false,
+		MethodSuffix: "AdminRequireTwoFactor",
+		Group:        "security",
+		Label:        "管理员必须启用两步验证",
+		Description:  "开启后未启用两步验证的管理员登录时需先完成绑定",
 	},
 }
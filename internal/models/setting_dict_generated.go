@@ -17,6 +17,13 @@ const (
 	SettingDictKeyStrmSupportFileExtList    = "strm_support_file_ext_list"
 	SettingDictKeyLinkFileAutoDelete        = "link_file_auto_delete"
 	SettingDictKeyStrmBaseURL               = "strm_base_url"
+	SettingDictKeyTokenHealthCheckMinutes   = "token_health_check_minutes"
+	SettingDictKeyTokenExpireWarnDays       = "token_expire_warn_days"
+	SettingDictKeyMountUnhealthyActions     = "mount_unhealthy_actions"
+	SettingDictKeyMirrorAutoDetect          = "mirror_auto_detect"
+	SettingDictKeyDuplicateHideTargets      = "duplicate_hide_targets"
+	SettingDictKeyFileEventRetentionDays    = "file_event_retention_days"
+	SettingDictKeyAdminRequireTwoFactor     = "admin_require_two_factor"
 )
 
 // 默认值定义
@@ -26,11 +33,22 @@ const (
 	DefaultStrmFileEnable            = false
 	DefaultLinkFileAutoDelete        = true
 	DefaultStrmBaseURL               = ""
+	DefaultTokenHealthCheckMinutes   = 30
+	DefaultTokenExpireWarnDays       = 3
+	DefaultMirrorAutoDetect          = false
+	DefaultFileEventRetentionDays    = 30
+	DefaultAdminRequireTwoFactor     = false
 )
 
 var (
 	DefaultStrmSupportFileExtList = []string{"mp4", "mkv", "avi", "mov", "wmv", "flv", "webm", "m4v", "mpg", "mpeg", "m2v", "m4p", "m4b", "ts", "mts", "m2ts", "m2t", "mxf", "dv", "dvr-ms", "asf", "3gp", "3g2", "f4v", "f4p", "f4a", "f4b", "vob", "ogv", "ogg", "divx", "xvid", "rm", "rmvb", "dat", "nsv", "qt", "amv", "mpv", "m1v", "svi", "viv", "fli", "flc"}
 )
+var (
+	DefaultMountUnhealthyActions = []string{"alert"}
+)
+var (
+	DefaultDuplicateHideTargets = []string{}
+)
 
 // 生成的 Get/Set 方法
 
@@ -137,3 +155,133 @@ func (s *SettingDict) GetStrmBaseURL(db *gorm.DB) string {
 func (s *SettingDict) SetStrmBaseURL(db *gorm.DB, value string) *gorm.DB {
 	return s.store(db, SettingDictKeyStrmBaseURL, value, "string")
 }
+
+func (s *SettingDict) GetTokenHealthCheckMinutes(db *gorm.DB) int {
+	value, err := s.query(db, SettingDictKeyTokenHealthCheckMinutes)
+	if err != nil {
+		return DefaultTokenHealthCheckMinutes
+	}
+	var v int64
+
+	if v, err = strconv.ParseInt(value, 10, 64); err != nil {
+		return DefaultTokenHealthCheckMinutes
+	}
+
+	return int(v)
+}
+
+func (s *SettingDict) SetTokenHealthCheckMinutes(db *gorm.DB, value int) *gorm.DB {
+	return s.store(db, SettingDictKeyTokenHealthCheckMinutes, strconv.FormatInt(int64(value), 10), "int")
+}
+
+func (s *SettingDict) GetTokenExpireWarnDays(db *gorm.DB) int {
+	value, err := s.query(db, SettingDictKeyTokenExpireWarnDays)
+	if err != nil {
+		return DefaultTokenExpireWarnDays
+	}
+	var v int64
+
+	if v, err = strconv.ParseInt(value, 10, 64); err != nil {
+		return DefaultTokenExpireWarnDays
+	}
+
+	return int(v)
+}
+
+func (s *SettingDict) SetTokenExpireWarnDays(db *gorm.DB, value int) *gorm.DB {
+	return s.store(db, SettingDictKeyTokenExpireWarnDays, strconv.FormatInt(int64(value), 10), "int")
+}
+
+func (s *SettingDict) GetMountUnhealthyActions(db *gorm.DB) []string {
+	value, err := s.query(db, SettingDictKeyMountUnhealthyActions)
+	if err != nil {
+		return DefaultMountUnhealthyActions
+	}
+	var v []string
+
+	if err = json.Unmarshal([]byte(value), &v); err != nil {
+		return DefaultMountUnhealthyActions
+	}
+
+	return v
+}
+
+func (s *SettingDict) SetMountUnhealthyActions(db *gorm.DB, value []string) *gorm.DB {
+	b, _ := json.Marshal(value)
+
+	return s.store(db, SettingDictKeyMountUnhealthyActions, string(b), "json")
+}
+
+func (s *SettingDict) GetMirrorAutoDetect(db *gorm.DB) bool {
+	value, err := s.query(db, SettingDictKeyMirrorAutoDetect)
+	if err != nil {
+		return DefaultMirrorAutoDetect
+	}
+	var v bool
+
+	if v, err = strconv.ParseBool(value); err != nil {
+		return DefaultMirrorAutoDetect
+	}
+
+	return v
+}
+
+func (s *SettingDict) SetMirrorAutoDetect(db *gorm.DB, value bool) *gorm.DB {
+	return s.store(db, SettingDictKeyMirrorAutoDetect, strconv.FormatBool(value), "bool")
+}
+
+func (s *SettingDict) GetDuplicateHideTargets(db *gorm.DB) []string {
+	value, err := s.query(db, SettingDictKeyDuplicateHideTargets)
+	if err != nil {
+		return DefaultDuplicateHideTargets
+	}
+	var v []string
+
+	if err = json.Unmarshal([]byte(value), &v); err != nil {
+		return DefaultDuplicateHideTargets
+	}
+
+	return v
+}
+
+func (s *SettingDict) SetDuplicateHideTargets(db *gorm.DB, value []string) *gorm.DB {
+	b, _ := json.Marshal(value)
+
+	return s.store(db, SettingDictKeyDuplicateHideTargets, string(b), "json")
+}
+
+func (s *SettingDict) GetFileEventRetentionDays(db *gorm.DB) int {
+	value, err := s.query(db, SettingDictKeyFileEventRetentionDays)
+	if err != nil {
+		return DefaultFileEventRetentionDays
+	}
+	var v int64
+
+	if v, err = strconv.ParseInt(value, 10, 64); err != nil {
+		return DefaultFileEventRetentionDays
+	}
+
+	return int(v)
+}
+
+func (s *SettingDict) SetFileEventRetentionDays(db *gorm.DB, value int) *gorm.DB {
+	return s.store(db, SettingDictKeyFileEventRetentionDays, strconv.FormatInt(int64(value), 10), "int")
+}
+
+func (s *SettingDict) GetAdminRequireTwoFactor(db *gorm.DB) bool {
+	value, err := s.query(db, SettingDictKeyAdminRequireTwoFactor)
+	if err != nil {
+		return DefaultAdminRequireTwoFactor
+	}
+	var v bool
+
+	if v, err = strconv.ParseBool(value); err != nil {
+		return DefaultAdminRequireTwoFactor
+	}
+
+	return v
+}
+
+func (s *SettingDict) SetAdminRequireTwoFactor(db *gorm.DB, value bool) *gorm.DB {
+	return s.store(db, SettingDictKeyAdminRequireTwoFactor, strconv.FormatBool(value), "bool")
+}
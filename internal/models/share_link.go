@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// ShareLink 用户为虚拟目录中的文件或文件夹创建的公开分享链接，访问者无需登录
+type ShareLink struct {
+	ID            int64      `gorm:"primaryKey" json:"id"`
+	Code          string     `gorm:"column:code;type:varchar(32);not null;uniqueIndex:uk_share_link_code" json:"code"` // 链接中的分享码
+	UserID        int64      `gorm:"column:user_id;not null;index:idx_share_link_user_id" json:"userId"`               // 创建者，下载流量计入该用户
+	FileID        int64      `gorm:"column:file_id;not null;index:idx_share_link_file_id" json:"fileId"`
+	Name          string     `gorm:"column:name;type:varchar(1024);not null;default:''" json:"name"`  // 创建时的文件名
+	Password      string     `gorm:"column:password;type:varchar(64);not null;default:''" json:"-"`   // 提取码的哈希，为空表示无需提取码
+	ExpiresAt     *time.Time `gorm:"column:expires_at;type:datetime" json:"expiresAt"`                // 为空表示永不过期
+	MaxDownloads  int64      `gorm:"column:max_downloads;not null;default:0" json:"maxDownloads"`     // 0 表示不限
+	AllowPreview  bool       `gorm:"column:allow_preview;not null;default:false" json:"allowPreview"` // 允许在线播放、查看，不计下载次数
+	AllowDownload bool       `gorm:"column:allow_download;not null;default:false" json:"allowDownload"`
+	ViewCount     int64      `gorm:"column:view_count;not null;default:0" json:"viewCount"`         // 打开分享页的次数
+	PreviewCount  int64      `gorm:"column:preview_count;not null;default:0" json:"previewCount"`   // 在线播放、查看的次数
+	DownloadCount int64      `gorm:"column:download_count;not null;default:0" json:"downloadCount"` // 下载次数，断点续传的后续分段不重复计算
+	LastAccessAt  *time.Time `gorm:"column:last_access_at;type:datetime" json:"lastAccessAt"`
+	CreatedAt     time.Time  `gorm:"column:created_at;autoCreateTime;type:datetime;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt     time.Time  `gorm:"column:updated_at;autoUpdateTime;type:datetime;default:CURRENT_TIMESTAMP;on update:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (s *ShareLink) TableName() string {
+	return "share_links"
+}
@@ -27,6 +27,14 @@ func (c *CloudToken) TableName() string {
 const (
 	CloudTokenAdditionAutoLoginResultKey = "auto_login_result"
 	CloudTokenAdditionAutoLoginTimes     = "auto_login_times"
+	CloudTokenAdditionHealthCheckResult  = "health_check_result"
+	CloudTokenAdditionHealthCheckAt      = "health_check_at"
+	CloudTokenAdditionExpireAlertDay     = "expire_alert_day" // 最近一次发送过期提醒的日期，避免重复提醒
+)
+
+const (
+	CloudTokenStatusNormal      = 1
+	CloudTokenStatusLoginFailed = 2
 )
 
 const (
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+type TrafficScope = string
+
+const (
+	TrafficScopeGlobal TrafficScope = "global"
+	TrafficScopeGroup  TrafficScope = "group"
+	TrafficScopeUser   TrafficScope = "user"
+)
+
+// TrafficLimit 流量限制规则，同一 scope 下的所有下载共享该限制
+type TrafficLimit struct {
+	ID           int64        `gorm:"primaryKey" json:"id"`
+	Scope        TrafficScope `gorm:"column:scope;type:varchar(20);not null;uniqueIndex:idx_scope_target" json:"scope"`
+	TargetId     int64        `gorm:"column:target_id;type:bigint;not null;default:0;uniqueIndex:idx_scope_target" json:"targetId"` // 用户ID或用户组ID，global 时为 0
+	RateLimit    int64        `gorm:"column:rate_limit;type:bigint;not null;default:0" json:"rateLimit"`                            // 速率限制（字节/秒），0 不限制
+	Burst        int64        `gorm:"column:burst;type:bigint;not null;default:0" json:"burst"`                                     // 突发容量（字节），0 时等于 rate_limit
+	MaxStreams   int          `gorm:"column:max_streams;type:int;not null;default:0" json:"maxStreams"`                             // 最大并发流数，0 不限制
+	MonthlyQuota int64        `gorm:"column:monthly_quota;type:bigint;not null;default:0" json:"monthlyQuota"`                      // 每月流量配额（字节），0 不限制
+	CreatedAt    time.Time    `gorm:"column:created_at;autoCreateTime;type:datetime;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt    time.Time    `gorm:"column:updated_at;autoUpdateTime;type:datetime;default:CURRENT_TIMESTAMP;on update:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (t *TrafficLimit) TableName() string {
+	return "traffic_limits"
+}
+
+// TrafficUsage 按 用户/文件/天 汇总的流量记录
+type TrafficUsage struct {
+	ID        int64     `gorm:"primaryKey" json:"id"`
+	UserId    int64     `gorm:"column:user_id;type:bigint;not null;default:0;uniqueIndex:idx_user_file_day" json:"userId"`
+	FileId    int64     `gorm:"column:file_id;type:bigint;not null;default:0;uniqueIndex:idx_user_file_day" json:"fileId"`
+	Day       string    `gorm:"column:day;type:varchar(10);not null;uniqueIndex:idx_user_file_day;index:idx_day" json:"day"` // 2006-01-02
+	Bytes     int64     `gorm:"column:bytes;type:bigint;not null;default:0" json:"bytes"`
+	Requests  int64     `gorm:"column:requests;type:bigint;not null;default:0" json:"requests"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime;type:datetime;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime;type:datetime;default:CURRENT_TIMESTAMP;on update:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+func (t *TrafficUsage) TableName() string {
+	return "traffic_usages"
+}
@@ -16,8 +16,16 @@ type User struct {
 	Permissions uint8     `gorm:"column:permissions;type:tinyint(1);default:1" json:"permissions"`
 	GroupID     int64     `gorm:"column:group_id;type:bigint(20);default:0" json:"groupId"`
 	Version     int       `gorm:"column:version;type:int(11);default:1" json:"version"`
+	FeedVersion int       `gorm:"column:feed_version;type:int(11);default:1" json:"-"` // 订阅地址版本，重置后此前生成的订阅地址全部失效
 	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime;type:datetime;default:CURRENT_TIMESTAMP" json:"createdAt"`
 	UpdatedAt   time.Time `gorm:"column:updated_at;autoUpdateTime;type:datetime;default:CURRENT_TIMESTAMP;on update:CURRENT_TIMESTAMP" json:"updatedAt"`
+
+	// 两步验证：TOTPSecret 为已启用或待验证的密钥，RecoveryCodes 为未使用恢复码的摘要（逗号分隔），
+	// TOTPLastStep 为最后一次通过验证的时间步，同一验证码不能重复使用
+	TOTPEnabled   bool   `gorm:"column:totp_enabled;not null;default:false" json:"totpEnabled"`
+	TOTPSecret    string `gorm:"column:totp_secret;type:varchar(64);not null;default:''" json:"-"`
+	TOTPLastStep  int64  `gorm:"column:totp_last_step;type:bigint;not null;default:0" json:"-"`
+	RecoveryCodes string `gorm:"column:recovery_codes;type:text" json:"-"`
 }
 
 func (u *User) TableName() string {
@@ -0,0 +1,195 @@
+// Package cloudbatch 天翼云盘批量任务接口
+//
+// cloudpan189-interface 未提供批量任务（转存、复制等）相关接口，这里沿用其 TV 端的
+// AppKey 和签名方式直接请求 /open/batch/* 接口。
+package cloudbatch
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/xxcheng123/cloudpan189-interface/client"
+)
+
+const (
+	apiCreateBatchTask = "/open/batch/createBatchTask.action"
+	apiCheckBatchTask  = "/open/batch/checkBatchTask.action"
+)
+
+type TaskType = string
+
+const (
+	TaskTypeShareSave TaskType = "SHARE_SAVE"
+)
+
+// 任务状态
+const (
+	TaskStatusRunning  = 1
+	TaskStatusConflict = 2 // 存在同名文件冲突
+	TaskStatusPending  = 3
+	TaskStatusSuccess  = 4
+)
+
+// TaskInfo 批量任务中的单个文件
+type TaskInfo struct {
+	FileId   string `json:"fileId"`
+	FileName string `json:"fileName"`
+	IsFolder int    `json:"isFolder"`
+}
+
+// TaskStatus 批量任务进度
+type TaskStatus struct {
+	TaskId         string  `json:"taskId"`
+	TaskStatus     int     `json:"taskStatus"`
+	SubTaskCount   int64   `json:"subTaskCount"`
+	SuccessedCount int64   `json:"successedCount"`
+	FailedCount    int64   `json:"failedCount"`
+	SkipCount      int64   `json:"skipCount"`
+	Process        float64 `json:"process"`
+}
+
+// ErrTokenExpired 令牌已过期，无需请求接口
+var ErrTokenExpired = errors.New("auth token expired")
+
+var httpClient = &http.Client{
+	Timeout: client.DefaultTimeout,
+}
+
+// CreateShareSaveTask 创建转存任务，familyId 为空时转存到个人云
+func CreateShareSaveTask(ctx context.Context, token client.AuthToken, shareId int64, familyId, targetFolderId string, infos []TaskInfo) (string, error) {
+	taskInfos, err := json.Marshal(infos)
+	if err != nil {
+		return "", err
+	}
+
+	values := url.Values{
+		"type":           []string{TaskTypeShareSave},
+		"taskInfos":      []string{string(taskInfos)},
+		"targetFolderId": []string{targetFolderId},
+		"shareId":        []string{strconv.FormatInt(shareId, 10)},
+	}
+
+	if familyId != "" {
+		values.Set("familyId", familyId)
+	}
+
+	var result struct {
+		TaskId client.String `json:"taskId"`
+	}
+
+	if err = post(ctx, token, apiCreateBatchTask, values, &result); err != nil {
+		return "", err
+	}
+
+	if result.TaskId == "" {
+		return "", errors.New("创建任务失败，未返回任务ID")
+	}
+
+	return string(result.TaskId), nil
+}
+
+// CheckTask 查询批量任务进度
+func CheckTask(ctx context.Context, token client.AuthToken, taskType TaskType, taskId string) (*TaskStatus, error) {
+	values := url.Values{
+		"type":   []string{taskType},
+		"taskId": []string{taskId},
+	}
+
+	var result = new(TaskStatus)
+	if err := post(ctx, token, apiCheckBatchTask, values, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func post(ctx context.Context, token client.AuthToken, api string, values url.Values, result any) error {
+	if token == nil || token.IsExpired() {
+		return ErrTokenExpired
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, client.ApiUrl+api, strings.NewReader(values.Encode()))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", client.UserAgent)
+	req.Header.Set("Accept", client.Accept)
+
+	for k, v := range signatureHeader(values, token.AccessToken()) {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var body json.RawMessage
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return errors.Wrapf(err, "解析响应失败，状态码: %d", resp.StatusCode)
+	}
+
+	var respErr = new(client.RespErr)
+	if err = json.Unmarshal(body, respErr); err == nil && respErr.HasError() {
+		return respErr
+	}
+
+	return json.Unmarshal(body, result)
+}
+
+// signatureHeader 与 cloudpan189-interface 中的签名算法保持一致
+func signatureHeader(values url.Values, accessToken string) map[string]string {
+	tt := strconv.FormatInt(time.Now().UnixMilli(), 10)
+
+	signValues := url.Values{}
+	for k, v := range values {
+		signValues[k] = append([]string{}, v...)
+	}
+
+	signValues.Set("AccessToken", accessToken)
+	signValues.Set("Timestamp", tt)
+	signValues.Set("AppKey", client.TVAppKey)
+
+	keys := make([]string, 0, len(signValues))
+	for k := range signValues {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	var query strings.Builder
+	for _, k := range keys {
+		vals := signValues[k]
+		sort.Strings(vals)
+
+		for _, v := range vals {
+			if query.Len() > 0 {
+				query.WriteString("&")
+			}
+
+			query.WriteString(k + "=" + v)
+		}
+	}
+
+	h := md5.Sum([]byte(query.String()))
+
+	return map[string]string{
+		"Signature":   hex.EncodeToString(h[:]),
+		"Sign-Type":   "1",
+		"Timestamp":   tt,
+		"AppKey":      client.TVAppKey,
+		"AccessToken": accessToken,
+	}
+}
@@ -0,0 +1,69 @@
+package enc
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestEncVerify(t *testing.T) {
+	const key = "salt-key"
+
+	signed := func() url.Values {
+		return Enc(url.Values{
+			"mountId":   []string{"12"},
+			"uid":       []string{"3"},
+			"version":   []string{"1"},
+			"random":    []string{"abc"},
+			"timestamp": []string{"-1"},
+		}, key)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(v url.Values)
+		key    string
+		want   bool
+	}{
+		{"unchanged", func(url.Values) {}, key, true},
+		{"wrong key", func(url.Values) {}, "other-key", false},
+		{"uid changed", func(v url.Values) { v.Set("uid", "4") }, key, false},
+		{"version changed", func(v url.Values) { v.Set("version", "2") }, key, false},
+		{"timestamp changed", func(v url.Values) { v.Set("timestamp", "1") }, key, false},
+		{"extra field", func(v url.Values) { v.Set("admin", "1") }, key, false},
+		{"field removed", func(v url.Values) { v.Del("random") }, key, false},
+		{"sign missing", func(v url.Values) { v.Del("sign") }, key, false},
+		{"sign tampered", func(v url.Values) { v.Set("sign", "0"+v.Get("sign")[1:]) }, key, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := signed()
+			tt.mutate(v)
+
+			if got := Verify(v, tt.key); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncOrderIndependent(t *testing.T) {
+	a := Enc(url.Values{"a": []string{"1"}, "b": []string{"2"}, "timestamp": []string{"-1"}}, "k")
+	b := Enc(url.Values{"timestamp": []string{"-1"}, "b": []string{"2"}, "a": []string{"1"}}, "k")
+
+	if a.Get("sign") != b.Get("sign") {
+		t.Errorf("sign depends on insertion order: %s != %s", a.Get("sign"), b.Get("sign"))
+	}
+}
+
+func TestEncDefaultTimestamp(t *testing.T) {
+	v := Enc(url.Values{"id": []string{"1"}}, "k")
+
+	if v.Get("timestamp") == "" {
+		t.Fatal("Enc() should add a timestamp")
+	}
+
+	if !Verify(v, "k") {
+		t.Error("Verify() = false for a freshly signed value")
+	}
+}
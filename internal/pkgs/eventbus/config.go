@@ -4,6 +4,9 @@ package eventbus
 type Config struct {
 	BufferSize     int // channel缓冲区大小
 	MaxConcurrency int // 整个bus的最大并发处理数
+
+	// OnTaskChange 任务状态变化时回调（pending/running/completed/failed），在处理协程中同步调用，不应阻塞
+	OnTaskChange func(task TaskInfo)
 }
 
 // DefaultConfig 默认配置
@@ -3,6 +3,8 @@ package eventbus
 import (
 	"context"
 	"time"
+
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/trace"
 )
 
 // Event 事件
@@ -11,8 +13,9 @@ type Event struct {
 	Topic     string
 	Data      interface{}
 	Context   context.Context
+	Trace     trace.SpanContext // 发布时的追踪信息，处理器的 ctx 和日志沿用同一个 trace id
 	Handler   Handler
 	Result    chan error // 同步发布时使用
-	Status    string     // 事件状态: "pending", "running", "completed"
+	Status    string     // 事件状态: "pending", "running", "completed", "failed"
 	StartTime time.Time  // 创建时间
 }
@@ -6,6 +6,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/trace"
 )
 
 // eventBus 事件总线实现
@@ -18,9 +20,14 @@ type eventBus struct {
 
 	eventCh     chan *Event
 	done        chan struct{}
+	stopped     chan struct{}  // processor 退出后关闭
 	concurrency chan struct{}  // 全局并发控制信号量
 	wg          sync.WaitGroup // 等待所有处理完成
 
+	// baseCtx 在 Shutdown 时取消，所有处理器的 ctx 都派生自它
+	baseCtx    context.Context
+	baseCancel context.CancelFunc
+
 	// 任务状态跟踪
 	runningTasks   map[string]*Event // 正在运行的任务
 	pendingQueue   []*Event          // 待处理队列快照
@@ -107,6 +114,7 @@ func (eb *eventBus) Publish(ctx context.Context, topic string, data interface{})
 			Topic:     topic,
 			Data:      data,
 			Context:   ctx,
+			Trace:     publishTrace(ctx),
 			Handler:   sub.handler,
 			Status:    "pending",
 			StartTime: time.Now(),
@@ -119,6 +127,7 @@ func (eb *eventBus) Publish(ctx context.Context, topic string, data interface{})
 			eb.taskMu.Lock()
 			eb.pendingQueue = append(eb.pendingQueue, event)
 			eb.taskMu.Unlock()
+			eb.notifyTaskChange(event, "pending", nil)
 			successCount++
 		case <-ctx.Done():
 			failedCount++
@@ -180,6 +189,7 @@ func (eb *eventBus) PublishSync(ctx context.Context, topic string, data interfac
 			Topic:     topic,
 			Data:      data,
 			Context:   ctx,
+			Trace:     publishTrace(ctx),
 			Handler:   sub.handler,
 			Result:    make(chan error, 1),
 			Status:    "pending",
@@ -193,6 +203,7 @@ func (eb *eventBus) PublishSync(ctx context.Context, topic string, data interfac
 			eb.taskMu.Lock()
 			eb.pendingQueue = append(eb.pendingQueue, event)
 			eb.taskMu.Unlock()
+			eb.notifyTaskChange(event, "pending", nil)
 			// 成功发送，等待结果
 			go func() {
 				defer wg.Done()
@@ -245,6 +256,8 @@ func (eb *eventBus) PublishSync(ctx context.Context, topic string, data interfac
 
 // processor 全局事件处理器，控制整个bus的并发
 func (eb *eventBus) processor() {
+	defer close(eb.stopped)
+
 	defer func() {
 		// 处理剩余事件
 		for {
@@ -305,15 +318,28 @@ func (eb *eventBus) handleEventDirect(event *Event) {
 	}
 	eb.taskMu.Unlock()
 
+	eb.notifyTaskChange(event, "running", nil)
+
+	// 发布方的 ctx 或总线关闭任一取消，处理器都应尽快退出
+	handlerCtx, cancel := context.WithCancel(event.Context)
+	stop := context.AfterFunc(eb.baseCtx, cancel)
+
+	spanCtx, span := trace.Start(trace.ContextWith(handlerCtx, event.Trace), "bus "+event.Topic, trace.SpanKindInternal)
+	span.SetAttr("bus.event_id", event.ID)
+
 	// 检查context
 	select {
-	case <-event.Context.Done():
-		err = event.Context.Err()
+	case <-handlerCtx.Done():
+		err = handlerCtx.Err()
 	default:
 		// 执行处理器
-		err = event.Handler(event.Context, event.Data)
+		err = event.Handler(spanCtx, event.Data)
 	}
 
+	span.End(err)
+	stop()
+	cancel()
+
 	// 标记任务完成
 	eb.taskMu.Lock()
 	event.Status = "completed"
@@ -330,6 +356,13 @@ func (eb *eventBus) handleEventDirect(event *Event) {
 	atomic.AddInt64(&eb.completedCount, 1)
 	eb.taskMu.Unlock()
 
+	status := "completed"
+	if err != nil {
+		status = "failed"
+	}
+
+	eb.notifyTaskChange(event, status, err)
+
 	// 返回结果（同步发布时）
 	if event.Result != nil {
 		select {
@@ -339,6 +372,37 @@ func (eb *eventBus) handleEventDirect(event *Event) {
 	}
 }
 
+// publishTrace 取发布方的追踪信息，定时任务等没有来源请求时开启新的 trace
+func publishTrace(ctx context.Context) trace.SpanContext {
+	if sc := trace.FromContext(ctx); sc.Valid() {
+		return sc
+	}
+
+	return trace.SpanContext{TraceID: trace.NewTraceID()}
+}
+
+// notifyTaskChange 通知任务状态变化，status 由调用方传入，避免在锁外读取 event.Status
+func (eb *eventBus) notifyTaskChange(event *Event, status string, err error) {
+	if eb.config.OnTaskChange == nil {
+		return
+	}
+
+	info := TaskInfo{
+		ID:        event.ID,
+		Topic:     event.Topic,
+		Status:    status,
+		StartTime: event.StartTime,
+		Data:      event.Data,
+		TraceID:   event.Trace.TraceID,
+	}
+
+	if err != nil {
+		info.Error = err.Error()
+	}
+
+	eb.config.OnTaskChange(info)
+}
+
 // GetRunningTasks 获取正在运行的任务
 func (eb *eventBus) GetRunningTasks() []TaskInfo {
 	eb.taskMu.RLock()
@@ -352,6 +416,7 @@ func (eb *eventBus) GetRunningTasks() []TaskInfo {
 			Status:    event.Status,
 			StartTime: event.StartTime,
 			Data:      event.Data,
+			TraceID:   event.Trace.TraceID,
 		})
 	}
 	return tasks
@@ -370,6 +435,7 @@ func (eb *eventBus) GetPendingTasks() []TaskInfo {
 			Status:    event.Status,
 			StartTime: event.StartTime,
 			Data:      event.Data,
+			TraceID:   event.Trace.TraceID,
 		})
 	}
 
@@ -405,6 +471,48 @@ func (eb *eventBus) GetStats() BusStats {
 	}
 }
 
+// Shutdown 停止接收新任务并取消正在运行的处理器，等待处理器退出直到 ctx 结束；
+// 队列中尚未执行的任务会以 context.Canceled 结束
+func (eb *eventBus) Shutdown(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&eb.closed, 0, 1) {
+		return nil
+	}
+
+	eb.baseCancel()
+	close(eb.done)
+
+	finished := make(chan struct{})
+	go func() {
+		<-eb.stopped
+		eb.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	for _, subs := range eb.subscribers {
+		for _, sub := range subs {
+			sub.Close()
+		}
+	}
+
+	eb.subscribers = make(map[string][]*subscription)
+
+	return nil
+}
+
+// Closed 是否已关闭
+func (eb *eventBus) Closed() bool {
+	return atomic.LoadInt32(&eb.closed) == 1
+}
+
 // Close 关闭事件总线
 func (eb *eventBus) Close() {
 	if !atomic.CompareAndSwapInt32(&eb.closed, 0, 1) {
@@ -19,9 +19,11 @@ type Subscription interface {
 type TaskInfo struct {
 	ID        string      `json:"id"`
 	Topic     string      `json:"topic"`
-	Status    string      `json:"status"` // "pending", "running", "completed"
+	Status    string      `json:"status"` // "pending", "running", "completed", "failed"
 	StartTime time.Time   `json:"startTime"`
 	Data      interface{} `json:"data,omitempty"`
+	Error     string      `json:"error,omitempty"` // 仅 failed 时有值
+	TraceID   string      `json:"traceId,omitempty"`
 }
 
 // BusStats 总线统计信息
@@ -41,6 +43,8 @@ type EventBus interface {
 	Publish(ctx context.Context, topic string, data interface{}) error
 	PublishSync(ctx context.Context, topic string, data interface{}) error
 	Close()
+	Shutdown(ctx context.Context) error
+	Closed() bool
 
 	// GetRunningTasks 任务状态查询接口
 	GetRunningTasks() []TaskInfo
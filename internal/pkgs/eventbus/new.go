@@ -1,5 +1,7 @@
 package eventbus
 
+import "context"
+
 // New 创建事件总线
 func New() EventBus {
 	return NewWithConfig(DefaultConfig())
@@ -16,12 +18,15 @@ func NewWithConfig(config *Config) EventBus {
 		config:      config,
 		eventCh:     make(chan *Event, config.BufferSize),
 		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
 		concurrency: make(chan struct{}, config.MaxConcurrency),
 		// 初始化任务状态跟踪
 		runningTasks: make(map[string]*Event),
 		pendingQueue: make([]*Event, 0),
 	}
 
+	eb.baseCtx, eb.baseCancel = context.WithCancel(context.Background())
+
 	// 启动全局事件处理器
 	go eb.processor()
 
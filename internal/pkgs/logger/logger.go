@@ -24,6 +24,7 @@ type Option func(*option)
 
 type option struct {
 	level           zapcore.Level
+	atomicLevel     *zap.AtomicLevel
 	fields          map[string]string
 	file            io.Writer
 	timeLayout      string
@@ -58,6 +59,13 @@ func WithErrorLevel() Option {
 	}
 }
 
+// WithAtomicLevel use a level that can be changed at runtime, overrides the other level options
+func WithAtomicLevel(level zap.AtomicLevel) Option {
+	return func(opt *option) {
+		opt.atomicLevel = &level
+	}
+}
+
 // WithField add some field(s) to log
 func WithField(key, value string) Option {
 	return func(opt *option) {
@@ -146,14 +154,22 @@ func NewJSONLogger(opts ...Option) (*zap.Logger, error) {
 
 	jsonEncoder := zapcore.NewJSONEncoder(encoderConfig)
 
+	minLevel := func() zapcore.Level {
+		if opt.atomicLevel != nil {
+			return opt.atomicLevel.Level()
+		}
+
+		return opt.level
+	}
+
 	// lowPriority usd by info\debug\warn
 	lowPriority := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-		return lvl >= opt.level && lvl < zapcore.ErrorLevel
+		return lvl >= minLevel() && lvl < zapcore.ErrorLevel
 	})
 
 	// highPriority usd by error\panic\fatal
 	highPriority := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-		return lvl >= opt.level && lvl >= zapcore.ErrorLevel
+		return lvl >= minLevel() && lvl >= zapcore.ErrorLevel
 	})
 
 	stdout := zapcore.Lock(os.Stdout) // lock for concurrent safe
@@ -179,7 +195,7 @@ func NewJSONLogger(opts ...Option) (*zap.Logger, error) {
 			zapcore.NewCore(jsonEncoder,
 				zapcore.AddSync(opt.file),
 				zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-					return lvl >= opt.level
+					return lvl >= minLevel()
 				}),
 			),
 		)
@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type Type = string
+
+const (
+	TypeWebhook  Type = "webhook"
+	TypeSMTP     Type = "smtp"
+	TypeTelegram Type = "telegram"
+)
+
+type Level = string
+
+const (
+	LevelInfo  Level = "info"
+	LevelWarn  Level = "warn"
+	LevelError Level = "error"
+)
+
+// Message 通知内容
+type Message struct {
+	Title   string    `json:"title"`
+	Content string    `json:"content"`
+	Level   Level     `json:"level"`
+	Time    time.Time `json:"time"`
+}
+
+// Notifier 通知渠道
+type Notifier interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+var httpClient = &http.Client{
+	Timeout: time.Second * 10,
+}
+
+// New 根据类型和配置创建通知渠道
+func New(typ Type, config map[string]any) (Notifier, error) {
+	switch typ {
+	case TypeWebhook:
+		return newWebhook(config)
+	case TypeSMTP:
+		return newSMTP(config)
+	case TypeTelegram:
+		return newTelegram(config)
+	default:
+		return nil, fmt.Errorf("不支持的通知类型: %s", typ)
+	}
+}
+
+func getString(config map[string]any, key string) string {
+	if v, ok := config[key]; ok {
+		if s, ok := v.(string); ok {
+			return s
+		}
+
+		return fmt.Sprintf("%v", v)
+	}
+
+	return ""
+}
+
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("通知发送失败，状态码: %d", resp.StatusCode)
+	}
+
+	return nil
+}
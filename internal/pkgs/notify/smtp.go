@@ -0,0 +1,78 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// smtpNotifier 通过 SMTP 发送邮件，主要用于本地中继，用户名为空时不做认证
+type smtpNotifier struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+func newSMTP(config map[string]any) (Notifier, error) {
+	s := &smtpNotifier{
+		host:     getString(config, "host"),
+		port:     getString(config, "port"),
+		username: getString(config, "username"),
+		password: getString(config, "password"),
+		from:     getString(config, "from"),
+	}
+
+	for _, to := range strings.Split(getString(config, "to"), ",") {
+		if to = strings.TrimSpace(to); to != "" {
+			s.to = append(s.to, to)
+		}
+	}
+
+	if s.port == "" {
+		s.port = "25"
+	}
+
+	if s.host == "" || s.from == "" || len(s.to) == 0 {
+		return nil, errors.New("host、from、to 不能为空")
+	}
+
+	return s, nil
+}
+
+func (s *smtpNotifier) Send(ctx context.Context, msg *Message) error {
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+
+	var body strings.Builder
+	body.WriteString(fmt.Sprintf("From: %s\r\n", s.from))
+	body.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(s.to, ", ")))
+	body.WriteString(fmt.Sprintf("Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", msg.Title)))
+	body.WriteString(fmt.Sprintf("Date: %s\r\n", msg.Time.Format(time.RFC1123Z)))
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	body.WriteString("\r\n")
+	body.WriteString(msg.Content)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(net.JoinHostPort(s.host, s.port), auth, s.from, s.to, []byte(body.String()))
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-done:
+		return err
+	}
+}
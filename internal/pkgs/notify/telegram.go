@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const defaultTelegramAPI = "https://api.telegram.org"
+
+// telegram 兼容 Telegram Bot API 的 sendMessage 接口
+type telegram struct {
+	apiBase  string
+	botToken string
+	chatId   string
+}
+
+func newTelegram(config map[string]any) (Notifier, error) {
+	t := &telegram{
+		apiBase:  strings.TrimRight(getString(config, "apiBase"), "/"),
+		botToken: getString(config, "botToken"),
+		chatId:   getString(config, "chatId"),
+	}
+
+	if t.apiBase == "" {
+		t.apiBase = defaultTelegramAPI
+	}
+
+	if t.botToken == "" || t.chatId == "" {
+		return nil, errors.New("botToken 和 chatId 不能为空")
+	}
+
+	return t, nil
+}
+
+func (t *telegram) Send(ctx context.Context, msg *Message) error {
+	body, err := json.Marshal(map[string]any{
+		"chat_id": t.chatId,
+		"text":    fmt.Sprintf("%s\n\n%s", msg.Title, msg.Content),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/bot%s/sendMessage", t.apiBase, t.botToken), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp)
+}
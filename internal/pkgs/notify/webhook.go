@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// webhook 以 JSON 格式 POST 到指定地址
+type webhook struct {
+	url string
+}
+
+func newWebhook(config map[string]any) (Notifier, error) {
+	w := &webhook{
+		url: getString(config, "url"),
+	}
+
+	if w.url == "" {
+		return nil, errors.New("webhook 地址不能为空")
+	}
+
+	return w, nil
+}
+
+func (w *webhook) Send(ctx context.Context, msg *Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkResponse(resp)
+}
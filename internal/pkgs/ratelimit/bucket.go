@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Bucket 令牌桶，rate 为每秒产生的令牌数（字节），burst 为桶容量
+type Bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewBucket 创建令牌桶，rate <= 0 表示不限制
+func NewBucket(rate, burst int64) *Bucket {
+	b := &Bucket{
+		last: time.Now(),
+	}
+
+	b.SetRate(rate, burst)
+	b.tokens = b.burst
+
+	return b
+}
+
+// SetRate 动态调整速率，burst <= 0 时默认为 1 秒的流量
+func (b *Bucket) SetRate(rate, burst int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if burst <= 0 {
+		burst = rate
+	}
+
+	b.rate = float64(rate)
+	b.burst = float64(burst)
+
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// Unlimited 是否不限速
+func (b *Bucket) Unlimited() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.rate <= 0
+}
+
+// Burst 桶容量
+func (b *Bucket) Burst() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return int(b.burst)
+}
+
+// WaitN 阻塞直到获取 n 个令牌，n 不应超过 burst
+func (b *Bucket) WaitN(ctx context.Context, n int) error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+
+	if b.rate <= 0 {
+		b.mu.Unlock()
+
+		return nil
+	}
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	// 先预支令牌，不足部分按速率换算成等待时间
+	b.tokens -= float64(n)
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+
+	b.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		// 归还预支的令牌
+		b.mu.Lock()
+		b.tokens += float64(n)
+		b.mu.Unlock()
+
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,110 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfcSecret RFC 6238 附录 B 的 SHA1 测试密钥 "12345678901234567890"
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateRFCVectors(t *testing.T) {
+	// RFC 6238 给出的是 8 位验证码，取后 6 位
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		step, ok := Validate(rfcSecret, tt.code, time.Unix(tt.unix, 0))
+		if !ok {
+			t.Errorf("Validate(%q) at %d = false, want true", tt.code, tt.unix)
+
+			continue
+		}
+
+		if want := tt.unix / period; step != want {
+			t.Errorf("Validate(%q) at %d step = %d, want %d", tt.code, tt.unix, step, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	// 1111111111 所在时间步的验证码
+	const code = "050471"
+
+	at := time.Unix(1111111111, 0)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		t      time.Time
+		want   bool
+	}{
+		{"current step", rfcSecret, code, at, true},
+		{"lower case secret with spaces", " gezdgnbvgy3tqojqgezdgnbvgy3tqojq ", code, at, true},
+		{"one step behind", rfcSecret, code, at.Add(period * time.Second), true},
+		{"one step ahead", rfcSecret, code, at.Add(-period * time.Second), true},
+		{"two steps behind", rfcSecret, code, at.Add(2 * period * time.Second), false},
+		{"wrong code", rfcSecret, "123456", at, false},
+		{"short code", rfcSecret, "50471", at, false},
+		{"long code", rfcSecret, "0050471", at, false},
+		{"invalid secret", "not base32!", code, at, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := Validate(tt.secret, tt.code, tt.t); ok != tt.want {
+				t.Errorf("Validate() = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+
+	key, err := encoding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Fatalf("GenerateSecret() = %q, want 20 bytes base32", secret)
+	}
+
+	now := time.Now()
+	if _, ok := Validate(secret, generate(key, now.Unix()/period), now); !ok {
+		t.Error("generated code should validate")
+	}
+}
+
+func TestURI(t *testing.T) {
+	u, err := url.Parse(URI("My Share", "admin@example.com", rfcSecret))
+	if err != nil {
+		t.Fatalf("URI() is not a valid url: %v", err)
+	}
+
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/My Share:admin@example.com" {
+		t.Errorf("URI() = %s", u)
+	}
+
+	query := u.Query()
+	for key, want := range map[string]string{
+		"secret": rfcSecret,
+		"issuer": "My Share",
+		"digits": "6",
+		"period": "30",
+	} {
+		if got := query.Get(key); got != want {
+			t.Errorf("query %s = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	exportQueueSize = 2048
+	exportBatchSize = 256
+	exportInterval  = time.Second * 5
+	exportTimeout   = time.Second * 10
+)
+
+// exporter 按 OTLP/HTTP JSON 格式批量上报 span，不引入 opentelemetry SDK
+type exporter struct {
+	url         string
+	serviceName string
+	logger      *zap.Logger
+	client      *http.Client
+
+	queue   chan *otlpSpan
+	done    chan struct{}
+	stopped chan struct{}
+	dropped atomic.Int64
+}
+
+var current atomic.Pointer[exporter]
+
+func currentExporter() *exporter {
+	return current.Load()
+}
+
+// StartExporter 开始向 endpoint（如 http://127.0.0.1:4318）导出 span，重复调用会替换之前的导出器
+func StartExporter(endpoint, serviceName string, logger *zap.Logger) {
+	exp := &exporter{
+		url:         strings.TrimRight(endpoint, "/") + "/v1/traces",
+		serviceName: serviceName,
+		logger:      logger,
+		client:      &http.Client{Timeout: exportTimeout},
+		queue:       make(chan *otlpSpan, exportQueueSize),
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+	}
+
+	go exp.loop()
+
+	if old := current.Swap(exp); old != nil {
+		old.stop(context.Background())
+	}
+
+	logger.Info("trace exporter started", zap.String("url", exp.url))
+}
+
+// StopExporter 停止导出并上报剩余 span，未启动时直接返回
+func StopExporter(ctx context.Context) {
+	if exp := current.Swap(nil); exp != nil {
+		exp.stop(ctx)
+	}
+}
+
+// enqueue 队列满时丢弃，不阻塞业务
+func (e *exporter) enqueue(span *otlpSpan) {
+	select {
+	case e.queue <- span:
+	default:
+		e.dropped.Add(1)
+	}
+}
+
+func (e *exporter) stop(ctx context.Context) {
+	close(e.done)
+
+	select {
+	case <-e.stopped:
+	case <-ctx.Done():
+	}
+}
+
+func (e *exporter) loop() {
+	defer close(e.stopped)
+
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]*otlpSpan, 0, exportBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		if err := e.export(batch); err != nil {
+			e.logger.Warn("trace export failed", zap.Int("spans", len(batch)), zap.Error(err))
+		}
+
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case span := <-e.queue:
+			if batch = append(batch, span); len(batch) >= exportBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+
+			if n := e.dropped.Swap(0); n > 0 {
+				e.logger.Warn("trace export queue full, spans dropped", zap.Int64("count", n))
+			}
+		case <-e.done:
+			for {
+				select {
+				case span := <-e.queue:
+					batch = append(batch, span)
+				default:
+					flush()
+
+					return
+				}
+			}
+		}
+	}
+}
+
+func (e *exporter) export(spans []*otlpSpan) error {
+	body, err := json.Marshal(map[string]any{
+		"resourceSpans": []any{
+			map[string]any{
+				"resource": map[string]any{
+					"attributes": []otlpAttr{stringAttr("service.name", e.serviceName)},
+				},
+				"scopeSpans": []any{
+					map[string]any{
+						"scope": map[string]any{"name": "cloudpan189-share"},
+						"spans": spans,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// otlpSpan OTLP JSON 编码中 trace/span id 使用十六进制字符串，64 位整数使用字符串
+type otlpSpan struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              SpanKind   `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []otlpAttr `json:"attributes,omitempty"`
+	Status            otlpStatus `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"` // 1 成功 2 失败
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttr struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+func stringAttr(key, value string) otlpAttr {
+	return otlpAttr{Key: key, Value: map[string]any{"stringValue": value}}
+}
+
+func toAttr(key string, value any) otlpAttr {
+	switch v := value.(type) {
+	case string:
+		return stringAttr(key, v)
+	case bool:
+		return otlpAttr{Key: key, Value: map[string]any{"boolValue": v}}
+	case int:
+		return otlpAttr{Key: key, Value: map[string]any{"intValue": strconv.Itoa(v)}}
+	case int64:
+		return otlpAttr{Key: key, Value: map[string]any{"intValue": strconv.FormatInt(v, 10)}}
+	case float64:
+		return otlpAttr{Key: key, Value: map[string]any{"doubleValue": v}}
+	default:
+		return stringAttr(key, fmt.Sprint(v))
+	}
+}
+
+func (s *Span) toOTLP(end time.Time) *otlpSpan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	span := &otlpSpan{
+		TraceID:           s.sc.TraceID,
+		SpanID:            s.sc.SpanID,
+		ParentSpanID:      s.parentID,
+		Name:              s.name,
+		Kind:              s.kind,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(end.UnixNano(), 10),
+		Status:            otlpStatus{Code: 1},
+	}
+
+	for k, v := range s.attrs {
+		span.Attributes = append(span.Attributes, toAttr(k, v))
+	}
+
+	if s.err != nil {
+		span.Status = otlpStatus{Code: 2, Message: s.err.Error()}
+	}
+
+	return span
+}
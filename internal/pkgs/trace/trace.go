@@ -0,0 +1,179 @@
+// Package trace 请求追踪：为每次请求分配 trace id，经 ctx 传递到总线任务和云盘接口调用，
+// 日志带上同一个 request_id 便于检索；配置 OTLP 地址后同时导出 span
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// GinKey 中间件把 SpanContext 存入 gin.Context 使用的键
+//
+// gin.Context 作为 context.Context 使用时只能通过字符串键读取 Keys，业务代码常把它直接传给总线
+const GinKey = "trace.span_context"
+
+// LogField 日志中 trace id 的字段名
+const LogField = "request_id"
+
+type ctxKey struct{}
+
+// SpanContext 跨进程、跨协程传递的追踪信息
+type SpanContext struct {
+	TraceID string // 32 位十六进制
+	SpanID  string // 16 位十六进制
+}
+
+// Valid 是否包含有效的 trace id
+func (sc SpanContext) Valid() bool {
+	return len(sc.TraceID) == 32
+}
+
+// NewTraceID 生成 trace id
+func NewTraceID() string {
+	return randomHex(16)
+}
+
+// NewSpanID 生成 span id
+func NewSpanID() string {
+	return randomHex(8)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// ContextWith 返回携带 sc 的 ctx
+func ContextWith(ctx context.Context, sc SpanContext) context.Context {
+	if !sc.Valid() {
+		return ctx
+	}
+
+	return context.WithValue(ctx, ctxKey{}, sc)
+}
+
+// FromContext 取 ctx 中的追踪信息，兼容直接传入的 gin.Context
+func FromContext(ctx context.Context) SpanContext {
+	if ctx == nil {
+		return SpanContext{}
+	}
+
+	if sc, ok := ctx.Value(ctxKey{}).(SpanContext); ok {
+		return sc
+	}
+
+	if sc, ok := ctx.Value(GinKey).(SpanContext); ok {
+		return sc
+	}
+
+	return SpanContext{}
+}
+
+// ID 取 trace id，没有时返回空
+func ID(ctx context.Context) string {
+	return FromContext(ctx).TraceID
+}
+
+// Logger 为日志附加 request_id，ctx 中没有追踪信息时原样返回
+func Logger(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	if id := ID(ctx); id != "" {
+		return logger.With(zap.String(LogField, id))
+	}
+
+	return logger
+}
+
+// SpanKind 与 OTLP 的 span kind 取值一致
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// Span 一段耗时操作，未配置导出时只用于生成 span id，开销可忽略
+type Span struct {
+	name     string
+	kind     SpanKind
+	sc       SpanContext
+	parentID string
+	start    time.Time
+
+	mu    sync.Mutex
+	attrs map[string]any
+	err   error
+	ended bool
+}
+
+// Start 开始一个 span，ctx 中没有追踪信息时开启新的 trace
+func Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	parent := FromContext(ctx)
+
+	span := &Span{
+		name:     name,
+		kind:     kind,
+		parentID: parent.SpanID,
+		start:    time.Now(),
+		sc: SpanContext{
+			TraceID: parent.TraceID,
+			SpanID:  NewSpanID(),
+		},
+	}
+
+	if !parent.Valid() {
+		span.sc.TraceID = NewTraceID()
+	}
+
+	return ContextWith(ctx, span.sc), span
+}
+
+// SpanContext 当前 span 的追踪信息
+func (s *Span) SpanContext() SpanContext {
+	return s.sc
+}
+
+// SetName 修改名称，用于开始时还不知道路由等信息的情况
+func (s *Span) SetName(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.name = name
+}
+
+// SetAttr 设置属性，值支持字符串、整数、浮点数和布尔
+func (s *Span) SetAttr(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.attrs == nil {
+		s.attrs = make(map[string]any)
+	}
+
+	s.attrs[key] = value
+}
+
+// End 结束 span，err 不为空时标记为失败；重复调用只有第一次生效
+func (s *Span) End(err error) {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+
+		return
+	}
+
+	s.ended = true
+	s.err = err
+	s.mu.Unlock()
+
+	if exp := currentExporter(); exp != nil {
+		exp.enqueue(s.toOTLP(time.Now()))
+	}
+}
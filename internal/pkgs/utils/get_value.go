@@ -118,3 +118,23 @@ func StringSlice(value interface{}) []string {
 		return []string{String(v)}
 	}
 }
+
+func Int64Slice(value interface{}) []int64 {
+	switch v := value.(type) {
+	case []int64:
+		return v
+	case []interface{}:
+		var s []int64
+		for _, v := range v {
+			if i, err := Int64(v); err == nil {
+				s = append(s, i)
+			}
+		}
+		return s
+	default:
+		if i, err := Int64(v); err == nil {
+			return []int64{i}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,48 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/configs"
+)
+
+var (
+	corsAllowMethods = strings.Join([]string{
+		http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+		"PROPFIND", "MKCOL", "MOVE", "LOCK", "UNLOCK",
+	}, ", ")
+	corsAllowHeaders  = "Authorization, Content-Type, Depth, Destination, Overwrite, X-Request-Id"
+	corsExposeHeaders = "Content-Disposition, Content-Length, X-Request-Id"
+)
+
+// corsMiddleware 按配置中的 corsOrigins 返回跨域响应头，每次请求读取当前配置，重新加载后立即生效
+func corsMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		origin := ctx.GetHeader("Origin")
+		if origin == "" || !configs.GetConfig().AllowOrigin(origin) {
+			ctx.Next()
+
+			return
+		}
+
+		header := ctx.Writer.Header()
+		header.Add("Vary", "Origin")
+		header.Set("Access-Control-Allow-Origin", origin)
+		header.Set("Access-Control-Expose-Headers", corsExposeHeaders)
+
+		// WebDAV 客户端也会发 OPTIONS，只有带 Access-Control-Request-Method 的才是预检
+		if ctx.Request.Method == http.MethodOptions && ctx.GetHeader("Access-Control-Request-Method") != "" {
+			header.Set("Access-Control-Allow-Methods", corsAllowMethods)
+			header.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+			header.Set("Access-Control-Max-Age", "600")
+
+			ctx.AbortWithStatus(http.StatusNoContent)
+
+			return
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,68 @@
+package router
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/xxcheng123/cloudpan189-share/configs"
+	"github.com/xxcheng123/cloudpan189-share/internal/stream"
+)
+
+// newHTTPServer 只限制读取请求头的时间；不设置 ReadTimeout 和 WriteTimeout，
+// 否则大文件上传下载、视频播放和 SSE 这类长连接会在超时后被强制断开
+func newHTTPServer(handler http.Handler) *http.Server {
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: time.Second * 10,
+		IdleTimeout:       time.Second * 120,
+	}
+
+	// Shutdown 不会等到 SSE 连接自行结束，先通知其断开
+	server.RegisterOnShutdown(stream.Close)
+
+	return server
+}
+
+// listenUnix 监听 Unix socket，清理上次异常退出残留的 socket 文件
+func listenUnix(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		_ = os.Remove(path)
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, errors.Wrap(err, "监听 unix socket 失败")
+	}
+
+	if err := os.Chmod(path, 0o660); err != nil {
+		_ = ln.Close()
+
+		return nil, errors.Wrap(err, "设置 unix socket 权限失败")
+	}
+
+	return ln, nil
+}
+
+type unixSocketCtxKey struct{}
+
+// unixHandler Unix socket 上的连接没有客户端地址，以 configs.UnixSocketRemoteIP 标记来源，
+// 不伪装成本机地址，是否采信其 X-Forwarded-* 请求头由 unixSocketTrustProxy 决定
+func unixHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.RemoteAddr = net.JoinHostPort(configs.UnixSocketRemoteIP, "0")
+		r = r.WithContext(context.WithValue(r.Context(), unixSocketCtxKey{}, true))
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// fromUnixSocket 请求是否来自 Unix socket
+func fromUnixSocket(r *http.Request) bool {
+	v, _ := r.Context().Value(unixSocketCtxKey{}).(bool)
+
+	return v
+}
@@ -1,37 +1,57 @@
 package router
 
 import (
-	"fmt"
-	"io/fs"
+	"context"
 	"net/http"
-	"strings"
 
 	"github.com/xxcheng123/cloudpan189-share/internal/services/storage"
 
 	"github.com/xxcheng123/cloudpan189-share/internal/services/advancedops"
 
 	"github.com/xxcheng123/cloudpan189-share/internal/services/usergroup"
+	"github.com/xxcheng123/cloudpan189-share/internal/services/webhook"
 
 	"github.com/gin-gonic/gin"
 	embed "github.com/xxcheng123/cloudpan189-share"
 	"github.com/xxcheng123/cloudpan189-share/configs"
+	"github.com/xxcheng123/cloudpan189-share/internal/authguard"
+	"github.com/xxcheng123/cloudpan189-share/internal/metrics"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/trace"
+	"github.com/xxcheng123/cloudpan189-share/internal/services/auditlog"
+	backupS "github.com/xxcheng123/cloudpan189-share/internal/services/backup"
 	"github.com/xxcheng123/cloudpan189-share/internal/services/cloudtoken"
+	"github.com/xxcheng123/cloudpan189-share/internal/services/eventstream"
+	"github.com/xxcheng123/cloudpan189-share/internal/services/fileevent"
+	"github.com/xxcheng123/cloudpan189-share/internal/services/health"
+	"github.com/xxcheng123/cloudpan189-share/internal/services/notifier"
+	"github.com/xxcheng123/cloudpan189-share/internal/services/scanrun"
+	"github.com/xxcheng123/cloudpan189-share/internal/services/security"
 	settingS "github.com/xxcheng123/cloudpan189-share/internal/services/setting"
+	"github.com/xxcheng123/cloudpan189-share/internal/services/share"
 	storageBridge "github.com/xxcheng123/cloudpan189-share/internal/services/storage/bridge"
+	trafficS "github.com/xxcheng123/cloudpan189-share/internal/services/traffic"
 	"github.com/xxcheng123/cloudpan189-share/internal/services/universalfs"
 	"github.com/xxcheng123/cloudpan189-share/internal/services/user"
 	"go.uber.org/zap"
 )
 
-func StartHTTPServer() error {
+// StartHTTPServer 启动 HTTP 服务，ctx 结束后停止接收新连接并等待已有请求处理完成
+func StartHTTPServer(ctx context.Context) error {
 	var (
-		engine = gin.Default()
+		engine = gin.New()
 		db     = configs.DB()
 		logger = configs.Logger()
 		config = configs.GetConfig()
 	)
 
+	// 只采信可信代理转发的客户端地址
+	if err := engine.SetTrustedProxies(config.GetTrustedProxies()); err != nil {
+		return err
+	}
+
+	engine.Use(trace.Middleware(logger.With(zap.String("module", "http"))), gin.Recovery(), corsMiddleware())
+
 	var (
 		userService          = user.NewService(db, logger)
 		cloudTokenService    = cloudtoken.NewService(db, logger)
@@ -41,13 +61,33 @@ func StartHTTPServer() error {
 		universalFsService   = universalfs.NewService(db, logger)
 		userGroupService     = usergroup.NewService(db, logger)
 		advancedOpsService   = advancedops.NewService(db, logger)
+		trafficService       = trafficS.NewService(db, logger)
+		notifierService      = notifier.NewService(db, logger)
+		webhookService       = webhook.NewService(db, logger)
+		fileEventService     = fileevent.NewService(db, logger)
+		scanRunService       = scanrun.NewService(db, logger)
+		eventStreamService   = eventstream.NewService(db, logger)
+		healthService        = health.NewService(db, logger)
+		auditLogService      = auditlog.NewService(db, logger)
+		backupService        = backupS.NewService(db, logger)
+		securityService      = security.NewService(db, logger)
+		shareService         = share.NewService(db, logger)
 	)
 
-	openapiRouter := engine.Group("/api")
+	// 所有路由都挂在 basePath 下，部署在子路径时无需代理改写路径
+	root := engine.Group(config.BasePath)
+
+	root.GET("/metrics", metrics.Handler())
+	root.GET("/healthz", healthService.Healthz())
+	root.GET("/readyz", healthService.Readyz())
+
+	openapiRouter := root.Group("/api", authguard.IPFilter(models.IPRuleScopeAPI))
 	userRouter := openapiRouter.Group("/user")
 	{
 		userRouter.POST("/login", userService.Login())
 		userRouter.POST("/refresh_token", userService.RefreshToken())
+		userRouter.POST("/login/two_factor", userService.LoginTwoFactor())
+		userRouter.POST("/login/two_factor/setup", userService.LoginTwoFactorSetup())
 
 		userRouter.POST("/add", userService.AuthMiddleware(models.PermissionAdmin), userService.Add())
 		userRouter.POST("/del", userService.AuthMiddleware(models.PermissionAdmin), userService.Del())
@@ -58,6 +98,15 @@ func StartHTTPServer() error {
 
 		userRouter.GET("/info", userService.AuthMiddleware(models.PermissionBase), userService.Info())
 		userRouter.POST("/modify_own_pass", userService.AuthMiddleware(models.PermissionBase), userService.ModifyOwnPass())
+		userRouter.POST("/two_factor/setup", userService.AuthMiddleware(models.PermissionBase), userService.TwoFactorSetup())
+		userRouter.POST("/two_factor/enable", userService.AuthMiddleware(models.PermissionBase), userService.TwoFactorEnable())
+		userRouter.POST("/two_factor/disable", userService.AuthMiddleware(models.PermissionBase), userService.TwoFactorDisable())
+		userRouter.POST("/two_factor/recovery_codes", userService.AuthMiddleware(models.PermissionBase), userService.TwoFactorRecoveryCodes())
+		userRouter.POST("/two_factor/reset", userService.AuthMiddleware(models.PermissionAdmin), userService.TwoFactorReset())
+		userRouter.POST("/logout", userService.AuthMiddleware(models.PermissionBase), userService.Logout())
+		userRouter.GET("/sessions", userService.AuthMiddleware(models.PermissionBase), userService.Sessions())
+		userRouter.POST("/session/revoke", userService.AuthMiddleware(models.PermissionBase), userService.SessionRevoke())
+		userRouter.GET("/session/list", userService.AuthMiddleware(models.PermissionAdmin), userService.SessionList())
 	}
 
 	userGroupRouter := openapiRouter.Group("/user_group", userService.AuthMiddleware(models.PermissionAdmin))
@@ -80,24 +129,89 @@ func StartHTTPServer() error {
 		cloudTokenRouter.POST("/username_login", cloudTokenService.UsernameLogin())
 	}
 
+	notifierRouter := openapiRouter.Group("/notifier", userService.AuthMiddleware(models.PermissionAdmin))
+	{
+		notifierRouter.GET("/list", notifierService.List())
+		notifierRouter.POST("/add", notifierService.Add())
+		notifierRouter.POST("/update", notifierService.Update())
+		notifierRouter.POST("/delete", notifierService.Delete())
+		notifierRouter.POST("/test", notifierService.Test())
+	}
+
+	webhookRouter := openapiRouter.Group("/webhook", userService.AuthMiddleware(models.PermissionAdmin))
+	{
+		webhookRouter.GET("/list", webhookService.List())
+		webhookRouter.POST("/add", webhookService.Add())
+		webhookRouter.POST("/update", webhookService.Update())
+		webhookRouter.POST("/delete", webhookService.Delete())
+		webhookRouter.POST("/test", webhookService.Test())
+	}
+
+	scanRunRouter := openapiRouter.Group("/scan_run", userService.AuthMiddleware(models.PermissionAdmin))
+	{
+		scanRunRouter.GET("/list", scanRunService.List())
+		scanRunRouter.GET("/last_success", scanRunService.LastSuccess())
+	}
+
+	auditLogRouter := openapiRouter.Group("/audit_log", userService.AuthMiddleware(models.PermissionAdmin))
+	{
+		auditLogRouter.GET("/list", auditLogService.List())
+		auditLogRouter.GET("/export", auditLogService.Export())
+	}
+
+	backupRouter := openapiRouter.Group("/backup", userService.AuthMiddleware(models.PermissionAdmin))
+	{
+		backupRouter.POST("/export", backupService.Export())
+		backupRouter.POST("/import", backupService.Import())
+		backupRouter.GET("/snapshots", backupService.SnapshotList())
+		backupRouter.POST("/snapshot", backupService.Snapshot())
+	}
+
+	securityRouter := openapiRouter.Group("/security", userService.AuthMiddleware(models.PermissionAdmin))
+	{
+		securityRouter.GET("/failures", securityService.FailureList())
+		securityRouter.GET("/locks", securityService.LockList())
+		securityRouter.POST("/unlock", securityService.Unlock())
+		securityRouter.GET("/ip_rule/list", securityService.IPRuleList())
+		securityRouter.POST("/ip_rule/save", securityService.IPRuleSave())
+		securityRouter.POST("/ip_rule/delete", securityService.IPRuleDelete())
+	}
+
+	shareLinkRouter := openapiRouter.Group("/share_link", userService.AuthMiddleware(models.PermissionBase))
+	{
+		shareLinkRouter.GET("/list", shareService.List())
+		shareLinkRouter.POST("/save", shareService.Save())
+		shareLinkRouter.POST("/delete", shareService.Delete())
+	}
+
+	// 分享链接的匿名访问入口，提取码验证后凭 token 浏览和下载
+	shareRouter := openapiRouter.Group("/share/:code")
+	{
+		shareRouter.GET("", shareService.LinkMiddleware(false), shareService.Info())
+		shareRouter.POST("/verify", shareService.LinkMiddleware(false), shareService.Verify())
+		shareRouter.GET("/browse", shareService.LinkMiddleware(true), shareService.Browse())
+		shareRouter.GET("/preview", shareService.LinkMiddleware(true), shareService.FileMiddleware(false), universalFsService.ServeFile())
+		shareRouter.GET("/download", shareService.LinkMiddleware(true), shareService.FileMiddleware(true), universalFsService.ServeFile())
+	}
+
+	// EventSource 无法设置 Authorization 头，先凭访问Token换取短期凭证，再带在 URL 中建立连接
+	openapiRouter.GET("/event_stream", userService.StreamAuthMiddleware(models.PermissionAdmin), eventStreamService.Stream())
+	openapiRouter.POST("/event_stream/ticket", userService.AuthMiddleware(models.PermissionAdmin), userService.StreamTicket())
+
+	openapiRouter.GET("/file_event/feed", fileEventService.Feed())
+	fileEventRouter := openapiRouter.Group("/file_event", userService.AuthMiddleware(models.PermissionBase))
+	{
+		fileEventRouter.GET("/recent", fileEventService.Recent())
+		fileEventRouter.GET("/feed_url", fileEventService.FeedURL())
+		fileEventRouter.POST("/feed_url/revoke", fileEventService.RevokeFeedURL())
+	}
+
 	openapiRouter.GET("/setting/get", settingService.Get())
 	settingRouter := openapiRouter.Group("/setting", userService.AuthMiddleware(models.PermissionAdmin))
 	{
-		settingRouter.POST("/modify_name", settingService.ModifyName())
+		settingRouter.GET("/schema", settingService.Schema())
+		settingRouter.PATCH("", settingService.Patch())
 		settingRouter.POST("/refresh_key", settingService.RefreshKey())
-		settingRouter.POST("/toggle_auth", settingService.ToggleAuth())
-		settingRouter.POST("/toggle_local_proxy", settingService.ToggleLocalProxy())
-		settingRouter.POST("/toggle_multiple_stream", settingService.ToggleMultipleStream())
-		settingRouter.POST("/modify_base_url", settingService.ModifyBaseURL())
-		settingRouter.POST("/toggle_enable_top_file_auto_refresh", settingService.ToggleEnableTopFileAutoRefresh())
-		settingRouter.POST("/modify_job_thread_count", settingService.ModifyJobThreadCount())
-		settingRouter.POST("/modify_auto_refresh_minutes", settingService.ModifyAutoRefreshMinutes())
-		settingRouter.POST("/modify_multiple_stream_thread_count", settingService.ModifyMultipleStreamThreadCount())
-		settingRouter.POST("/modify_multiple_stream_chunk_size", settingService.ModifyMultipleStreamChunkSize())
-		settingRouter.POST("/toggle_strm_file_enable", settingService.ToggleStrmFileEnable())
-		settingRouter.POST("/modify_strm_support_file_ext_list", settingService.ModifyStrmSupportFileExtList())
-		settingRouter.POST("/toggle_link_file_auto_delete", settingService.ToggleLinkFileAutoDelete())
-		settingRouter.POST("/modify_strm_base_url", settingService.ModifyStrmBaseURL())
 
 		openapiRouter.POST("/setting/init_system", settingService.InitSystem())
 	}
@@ -108,10 +222,13 @@ func StartHTTPServer() error {
 		storageRouter.POST("/pre_add", storageService.PreAdd())
 		storageRouter.POST("/delete", storageService.Delete())
 		storageRouter.POST("/modify_token", storageService.ModifyToken())
+		storageRouter.POST("/modify_token_pool", storageService.ModifyTokenPool())
+		storageRouter.POST("/modify_mirror_group", storageService.ModifyMirrorGroup())
 		storageRouter.POST("/batch_bind_token", storageService.BatchBindToken())
 		storageRouter.GET("/list", storageService.List())
 		storageRouter.POST("/toggle_auto_scan", storageService.ToggleAutoScan())
 		storageRouter.POST("/scan_top", storageService.ScanTop())
+		storageRouter.POST("/save_share", storageService.SaveShare())
 		storageBridgeRouter := storageRouter.Group("/bridge")
 		{
 			storageBridgeRouter.GET("/get_person_nodes", storageBridgeService.GetPersonNodes())
@@ -128,6 +245,18 @@ func StartHTTPServer() error {
 		advancedOpsRouter.POST("/rebuild_strm", advancedOpsService.RebuildStrm())
 		advancedOpsRouter.POST("/clear_media", advancedOpsService.ClearMedia())
 		advancedOpsRouter.GET("/bus_detail", advancedOpsService.BusDetail())
+		advancedOpsRouter.GET("/duplicates", advancedOpsService.Duplicates())
+		advancedOpsRouter.POST("/rebuild_search_index", advancedOpsService.RebuildSearchIndex())
+	}
+
+	trafficRouter := openapiRouter.Group("/traffic", userService.AuthMiddleware(models.PermissionAdmin))
+	{
+		trafficRouter.GET("/limit/list", trafficService.LimitList())
+		trafficRouter.POST("/limit/save", trafficService.LimitSave())
+		trafficRouter.POST("/limit/delete", trafficService.LimitDelete())
+		trafficRouter.GET("/usage", trafficService.UsageReport())
+		trafficRouter.GET("/sources", trafficService.SourceReport())
+		trafficRouter.GET("/streams", trafficService.Streams())
 	}
 
 	{
@@ -138,10 +267,18 @@ func StartHTTPServer() error {
 
 		handler := []gin.HandlerFunc{
 			userService.BasicAuthMiddleware(models.PermissionDavRead),
+			metrics.DavMiddleware(),
 			universalFsService.DavMiddleware(),
 			universalFsService.BaseMiddleware(),
 		}
 
+		// 先校验客户端证书，没有证书的连接无法尝试密码
+		if config.EnableDavMTLS() {
+			handler = append([]gin.HandlerFunc{davClientCertMiddleware()}, handler...)
+		}
+
+		handler = append([]gin.HandlerFunc{authguard.IPFilter(models.IPRuleScopeDav)}, handler...)
+
 		registry := []struct {
 			path     string
 			prefix   string
@@ -164,47 +301,98 @@ func StartHTTPServer() error {
 
 		for _, method := range davMethods {
 			for _, r := range registry {
-				engine.Handle(method, r.path, append(r.handlers, universalFsService.Open(r.prefix, r.format))...)
+				root.Handle(method, r.path, append(r.handlers, universalFsService.Open(r.prefix, r.format))...)
 			}
 		}
 
 		openapiRouter.GET("/file_download", universalFsService.FileDownload())
 	}
 
-	{
-		staticFS, ok := embed.StaticFS()
-		if ok {
-			assetsFS, _ := fs.Sub(staticFS, "assets")
+	if staticFS, ok := embed.StaticFS(); ok {
+		registerStatic(engine, root, staticFS, config.BasePath)
+	}
 
-			engine.StaticFS("/assets", http.FS(assetsFS))
+	logger.Info("start http server",
+		zap.String("listen", config.GetListen()),
+		zap.Bool("tls", config.EnableTLS()),
+		zap.Bool("davMTLS", config.EnableDavMTLS()),
+		zap.String("unixSocket", config.UnixSocket),
+	)
+
+	server := newHTTPServer(engine)
+	server.Addr = config.GetListen()
 
-			engine.NoRoute(func(c *gin.Context) {
-				if strings.HasPrefix(c.Request.URL.Path, "/api") {
-					c.Status(404)
+	servers := []*http.Server{server}
+
+	if config.EnableTLS() {
+		var caFile string
+		if config.EnableDavMTLS() {
+			caFile = config.TLSClientCA
+		}
 
-					return
-				}
+		reloader, err := newCertReloader(config.TLSCert, config.TLSKey, caFile, logger)
+		if err != nil {
+			return err
+		}
 
-				// 返回 index.html
-				file, err := staticFS.Open("index.html")
-				if err != nil {
-					c.Status(404)
+		go reloader.watch(ctx)
 
-					return
-				}
+		// 自定义 TLSConfig 时仍会协商 HTTP/2
+		server.TLSConfig = reloader.TLSConfig()
+	}
 
-				defer file.Close()
+	errCh := make(chan error, 2)
+	go func() {
+		if config.EnableTLS() {
+			errCh <- server.ListenAndServeTLS("", "")
 
-				stat, _ := file.Stat()
-				c.Header("Content-Type", "text/html")
-				c.DataFromReader(200, stat.Size(), "text/html", file, nil)
-			})
+			return
 		}
+
+		errCh <- server.ListenAndServe()
+	}()
+
+	if config.UnixSocket != "" {
+		ln, err := listenUnix(config.UnixSocket)
+		if err != nil {
+			_ = server.Close()
+
+			return err
+		}
+
+		unixServer := newHTTPServer(unixHandler(engine))
+		servers = append(servers, unixServer)
+
+		go func() {
+			errCh <- unixServer.Serve(ln)
+		}()
 	}
 
-	logger.Info("start http server",
-		zap.Int("port", config.Port),
-	)
+	select {
+	case err := <-errCh:
+		for _, srv := range servers {
+			_ = srv.Close()
+		}
+
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info("shutting down http server")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.GetShutdownTimeout())
+	defer cancel()
+
+	var shutdownErr error
+
+	for _, srv := range servers {
+		// 超时仍未结束的请求（如大文件下载）强制断开
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			_ = srv.Close()
+
+			shutdownErr = err
+		}
+	}
 
-	return engine.Run(fmt.Sprintf(":%d", config.Port))
+	return shutdownErr
 }
@@ -0,0 +1,58 @@
+package router
+
+import (
+	"bytes"
+	"html"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/baseurl"
+)
+
+// registerStatic 托管前端页面：/assets 下为静态资源，其余未匹配的路径返回 index.html 交给前端路由
+func registerStatic(engine *gin.Engine, root *gin.RouterGroup, staticFS fs.FS, basePath string) {
+	assetsFS, _ := fs.Sub(staticFS, "assets")
+
+	root.StaticFS("/assets", http.FS(assetsFS))
+
+	index, err := fs.ReadFile(staticFS, "index.html")
+	if err != nil {
+		return
+	}
+
+	engine.NoRoute(func(c *gin.Context) {
+		p := c.Request.URL.Path
+
+		// 访问根路径时跳转到子路径
+		if basePath != "" && (p == "/" || p == "") {
+			c.Redirect(http.StatusFound, basePath+"/")
+
+			return
+		}
+
+		if basePath != "" && p != basePath && !strings.HasPrefix(p, basePath+"/") {
+			c.Status(http.StatusNotFound)
+
+			return
+		}
+
+		if strings.HasPrefix(strings.TrimPrefix(p, basePath), "/api") {
+			c.Status(http.StatusNotFound)
+
+			return
+		}
+
+		c.Data(http.StatusOK, "text/html; charset=utf-8", injectBasePath(index, baseurl.Prefix(c)))
+	})
+}
+
+// injectBasePath 在 index.html 中写入 <base> 和 window.__BASE_PATH__，前端资源以相对路径引用，由此定位到子路径
+func injectBasePath(index []byte, prefix string) []byte {
+	snippet := `<base href="` + html.EscapeString(prefix+"/") + `">` +
+		`<script>window.__BASE_PATH__=` + strconv.Quote(prefix) + `</script>`
+
+	return bytes.Replace(index, []byte("<head>"), []byte("<head>"+snippet), 1)
+}
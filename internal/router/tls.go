@@ -0,0 +1,171 @@
+package router
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/xxcheng123/cloudpan189-share/configs"
+	"go.uber.org/zap"
+)
+
+// certWatchInterval 检查证书文件是否更新的间隔
+const certWatchInterval = time.Second * 10
+
+// certReloader 持有当前的证书和客户端 CA，文件更新后重新加载，续期证书无需重启服务
+type certReloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+	logger   *zap.Logger
+
+	mu      sync.RWMutex
+	config  *tls.Config
+	modTime time.Time
+}
+
+func newCertReloader(certFile, keyFile, caFile string, logger *zap.Logger) (*certReloader, error) {
+	r := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+		logger:   logger,
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *certReloader) load() error {
+	modTime := r.latestModTime()
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return errors.Wrap(err, "加载证书失败")
+	}
+
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+
+	if r.caFile != "" {
+		data, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return errors.Wrap(err, "读取客户端 CA 失败")
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return errors.New("客户端 CA 文件中没有有效的证书")
+		}
+
+		// 只有 WebDAV 强制要求客户端证书，网页和接口不出示证书也能访问
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+		config.ClientCAs = pool
+	}
+
+	r.mu.Lock()
+	r.config = config
+	r.modTime = modTime
+	r.mu.Unlock()
+
+	return nil
+}
+
+// latestModTime 证书、私钥和 CA 文件中最晚的修改时间
+func (r *certReloader) latestModTime() time.Time {
+	var latest time.Time
+
+	for _, file := range []string{r.certFile, r.keyFile, r.caFile} {
+		if file == "" {
+			continue
+		}
+
+		if info, err := os.Stat(file); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest
+}
+
+// watch 定时检查文件修改时间，变化后重新加载，加载失败时继续使用原证书
+func (r *certReloader) watch(ctx context.Context) {
+	ticker := time.NewTicker(certWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		r.mu.RLock()
+		modTime := r.modTime
+		r.mu.RUnlock()
+
+		if r.latestModTime().Equal(modTime) {
+			continue
+		}
+
+		if err := r.load(); err != nil {
+			r.logger.Error("重新加载证书失败，继续使用原证书", zap.Error(err))
+
+			continue
+		}
+
+		r.logger.Info("证书已重新加载", zap.String("cert", r.certFile))
+	}
+}
+
+// TLSConfig 握手时取当前的证书配置
+func (r *certReloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+
+			return r.config, nil
+		},
+	}
+}
+
+// davClientCertMiddleware 要求 TLS 连接出示已通过校验的客户端证书，非 TLS 请求一律拒绝；
+// 只有开启 unixSocketTrustProxy 时，Unix socket 上的请求交由本机代理校验
+func davClientCertMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.TLS == nil {
+			if fromUnixSocket(ctx.Request) && configs.GetConfig().UnixSocketTrustProxy {
+				ctx.Next()
+
+				return
+			}
+
+			ctx.AbortWithStatus(http.StatusForbidden)
+
+			return
+		}
+
+		if len(ctx.Request.TLS.VerifiedChains) == 0 {
+			ctx.AbortWithStatus(http.StatusForbidden)
+
+			return
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,278 @@
+// Package search 基于 SQLite FTS5 的文件名/路径全文索引
+//
+// FTS5 自带的 unicode61 分词器会把连续的中文当成一个词，这里在写入和查询前
+// 先把中日韩字符拆成单字，查询时再用短语匹配相邻的字，从而支持任意长度的中文子串搜索。
+package search
+
+import (
+	"context"
+	"path"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/bytedance/gopkg/util/gopool"
+	"github.com/pkg/errors"
+	"github.com/xxcheng123/cloudpan189-share/configs"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// TableName 全文索引表，rowid 与 virtual_files.id 一致
+const TableName = "file_search"
+
+const rebuildBatchSize = 500
+
+var ErrRebuilding = errors.New("搜索索引正在重建")
+
+var onceLoad sync.Once
+
+var singletonIndexer *indexer
+
+type indexer struct {
+	db      *gorm.DB
+	logger  *zap.Logger
+	mu      sync.Mutex
+	rebuild sync.Mutex
+}
+
+func Init() {
+	onceLoad.Do(func() {
+		singletonIndexer = &indexer{
+			db:     configs.DB(),
+			logger: configs.Logger().With(zap.String("module", "search")),
+		}
+
+		if err := singletonIndexer.migrate(); err != nil {
+			panic(err)
+		}
+
+		var count int64
+		if err := singletonIndexer.db.Table(TableName).Count(&count).Error; err == nil && count == 0 {
+			gopool.Go(func() {
+				_, _ = Rebuild(context.Background())
+			})
+		}
+	})
+}
+
+func (i *indexer) migrate() error {
+	if err := i.db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS ` + TableName + ` USING fts5(
+	name,
+	path,
+	full_path UNINDEXED,
+	top_id UNINDEXED,
+	tokenize = 'unicode61 remove_diacritics 2'
+)`).Error; err != nil {
+		return err
+	}
+
+	// 文件删除时同步删除索引，无论从哪条路径删除
+	return i.db.Exec(`CREATE TRIGGER IF NOT EXISTS ` + TableName + `_delete AFTER DELETE ON virtual_files BEGIN
+	DELETE FROM ` + TableName + ` WHERE rowid = old.id;
+END`).Error
+}
+
+// Index 为文件建立或更新索引，files 中的父级目录需已入库
+func Index(ctx context.Context, files ...*models.VirtualFile) {
+	if singletonIndexer == nil || len(files) == 0 {
+		return
+	}
+
+	singletonIndexer.index(ctx, files, make(map[int64]*models.VirtualFile))
+}
+
+// Rebuild 清空并重建全部索引
+func Rebuild(ctx context.Context) (int64, error) {
+	if singletonIndexer == nil {
+		return 0, nil
+	}
+
+	return singletonIndexer.rebuildAll(ctx)
+}
+
+func (i *indexer) index(ctx context.Context, files []*models.VirtualFile, parents map[int64]*models.VirtualFile) {
+	type row struct {
+		id       int64
+		name     string
+		fullPath string
+		topId    int64
+	}
+
+	var rows = make([]*row, 0, len(files))
+	for _, file := range files {
+		fullPath, topId, err := ResolvePath(ctx, i.db, file, parents)
+		if err != nil {
+			i.logger.Warn("计算索引路径失败", zap.Int64("fileId", file.ID), zap.Error(err))
+
+			continue
+		}
+
+		rows = append(rows, &row{id: file.ID, name: file.Name, fullPath: fullPath, topId: topId})
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if err := i.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, r := range rows {
+			if err := tx.Exec(`DELETE FROM `+TableName+` WHERE rowid = ?`, r.id).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Exec(`INSERT INTO `+TableName+` (rowid, name, path, full_path, top_id) VALUES (?, ?, ?, ?, ?)`,
+				r.id, Tokenize(r.name), Tokenize(path.Dir(r.fullPath)), r.fullPath, r.topId).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		i.logger.Error("写入搜索索引失败", zap.Int("count", len(rows)), zap.Error(err))
+	}
+}
+
+// ResolvePath 计算文件完整路径及所属挂载点ID，parents 用于缓存已查询的父级目录
+func ResolvePath(ctx context.Context, db *gorm.DB, file *models.VirtualFile, parents map[int64]*models.VirtualFile) (string, int64, error) {
+	var (
+		names = []string{file.Name}
+		topId int64
+		cur   = file
+	)
+
+	for {
+		if cur.IsTop == 1 {
+			topId = cur.ID
+		}
+
+		if cur.ParentId <= 0 {
+			break
+		}
+
+		parent, ok := parents[cur.ParentId]
+		if !ok {
+			parent = new(models.VirtualFile)
+			if err := db.WithContext(ctx).Select("id", "parent_id", "name", "is_top").Where("id = ?", cur.ParentId).First(parent).Error; err != nil {
+				return "", 0, err
+			}
+
+			parents[parent.ID] = parent
+		}
+
+		names = append(names, parent.Name)
+		cur = parent
+	}
+
+	var b strings.Builder
+	for idx := len(names) - 1; idx >= 0; idx-- {
+		b.WriteString("/")
+		b.WriteString(names[idx])
+	}
+
+	return b.String(), topId, nil
+}
+
+func (i *indexer) rebuildAll(ctx context.Context) (int64, error) {
+	if !i.rebuild.TryLock() {
+		return 0, ErrRebuilding
+	}
+
+	defer i.rebuild.Unlock()
+
+	i.logger.Info("开始重建搜索索引")
+
+	i.mu.Lock()
+	err := i.db.WithContext(ctx).Exec(`DELETE FROM ` + TableName).Error
+	i.mu.Unlock()
+
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		count   int64
+		lastId  int64
+		parents = make(map[int64]*models.VirtualFile)
+	)
+
+	for {
+		var files = make([]*models.VirtualFile, 0, rebuildBatchSize)
+		if err = i.db.WithContext(ctx).
+			Select("id", "parent_id", "name", "is_top").
+			Where("id > ?", lastId).
+			Order("id ASC").
+			Limit(rebuildBatchSize).
+			Find(&files).Error; err != nil {
+			return count, err
+		}
+
+		if len(files) == 0 {
+			break
+		}
+
+		i.index(ctx, files, parents)
+
+		count += int64(len(files))
+		lastId = files[len(files)-1].ID
+	}
+
+	i.logger.Info("重建搜索索引完成", zap.Int64("count", count))
+
+	return count, nil
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
+
+// Tokenize 将中日韩字符拆成单字，其余字符按非字母数字切分
+func Tokenize(s string) string {
+	var (
+		b       strings.Builder
+		inToken bool
+	)
+
+	sep := func() {
+		if inToken {
+			b.WriteByte(' ')
+			inToken = false
+		}
+	}
+
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case isCJK(r):
+			sep()
+			b.WriteRune(r)
+			b.WriteByte(' ')
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			inToken = true
+		default:
+			sep()
+		}
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// MatchExpr 将用户输入的关键字转为 FTS5 查询表达式，空格分隔的每个词都必须命中，
+// 词内的各个单字需相邻，最后一个字按前缀匹配
+func MatchExpr(keyword string) string {
+	var terms []string
+
+	for _, field := range strings.Fields(keyword) {
+		tokens := Tokenize(field)
+		if tokens == "" {
+			continue
+		}
+
+		terms = append(terms, `"`+tokens+`"*`)
+	}
+
+	return strings.Join(terms, " AND ")
+}
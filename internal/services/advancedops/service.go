@@ -10,6 +10,8 @@ type Service interface {
 	RebuildStrm() gin.HandlerFunc
 	ClearMedia() gin.HandlerFunc
 	BusDetail() gin.HandlerFunc
+	Duplicates() gin.HandlerFunc
+	RebuildSearchIndex() gin.HandlerFunc
 }
 
 type service struct {
@@ -0,0 +1,221 @@
+package advancedops
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/duplicate"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type duplicatesRequest struct {
+	CurrentPage int    `form:"currentPage" binding:"omitempty"`
+	PageSize    int    `form:"pageSize" binding:"omitempty"`
+	MountId     int64  `form:"mountId" binding:"omitempty"` // 只看包含该挂载点文件的重复组
+	Ext         string `form:"ext" binding:"omitempty"`     // 扩展名，如 mkv
+	MinSize     int64  `form:"minSize" binding:"omitempty"` // 最小文件大小（字节）
+}
+
+type duplicateFile struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	MountId   int64  `json:"mountId"`
+	MountName string `json:"mountName"`
+	Kept      bool   `json:"kept"` // 开启隐藏重复文件时保留的那一份
+}
+
+type duplicateGroup struct {
+	Hash       string           `json:"hash"`
+	Size       int64            `json:"size"`
+	Count      int64            `json:"count"`
+	WastedSize int64            `json:"wastedSize"`
+	Files      []*duplicateFile `json:"files" gorm:"-"`
+}
+
+type duplicatesResponse struct {
+	Total           int64             `json:"total"`
+	CurrentPage     int               `json:"currentPage"`
+	PageSize        int               `json:"pageSize"`
+	TotalWastedSize int64             `json:"totalWastedSize"`
+	Data            []*duplicateGroup `json:"data"`
+}
+
+// Duplicates 按 Hash+Size 汇总跨挂载点的重复文件，按浪费空间倒序
+func (s *service) Duplicates() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(duplicatesRequest)
+		if err := ctx.ShouldBindQuery(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+
+			return
+		}
+
+		if req.CurrentPage <= 0 {
+			req.CurrentPage = 1
+		}
+
+		if req.PageSize <= 0 {
+			req.PageSize = 10
+		}
+
+		base := s.db.WithContext(ctx).Model(&models.VirtualFile{}).
+			Where("hash <> '' AND is_folder = 0 AND size > 0").
+			Where("os_type IN ?", duplicate.CloudFileOsTypes)
+
+		if req.MinSize > 0 {
+			base = base.Where("size >= ?", req.MinSize)
+		}
+
+		if ext := strings.TrimPrefix(strings.ToLower(req.Ext), "."); ext != "" {
+			base = base.Where("LOWER(name) LIKE ?", "%."+ext)
+		}
+
+		if req.MountId > 0 {
+			mountFiles := s.db.Raw(`WITH RECURSIVE tree(id) AS (
+	SELECT id FROM virtual_files WHERE id = ?
+	UNION ALL
+	SELECT v.id FROM virtual_files v JOIN tree t ON v.parent_id = t.id
+) SELECT id FROM tree`, req.MountId)
+
+			base = base.Where("hash IN (?)", s.db.Model(&models.VirtualFile{}).Select("hash").Where("id IN (?)", mountFiles))
+		}
+
+		groups := base.Session(&gorm.Session{}).
+			Select("hash, size, COUNT(*) AS count, size * (COUNT(*) - 1) AS wasted_size").
+			Group("hash, size").
+			Having("COUNT(*) > 1")
+
+		var total struct {
+			Count      int64
+			WastedSize int64
+		}
+
+		if err := s.db.WithContext(ctx).Table("(?) AS t", groups).
+			Select("COUNT(*) AS count, COALESCE(SUM(wasted_size), 0) AS wasted_size").
+			Scan(&total).Error; err != nil {
+			s.logger.Error("查询重复文件失败", zap.Error(err))
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+
+			return
+		}
+
+		var list = make([]*duplicateGroup, 0)
+		if err := groups.Order("wasted_size DESC, hash ASC").
+			Offset((req.CurrentPage - 1) * req.PageSize).
+			Limit(req.PageSize).
+			Scan(&list).Error; err != nil {
+			s.logger.Error("查询重复文件失败", zap.Error(err))
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+
+			return
+		}
+
+		resolver := &pathResolver{db: s.db, cache: make(map[int64]*models.VirtualFile)}
+
+		for _, group := range list {
+			var files = make([]*models.VirtualFile, 0)
+			if err := base.Session(&gorm.Session{}).
+				Where("hash = ? AND size = ?", group.Hash, group.Size).
+				Order("id ASC").
+				Find(&files).Error; err != nil {
+				s.logger.Error("查询重复文件失败", zap.String("hash", group.Hash), zap.Error(err))
+
+				continue
+			}
+
+			hidden, err := duplicate.FindHidden(ctx, s.db, files, nil)
+			if err != nil {
+				s.logger.Warn("计算保留文件失败", zap.String("hash", group.Hash), zap.Error(err))
+			}
+
+			for _, file := range files {
+				p, top := resolver.resolve(ctx, file)
+
+				item := &duplicateFile{
+					ID:   file.ID,
+					Name: file.Name,
+					Path: p,
+				}
+
+				if top != nil {
+					item.MountId = top.ID
+					item.MountName = top.Name
+				}
+
+				if hidden != nil {
+					_, isHidden := hidden[file.ID]
+					item.Kept = !isHidden
+				}
+
+				group.Files = append(group.Files, item)
+			}
+		}
+
+		ctx.JSON(http.StatusOK, &duplicatesResponse{
+			Total:           total.Count,
+			CurrentPage:     req.CurrentPage,
+			PageSize:        req.PageSize,
+			TotalWastedSize: total.WastedSize,
+			Data:            list,
+		})
+	}
+}
+
+// pathResolver 计算文件的完整路径及所属挂载点，缓存查询过的父级目录
+type pathResolver struct {
+	db    *gorm.DB
+	cache map[int64]*models.VirtualFile
+}
+
+func (r *pathResolver) resolve(ctx context.Context, file *models.VirtualFile) (string, *models.VirtualFile) {
+	var (
+		paths = []string{file.Name}
+		top   *models.VirtualFile
+		cur   = file
+	)
+
+	for {
+		if cur.IsTop == 1 {
+			top = cur
+		}
+
+		if cur.ParentId <= 0 {
+			break
+		}
+
+		parent, ok := r.cache[cur.ParentId]
+		if !ok {
+			parent = new(models.VirtualFile)
+			if err := r.db.WithContext(ctx).Where("id", cur.ParentId).First(parent).Error; err != nil {
+				break
+			}
+
+			r.cache[cur.ParentId] = parent
+		}
+
+		paths = append(paths, parent.Name)
+		cur = parent
+	}
+
+	paths = append(paths, "/")
+
+	slices.Reverse(paths)
+
+	return path.Join(paths...), top
+}
@@ -0,0 +1,28 @@
+package advancedops
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bytedance/gopkg/util/gopool"
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/search"
+	"github.com/xxcheng123/cloudpan189-share/internal/types"
+	"go.uber.org/zap"
+)
+
+// RebuildSearchIndex 后台重建文件搜索索引
+func (s *service) RebuildSearchIndex() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		gopool.Go(func() {
+			if _, err := search.Rebuild(context.Background()); err != nil {
+				s.logger.Error("重建搜索索引失败", zap.Error(err))
+			}
+		})
+
+		ctx.JSON(http.StatusOK, types.SuccessResponse{
+			Code:    http.StatusOK,
+			Message: "已开始重建搜索索引",
+		})
+	}
+}
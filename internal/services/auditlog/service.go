@@ -0,0 +1,25 @@
+package auditlog
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	List() gin.HandlerFunc
+	Export() gin.HandlerFunc
+}
+
+type service struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+// NewService 创建审计日志服务
+func NewService(db *gorm.DB, logger *zap.Logger) Service {
+	return &service{
+		db:     db,
+		logger: logger,
+	}
+}
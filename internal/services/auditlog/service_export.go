@@ -0,0 +1,84 @@
+package auditlog
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 每批从数据库读取的条数，导出不加载全部数据到内存
+const exportBatchSize = 500
+
+// Export 按筛选条件导出 CSV，带 BOM 便于 Excel 识别中文
+func (s *service) Export() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		f := new(filter)
+		if err := ctx.ShouldBindQuery(f); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		filename := fmt.Sprintf("audit_logs_%s.csv", time.Now().Format("20060102150405"))
+
+		ctx.Header("Content-Type", "text/csv; charset=utf-8")
+		ctx.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+		ctx.Status(http.StatusOK)
+
+		_, _ = ctx.Writer.WriteString("\xEF\xBB\xBF")
+
+		w := csv.NewWriter(ctx.Writer)
+		_ = w.Write([]string{"id", "time", "user_id", "username", "ip", "action", "target_type", "target_id", "target_name", "before", "after", "request_id"})
+
+		var list []*models.AuditLog
+		// FindInBatches 按主键升序分批，导出结果为时间正序
+		err := s.query(ctx, f).FindInBatches(&list, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			for _, item := range list {
+				_ = w.Write([]string{
+					strconv.FormatInt(item.ID, 10),
+					item.CreatedAt.Format(time.DateTime),
+					strconv.FormatInt(item.UserId, 10),
+					csvSafe(item.Username),
+					item.IP,
+					item.Action,
+					item.TargetType,
+					csvSafe(item.TargetId),
+					csvSafe(item.TargetName),
+					string(item.Before),
+					string(item.After),
+					item.RequestId,
+				})
+			}
+
+			w.Flush()
+
+			return w.Error()
+		}).Error
+
+		w.Flush()
+
+		// 响应头已发出，出错只能记日志
+		if err != nil {
+			s.logger.Error("导出审计日志失败", zap.Error(err))
+		}
+	}
+}
+
+// csvSafe 以公式字符开头的内容加单引号，避免在表格软件中被当作公式执行
+func csvSafe(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+
+	return v
+}
@@ -0,0 +1,121 @@
+package auditlog
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"gorm.io/gorm"
+)
+
+// filter 列表与导出共用的筛选条件
+type filter struct {
+	UserId     int64  `form:"userId" binding:"omitempty"`
+	Username   string `form:"username" binding:"omitempty,max=255"`
+	Action     string `form:"action" binding:"omitempty,max=64"`
+	TargetType string `form:"targetType" binding:"omitempty,max=32"`
+	TargetId   string `form:"targetId" binding:"omitempty,max=255"`
+	StartDay   string `form:"startDay" binding:"omitempty,datetime=2006-01-02"`
+	EndDay     string `form:"endDay" binding:"omitempty,datetime=2006-01-02"` // 包含当天
+}
+
+func (s *service) query(ctx context.Context, f *filter) *gorm.DB {
+	query := s.db.WithContext(ctx).Model(&models.AuditLog{})
+
+	if f.UserId > 0 {
+		query = query.Where("user_id = ?", f.UserId)
+	}
+
+	if f.Username != "" {
+		query = query.Where("username = ?", f.Username)
+	}
+
+	if f.Action != "" {
+		query = query.Where("action = ?", f.Action)
+	}
+
+	if f.TargetType != "" {
+		query = query.Where("target_type = ?", f.TargetType)
+	}
+
+	if f.TargetId != "" {
+		query = query.Where("target_id = ?", f.TargetId)
+	}
+
+	if start, err := time.ParseInLocation(time.DateOnly, f.StartDay, time.Local); err == nil {
+		query = query.Where("created_at >= ?", start)
+	}
+
+	if end, err := time.ParseInLocation(time.DateOnly, f.EndDay, time.Local); err == nil {
+		query = query.Where("created_at < ?", end.AddDate(0, 0, 1))
+	}
+
+	return query
+}
+
+type listRequest struct {
+	filter
+	CurrentPage int `form:"currentPage" binding:"omitempty"`
+	PageSize    int `form:"pageSize" binding:"omitempty,max=100"`
+}
+
+type listResponse struct {
+	Total       int64              `json:"total"`
+	CurrentPage int                `json:"currentPage"`
+	PageSize    int                `json:"pageSize"`
+	Data        []*models.AuditLog `json:"data"`
+}
+
+// List 审计日志，按时间倒序
+func (s *service) List() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(listRequest)
+		if err := ctx.ShouldBindQuery(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		if req.CurrentPage <= 0 {
+			req.CurrentPage = 1
+		}
+
+		if req.PageSize <= 0 {
+			req.PageSize = 10
+		}
+
+		query := s.query(ctx, &req.filter)
+
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+			return
+		}
+
+		var list = make([]*models.AuditLog, 0)
+		if err := query.Order("id DESC").
+			Offset((req.CurrentPage - 1) * req.PageSize).
+			Limit(req.PageSize).
+			Find(&list).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, &listResponse{
+			Total:       count,
+			CurrentPage: req.CurrentPage,
+			PageSize:    req.PageSize,
+			Data:        list,
+		})
+	}
+}
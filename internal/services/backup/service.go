@@ -0,0 +1,27 @@
+package backup
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	Export() gin.HandlerFunc
+	Import() gin.HandlerFunc
+	SnapshotList() gin.HandlerFunc
+	Snapshot() gin.HandlerFunc
+}
+
+type service struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+// NewService 创建配置备份服务
+func NewService(db *gorm.DB, logger *zap.Logger) Service {
+	return &service{
+		db:     db,
+		logger: logger,
+	}
+}
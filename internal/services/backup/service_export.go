@@ -0,0 +1,69 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
+	backupCore "github.com/xxcheng123/cloudpan189-share/internal/backup"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+)
+
+type exportRequest struct {
+	Passphrase string `json:"passphrase" binding:"required,min=8"`
+}
+
+// Export 导出配置归档，云盘令牌和用户密码使用 passphrase 加密
+func (s *service) Export() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(exportRequest)
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		archive, err := backupCore.Export(ctx, s.db, req.Passphrase)
+		if err != nil {
+			s.logger.Error("导出配置失败", zap.Error(err))
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "导出配置失败",
+			})
+
+			return
+		}
+
+		data, err := json.MarshalIndent(archive, "", "  ")
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  err.Error(),
+			})
+
+			return
+		}
+
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditBackupExport,
+			TargetType: audit.TargetBackup,
+			After: gin.H{
+				"cloudTokens": len(archive.CloudTokens),
+				"users":       len(archive.Users),
+				"mounts":      len(archive.Mounts),
+			},
+		})
+
+		filename := fmt.Sprintf("share_config_%s.json", time.Now().Format("20060102150405"))
+
+		ctx.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+		ctx.Data(http.StatusOK, "application/json; charset=utf-8", data)
+	}
+}
@@ -0,0 +1,106 @@
+package backup
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
+	backupCore "github.com/xxcheng123/cloudpan189-share/internal/backup"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/services/user"
+	"go.uber.org/zap"
+)
+
+// 归档文件大小上限
+const maxArchiveSize = 32 << 20
+
+type importRequest struct {
+	Passphrase string `form:"passphrase" binding:"required"`
+	Mode       string `form:"mode" binding:"omitempty,oneof=merge replace"`
+	DryRun     bool   `form:"dryRun"`
+}
+
+// Import 导入配置归档（multipart 字段 file），dryRun 时只返回变更报告
+func (s *service) Import() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(importRequest)
+		if err := ctx.ShouldBind(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		fh, err := ctx.FormFile("file")
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  "请上传归档文件",
+			})
+			return
+		}
+
+		if fh.Size > maxArchiveSize {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  "归档文件过大",
+			})
+			return
+		}
+
+		file, err := fh.Open()
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		defer file.Close()
+
+		archive := new(backupCore.Archive)
+		if err = json.NewDecoder(file).Decode(archive); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  "归档文件格式错误：" + err.Error(),
+			})
+			return
+		}
+
+		report, err := backupCore.Import(ctx, s.db, archive, backupCore.ImportOptions{
+			Passphrase: req.Passphrase,
+			Mode:       req.Mode,
+			DryRun:     req.DryRun,
+		})
+		if err != nil {
+			s.logger.Warn("导入配置失败", zap.Error(err))
+
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+
+			return
+		}
+
+		if !report.DryRun {
+			// 用户可能被替换或删除，缓存中的 Basic 认证不能继续生效
+			user.FlushCredentialCache()
+
+			audit.Record(ctx, audit.Entry{
+				Action:     models.AuditBackupImport,
+				TargetType: audit.TargetBackup,
+				TargetName: fh.Filename,
+				After: gin.H{
+					"mode":    report.Mode,
+					"summary": report.Summary,
+				},
+			})
+		}
+
+		ctx.JSON(http.StatusOK, report)
+	}
+}
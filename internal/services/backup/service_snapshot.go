@@ -0,0 +1,51 @@
+package backup
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/configs"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
+	backupCore "github.com/xxcheng123/cloudpan189-share/internal/backup"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+)
+
+// Snapshot 立即备份一次数据库，并按 backupKeep 清理旧备份
+func (s *service) Snapshot() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		config := configs.GetConfig()
+		if config.BackupDir == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  "未配置备份目录",
+			})
+
+			return
+		}
+
+		snapshot, err := backupCore.TakeSnapshot(ctx, s.db, config.BackupDir)
+		if err != nil {
+			s.logger.Error("数据库备份失败", zap.Error(err))
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  err.Error(),
+			})
+
+			return
+		}
+
+		if _, err = backupCore.RotateSnapshots(config.BackupDir, config.BackupKeep); err != nil {
+			s.logger.Error("清理旧备份失败", zap.Error(err))
+		}
+
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditBackupSnapshot,
+			TargetType: audit.TargetBackup,
+			TargetName: snapshot.Name,
+		})
+
+		ctx.JSON(http.StatusOK, snapshot)
+	}
+}
@@ -0,0 +1,41 @@
+package backup
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/configs"
+	backupCore "github.com/xxcheng123/cloudpan189-share/internal/backup"
+)
+
+type snapshotListResponse struct {
+	Enabled bool                   `json:"enabled"` // 是否配置了 backupDir
+	Data    []*backupCore.Snapshot `json:"data"`
+}
+
+// SnapshotList 列出备份目录中的数据库快照
+func (s *service) SnapshotList() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		dir := configs.GetConfig().BackupDir
+		if dir == "" {
+			ctx.JSON(http.StatusOK, snapshotListResponse{Data: []*backupCore.Snapshot{}})
+
+			return
+		}
+
+		list, err := backupCore.ListSnapshots(dir)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  err.Error(),
+			})
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, snapshotListResponse{
+			Enabled: true,
+			Data:    list,
+		})
+	}
+}
@@ -6,7 +6,10 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/xxcheng123/cloudpan189-interface/client"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/stream"
+	"github.com/xxcheng123/cloudpan189-share/internal/tokenpool"
 )
 
 type checkQrcodeRequest struct {
@@ -37,6 +40,8 @@ func (s *service) CheckQrcode() gin.HandlerFunc {
 			return
 		}
 
+		tokenId := req.ID
+
 		if req.ID != 0 {
 			if err = s.db.WithContext(ctx).Model(&models.CloudToken{}).Where("id = ?", req.ID).Updates(map[string]interface{}{
 				"status":       1,
@@ -50,15 +55,19 @@ func (s *service) CheckQrcode() gin.HandlerFunc {
 
 				return
 			}
+
+			tokenpool.Reset(req.ID)
 		} else {
-			if err = s.db.WithContext(ctx).Model(&models.CloudToken{}).Create(&models.CloudToken{
+			m := &models.CloudToken{
 				Name:        "云盘令牌",
 				Status:      1,
 				AccessToken: resp.AccessToken,
 				ExpiresIn:   resp.ExpiresIn,
 				LoginType:   models.LoginTypeScan,
 				Addition:    map[string]interface{}{},
-			}).Error; err != nil {
+			}
+
+			if err = s.db.WithContext(ctx).Model(&models.CloudToken{}).Create(m).Error; err != nil {
 				ctx.JSON(http.StatusInternalServerError, gin.H{
 					"code": http.StatusInternalServerError,
 					"msg":  fmt.Sprintf("创建云盘令牌失败: %s", err.Error()),
@@ -66,8 +75,27 @@ func (s *service) CheckQrcode() gin.HandlerFunc {
 
 				return
 			}
+
+			tokenId = m.ID
+
+			audit.Record(ctx, audit.Entry{
+				Action:     models.AuditCloudTokenAdd,
+				TargetType: audit.TargetCloudToken,
+				TargetId:   m.ID,
+				TargetName: m.Name,
+				After: gin.H{
+					"loginType": m.LoginType,
+				},
+			})
 		}
 
+		stream.Publish(stream.TopicToken, "login", &stream.TokenLogin{
+			TokenId:   tokenId,
+			LoginType: models.LoginTypeScan,
+			Success:   true,
+			Message:   "扫码登录成功",
+		})
+
 		ctx.JSON(http.StatusOK, gin.H{
 			"code": http.StatusOK,
 			"msg":  "绑定成功",
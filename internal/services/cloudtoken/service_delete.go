@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 )
 
@@ -26,6 +27,9 @@ func (s *service) Delete() gin.HandlerFunc {
 
 		// 检查有没有被绑定
 
+		before := new(models.CloudToken)
+		s.db.WithContext(ctx).Where("id = ?", req.ID).Limit(1).Find(before)
+
 		if err := s.db.Where("id = ?", req.ID).Delete(&models.CloudToken{}).Error; err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{
 				"code": http.StatusInternalServerError,
@@ -35,6 +39,19 @@ func (s *service) Delete() gin.HandlerFunc {
 			return
 		}
 
+		// 令牌和密码不记录
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditCloudTokenDelete,
+			TargetType: audit.TargetCloudToken,
+			TargetId:   req.ID,
+			TargetName: before.Name,
+			Before: gin.H{
+				"loginType": before.LoginType,
+				"username":  before.Username,
+				"expiresIn": before.ExpiresIn,
+			},
+		})
+
 		ctx.JSON(http.StatusOK, gin.H{
 			"code": http.StatusOK,
 			"msg":  "删除成功",
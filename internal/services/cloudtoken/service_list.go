@@ -5,12 +5,18 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/tokenpool"
 )
 
 type listRequest struct {
 	Name string `form:"name" binding:"omitempty"`
 }
 
+type listItem struct {
+	*models.CloudToken
+	Health tokenpool.Health `json:"health"`
+}
+
 func (s *service) List() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var req listRequest
@@ -40,6 +46,14 @@ func (s *service) List() gin.HandlerFunc {
 			return
 		}
 
-		ctx.JSON(http.StatusOK, cloudTokens)
+		items := make([]*listItem, 0, len(cloudTokens))
+		for _, ct := range cloudTokens {
+			items = append(items, &listItem{
+				CloudToken: ct,
+				Health:     tokenpool.GetHealth(ct.ID),
+			})
+		}
+
+		ctx.JSON(http.StatusOK, items)
 	}
 }
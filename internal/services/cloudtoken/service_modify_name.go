@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 )
 
@@ -25,6 +26,9 @@ func (s *service) ModifyName() gin.HandlerFunc {
 			return
 		}
 
+		before := new(models.CloudToken)
+		s.db.WithContext(ctx).Where("id = ?", req.ID).Limit(1).Find(before)
+
 		if err := s.db.WithContext(ctx).Model(&models.CloudToken{}).Where("id = ?", req.ID).Update("name", req.Name).Error; err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{
 				"code": http.StatusInternalServerError,
@@ -34,6 +38,15 @@ func (s *service) ModifyName() gin.HandlerFunc {
 			return
 		}
 
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditCloudTokenRename,
+			TargetType: audit.TargetCloudToken,
+			TargetId:   req.ID,
+			TargetName: req.Name,
+			Before:     before.Name,
+			After:      req.Name,
+		})
+
 		ctx.JSON(http.StatusOK, gin.H{
 			"code": http.StatusOK,
 			"msg":  "修改成功",
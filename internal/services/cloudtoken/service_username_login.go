@@ -7,7 +7,10 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/tickstep/cloudpan189-api/cloudpan"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/stream"
+	"github.com/xxcheng123/cloudpan189-share/internal/tokenpool"
 	"go.uber.org/zap"
 )
 
@@ -39,6 +42,12 @@ func (s *service) UsernameLogin() gin.HandlerFunc {
 
 		loginResult, loginErr := cloudpan.AppLogin(req.Username, req.Password)
 		if loginErr != nil {
+			stream.Publish(stream.TopicToken, "login", &stream.TokenLogin{
+				TokenId:   req.ID,
+				LoginType: models.LoginTypePassword,
+				Message:   fmt.Sprintf("登录失败: %s", loginErr.Error()),
+			})
+
 			ctx.JSON(http.StatusBadRequest, gin.H{
 				"code": http.StatusBadRequest,
 				"msg":  fmt.Sprintf("登录失败: %s", loginErr.Error()),
@@ -91,6 +100,16 @@ func (s *service) UsernameLogin() gin.HandlerFunc {
 				return
 			}
 
+			tokenpool.Reset(oldToken.ID)
+
+			stream.Publish(stream.TopicToken, "login", &stream.TokenLogin{
+				TokenId:   oldToken.ID,
+				Name:      oldToken.Name,
+				LoginType: models.LoginTypePassword,
+				Success:   true,
+				Message:   "登录成功",
+			})
+
 			ctx.JSON(http.StatusOK, modifyUsernameLoginResponse{
 				RowsAffected: result.RowsAffected,
 			})
@@ -115,6 +134,25 @@ func (s *service) UsernameLogin() gin.HandlerFunc {
 				return
 			}
 
+			stream.Publish(stream.TopicToken, "login", &stream.TokenLogin{
+				TokenId:   m.ID,
+				Name:      m.Name,
+				LoginType: models.LoginTypePassword,
+				Success:   true,
+				Message:   "登录成功",
+			})
+
+			audit.Record(ctx, audit.Entry{
+				Action:     models.AuditCloudTokenAdd,
+				TargetType: audit.TargetCloudToken,
+				TargetId:   m.ID,
+				TargetName: m.Name,
+				After: gin.H{
+					"loginType": m.LoginType,
+					"username":  m.Username,
+				},
+			})
+
 			ctx.JSON(http.StatusOK, addByUsernameResponse{
 				ID: m.ID,
 			})
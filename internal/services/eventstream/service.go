@@ -0,0 +1,24 @@
+package eventstream
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	Stream() gin.HandlerFunc
+}
+
+type service struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+// NewService 创建实时事件推送服务
+func NewService(db *gorm.DB, logger *zap.Logger) Service {
+	return &service{
+		db:     db,
+		logger: logger,
+	}
+}
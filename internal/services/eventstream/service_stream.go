@@ -0,0 +1,119 @@
+package eventstream
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+	"github.com/xxcheng123/cloudpan189-share/internal/bus"
+	"github.com/xxcheng123/cloudpan189-share/internal/stream"
+)
+
+// 心跳间隔，避免反向代理因长时间无数据断开连接
+const heartbeatInterval = time.Second * 15
+
+type streamRequest struct {
+	Topics string `form:"topics"` // 逗号分隔，为空时订阅全部
+}
+
+// Stream 以 SSE 推送任务、扫描进度和令牌登录事件，连接建立后先推送一次当前快照
+//
+// 事件名为主题名，data 为 stream.Message 的 JSON
+func (s *service) Stream() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req streamRequest
+		if err := ctx.ShouldBindQuery(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  "参数错误",
+			})
+
+			return
+		}
+
+		var topics []string
+		for _, topic := range strings.Split(req.Topics, ",") {
+			if topic = strings.TrimSpace(topic); topic == "" {
+				continue
+			}
+
+			if !lo.Contains(stream.Topics, topic) {
+				ctx.JSON(http.StatusBadRequest, gin.H{
+					"code": http.StatusBadRequest,
+					"msg":  "不支持的主题: " + topic,
+				})
+
+				return
+			}
+
+			topics = append(topics, topic)
+		}
+
+		if len(topics) == 0 {
+			topics = stream.Topics
+		}
+
+		ch, cancel := stream.Subscribe(topics)
+		defer cancel()
+
+		ctx.Header("Content-Type", "text/event-stream")
+		ctx.Header("Cache-Control", "no-cache")
+		ctx.Header("Connection", "keep-alive")
+		ctx.Header("X-Accel-Buffering", "no")
+
+		for _, msg := range snapshot(topics) {
+			ctx.SSEvent(msg.Topic, msg)
+		}
+
+		ctx.Writer.Flush()
+
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+
+		ctx.Stream(func(w io.Writer) bool {
+			select {
+			case <-ctx.Request.Context().Done():
+				return false
+			case <-stream.Done():
+				return false
+			case msg := <-ch:
+				ctx.SSEvent(msg.Topic, msg)
+			case <-heartbeat.C:
+				_, _ = io.WriteString(w, ": ping\n\n")
+			}
+
+			return true
+		})
+	}
+}
+
+// snapshot 连接建立时的当前状态，便于前端直接渲染而不用等下一次变化
+func snapshot(topics []string) []*stream.Message {
+	var (
+		list = make([]*stream.Message, 0, 2)
+		now  = time.Now().UnixMilli()
+	)
+
+	if lo.Contains(topics, stream.TopicBus) {
+		list = append(list, &stream.Message{
+			Topic: stream.TopicBus,
+			Type:  "snapshot",
+			Time:  now,
+			Data:  bus.Detail(),
+		})
+	}
+
+	if lo.Contains(topics, stream.TopicScan) {
+		list = append(list, &stream.Message{
+			Topic: stream.TopicScan,
+			Type:  "snapshot",
+			Time:  now,
+			Data:  bus.ScanFileStats(),
+		})
+	}
+
+	return list
+}
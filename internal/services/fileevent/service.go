@@ -0,0 +1,26 @@
+package fileevent
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	Recent() gin.HandlerFunc
+	FeedURL() gin.HandlerFunc
+	RevokeFeedURL() gin.HandlerFunc
+	Feed() gin.HandlerFunc
+}
+
+type service struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+func NewService(db *gorm.DB, logger *zap.Logger) Service {
+	return &service{
+		db:     db,
+		logger: logger,
+	}
+}
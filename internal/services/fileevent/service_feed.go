@@ -0,0 +1,219 @@
+package fileevent
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/baseurl"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/enc"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/utils"
+	"github.com/xxcheng123/cloudpan189-share/internal/services/universalfs"
+	"github.com/xxcheng123/cloudpan189-share/internal/shared"
+	"go.uber.org/zap"
+)
+
+const (
+	feedFormatRSS  = "rss"
+	feedFormatAtom = "atom"
+
+	feedItemLimit = 50
+)
+
+type feedRequest struct {
+	MountId   int64  `form:"mountId" binding:"required"`
+	UID       int64  `form:"uid" binding:"required"`
+	Version   int    `form:"version" binding:"required"`
+	Format    string `form:"format" binding:"omitempty,oneof=rss atom"`
+	Random    string `form:"random" binding:"required"`
+	Timestamp int64  `form:"timestamp" binding:"required"`
+	Sign      string `form:"sign" binding:"required"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	LastBuildDate string     `xml:"lastBuildDate"`
+	Items         []*rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string       `xml:"title"`
+	ID      string       `xml:"id"`
+	Updated string       `xml:"updated"`
+	Entries []*atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	Title   string    `xml:"title"`
+	ID      string    `xml:"id"`
+	Updated string    `xml:"updated"`
+	Link    *atomLink `xml:"link,omitempty"`
+	Summary string    `xml:"summary"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+// Feed 挂载点新增文件的 RSS/Atom 订阅，通过 FeedURL 生成的签名访问。
+// 条目链接绑定订阅地址所属的用户，6 小时后过期，阅读器刷新订阅时会重新生成
+func (s *service) Feed() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(feedRequest)
+		if err := ctx.ShouldBindQuery(req); err != nil {
+			ctx.String(http.StatusBadRequest, err.Error())
+
+			return
+		}
+
+		if !enc.Verify(url.Values{
+			"mountId":   []string{strconv.FormatInt(req.MountId, 10)},
+			"uid":       []string{strconv.FormatInt(req.UID, 10)},
+			"version":   []string{strconv.Itoa(req.Version)},
+			"random":    []string{req.Random},
+			"timestamp": []string{strconv.FormatInt(req.Timestamp, 10)},
+			"sign":      []string{req.Sign},
+		}, shared.Setting.SaltKey) {
+			ctx.String(http.StatusUnauthorized, "签名验证失败")
+
+			return
+		}
+
+		// 用户被禁用或重置过订阅地址后，之前的地址不再可用
+		user := new(models.User)
+		if err := s.db.WithContext(ctx).Where("id = ?", req.UID).First(user).Error; err != nil || user.Status != 1 || user.FeedVersion != req.Version {
+			ctx.String(http.StatusUnauthorized, "订阅地址已失效")
+
+			return
+		}
+
+		mount := new(models.VirtualFile)
+		if err := s.db.WithContext(ctx).Where("id = ? AND is_top = 1", req.MountId).First(mount).Error; err != nil {
+			ctx.String(http.StatusNotFound, "挂载点不存在")
+
+			return
+		}
+
+		if user.GroupID != 0 {
+			var count int64
+			if err := s.db.WithContext(ctx).Model(&models.Group2File{}).Where("group_id = ? AND file_id = ?", user.GroupID, mount.ID).Count(&count).Error; err != nil {
+				s.logger.Error("查询用户组文件关系失败", zap.Int64("groupId", user.GroupID), zap.Error(err))
+				ctx.String(http.StatusInternalServerError, "查询失败")
+
+				return
+			}
+
+			if count == 0 {
+				ctx.String(http.StatusForbidden, "无权限访问")
+
+				return
+			}
+		}
+
+		var events = make([]*models.FileEvent, 0)
+		if err := s.db.WithContext(ctx).
+			Where("top_id = ? AND action = ? AND is_folder = 0", mount.ID, models.FileEventCreate).
+			Order("id DESC").
+			Limit(feedItemLimit).
+			Find(&events).Error; err != nil {
+			s.logger.Error("查询订阅内容失败", zap.Int64("mountId", mount.ID), zap.Error(err))
+			ctx.String(http.StatusInternalServerError, "查询失败")
+
+			return
+		}
+
+		updated := time.Now()
+		if len(events) > 0 {
+			updated = events[0].CreatedAt
+		}
+
+		title := fmt.Sprintf("%s - %s", shared.Setting.Title, mount.Name)
+
+		if req.Format == feedFormatAtom {
+			feed := &atomFeed{
+				Title:   title,
+				ID:      fmt.Sprintf("urn:cloudpan189-share:mount:%d", mount.ID),
+				Updated: updated.Format(time.RFC3339),
+			}
+
+			for _, e := range events {
+				entry := &atomEntry{
+					Title:   e.Name,
+					ID:      fmt.Sprintf("urn:cloudpan189-share:event:%d", e.ID),
+					Updated: e.CreatedAt.Format(time.RFC3339),
+					Summary: fmt.Sprintf("%s (%s)", e.Path, utils.FormatBytes(e.Size)),
+				}
+
+				if link := universalfs.DownloadURL(ctx, e.FileId, user.ID); link != "" {
+					entry.Link = &atomLink{Href: link}
+				}
+
+				feed.Entries = append(feed.Entries, entry)
+			}
+
+			s.responseXML(ctx, "application/atom+xml; charset=utf-8", feed)
+
+			return
+		}
+
+		feed := &rssFeed{
+			Version: "2.0",
+			Channel: rssChannel{
+				Title:         title,
+				Link:          baseurl.Resolve(ctx),
+				Description:   fmt.Sprintf("挂载点「%s」的新增文件", mount.Name),
+				LastBuildDate: updated.Format(time.RFC1123Z),
+			},
+		}
+
+		for _, e := range events {
+			feed.Channel.Items = append(feed.Channel.Items, &rssItem{
+				Title:       e.Name,
+				Link:        universalfs.DownloadURL(ctx, e.FileId, user.ID),
+				Description: fmt.Sprintf("%s (%s)", e.Path, utils.FormatBytes(e.Size)),
+				GUID:        rssGUID{Value: fmt.Sprintf("cloudpan189-share-event-%d", e.ID)},
+				PubDate:     e.CreatedAt.Format(time.RFC1123Z),
+			})
+		}
+
+		s.responseXML(ctx, "application/rss+xml; charset=utf-8", feed)
+	}
+}
+
+func (s *service) responseXML(ctx *gin.Context, contentType string, v any) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+
+		return
+	}
+
+	ctx.Data(http.StatusOK, contentType, append([]byte(xml.Header), data...))
+}
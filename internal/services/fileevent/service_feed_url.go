@@ -0,0 +1,119 @@
+package fileevent
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/xxcheng123/cloudpan189-share/internal/baseurl"
+	"github.com/xxcheng123/cloudpan189-share/internal/consts"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/enc"
+	"github.com/xxcheng123/cloudpan189-share/internal/shared"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type feedURLRequest struct {
+	MountId int64  `form:"mountId" binding:"required"`
+	Format  string `form:"format" binding:"omitempty,oneof=rss atom"` // 默认 rss
+}
+
+type feedURLResponse struct {
+	URL string `json:"url"`
+}
+
+// FeedURL 生成挂载点的订阅地址，地址带签名并绑定当前用户，阅读器无需登录即可访问；
+// 访问时按该用户的状态和用户组权限重新校验，可通过 RevokeFeedURL 作废
+func (s *service) FeedURL() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(feedURLRequest)
+		if err := ctx.ShouldBindQuery(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+
+			return
+		}
+
+		mount := new(models.VirtualFile)
+		if err := s.db.WithContext(ctx).Where("id = ? AND is_top = 1", req.MountId).First(mount).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"code": http.StatusNotFound,
+				"msg":  "挂载点不存在",
+			})
+
+			return
+		}
+
+		if gid := ctx.GetInt64(consts.CtxKeyGroupId); gid != 0 {
+			var count int64
+			s.db.WithContext(ctx).Model(&models.Group2File{}).Where("group_id = ? AND file_id = ?", gid, mount.ID).Count(&count)
+
+			if count == 0 {
+				ctx.JSON(http.StatusForbidden, gin.H{
+					"code": http.StatusForbidden,
+					"msg":  "无权限访问",
+				})
+
+				return
+			}
+		}
+
+		user := new(models.User)
+		if err := s.db.WithContext(ctx).Where("id = ?", ctx.GetInt64("user_id")).First(user).Error; err != nil {
+			s.logger.Error("查询用户失败", zap.Int64("userId", ctx.GetInt64("user_id")), zap.Error(err))
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询用户失败",
+			})
+
+			return
+		}
+
+		if req.Format == "" {
+			req.Format = feedFormatRSS
+		}
+
+		values := enc.Enc(url.Values{
+			"mountId":   []string{strconv.FormatInt(mount.ID, 10)},
+			"uid":       []string{strconv.FormatInt(user.ID, 10)},
+			"version":   []string{strconv.Itoa(user.FeedVersion)},
+			"random":    []string{uuid.NewString()},
+			"timestamp": []string{"-1"},
+		}, shared.Setting.SaltKey)
+		values.Set("format", req.Format)
+
+		ctx.JSON(http.StatusOK, &feedURLResponse{
+			URL: fmt.Sprintf("%s/api/file_event/feed?%s", baseurl.Resolve(ctx), values.Encode()),
+		})
+	}
+}
+
+// RevokeFeedURL 作废当前用户此前生成的所有订阅地址
+func (s *service) RevokeFeedURL() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		uid := ctx.GetInt64("user_id")
+
+		if err := s.db.WithContext(ctx).Model(&models.User{}).
+			Where("id = ?", uid).
+			Update("feed_version", gorm.Expr("feed_version + 1")).Error; err != nil {
+			s.logger.Error("作废订阅地址失败", zap.Int64("userId", uid), zap.Error(err))
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "作废订阅地址失败",
+			})
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"code": http.StatusOK,
+			"msg":  "已作废之前生成的所有订阅地址",
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package fileevent
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/consts"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"gorm.io/gorm"
+)
+
+type recentRequest struct {
+	MountId  int64  `form:"mountId" binding:"omitempty"`
+	Action   string `form:"action" binding:"omitempty,oneof=create update delete"` // 默认 create
+	Cursor   int64  `form:"cursor" binding:"omitempty"`                            // 上一页返回的 nextCursor
+	PageSize int    `form:"pageSize" binding:"omitempty,min=1,max=100"`
+}
+
+type recentResponse struct {
+	Total      int64               `json:"total"`
+	PageSize   int                 `json:"pageSize"`
+	NextCursor int64               `json:"nextCursor"` // 为 0 时没有下一页
+	Data       []*models.FileEvent `json:"data"`
+}
+
+// Recent 最近新增（或其他变更）的文件，按时间倒序
+func (s *service) Recent() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(recentRequest)
+		if err := ctx.ShouldBindQuery(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+
+			return
+		}
+
+		if req.Action == "" {
+			req.Action = models.FileEventCreate
+		}
+
+		if req.PageSize <= 0 {
+			req.PageSize = 20
+		}
+
+		query := s.db.WithContext(ctx).Model(&models.FileEvent{}).Where("action = ?", req.Action)
+
+		if req.MountId > 0 {
+			query = query.Where("top_id = ?", req.MountId)
+		}
+
+		// 按用户组过滤，只能看到组内挂载点的变更
+		if gid := ctx.GetInt64(consts.CtxKeyGroupId); gid != 0 {
+			query = query.Where("top_id IN (?)", s.db.Model(&models.Group2File{}).Select("file_id").Where("group_id = ?", gid))
+		}
+
+		var count int64
+		if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+
+			return
+		}
+
+		if req.Cursor > 0 {
+			query = query.Where("id < ?", req.Cursor)
+		}
+
+		var list = make([]*models.FileEvent, 0)
+		if err := query.Order("id DESC").Limit(req.PageSize + 1).Find(&list).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+
+			return
+		}
+
+		var nextCursor int64
+		if len(list) > req.PageSize {
+			list = list[:req.PageSize]
+			nextCursor = list[len(list)-1].ID
+		}
+
+		ctx.JSON(http.StatusOK, &recentResponse{
+			Total:      count,
+			PageSize:   req.PageSize,
+			NextCursor: nextCursor,
+			Data:       list,
+		})
+	}
+}
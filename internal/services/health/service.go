@@ -0,0 +1,25 @@
+package health
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	Healthz() gin.HandlerFunc
+	Readyz() gin.HandlerFunc
+}
+
+type service struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+// NewService 创建健康检查服务
+func NewService(db *gorm.DB, logger *zap.Logger) Service {
+	return &service{
+		db:     db,
+		logger: logger,
+	}
+}
@@ -0,0 +1,62 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/bus"
+	"github.com/xxcheng123/cloudpan189-share/internal/shared"
+	"go.uber.org/zap"
+)
+
+const pingTimeout = time.Second * 3
+
+// Readyz 就绪检查，数据库可访问且总线在运行时返回 200，否则 503
+//
+// initialized 仅作展示：未初始化时仍需对外提供初始化接口，不能因此摘除流量
+func (s *service) Readyz() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		checks := gin.H{
+			"database": "ok",
+			"bus":      "ok",
+		}
+		ready := true
+
+		if err := s.pingDB(ctx.Request.Context()); err != nil {
+			s.logger.Warn("就绪检查：数据库不可用", zap.Error(err))
+
+			checks["database"] = err.Error()
+			ready = false
+		}
+
+		if !bus.Running() {
+			checks["bus"] = "stopped"
+			ready = false
+		}
+
+		status, code := "ok", http.StatusOK
+		if !ready {
+			status, code = "unavailable", http.StatusServiceUnavailable
+		}
+
+		ctx.JSON(code, gin.H{
+			"status":      status,
+			"checks":      checks,
+			"initialized": shared.Setting.Initialized,
+		})
+	}
+}
+
+func (s *service) pingDB(ctx context.Context) error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	return sqlDB.PingContext(pingCtx)
+}
@@ -0,0 +1,64 @@
+package notifier
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/notify"
+	"go.uber.org/zap"
+)
+
+type addRequest struct {
+	Name    string         `json:"name" binding:"required,min=1,max=255"`
+	Type    string         `json:"type" binding:"required,oneof=webhook smtp telegram"`
+	Enabled bool           `json:"enabled"`
+	Config  map[string]any `json:"config" binding:"required"`
+}
+
+type addResponse struct {
+	ID int64 `json:"id"`
+}
+
+func (s *service) Add() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(addRequest)
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		// 提前校验配置是否完整
+		if _, err := notify.New(req.Type, req.Config); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		m := &models.Notifier{
+			Name:    req.Name,
+			Type:    req.Type,
+			Enabled: req.Enabled,
+			Config:  req.Config,
+		}
+
+		if err := s.db.WithContext(ctx).Create(m).Error; err != nil {
+			s.logger.Error("notifier create failure", zap.Error(err))
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "通知渠道创建失败",
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, &addResponse{
+			ID: m.ID,
+		})
+	}
+}
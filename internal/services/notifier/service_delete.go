@@ -0,0 +1,53 @@
+package notifier
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+)
+
+type deleteRequest struct {
+	ID int64 `json:"id" binding:"required,min=1"`
+}
+
+type deleteResponse struct {
+	RowsAffected int64 `json:"rowsAffected"`
+}
+
+func (s *service) Delete() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(deleteRequest)
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		result := s.db.WithContext(ctx).Where("id = ?", req.ID).Delete(&models.Notifier{})
+		if result.Error != nil {
+			s.logger.Error("notifier delete failure", zap.Error(result.Error))
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "通知渠道删除失败",
+			})
+			return
+		}
+
+		if result.RowsAffected == 0 {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"code": http.StatusNotFound,
+				"msg":  "通知渠道不存在",
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, &deleteResponse{
+			RowsAffected: result.RowsAffected,
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package notifier
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/notify"
+)
+
+type testRequest struct {
+	ID int64 `json:"id" binding:"required,min=1"`
+}
+
+// Test 向指定渠道发送一条测试消息
+func (s *service) Test() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(testRequest)
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		m := new(models.Notifier)
+		if err := s.db.WithContext(ctx).Where("id = ?", req.ID).First(m).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"code": http.StatusNotFound,
+				"msg":  "通知渠道不存在",
+			})
+			return
+		}
+
+		n, err := notify.New(m.Type, m.Config)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		if err = n.Send(ctx, &notify.Message{
+			Title:   "测试通知",
+			Content: fmt.Sprintf("这是一条来自 %s 的测试通知", m.Name),
+			Level:   notify.LevelInfo,
+			Time:    time.Now(),
+		}); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  fmt.Sprintf("发送失败：%s", err.Error()),
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"code": http.StatusOK,
+			"msg":  "发送成功",
+		})
+	}
+}
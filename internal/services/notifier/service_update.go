@@ -0,0 +1,72 @@
+package notifier
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/notify"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+)
+
+type updateRequest struct {
+	ID      int64          `json:"id" binding:"required,min=1"`
+	Name    string         `json:"name" binding:"required,min=1,max=255"`
+	Type    string         `json:"type" binding:"required,oneof=webhook smtp telegram"`
+	Enabled bool           `json:"enabled"`
+	Config  map[string]any `json:"config" binding:"required"`
+}
+
+type updateResponse struct {
+	RowsAffected int64 `json:"rowsAffected"`
+}
+
+func (s *service) Update() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(updateRequest)
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		if _, err := notify.New(req.Type, req.Config); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		result := s.db.WithContext(ctx).Model(&models.Notifier{}).Where("id = ?", req.ID).Updates(map[string]interface{}{
+			"name":    req.Name,
+			"type":    req.Type,
+			"enabled": req.Enabled,
+			"config":  datatypes.JSONMap(req.Config),
+		})
+		if result.Error != nil {
+			s.logger.Error("notifier update failure", zap.Error(result.Error))
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "通知渠道修改失败",
+			})
+			return
+		}
+
+		if result.RowsAffected == 0 {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"code": http.StatusNotFound,
+				"msg":  "通知渠道不存在",
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, &updateResponse{
+			RowsAffected: result.RowsAffected,
+		})
+	}
+}
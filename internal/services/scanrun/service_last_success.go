@@ -0,0 +1,53 @@
+package scanrun
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"gorm.io/gorm"
+)
+
+type lastSuccessRequest struct {
+	FileId int64 `form:"fileId" binding:"required"`
+}
+
+type lastSuccessResponse struct {
+	Run *models.ScanRun `json:"run"` // 从未成功扫描时为 null
+}
+
+// LastSuccess 挂载点最近一次成功的扫描
+func (s *service) LastSuccess() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(lastSuccessRequest)
+		if err := ctx.ShouldBindQuery(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  "参数错误",
+			})
+			return
+		}
+
+		run := new(models.ScanRun)
+		if err := s.db.WithContext(ctx).
+			Where("file_id = ?", req.FileId).
+			Where("status = ?", models.ScanRunSuccess).
+			Order("id DESC").
+			First(run).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				ctx.JSON(http.StatusOK, &lastSuccessResponse{})
+
+				return
+			}
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, &lastSuccessResponse{Run: run})
+	}
+}
@@ -0,0 +1,87 @@
+package scanrun
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+)
+
+type listRequest struct {
+	CurrentPage int    `form:"currentPage" binding:"omitempty"`
+	PageSize    int    `form:"pageSize" binding:"omitempty,max=100"`
+	FileId      int64  `form:"fileId" binding:"omitempty"`
+	Trigger     string `form:"trigger" binding:"omitempty,oneof=manual schedule api"`
+	Status      string `form:"status" binding:"omitempty,oneof=running success partial failed interrupted"`
+}
+
+type listResponse struct {
+	Total       int64             `json:"total"`
+	CurrentPage int               `json:"currentPage"`
+	PageSize    int               `json:"pageSize"`
+	Data        []*models.ScanRun `json:"data"`
+}
+
+// List 扫描运行记录，按开始时间倒序
+func (s *service) List() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(listRequest)
+		if err := ctx.ShouldBindQuery(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		if req.CurrentPage <= 0 {
+			req.CurrentPage = 1
+		}
+
+		if req.PageSize <= 0 {
+			req.PageSize = 10
+		}
+
+		query := s.db.WithContext(ctx).Model(&models.ScanRun{})
+
+		if req.FileId > 0 {
+			query = query.Where("file_id = ?", req.FileId)
+		}
+
+		if req.Trigger != "" {
+			query = query.Where("trigger_type = ?", req.Trigger)
+		}
+
+		if req.Status != "" {
+			query = query.Where("status = ?", req.Status)
+		}
+
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+			return
+		}
+
+		var list = make([]*models.ScanRun, 0)
+		if err := query.Order("id DESC").
+			Offset((req.CurrentPage - 1) * req.PageSize).
+			Limit(req.PageSize).
+			Find(&list).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, &listResponse{
+			Total:       count,
+			CurrentPage: req.CurrentPage,
+			PageSize:    req.PageSize,
+			Data:        list,
+		})
+	}
+}
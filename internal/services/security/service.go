@@ -0,0 +1,29 @@
+package security
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	FailureList() gin.HandlerFunc
+	LockList() gin.HandlerFunc
+	Unlock() gin.HandlerFunc
+	IPRuleList() gin.HandlerFunc
+	IPRuleSave() gin.HandlerFunc
+	IPRuleDelete() gin.HandlerFunc
+}
+
+type service struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+// NewService 创建登录安全管理服务
+func NewService(db *gorm.DB, logger *zap.Logger) Service {
+	return &service{
+		db:     db,
+		logger: logger,
+	}
+}
@@ -0,0 +1,87 @@
+package security
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+)
+
+type failureListRequest struct {
+	Username    string `form:"username" binding:"omitempty,max=255"`
+	IP          string `form:"ip" binding:"omitempty,max=64"`
+	Source      string `form:"source" binding:"omitempty,oneof=login dav share"`
+	CurrentPage int    `form:"currentPage" binding:"omitempty"`
+	PageSize    int    `form:"pageSize" binding:"omitempty,max=100"`
+}
+
+type failureListResponse struct {
+	Total       int64                  `json:"total"`
+	CurrentPage int                    `json:"currentPage"`
+	PageSize    int                    `json:"pageSize"`
+	Data        []*models.LoginFailure `json:"data"`
+}
+
+// FailureList 登录和 WebDAV 认证失败记录，按时间倒序
+func (s *service) FailureList() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(failureListRequest)
+		if err := ctx.ShouldBindQuery(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		if req.CurrentPage <= 0 {
+			req.CurrentPage = 1
+		}
+
+		if req.PageSize <= 0 {
+			req.PageSize = 10
+		}
+
+		query := s.db.WithContext(ctx).Model(&models.LoginFailure{})
+
+		if req.Username != "" {
+			query = query.Where("username = ?", req.Username)
+		}
+
+		if req.IP != "" {
+			query = query.Where("ip = ?", req.IP)
+		}
+
+		if req.Source != "" {
+			query = query.Where("source = ?", req.Source)
+		}
+
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+			return
+		}
+
+		var list = make([]*models.LoginFailure, 0)
+		if err := query.Order("id DESC").
+			Offset((req.CurrentPage - 1) * req.PageSize).
+			Limit(req.PageSize).
+			Find(&list).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, &failureListResponse{
+			Total:       count,
+			CurrentPage: req.CurrentPage,
+			PageSize:    req.PageSize,
+			Data:        list,
+		})
+	}
+}
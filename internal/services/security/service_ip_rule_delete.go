@@ -0,0 +1,96 @@
+package security
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
+	"github.com/xxcheng123/cloudpan189-share/internal/authguard"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+)
+
+type ipRuleDeleteRequest struct {
+	ID int64 `json:"id" binding:"required,min=1"`
+}
+
+// IPRuleDelete 删除 IP 规则，删除后会导致当前 IP 无法访问接口时拒绝删除（如删除了唯一一条包含自己的 allow 规则）
+func (s *service) IPRuleDelete() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(ipRuleDeleteRequest)
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		var rules = make([]*models.IPRule, 0)
+		if err := s.db.WithContext(ctx).Find(&rules).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+			return
+		}
+
+		var (
+			before *models.IPRule
+			next   = make([]*models.IPRule, 0, len(rules))
+		)
+
+		for _, item := range rules {
+			if item.ID == req.ID {
+				before = item
+
+				continue
+			}
+
+			next = append(next, item)
+		}
+
+		if before == nil {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"code": http.StatusNotFound,
+				"msg":  "规则不存在",
+			})
+			return
+		}
+
+		if ip := ctx.ClientIP(); !authguard.Allowed(next, ip, models.IPRuleScopeAPI) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  "删除后当前 IP（" + ip + "）将无法访问管理接口",
+			})
+			return
+		}
+
+		if err := s.db.WithContext(ctx).Delete(before).Error; err != nil {
+			s.logger.Error("ip rule delete failure", zap.Error(err))
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "IP 规则删除失败",
+			})
+			return
+		}
+
+		if err := authguard.ReloadRules(ctx); err != nil {
+			s.logger.Error("ip rule reload failure", zap.Error(err))
+		}
+
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditIPRuleDelete,
+			TargetType: audit.TargetIPRule,
+			TargetId:   before.ID,
+			TargetName: before.CIDR,
+			Before:     before,
+		})
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"code": http.StatusOK,
+			"msg":  "删除成功",
+		})
+	}
+}
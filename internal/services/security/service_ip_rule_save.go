@@ -0,0 +1,123 @@
+package security
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
+	"github.com/xxcheng123/cloudpan189-share/internal/authguard"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+)
+
+type ipRuleSaveRequest struct {
+	ID     int64  `json:"id" binding:"min=0"` // 0 表示新增
+	CIDR   string `json:"cidr" binding:"required,max=64"`
+	Action string `json:"action" binding:"required,oneof=allow deny"`
+	Scope  string `json:"scope" binding:"required,oneof=all api dav"`
+	Remark string `json:"remark" binding:"max=255"`
+}
+
+// IPRuleSave 新增或修改 IP 规则，保存后会导致当前 IP 无法访问接口时拒绝保存
+func (s *service) IPRuleSave() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(ipRuleSaveRequest)
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		req.CIDR = strings.TrimSpace(req.CIDR)
+		if _, err := authguard.ParseCIDR(req.CIDR); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		var rules = make([]*models.IPRule, 0)
+		if err := s.db.WithContext(ctx).Find(&rules).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+			return
+		}
+
+		rule := &models.IPRule{
+			ID:     req.ID,
+			CIDR:   req.CIDR,
+			Action: req.Action,
+			Scope:  req.Scope,
+			Remark: req.Remark,
+		}
+
+		var (
+			before *models.IPRule
+			next   = make([]*models.IPRule, 0, len(rules)+1)
+		)
+
+		for _, item := range rules {
+			if item.ID == req.ID {
+				before = item
+
+				continue
+			}
+
+			next = append(next, item)
+		}
+
+		if req.ID > 0 && before == nil {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"code": http.StatusNotFound,
+				"msg":  "规则不存在",
+			})
+			return
+		}
+
+		if ip := ctx.ClientIP(); !authguard.Allowed(append(next, rule), ip, models.IPRuleScopeAPI) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  "保存后当前 IP（" + ip + "）将无法访问管理接口",
+			})
+			return
+		}
+
+		var err error
+		if req.ID > 0 {
+			err = s.db.WithContext(ctx).Model(rule).Select("cidr", "action", "scope", "remark").Updates(rule).Error
+		} else {
+			err = s.db.WithContext(ctx).Create(rule).Error
+		}
+
+		if err != nil {
+			s.logger.Error("ip rule save failure", zap.Error(err))
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "IP 规则保存失败",
+			})
+			return
+		}
+
+		if err := authguard.ReloadRules(ctx); err != nil {
+			s.logger.Error("ip rule reload failure", zap.Error(err))
+		}
+
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditIPRuleSave,
+			TargetType: audit.TargetIPRule,
+			TargetId:   rule.ID,
+			TargetName: rule.CIDR,
+			Before:     before,
+			After:      rule,
+		})
+
+		ctx.JSON(http.StatusOK, rule)
+	}
+}
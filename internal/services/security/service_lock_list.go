@@ -0,0 +1,15 @@
+package security
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/authguard"
+)
+
+// LockList 当前因失败次数过多被锁定的 IP 和用户名，锁定状态只在内存中，重启后清空
+func (s *service) LockList() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, authguard.Locks())
+	}
+}
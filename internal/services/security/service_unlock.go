@@ -0,0 +1,49 @@
+package security
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
+	"github.com/xxcheng123/cloudpan189-share/internal/authguard"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+)
+
+type unlockRequest struct {
+	Type  string `json:"type" binding:"required,oneof=ip username"`
+	Value string `json:"value" binding:"required,max=255"`
+}
+
+// Unlock 解除 IP 或用户名的锁定
+func (s *service) Unlock() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(unlockRequest)
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		if !authguard.Unlock(req.Type, req.Value) {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"code": http.StatusNotFound,
+				"msg":  "未被锁定",
+			})
+			return
+		}
+
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditLoginUnlock,
+			TargetType: audit.TargetLoginLock,
+			TargetId:   req.Value,
+			TargetName: req.Type,
+		})
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"code": http.StatusOK,
+			"msg":  "已解除锁定",
+		})
+	}
+}
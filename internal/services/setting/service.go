@@ -13,22 +13,10 @@ import (
 
 type Service interface {
 	Get() gin.HandlerFunc
+	Schema() gin.HandlerFunc
+	Patch() gin.HandlerFunc
 	RefreshKey() gin.HandlerFunc
-	ModifyName() gin.HandlerFunc
-	ToggleAuth() gin.HandlerFunc
-	ToggleLocalProxy() gin.HandlerFunc
-	ToggleMultipleStream() gin.HandlerFunc
-	ModifyBaseURL() gin.HandlerFunc
-	ToggleEnableTopFileAutoRefresh() gin.HandlerFunc
 	InitSystem() gin.HandlerFunc
-	ModifyJobThreadCount() gin.HandlerFunc
-	ModifyAutoRefreshMinutes() gin.HandlerFunc
-	ModifyMultipleStreamThreadCount() gin.HandlerFunc
-	ModifyMultipleStreamChunkSize() gin.HandlerFunc
-	ToggleStrmFileEnable() gin.HandlerFunc
-	ModifyStrmSupportFileExtList() gin.HandlerFunc
-	ToggleLinkFileAutoDelete() gin.HandlerFunc
-	ModifyStrmBaseURL() gin.HandlerFunc
 }
 
 type service struct {
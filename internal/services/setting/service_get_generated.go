@@ -20,6 +20,13 @@ type getResponse struct {
 	StrmSupportFileExtList    []string `json:"strmSupportFileExtList"`
 	LinkFileAutoDelete        bool     `json:"linkFileAutoDelete"`
 	StrmBaseURL               string   `json:"strmBaseURL"`
+	TokenHealthCheckMinutes   int      `json:"tokenHealthCheckMinutes"`
+	TokenExpireWarnDays       int      `json:"tokenExpireWarnDays"`
+	MountUnhealthyActions     []string `json:"mountUnhealthyActions"`
+	MirrorAutoDetect          bool     `json:"mirrorAutoDetect"`
+	DuplicateHideTargets      []string `json:"duplicateHideTargets"`
+	FileEventRetentionDays    int      `json:"fileEventRetentionDays"`
+	AdminRequireTwoFactor     bool     `json:"adminRequireTwoFactor"`
 }
 
 func (s *service) Get() gin.HandlerFunc {
@@ -33,6 +40,13 @@ func (s *service) Get() gin.HandlerFunc {
 			StrmSupportFileExtList:    shared.StrmSupportFileExtList,
 			LinkFileAutoDelete:        shared.LinkFileAutoDelete,
 			StrmBaseURL:               shared.StrmBaseURL,
+			TokenHealthCheckMinutes:   shared.TokenHealthCheckMinutes,
+			TokenExpireWarnDays:       shared.TokenExpireWarnDays,
+			MountUnhealthyActions:     shared.MountUnhealthyActions,
+			MirrorAutoDetect:          shared.MirrorAutoDetect,
+			DuplicateHideTargets:      shared.DuplicateHideTargets,
+			FileEventRetentionDays:    shared.FileEventRetentionDays,
+			AdminRequireTwoFactor:     shared.AdminRequireTwoFactor,
 		})
 	}
 }
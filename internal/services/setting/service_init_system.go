@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 	"github.com/xxcheng123/cloudpan189-share/internal/shared"
 	"go.uber.org/zap"
@@ -14,7 +15,7 @@ import (
 type initSystemRequest struct {
 	Title         string `json:"title" binding:"required"`
 	EnableAuth    bool   `json:"enableAuth" binding:"required"`
-	BaseURL       string `json:"baseURL" binding:"required,url"`
+	BaseURL       string `json:"baseURL" binding:"omitempty,url"`
 	SuperUsername string `json:"superUsername" binding:"required,min=3,max=20"`
 	SuperPassword string `json:"superPassword" binding:"required,min=6,max=20"`
 }
@@ -99,6 +100,18 @@ func (s *service) InitSystem() gin.HandlerFunc {
 
 		shared.Setting = setting
 
+		// 初始化时尚未登录，记录创建的管理员
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditSettingInit,
+			TargetType: audit.TargetSetting,
+			TargetName: req.SuperUsername,
+			After: gin.H{
+				"title":      req.Title,
+				"baseURL":    req.BaseURL,
+				"enableAuth": req.EnableAuth,
+			},
+		})
+
 		ctx.JSON(http.StatusOK, gin.H{
 			"code": http.StatusOK,
 			"msg":  "初始化成功",
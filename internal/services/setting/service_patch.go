@@ -0,0 +1,107 @@
+package setting
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/samber/lo"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"gorm.io/gorm"
+)
+
+// settingChange 一个发生变化的设置项
+type settingChange struct {
+	Key             string
+	Before          any
+	After           any
+	RestartRequired bool
+}
+
+type patchResponse struct {
+	Changed         []string `json:"changed"`         // 发生变化的设置项键名
+	RestartRequired bool     `json:"restartRequired"` // 是否有需要重启才生效的项
+}
+
+// Patch 批量修改设置，请求字段见 GET /setting/schema，只修改传入的字段，全部校验通过后在一个事务中写入
+func (s *service) Patch() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req = new(patchRequest)
+
+		// 拒绝未知字段，避免字段名写错时静默忽略
+		decoder := json.NewDecoder(ctx.Request.Body)
+		decoder.DisallowUnknownFields()
+
+		if err := decoder.Decode(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  fmt.Sprintf("参数错误：%s", err.Error()),
+			})
+
+			return
+		}
+
+		if err := binding.Validator.ValidateStruct(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  fmt.Sprintf("参数错误：%s", err.Error()),
+			})
+
+			return
+		}
+
+		req.normalize()
+
+		changes := req.changes()
+		if len(changes) == 0 {
+			ctx.JSON(http.StatusOK, patchResponse{Changed: make([]string, 0)})
+
+			return
+		}
+
+		record, err := s.get(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询配置失败",
+			})
+
+			return
+		}
+
+		if err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return req.save(tx, record.ID, changes)
+		}); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  fmt.Sprintf("修改失败：%s", err.Error()),
+			})
+
+			return
+		}
+
+		req.apply(changes)
+
+		for _, c := range changes {
+			audit.Record(ctx, audit.Entry{
+				Action:     models.AuditSettingUpdate,
+				TargetType: audit.TargetSetting,
+				TargetId:   c.Key,
+				Before:     c.Before,
+				After:      c.After,
+			})
+		}
+
+		ctx.JSON(http.StatusOK, patchResponse{
+			Changed: lo.Map(changes, func(c settingChange, _ int) string {
+				return c.Key
+			}),
+			RestartRequired: lo.SomeBy(changes, func(c settingChange) bool {
+				return c.RestartRequired
+			}),
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/utils"
 	"github.com/xxcheng123/cloudpan189-share/internal/shared"
@@ -34,6 +35,13 @@ func (s *service) RefreshKey() gin.HandlerFunc {
 
 		shared.Setting.SaltKey = key
 
+		// 密钥不落库到审计日志
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditSettingRefreshKey,
+			TargetType: audit.TargetSetting,
+			TargetId:   "salt_key",
+		})
+
 		ctx.JSON(http.StatusOK, gin.H{
 			"msg": "刷新密钥成功",
 		})
@@ -0,0 +1,56 @@
+package setting
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+)
+
+type schemaItem struct {
+	Key             string   `json:"key"`
+	Field           string   `json:"field"` // PATCH /setting 中的字段名
+	Type            string   `json:"type"`  // int, int64, bool, string, json（字符串数组）
+	Group           string   `json:"group"`
+	Label           string   `json:"label"`
+	Description     string   `json:"description"`
+	Validate        string   `json:"validate"` // 校验规则，gin binding 语法
+	Options         []string `json:"options"`
+	RestartRequired bool     `json:"restartRequired"`
+	Default         any      `json:"default"`
+	Value           any      `json:"value"`
+}
+
+type schemaResponse struct {
+	Groups []models.SettingGroup `json:"groups"`
+	Items  []schemaItem          `json:"items"`
+}
+
+// Schema 返回全部可修改的设置项及当前值
+func (s *service) Schema() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		values := settingValues()
+
+		items := make([]schemaItem, 0, len(models.SettingItems))
+		for _, item := range models.SettingItems {
+			items = append(items, schemaItem{
+				Key:             item.Key,
+				Field:           settingFields[item.Key],
+				Type:            item.Type,
+				Group:           item.Group,
+				Label:           item.Label,
+				Description:     item.Description,
+				Validate:        item.Validate,
+				Options:         item.Options,
+				RestartRequired: item.RestartRequired,
+				Default:         item.DefaultValue,
+				Value:           values[item.Key],
+			})
+		}
+
+		ctx.JSON(http.StatusOK, schemaResponse{
+			Groups: models.SettingGroups,
+			Items:  items,
+		})
+	}
+}
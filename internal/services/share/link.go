@@ -0,0 +1,255 @@
+package share
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"github.com/xxcheng123/cloudpan189-share/internal/baseurl"
+	"github.com/xxcheng123/cloudpan189-share/internal/consts"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/utils"
+	"github.com/xxcheng123/cloudpan189-share/internal/shared"
+	"gorm.io/gorm"
+)
+
+const (
+	codeLength = 10
+	// countWindow 访问统计的去重窗口
+	countWindow = time.Hour * 6
+	// accessTokenExpire 输入提取码后免再次输入的时长
+	accessTokenExpire = time.Hour * 24
+
+	ctxKeyShareLink  = "share_link"
+	ctxKeyShareFile  = "share_file"
+	ctxKeyShareOwner = "share_owner"
+)
+
+// 分享状态
+const (
+	StatusActive    = "active"
+	StatusExpired   = "expired"
+	StatusExhausted = "exhausted" // 下载次数已用完，仍可浏览和预览
+)
+
+var (
+	errFileNotFound = errors.New("文件未找到")
+	errNoPermission = errors.New("无权限访问该文件")
+)
+
+// generateCode 随机分享码，去掉了易混淆的字符
+func generateCode() string {
+	const letters = "abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+	b := make([]byte, codeLength)
+	_, _ = rand.Read(b)
+
+	for i := range b {
+		b[i] = letters[int(b[i])%len(letters)]
+	}
+
+	return string(b)
+}
+
+// linkURL 访问者打开的分享页地址
+func linkURL(ctx *gin.Context, code string) string {
+	return baseurl.Resolve(ctx) + "/@share/" + code
+}
+
+func linkStatus(link *models.ShareLink, now time.Time) string {
+	switch {
+	case link.ExpiresAt != nil && !link.ExpiresAt.After(now):
+		return StatusExpired
+	case link.MaxDownloads > 0 && link.DownloadCount >= link.MaxDownloads:
+		return StatusExhausted
+	default:
+		return StatusActive
+	}
+}
+
+// hashPassword 提取码只保存哈希，以分享码加盐
+func hashPassword(code, password string) string {
+	if password == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(code + ":" + password))
+
+	return hex.EncodeToString(sum[:])
+}
+
+type accessClaims struct {
+	Key string `json:"key"`
+	jwt.RegisteredClaims
+}
+
+// passwordKey 提取码修改后，之前签发的访问令牌随之失效
+func passwordKey(link *models.ShareLink) string {
+	sum := sha256.Sum256([]byte(link.Code + ":" + link.Password))
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// generateAccessToken 提取码验证通过后签发，访问者凭此浏览和下载
+func generateAccessToken(link *models.ShareLink) (string, error) {
+	claims := &accessClaims{
+		Key: passwordKey(link),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(accessTokenExpire)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    "auth-service",
+			Subject:   "share-access",
+			ID:        link.Code,
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(shared.Setting.SaltKey))
+}
+
+func validAccessToken(link *models.ShareLink, tokenString string) bool {
+	if tokenString == "" {
+		return false
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &accessClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return []byte(shared.Setting.SaltKey), nil
+	})
+	if err != nil {
+		return false
+	}
+
+	claims, ok := token.Claims.(*accessClaims)
+
+	return ok && token.Valid && claims.Subject == "share-access" && claims.ID == link.Code && claims.Key == passwordKey(link)
+}
+
+// fileAccessible 文件所在挂载点未被隐藏，且用户所在的用户组可以访问；与浏览文件时的权限规则一致
+func (s *service) fileAccessible(ctx context.Context, user *models.User, file *models.VirtualFile) (bool, error) {
+	top := file
+
+	for top.IsTop != 1 {
+		if top.ParentId == 0 {
+			// 根目录下的普通文件夹，不属于任何挂载点
+			return true, nil
+		}
+
+		var parent = new(models.VirtualFile)
+		if err := s.db.WithContext(ctx).Where("id = ?", top.ParentId).First(parent).Error; err != nil {
+			return false, err
+		}
+
+		top = parent
+	}
+
+	return s.topAccessible(ctx, user, top)
+}
+
+// topAccessible 挂载点未被隐藏，且用户组可以访问
+func (s *service) topAccessible(ctx context.Context, user *models.User, top *models.VirtualFile) (bool, error) {
+	if hidden, _ := utils.Bool(top.Addition[consts.FileAdditionKeyHidden]); hidden {
+		return false, nil
+	}
+
+	if user.GroupID == 0 {
+		return true, nil
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(new(models.Group2File)).
+		Where("group_id = ? AND file_id = ?", user.GroupID, top.ID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// splitPath 分享内的相对路径
+func splitPath(p string) []string {
+	return lo.Compact(strings.Split(path.Clean("/"+p), "/"))
+}
+
+// resolvePath 从分享的根逐级查找相对路径对应的文件，途经的挂载点同样要求分享者可以访问
+func (s *service) resolvePath(ctx context.Context, owner *models.User, root *models.VirtualFile, rel string) (*models.VirtualFile, error) {
+	file := root
+
+	for _, name := range splitPath(rel) {
+		if file.IsFolder != 1 {
+			return nil, errFileNotFound
+		}
+
+		var child = new(models.VirtualFile)
+		if err := s.db.WithContext(ctx).Where("parent_id = ? AND name = ?", file.ID, name).First(child).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errFileNotFound
+			}
+
+			return nil, err
+		}
+
+		if child.OsType == models.OsTypeStrmFile {
+			return nil, errFileNotFound
+		}
+
+		if child.IsTop == 1 {
+			ok, err := s.topAccessible(ctx, owner, child)
+			if err != nil {
+				return nil, err
+			}
+
+			if !ok {
+				return nil, errNoPermission
+			}
+		}
+
+		file = child
+	}
+
+	return file, nil
+}
+
+// previewExts 可以在线播放、查看的文件类型，其余文件只能在允许下载时获取
+var previewExts = map[string]struct{}{
+	".mp4": {}, ".mkv": {}, ".webm": {}, ".mov": {}, ".m4v": {}, ".avi": {}, ".flv": {}, ".wmv": {}, ".ts": {},
+	".mp3": {}, ".flac": {}, ".wav": {}, ".aac": {}, ".ogg": {}, ".m4a": {},
+	".jpg": {}, ".jpeg": {}, ".png": {}, ".gif": {}, ".bmp": {}, ".webp": {},
+}
+
+func previewable(name string) bool {
+	_, ok := previewExts[strings.ToLower(path.Ext(name))]
+
+	return ok
+}
+
+// shareFile 对访问者公开的文件信息，不包含挂载和令牌等内部字段
+type shareFile struct {
+	Name       string `json:"name"`
+	Path       string `json:"path"` // 相对分享根目录的路径
+	IsFolder   bool   `json:"isFolder"`
+	Size       int64  `json:"size"`
+	ModifyDate string `json:"modifyDate"`
+}
+
+func newShareFile(file *models.VirtualFile, p string) *shareFile {
+	return &shareFile{
+		Name:       file.Name,
+		Path:       p,
+		IsFolder:   file.IsFolder == 1,
+		Size:       file.Size,
+		ModifyDate: file.ModifyDate,
+	}
+}
@@ -0,0 +1,203 @@
+package share
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	"github.com/xxcheng123/cloudpan189-share/internal/consts"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// LinkMiddleware 校验分享码对应的链接是否可访问，requirePassword 为 true 时还要求携带提取码验证后签发的 token
+func (s *service) LinkMiddleware(requirePassword bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var link = new(models.ShareLink)
+		if err := s.db.WithContext(ctx).Where("code = ?", ctx.Param("code")).First(link).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				s.logger.Error("查询分享链接失败", zap.String("code", ctx.Param("code")), zap.Error(err))
+			}
+
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+				"code": http.StatusNotFound,
+				"msg":  "分享不存在或已取消",
+			})
+			return
+		}
+
+		if linkStatus(link, time.Now()) == StatusExpired {
+			ctx.AbortWithStatusJSON(http.StatusGone, gin.H{
+				"code": http.StatusGone,
+				"msg":  "分享已过期",
+			})
+			return
+		}
+
+		// 分享者被禁用后其分享一并失效
+		var owner = new(models.User)
+		if err := s.db.WithContext(ctx).Where("id = ?", link.UserID).First(owner).Error; err != nil || owner.Status != 1 {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+				"code": http.StatusNotFound,
+				"msg":  "分享不存在或已取消",
+			})
+			return
+		}
+
+		// 文件被删除、挂载点被隐藏或分享者失去访问权限时，分享随之不可用
+		var root = new(models.VirtualFile)
+		if err := s.db.WithContext(ctx).Where("id = ?", link.FileID).First(root).Error; err != nil {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+				"code": http.StatusNotFound,
+				"msg":  "分享的文件已不存在",
+			})
+			return
+		}
+
+		ok, err := s.fileAccessible(ctx, owner, root)
+		if err != nil {
+			s.logger.Error("校验分享文件权限失败", zap.Int64("link_id", link.ID), zap.Error(err))
+		}
+
+		if !ok {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+				"code": http.StatusNotFound,
+				"msg":  "分享的文件已不存在",
+			})
+			return
+		}
+
+		if requirePassword && link.Password != "" && !validAccessToken(link, ctx.Query("token")) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code": http.StatusUnauthorized,
+				"msg":  "请输入提取码",
+			})
+			return
+		}
+
+		ctx.Set(ctxKeyShareLink, link)
+		ctx.Set(ctxKeyShareFile, root)
+		ctx.Set(ctxKeyShareOwner, owner)
+
+		ctx.Next()
+	}
+}
+
+// FileMiddleware 定位分享内 path 对应的文件并校验预览、下载权限，统计次数后交给下载处理，流量计入分享者；
+// 预览只接受音视频和图片，并以 inline 方式输出，不能替代下载
+func (s *service) FileMiddleware(download bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var (
+			link  = ctx.MustGet(ctxKeyShareLink).(*models.ShareLink)
+			root  = ctx.MustGet(ctxKeyShareFile).(*models.VirtualFile)
+			owner = ctx.MustGet(ctxKeyShareOwner).(*models.User)
+		)
+
+		if download && !link.AllowDownload {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"code": http.StatusForbidden,
+				"msg":  "分享者未允许下载",
+			})
+			return
+		}
+
+		if !download && !link.AllowPreview {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"code": http.StatusForbidden,
+				"msg":  "分享者未允许在线预览",
+			})
+			return
+		}
+
+		file, err := s.resolvePath(ctx, owner, root, ctx.Query("path"))
+		if err != nil || file.IsFolder == 1 {
+			if err != nil && !errors.Is(err, errFileNotFound) && !errors.Is(err, errNoPermission) {
+				s.logger.Error("查找分享文件失败", zap.Int64("link_id", link.ID), zap.Error(err))
+			}
+
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+				"code": http.StatusNotFound,
+				"msg":  "文件未找到",
+			})
+			return
+		}
+
+		if !download && !previewable(file.Name) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"code": http.StatusForbidden,
+				"msg":  "该文件类型不支持在线预览",
+			})
+			return
+		}
+
+		if !s.count(ctx, link, file, download) {
+			ctx.AbortWithStatusJSON(http.StatusGone, gin.H{
+				"code": http.StatusGone,
+				"msg":  "下载次数已用完",
+			})
+			return
+		}
+
+		ctx.Set(consts.CtxKeyFileId, file.ID)
+		ctx.Set(consts.CtxKeyServeInline, !download)
+		ctx.Set("user_id", link.UserID)
+
+		ctx.Next()
+	}
+}
+
+// count 记录预览或下载次数，窗口期内同一 IP 对同一文件的请求只计一次；下载次数达到上限时返回 false，
+// 包括之前已经计过次数的访问者
+func (s *service) count(ctx *gin.Context, link *models.ShareLink, file *models.VirtualFile, download bool) bool {
+	key := fmt.Sprintf("%d:%d:%s:%t", link.ID, file.ID, ctx.ClientIP(), download)
+
+	// 去重缓存只用来避免重复计数，上限以链接当前的次数为准
+	if download && linkStatus(link, time.Now()) == StatusExhausted {
+		return false
+	}
+
+	// Add 在键已存在时返回错误，并发的分段请求只有一个会进入统计
+	if err := s.counted.Add(key, struct{}{}, cache.DefaultExpiration); err != nil {
+		return true
+	}
+
+	now := time.Now()
+
+	if !download {
+		if err := s.db.WithContext(ctx).Model(new(models.ShareLink)).
+			Where("id = ?", link.ID).
+			Updates(map[string]any{
+				"preview_count":  gorm.Expr("preview_count + 1"),
+				"last_access_at": now,
+			}).Error; err != nil {
+			s.logger.Warn("更新分享预览次数失败", zap.Int64("link_id", link.ID), zap.Error(err))
+		}
+
+		return true
+	}
+
+	// 以次数未满为条件递增，并发下载时不会超出上限
+	result := s.db.WithContext(ctx).Model(new(models.ShareLink)).
+		Where("id = ? AND (max_downloads = 0 OR download_count < max_downloads)", link.ID).
+		Updates(map[string]any{
+			"download_count": gorm.Expr("download_count + 1"),
+			"last_access_at": now,
+		})
+	if result.Error != nil {
+		s.logger.Warn("更新分享下载次数失败", zap.Int64("link_id", link.ID), zap.Error(result.Error))
+
+		return true
+	}
+
+	if result.RowsAffected == 0 {
+		s.counted.Delete(key)
+
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,189 @@
+package share
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"github.com/patrickmn/go-cache"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func newTestService(t *testing.T) *service {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "share.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+
+	if err = db.AutoMigrate(new(models.ShareLink)); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+
+	return &service{
+		db:      db,
+		logger:  zap.NewNop(),
+		counted: cache.New(countWindow, time.Minute*10),
+	}
+}
+
+func newTestContext(ip string) *gin.Context {
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest("GET", "/", nil)
+	ctx.Request.RemoteAddr = ip + ":12345"
+
+	return ctx
+}
+
+func TestCount(t *testing.T) {
+	type visit struct {
+		ip       string
+		fileId   int64
+		download bool
+		want     bool
+	}
+
+	tests := []struct {
+		name          string
+		maxDownloads  int64
+		visits        []visit
+		wantDownloads int64
+		wantPreviews  int64
+	}{
+		{
+			name: "unlimited",
+			visits: []visit{
+				{"10.0.0.1", 1, true, true},
+				{"10.0.0.2", 1, true, true},
+				{"10.0.0.3", 1, true, true},
+			},
+			wantDownloads: 3,
+		},
+		{
+			name:         "same visitor counted once",
+			maxDownloads: 5,
+			visits: []visit{
+				{"10.0.0.1", 1, true, true},
+				{"10.0.0.1", 1, true, true},
+				{"10.0.0.1", 2, true, true},
+			},
+			wantDownloads: 2,
+		},
+		{
+			name:         "limit reached",
+			maxDownloads: 2,
+			visits: []visit{
+				{"10.0.0.1", 1, true, true},
+				{"10.0.0.2", 1, true, true},
+				{"10.0.0.3", 1, true, false},
+			},
+			wantDownloads: 2,
+		},
+		{
+			name:         "counted visitor rejected after exhausted",
+			maxDownloads: 2,
+			visits: []visit{
+				{"10.0.0.1", 1, true, true},
+				{"10.0.0.2", 1, true, true},
+				{"10.0.0.1", 1, true, false},
+			},
+			wantDownloads: 2,
+		},
+		{
+			name:         "preview not limited",
+			maxDownloads: 1,
+			visits: []visit{
+				{"10.0.0.1", 1, true, true},
+				{"10.0.0.1", 1, false, true},
+				{"10.0.0.1", 1, false, true},
+				{"10.0.0.2", 1, false, true},
+				{"10.0.0.2", 1, true, false},
+			},
+			wantDownloads: 1,
+			wantPreviews:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t)
+
+			link := &models.ShareLink{
+				Code:         generateCode(),
+				UserID:       1,
+				FileID:       1,
+				MaxDownloads: tt.maxDownloads,
+			}
+			if err := s.db.Create(link).Error; err != nil {
+				t.Fatalf("create link: %v", err)
+			}
+
+			for i, v := range tt.visits {
+				// 每次请求由中间件重新查询链接
+				var current = new(models.ShareLink)
+				if err := s.db.First(current, link.ID).Error; err != nil {
+					t.Fatalf("load link: %v", err)
+				}
+
+				file := &models.VirtualFile{ID: v.fileId}
+
+				if got := s.count(newTestContext(v.ip), current, file, v.download); got != v.want {
+					t.Errorf("visit %d (%s, download=%v) = %v, want %v", i, v.ip, v.download, got, v.want)
+				}
+			}
+
+			var got = new(models.ShareLink)
+			if err := s.db.First(got, link.ID).Error; err != nil {
+				t.Fatalf("load link: %v", err)
+			}
+
+			if got.DownloadCount != tt.wantDownloads || got.PreviewCount != tt.wantPreviews {
+				t.Errorf("counts = (download %d, preview %d), want (%d, %d)",
+					got.DownloadCount, got.PreviewCount, tt.wantDownloads, tt.wantPreviews)
+			}
+		})
+	}
+}
+
+func TestCountStaleLink(t *testing.T) {
+	s := newTestService(t)
+
+	link := &models.ShareLink{
+		Code:         generateCode(),
+		UserID:       1,
+		FileID:       1,
+		MaxDownloads: 1,
+	}
+	if err := s.db.Create(link).Error; err != nil {
+		t.Fatalf("create link: %v", err)
+	}
+
+	// 两个请求读到同一份次数未满的链接，只有一个能递增
+	stale := *link
+	file := &models.VirtualFile{ID: 1}
+
+	if !s.count(newTestContext("10.0.0.1"), &stale, file, true) {
+		t.Fatal("first download should pass")
+	}
+
+	if s.count(newTestContext("10.0.0.2"), &stale, file, true) {
+		t.Error("second download should be rejected by the conditional update")
+	}
+
+	// 被拒绝的请求不应留下去重记录
+	if _, ok := s.counted.Get("1:1:10.0.0.2:true"); ok {
+		t.Error("rejected download should not be cached")
+	}
+}
@@ -0,0 +1,37 @@
+package share
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patrickmn/go-cache"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	Save() gin.HandlerFunc
+	List() gin.HandlerFunc
+	Delete() gin.HandlerFunc
+	LinkMiddleware(requirePassword bool) gin.HandlerFunc
+	FileMiddleware(download bool) gin.HandlerFunc
+	Info() gin.HandlerFunc
+	Verify() gin.HandlerFunc
+	Browse() gin.HandlerFunc
+}
+
+type service struct {
+	db     *gorm.DB
+	logger *zap.Logger
+	// counted 同一访问者在窗口期内对同一文件的多次请求只计一次，播放器和断点续传会分段请求
+	counted *cache.Cache
+}
+
+// NewService 创建分享链接服务
+func NewService(db *gorm.DB, logger *zap.Logger) Service {
+	return &service{
+		db:      db,
+		logger:  logger,
+		counted: cache.New(countWindow, time.Minute*10),
+	}
+}
@@ -0,0 +1,86 @@
+package share
+
+import (
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+)
+
+type browseResponse struct {
+	File     *shareFile   `json:"file"`
+	Children []*shareFile `json:"children"` // path 为文件时为空
+}
+
+// Browse 浏览分享内 path 对应的文件或文件夹
+func (s *service) Browse() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var (
+			link  = ctx.MustGet(ctxKeyShareLink).(*models.ShareLink)
+			root  = ctx.MustGet(ctxKeyShareFile).(*models.VirtualFile)
+			owner = ctx.MustGet(ctxKeyShareOwner).(*models.User)
+			dir   = "/" + strings.Join(splitPath(ctx.Query("path")), "/")
+		)
+
+		file, err := s.resolvePath(ctx, owner, root, dir)
+		if err != nil {
+			if !errors.Is(err, errFileNotFound) && !errors.Is(err, errNoPermission) {
+				s.logger.Error("查找分享文件失败", zap.Int64("link_id", link.ID), zap.Error(err))
+			}
+
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"code": http.StatusNotFound,
+				"msg":  "文件未找到",
+			})
+			return
+		}
+
+		resp := &browseResponse{
+			File:     newShareFile(file, dir),
+			Children: make([]*shareFile, 0),
+		}
+
+		if file.IsFolder != 1 {
+			ctx.JSON(http.StatusOK, resp)
+			return
+		}
+
+		var children []*models.VirtualFile
+		if err = s.db.WithContext(ctx).
+			Where("parent_id = ? AND os_type <> ?", file.ID, models.OsTypeStrmFile).
+			Find(&children).Error; err != nil {
+			s.logger.Error("查询分享目录失败", zap.Int64("link_id", link.ID), zap.Error(err))
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+			return
+		}
+
+		for _, child := range children {
+			if child.IsTop == 1 {
+				if ok, _ := s.topAccessible(ctx, owner, child); !ok {
+					continue
+				}
+			}
+
+			resp.Children = append(resp.Children, newShareFile(child, path.Join(dir, child.Name)))
+		}
+
+		sort.SliceStable(resp.Children, func(i, j int) bool {
+			if resp.Children[i].IsFolder != resp.Children[j].IsFolder {
+				return resp.Children[i].IsFolder
+			}
+
+			return resp.Children[i].Name < resp.Children[j].Name
+		})
+
+		ctx.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,72 @@
+package share
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+)
+
+type deleteRequest struct {
+	ID int64 `json:"id" binding:"required,min=1"`
+}
+
+// Delete 取消分享，普通用户只能取消自己的，管理员可取消任意用户的
+func (s *service) Delete() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(deleteRequest)
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		var (
+			uid            = ctx.GetInt64("user_id")
+			permissions, _ = ctx.Value("permissions").(uint8)
+			isAdmin        = permissions&models.PermissionAdmin != 0
+		)
+
+		var link = new(models.ShareLink)
+		if err := s.db.WithContext(ctx).Where("id = ?", req.ID).First(link).Error; err != nil || (link.UserID != uid && !isAdmin) {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"code": http.StatusNotFound,
+				"msg":  "分享不存在",
+			})
+			return
+		}
+
+		if err := s.db.WithContext(ctx).Delete(link).Error; err != nil {
+			s.logger.Error("share link delete failure", zap.Int64("id", link.ID), zap.Error(err))
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "取消分享失败",
+			})
+			return
+		}
+
+		if link.UserID != uid {
+			audit.Record(ctx, audit.Entry{
+				Action:     models.AuditShareLinkDelete,
+				TargetType: audit.TargetShareLink,
+				TargetId:   link.ID,
+				TargetName: link.Name,
+				Before: gin.H{
+					"code":   link.Code,
+					"userId": link.UserID,
+					"fileId": link.FileID,
+				},
+			})
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"code": http.StatusOK,
+			"msg":  "已取消分享",
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package share
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patrickmn/go-cache"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type infoResponse struct {
+	Code               string     `json:"code"`
+	Name               string     `json:"name"`
+	IsFolder           bool       `json:"isFolder"`
+	Size               int64      `json:"size"`
+	OwnerName          string     `json:"ownerName"`
+	ExpiresAt          *time.Time `json:"expiresAt"`
+	AllowPreview       bool       `json:"allowPreview"`
+	AllowDownload      bool       `json:"allowDownload"`
+	PasswordRequired   bool       `json:"passwordRequired"`   // 需要输入提取码，携带有效 token 时为 false
+	RemainingDownloads int64      `json:"remainingDownloads"` // 剩余下载次数，-1 表示不限
+	Status             string     `json:"status"`
+	CreatedAt          time.Time  `json:"createdAt"`
+}
+
+// Info 分享页的基本信息，无需提取码，用于展示分享者和提示输入提取码
+func (s *service) Info() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var (
+			link  = ctx.MustGet(ctxKeyShareLink).(*models.ShareLink)
+			root  = ctx.MustGet(ctxKeyShareFile).(*models.VirtualFile)
+			owner = ctx.MustGet(ctxKeyShareOwner).(*models.User)
+			now   = time.Now()
+		)
+
+		if err := s.counted.Add(fmt.Sprintf("%d:view:%s", link.ID, ctx.ClientIP()), struct{}{}, cache.DefaultExpiration); err == nil {
+			if err = s.db.WithContext(ctx).Model(new(models.ShareLink)).
+				Where("id = ?", link.ID).
+				Updates(map[string]any{
+					"view_count":     gorm.Expr("view_count + 1"),
+					"last_access_at": now,
+				}).Error; err != nil {
+				s.logger.Warn("更新分享访问次数失败", zap.Int64("link_id", link.ID), zap.Error(err))
+			}
+		}
+
+		var remaining int64 = -1
+		if link.MaxDownloads > 0 {
+			remaining = max(link.MaxDownloads-link.DownloadCount, 0)
+		}
+
+		ctx.JSON(http.StatusOK, &infoResponse{
+			Code:               link.Code,
+			Name:               root.Name,
+			IsFolder:           root.IsFolder == 1,
+			Size:               root.Size,
+			OwnerName:          owner.Username,
+			ExpiresAt:          link.ExpiresAt,
+			AllowPreview:       link.AllowPreview,
+			AllowDownload:      link.AllowDownload,
+			PasswordRequired:   link.Password != "" && !validAccessToken(link, ctx.Query("token")),
+			RemainingDownloads: remaining,
+			Status:             linkStatus(link, now),
+			CreatedAt:          link.CreatedAt,
+		})
+	}
+}
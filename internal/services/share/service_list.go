@@ -0,0 +1,122 @@
+package share
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+)
+
+type listRequest struct {
+	All         bool  `form:"all" binding:"omitempty"` // 管理员查看所有用户的分享
+	FileID      int64 `form:"fileId" binding:"omitempty,min=1"`
+	CurrentPage int   `form:"currentPage" binding:"omitempty"`
+	PageSize    int   `form:"pageSize" binding:"omitempty,max=100"`
+}
+
+type listResponse struct {
+	Total       int64       `json:"total"`
+	CurrentPage int         `json:"currentPage"`
+	PageSize    int         `json:"pageSize"`
+	Data        []*linkItem `json:"data"`
+}
+
+// List 查看自己创建的分享链接及访问统计，按创建时间倒序
+func (s *service) List() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(listRequest)
+		if err := ctx.ShouldBindQuery(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		if req.CurrentPage <= 0 {
+			req.CurrentPage = 1
+		}
+
+		if req.PageSize <= 0 {
+			req.PageSize = 10
+		}
+
+		var (
+			permissions, _ = ctx.Value("permissions").(uint8)
+			isAdmin        = permissions&models.PermissionAdmin != 0
+		)
+
+		query := s.db.WithContext(ctx).Model(&models.ShareLink{})
+
+		if !req.All || !isAdmin {
+			query = query.Where("user_id = ?", ctx.GetInt64("user_id"))
+		}
+
+		if req.FileID > 0 {
+			query = query.Where("file_id = ?", req.FileID)
+		}
+
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+			return
+		}
+
+		var list = make([]*models.ShareLink, 0)
+		if err := query.Order("id DESC").
+			Offset((req.CurrentPage - 1) * req.PageSize).
+			Limit(req.PageSize).
+			Find(&list).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+			return
+		}
+
+		// 补充用户名
+		var users = make([]*models.User, 0)
+		if len(list) > 0 {
+			userIds := lo.Uniq(lo.Map(list, func(item *models.ShareLink, _ int) int64 {
+				return item.UserID
+			}))
+
+			if err := s.db.WithContext(ctx).Select("id", "username").Where("id IN ?", userIds).Find(&users).Error; err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{
+					"code": http.StatusInternalServerError,
+					"msg":  "查询用户失败",
+				})
+				return
+			}
+		}
+
+		names := lo.SliceToMap(users, func(user *models.User) (int64, string) {
+			return user.ID, user.Username
+		})
+
+		now := time.Now()
+
+		data := make([]*linkItem, 0, len(list))
+		for _, item := range list {
+			data = append(data, &linkItem{
+				ShareLink:   item,
+				HasPassword: item.Password != "",
+				URL:         linkURL(ctx, item.Code),
+				Status:      linkStatus(item, now),
+				Username:    names[item.UserID],
+			})
+		}
+
+		ctx.JSON(http.StatusOK, &listResponse{
+			Total:       count,
+			CurrentPage: req.CurrentPage,
+			PageSize:    req.PageSize,
+			Data:        data,
+		})
+	}
+}
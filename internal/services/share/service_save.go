@@ -0,0 +1,200 @@
+package share
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var passwordPattern = regexp.MustCompile(`^[a-zA-Z0-9]*$`)
+
+type saveRequest struct {
+	ID            int64      `json:"id" binding:"omitempty,min=1"`                         // 为空时新建
+	FileID        int64      `json:"fileId" binding:"required_without=ID,omitempty,min=1"` // 新建时必填，创建后不可修改
+	Password      *string    `json:"password" binding:"omitempty,max=32"`                  // 修改时为空保持不变，空字符串表示取消提取码
+	ExpiresAt     *time.Time `json:"expiresAt"`
+	MaxDownloads  int64      `json:"maxDownloads" binding:"omitempty,min=0"`
+	AllowPreview  bool       `json:"allowPreview"`
+	AllowDownload bool       `json:"allowDownload"`
+}
+
+type linkItem struct {
+	*models.ShareLink
+	HasPassword bool   `json:"hasPassword"`
+	URL         string `json:"url"`
+	Status      string `json:"status"`
+	Username    string `json:"username,omitempty"`
+}
+
+// Save 新建或修改分享链接，只能分享自己可以访问的文件；管理员可以修改他人的分享
+func (s *service) Save() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(saveRequest)
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		if req.Password != nil && !passwordPattern.MatchString(*req.Password) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  "提取码只能包含字母和数字",
+			})
+			return
+		}
+
+		if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  "过期时间必须晚于当前时间",
+			})
+			return
+		}
+
+		var (
+			link *models.ShareLink
+			err  error
+		)
+
+		if req.ID == 0 {
+			link, err = s.create(ctx, req)
+		} else {
+			link, err = s.update(ctx, req)
+		}
+
+		if err != nil {
+			var (
+				status = http.StatusInternalServerError
+				msg    = "保存分享链接失败"
+			)
+
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				status, msg = http.StatusNotFound, "分享不存在"
+			case errors.Is(err, errFileNotFound):
+				status, msg = http.StatusNotFound, err.Error()
+			case errors.Is(err, errNoPermission):
+				status, msg = http.StatusForbidden, err.Error()
+			default:
+				s.logger.Error("保存分享链接失败", zap.Int64("id", req.ID), zap.Error(err))
+			}
+
+			ctx.JSON(status, gin.H{
+				"code": status,
+				"msg":  msg,
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, &linkItem{
+			ShareLink:   link,
+			HasPassword: link.Password != "",
+			URL:         linkURL(ctx, link.Code),
+			Status:      linkStatus(link, time.Now()),
+		})
+	}
+}
+
+func (s *service) create(ctx *gin.Context, req *saveRequest) (*models.ShareLink, error) {
+	var user = new(models.User)
+	if err := s.db.WithContext(ctx).Where("id = ?", ctx.GetInt64("user_id")).First(user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errNoPermission
+		}
+
+		return nil, err
+	}
+
+	var file = new(models.VirtualFile)
+	if err := s.db.WithContext(ctx).Where("id = ?", req.FileID).First(file).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errFileNotFound
+		}
+
+		return nil, err
+	}
+
+	// strm 文件只是指向下载地址的文本，分享没有意义
+	if file.OsType == models.OsTypeStrmFile {
+		return nil, errFileNotFound
+	}
+
+	ok, err := s.fileAccessible(ctx, user, file)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, errNoPermission
+	}
+
+	link := &models.ShareLink{
+		UserID:        user.ID,
+		FileID:        file.ID,
+		Name:          file.Name,
+		ExpiresAt:     req.ExpiresAt,
+		MaxDownloads:  req.MaxDownloads,
+		AllowPreview:  req.AllowPreview,
+		AllowDownload: req.AllowDownload,
+	}
+
+	// 分享码冲突的概率极低，重试几次即可
+	for i := 0; i < 3; i++ {
+		link.Code = generateCode()
+		link.Password = hashPassword(link.Code, lo.FromPtr(req.Password))
+
+		if err = s.db.WithContext(ctx).Create(link).Error; err == nil {
+			return link, nil
+		}
+	}
+
+	return nil, err
+}
+
+func (s *service) update(ctx *gin.Context, req *saveRequest) (*models.ShareLink, error) {
+	var (
+		uid            = ctx.GetInt64("user_id")
+		permissions, _ = ctx.Value("permissions").(uint8)
+		isAdmin        = permissions&models.PermissionAdmin != 0
+	)
+
+	var link = new(models.ShareLink)
+	if err := s.db.WithContext(ctx).Where("id = ?", req.ID).First(link).Error; err != nil {
+		return nil, err
+	}
+
+	if link.UserID != uid && !isAdmin {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	updates := map[string]any{
+		"expires_at":     req.ExpiresAt,
+		"max_downloads":  req.MaxDownloads,
+		"allow_preview":  req.AllowPreview,
+		"allow_download": req.AllowDownload,
+	}
+
+	if req.Password != nil {
+		updates["password"] = hashPassword(link.Code, *req.Password)
+	}
+
+	if err := s.db.WithContext(ctx).Model(link).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+
+	if err := s.db.WithContext(ctx).Where("id = ?", link.ID).First(link).Error; err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
@@ -0,0 +1,73 @@
+package share
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/authguard"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+)
+
+type verifyRequest struct {
+	Password string `json:"password" binding:"required,max=32"`
+}
+
+// Verify 校验提取码，通过后签发访问 token；失败次数计入登录保护，按分享码和 IP 锁定
+func (s *service) Verify() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(verifyRequest)
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		var (
+			link     = ctx.MustGet(ctxKeyShareLink).(*models.ShareLink)
+			username = "share:" + link.Code
+		)
+
+		if wait, locked := authguard.Check(ctx, username); locked {
+			ctx.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+
+			ctx.JSON(http.StatusTooManyRequests, gin.H{
+				"code": http.StatusTooManyRequests,
+				"msg":  "失败次数过多，请 " + strconv.Itoa(int(wait.Minutes())+1) + " 分钟后再试",
+			})
+			return
+		}
+
+		if subtle.ConstantTimeCompare([]byte(hashPassword(link.Code, req.Password)), []byte(link.Password)) != 1 {
+			authguard.Fail(ctx, models.LoginFailureSourceShare, username, authguard.ReasonBadPassword)
+
+			ctx.JSON(http.StatusUnauthorized, gin.H{
+				"code": http.StatusUnauthorized,
+				"msg":  "提取码错误",
+			})
+			return
+		}
+
+		authguard.Succeed(username)
+
+		token, err := generateAccessToken(link)
+		if err != nil {
+			s.logger.Error("生成分享访问token失败", zap.Int64("link_id", link.ID), zap.Error(err))
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "验证失败",
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"token":     token,
+			"expiresIn": int64(accessTokenExpire.Seconds()),
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/xxcheng123/cloudpan189-interface/client"
+	"github.com/xxcheng123/cloudpan189-share/internal/metrics"
 	"github.com/xxcheng123/cloudpan189-share/internal/types"
 )
 
@@ -35,7 +36,7 @@ func (s *service) FamilyList() gin.HandlerFunc {
 			return
 		}
 
-		resp, err := client.New().
+		resp, err := metrics.NewClient().
 			WithToken(client.NewAuthToken(token.AccessToken, token.ExpiresIn)).
 			GetFamilyList(ctx)
 		if err != nil {
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/xxcheng123/cloudpan189-interface/client"
+	"github.com/xxcheng123/cloudpan189-share/internal/metrics"
 	"github.com/xxcheng123/cloudpan189-share/internal/types"
 )
 
@@ -46,7 +47,7 @@ func (s *service) GetFamilyNodes() gin.HandlerFunc {
 			return
 		}
 
-		resp, err := client.New().WithToken(client.NewAuthToken(token.AccessToken, token.ExpiresIn)).FamilyListFiles(ctx, client.String(req.FamilyId), client.String(req.ID), func(req2 *client.FamilyListFilesRequest) {
+		resp, err := metrics.NewClient().WithToken(client.NewAuthToken(token.AccessToken, token.ExpiresIn)).FamilyListFiles(ctx, client.String(req.FamilyId), client.String(req.ID), func(req2 *client.FamilyListFilesRequest) {
 			req2.PageSize = req.PageSize
 			req2.PageNum = req.CurrentPage
 		})
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/xxcheng123/cloudpan189-interface/client"
+	"github.com/xxcheng123/cloudpan189-share/internal/metrics"
 	"github.com/xxcheng123/cloudpan189-share/internal/types"
 )
 
@@ -45,7 +46,7 @@ func (s *service) GetPersonNodes() gin.HandlerFunc {
 			return
 		}
 
-		resp, err := client.New().WithToken(client.NewAuthToken(token.AccessToken, token.ExpiresIn)).ListFiles(ctx, client.String(req.ID), func(req2 *client.ListFilesRequest) {
+		resp, err := metrics.NewClient().WithToken(client.NewAuthToken(token.AccessToken, token.ExpiresIn)).ListFiles(ctx, client.String(req.ID), func(req2 *client.ListFilesRequest) {
 			req2.PageSize = req.PageSize
 			req2.PageNum = req.CurrentPage
 		})
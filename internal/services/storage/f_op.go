@@ -9,6 +9,7 @@ import (
 
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/utils"
+	"github.com/xxcheng123/cloudpan189-share/internal/search"
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
@@ -79,6 +80,8 @@ func (s *service) createAncestors(ctx context.Context, path string) error {
 					return err
 				}
 
+				search.Index(ctx, m)
+
 				pid = m.ID
 
 				continue
@@ -132,6 +135,8 @@ func (s *service) findOrCreateAncestors(ctx context.Context, path string) (int64
 					return 0, err
 				}
 
+				search.Index(ctx, m)
+
 				pid = m.ID
 
 				continue
@@ -19,11 +19,14 @@ type Service interface {
 	Delete() gin.HandlerFunc
 	List() gin.HandlerFunc
 	ModifyToken() gin.HandlerFunc
+	ModifyTokenPool() gin.HandlerFunc
+	ModifyMirrorGroup() gin.HandlerFunc
 	BatchBindToken() gin.HandlerFunc
 	DeepRefreshFile() gin.HandlerFunc
 	Search() gin.HandlerFunc
 	ToggleAutoScan() gin.HandlerFunc
 	ScanTop() gin.HandlerFunc
+	SaveShare() gin.HandlerFunc
 }
 
 type service struct {
@@ -10,9 +10,12 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 	"github.com/xxcheng123/cloudpan189-interface/client"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
 	"github.com/xxcheng123/cloudpan189-share/internal/consts"
+	"github.com/xxcheng123/cloudpan189-share/internal/metrics"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/utils"
+	"github.com/xxcheng123/cloudpan189-share/internal/search"
 	"gorm.io/datatypes"
 )
 
@@ -154,7 +157,7 @@ func (s *service) Add() gin.HandlerFunc {
 		}
 
 		if req.Protocol == "subscribe" {
-			_, err := client.New().GetUpResourceShare(ctx, req.SubscribeUser, 1, 30)
+			_, err := metrics.NewClient().GetUpResourceShare(ctx, req.SubscribeUser, 1, 30)
 			if err != nil {
 				ctx.JSON(http.StatusInternalServerError, types.ErrResponse{
 					Code:    http.StatusInternalServerError,
@@ -174,7 +177,7 @@ func (s *service) Add() gin.HandlerFunc {
 				})
 			}
 
-			resp, err := client.New().GetShareInfo(ctx, req.ShareCode, opts...)
+			resp, err := metrics.NewClient().GetShareInfo(ctx, req.ShareCode, opts...)
 			if err != nil {
 				var clientErr = new(client.RespErr)
 				if errors.As(err, &clientErr) {
@@ -226,7 +229,7 @@ func (s *service) Add() gin.HandlerFunc {
 			m.Addition[consts.FileAdditionKeyIsFolder] = true
 			m.Addition[consts.FileAdditionKeyFileId] = req.FileId
 
-			ct := client.New().WithToken(client.NewAuthToken(token.AccessToken, token.ExpiresIn))
+			ct := metrics.NewClient().WithToken(client.NewAuthToken(token.AccessToken, token.ExpiresIn))
 
 			if req.Protocol == "person" {
 				_, err = ct.ListFiles(ctx, client.String(req.FileId))
@@ -255,7 +258,7 @@ func (s *service) Add() gin.HandlerFunc {
 				m.OsType = models.OsTypeCloudFamilyFolder
 			}
 		} else if req.Protocol == "subscribe_share" {
-			resp, err := client.New().GetShareInfo(ctx, req.ShareCode)
+			resp, err := metrics.NewClient().GetShareInfo(ctx, req.ShareCode)
 			if err != nil {
 				var clientErr = new(client.RespErr)
 				if errors.As(err, &clientErr) {
@@ -312,7 +315,25 @@ func (s *service) Add() gin.HandlerFunc {
 			return
 		}
 
-		if err = bus.PublishVirtualFileRefresh(ctx, m.ID, false); err != nil {
+		search.Index(ctx, m)
+
+		// 提取码不记录
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditStorageAdd,
+			TargetType: audit.TargetStorage,
+			TargetId:   m.ID,
+			TargetName: req.LocalPath,
+			After: gin.H{
+				"protocol":      req.Protocol,
+				"subscribeUser": req.SubscribeUser,
+				"shareCode":     req.ShareCode,
+				"cloudToken":    req.CloudToken,
+				"fileId":        req.FileId,
+				"familyId":      req.FamilyId,
+			},
+		})
+
+		if err = bus.PublishVirtualFileRefresh(ctx, m.ID, false, models.ScanTriggerAPI); err != nil {
 			ctx.JSON(http.StatusInternalServerError, types.ErrResponse{
 				Code:    http.StatusInternalServerError,
 				Message: "创建成功，但是刷新文件失败",
@@ -3,6 +3,7 @@ package storage
 import (
 	"net/http"
 
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
 	"github.com/xxcheng123/cloudpan189-share/internal/consts"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 
@@ -68,11 +69,21 @@ func (s *service) BatchBindToken() gin.HandlerFunc {
 				file.Addition = make(map[string]interface{})
 			}
 
+			before := file.Addition[consts.FileAdditionKeyCloudToken]
 			file.Addition[consts.FileAdditionKeyCloudToken] = req.CloudToken
 
 			result := s.db.Model(&models.VirtualFile{}).Where("id = ?", file.ID).Update("addition", file.Addition)
 			if result.Error == nil && result.RowsAffected > 0 {
 				successCount++
+
+				audit.Record(ctx, audit.Entry{
+					Action:     models.AuditStorageBindToken,
+					TargetType: audit.TargetStorage,
+					TargetId:   file.ID,
+					TargetName: file.Name,
+					Before:     before,
+					After:      req.CloudToken,
+				})
 			} else {
 				failedFiles = append(failedFiles, file.ID)
 			}
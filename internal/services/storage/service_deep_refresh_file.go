@@ -45,7 +45,7 @@ func (s *service) DeepRefreshFile() gin.HandlerFunc {
 			return
 		}
 
-		if err := bus.PublishVirtualFileRefresh(ctx, file.ID, true); err != nil {
+		if err := bus.PublishVirtualFileRefresh(ctx, file.ID, true, models.ScanTriggerManual); err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{
 				"code": http.StatusInternalServerError,
 				"msg":  "下方刷新指令失败，请稍后再试",
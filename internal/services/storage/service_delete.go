@@ -7,6 +7,7 @@ import (
 	"github.com/xxcheng123/cloudpan189-share/internal/bus"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 	"gorm.io/gorm"
 )
@@ -24,6 +25,8 @@ func (s *service) Delete() gin.HandlerFunc {
 				"code":    http.StatusBadRequest,
 				"message": err.Error(),
 			})
+
+			return
 		}
 
 		var file = models.VirtualFile{}
@@ -101,6 +104,18 @@ func (s *service) Delete() gin.HandlerFunc {
 			return
 		}
 
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditStorageDelete,
+			TargetType: audit.TargetStorage,
+			TargetId:   file.ID,
+			TargetName: file.Name,
+			Before: gin.H{
+				"requestId": req.ID,
+				"parentId":  file.ParentId,
+				"osType":    file.OsType,
+			},
+		})
+
 		ctx.JSON(http.StatusOK, gin.H{
 			"code":    http.StatusOK,
 			"message": "已添加到删除任务队列，请稍后查看删除结果",
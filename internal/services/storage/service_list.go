@@ -14,12 +14,14 @@ type listRequest struct {
 	PageSize    int    `form:"pageSize" binding:"omitempty"`
 	NoPaginate  bool   `form:"noPaginate" binding:"omitempty"`
 	Name        string `form:"name" binding:"omitempty"`
+	HealthState string `form:"healthState" binding:"omitempty"`
 }
 
 type FileItem struct {
 	*models.VirtualFile
 	LocalPath    string            `json:"localPath"`
 	FileScanStat *bus.FileScanStat `json:"fileScanStat,omitempty"`
+	Health       *bus.MountHealth  `json:"health,omitempty"`
 }
 
 type listResponse struct {
@@ -48,6 +50,10 @@ func (s *service) List() gin.HandlerFunc {
 			query = query.Where("name like ?", "%"+req.Name+"%")
 		}
 
+		if req.HealthState != "" {
+			query = query.Where("json_extract(addition, '$.health_state') = ?", req.HealthState)
+		}
+
 		if !req.NoPaginate {
 			if req.CurrentPage <= 0 {
 				req.CurrentPage = 1
@@ -89,6 +95,7 @@ func (s *service) List() gin.HandlerFunc {
 				VirtualFile:  v,
 				LocalPath:    p,
 				FileScanStat: bus.FindScanFileStat(v.ID),
+				Health:       bus.FindMountHealth(v),
 			})
 		}
 
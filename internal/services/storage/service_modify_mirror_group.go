@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
+	"github.com/xxcheng123/cloudpan189-share/internal/consts"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type modifyMirrorGroupRequest struct {
+	ID          int64  `json:"id" binding:"required"`
+	MirrorGroup string `json:"mirrorGroup" binding:"max=64"` // 为空时移出镜像组
+}
+
+type modifyMirrorGroupResponse struct {
+	RowsAffected int64 `json:"rowsAffected"`
+}
+
+// ModifyMirrorGroup 设置挂载点所属的镜像组，同组挂载点中相同 Hash+Size 的文件在下载失败时互为备用源
+func (s *service) ModifyMirrorGroup() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req modifyMirrorGroupRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+
+			return
+		}
+
+		file := new(models.VirtualFile)
+		if err := s.db.First(file, req.ID).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"code": http.StatusNotFound,
+				"msg":  "文件不存在",
+			})
+
+			return
+		}
+
+		if file.IsTop != 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  "不是挂载点",
+			})
+
+			return
+		}
+
+		if file.Addition == nil {
+			file.Addition = map[string]interface{}{}
+		}
+
+		before := file.Addition[consts.FileAdditionKeyMirrorGroup]
+
+		if group := strings.TrimSpace(req.MirrorGroup); group == "" {
+			delete(file.Addition, consts.FileAdditionKeyMirrorGroup)
+		} else {
+			file.Addition[consts.FileAdditionKeyMirrorGroup] = group
+		}
+
+		result := s.db.Model(&models.VirtualFile{}).Where("id = ?", req.ID).Update("addition", file.Addition)
+
+		if result.Error != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "修改失败",
+			})
+
+			return
+		}
+
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditStorageMirrorGroup,
+			TargetType: audit.TargetStorage,
+			TargetId:   file.ID,
+			TargetName: file.Name,
+			Before:     before,
+			After:      strings.TrimSpace(req.MirrorGroup),
+		})
+
+		ctx.JSON(http.StatusOK, modifyMirrorGroupResponse{
+			RowsAffected: result.RowsAffected,
+		})
+	}
+}
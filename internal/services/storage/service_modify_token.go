@@ -3,6 +3,7 @@ package storage
 import (
 	"net/http"
 
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
 	"github.com/xxcheng123/cloudpan189-share/internal/consts"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 
@@ -49,6 +50,7 @@ func (s *service) ModifyToken() gin.HandlerFunc {
 			return
 		}
 
+		before := file.Addition[consts.FileAdditionKeyCloudToken]
 		file.Addition[consts.FileAdditionKeyCloudToken] = req.CloudToken
 
 		result := s.db.Model(&models.VirtualFile{}).Where("id = ?", req.ID).Update("addition", file.Addition)
@@ -62,6 +64,15 @@ func (s *service) ModifyToken() gin.HandlerFunc {
 			return
 		}
 
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditStorageModifyToken,
+			TargetType: audit.TargetStorage,
+			TargetId:   file.ID,
+			TargetName: file.Name,
+			Before:     before,
+			After:      req.CloudToken,
+		})
+
 		ctx.JSON(http.StatusOK, modifyTokenResponse{
 			RowsAffected: result.RowsAffected,
 		})
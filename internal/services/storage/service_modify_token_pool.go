@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"net/http"
+
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
+	"github.com/xxcheng123/cloudpan189-share/internal/consts"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/tokenpool"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+)
+
+type modifyTokenPoolRequest struct {
+	ID          int64   `json:"id" binding:"required"`
+	CloudTokens []int64 `json:"cloudTokens"`                                        // 备用令牌，为空时清除令牌池
+	Strategy    string  `json:"strategy" binding:"omitempty,oneof=round_robin lru"` // 默认 round_robin
+}
+
+type modifyTokenPoolResponse struct {
+	RowsAffected int64 `json:"rowsAffected"`
+}
+
+// ModifyTokenPool 设置挂载点的备用令牌池及选择策略，主令牌仍由 modify_token 设置
+func (s *service) ModifyTokenPool() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req modifyTokenPoolRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+
+			return
+		}
+
+		file := new(models.VirtualFile)
+		if err := s.db.WithContext(ctx).First(file, req.ID).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"code": http.StatusNotFound,
+				"msg":  "文件不存在",
+			})
+
+			return
+		}
+
+		if file.IsTop != 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  "不是挂载点",
+			})
+
+			return
+		}
+
+		if _, ok := file.Addition[consts.FileAdditionKeyCloudToken]; !ok {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  "挂载点未绑定主令牌",
+			})
+
+			return
+		}
+
+		// 清除令牌池不限挂载类型
+		if len(req.CloudTokens) > 0 && !lo.Contains(models.TokenPoolOsTypes, file.OsType) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  "只有分享类挂载可以配置令牌池，个人云和家庭云的文件只有所有者的令牌可以访问",
+			})
+
+			return
+		}
+
+		for _, id := range req.CloudTokens {
+			if _, err := s.getCloudToken(ctx, id); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{
+					"code": http.StatusBadRequest,
+					"msg":  err.Error(),
+				})
+
+				return
+			}
+		}
+
+		before := gin.H{
+			"cloudTokens": file.Addition[consts.FileAdditionKeyCloudTokenPool],
+			"strategy":    file.Addition[consts.FileAdditionKeyCloudTokenStrategy],
+		}
+
+		if len(req.CloudTokens) == 0 {
+			delete(file.Addition, consts.FileAdditionKeyCloudTokenPool)
+			delete(file.Addition, consts.FileAdditionKeyCloudTokenStrategy)
+		} else {
+			if req.Strategy == "" {
+				req.Strategy = tokenpool.StrategyRoundRobin
+			}
+
+			file.Addition[consts.FileAdditionKeyCloudTokenPool] = req.CloudTokens
+			file.Addition[consts.FileAdditionKeyCloudTokenStrategy] = req.Strategy
+		}
+
+		result := s.db.WithContext(ctx).Model(&models.VirtualFile{}).Where("id = ?", req.ID).Update("addition", file.Addition)
+
+		if result.Error != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "修改失败",
+			})
+
+			return
+		}
+
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditStorageTokenPool,
+			TargetType: audit.TargetStorage,
+			TargetId:   file.ID,
+			TargetName: file.Name,
+			Before:     before,
+			After: gin.H{
+				"cloudTokens": req.CloudTokens,
+				"strategy":    req.Strategy,
+			},
+		})
+
+		ctx.JSON(http.StatusOK, modifyTokenPoolResponse{
+			RowsAffected: result.RowsAffected,
+		})
+	}
+}
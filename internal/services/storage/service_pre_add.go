@@ -6,6 +6,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
 	"github.com/xxcheng123/cloudpan189-interface/client"
+	"github.com/xxcheng123/cloudpan189-share/internal/metrics"
 	"github.com/xxcheng123/cloudpan189-share/internal/types"
 )
 
@@ -65,7 +66,7 @@ func (s *service) PreAdd() gin.HandlerFunc {
 		var name string
 
 		if req.Protocol == "subscribe" {
-			subscribeUser, err := client.New().SubscribeGetUser(ctx, req.SubscribeUser)
+			subscribeUser, err := metrics.NewClient().SubscribeGetUser(ctx, req.SubscribeUser)
 			if err != nil {
 				ctx.JSON(http.StatusInternalServerError, gin.H{
 					"code":    http.StatusInternalServerError,
@@ -85,7 +86,7 @@ func (s *service) PreAdd() gin.HandlerFunc {
 				})
 			}
 
-			resp, err := client.New().GetShareInfo(ctx, req.ShareCode, opts...)
+			resp, err := metrics.NewClient().GetShareInfo(ctx, req.ShareCode, opts...)
 			if err != nil {
 				var clientErr = new(client.RespErr)
 				if errors.As(err, &clientErr) {
@@ -118,7 +119,7 @@ func (s *service) PreAdd() gin.HandlerFunc {
 
 			name = resp.FileName
 		} else if req.Protocol == "subscribe_share" {
-			resp, err := client.New().GetShareInfo(ctx, req.ShareCode)
+			resp, err := metrics.NewClient().GetShareInfo(ctx, req.ShareCode)
 			if err != nil {
 				var clientErr = new(client.RespErr)
 				if errors.As(err, &clientErr) {
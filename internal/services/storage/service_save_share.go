@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"net/http"
+
+	"github.com/xxcheng123/cloudpan189-share/internal/bus"
+	"github.com/xxcheng123/cloudpan189-share/internal/consts"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+)
+
+type saveShareRequest struct {
+	ID             int64  `json:"id" binding:"required"`             // 分享中的文件或文件夹
+	CloudToken     int64  `json:"cloudToken" binding:"required"`     // 转存到哪个令牌
+	FamilyId       string `json:"familyId"`                          // 家庭云ID，为空时转存到个人云
+	TargetFolderId string `json:"targetFolderId" binding:"required"` // 目标目录的云盘ID
+}
+
+// SaveShare 将分享中的文件转存到自己的云盘，转存进度可在 bus_detail 中查看
+func (s *service) SaveShare() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req = new(saveShareRequest)
+
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  "参数错误",
+			})
+
+			return
+		}
+
+		file := new(models.VirtualFile)
+		if err := s.db.WithContext(ctx).Where("id = ?", req.ID).First(file).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"code": http.StatusNotFound,
+				"msg":  "文件不存在",
+			})
+
+			return
+		}
+
+		if _, ok := file.Addition[consts.FileAdditionKeyShareId]; !ok {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  "只允许转存分享中的文件",
+			})
+
+			return
+		}
+
+		if _, err := s.getCloudToken(ctx, req.CloudToken); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+
+			return
+		}
+
+		if err := bus.PublishShareSave(ctx, bus.TopicShareSaveRequest{
+			FileId:         file.ID,
+			CloudTokenId:   req.CloudToken,
+			FamilyId:       req.FamilyId,
+			TargetFolderId: req.TargetFolderId,
+		}); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "下发转存指令失败，请稍后再试",
+			})
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"code": http.StatusOK,
+			"msg":  "转存指令已发送",
+		})
+	}
+}
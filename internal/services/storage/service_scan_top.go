@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/xxcheng123/cloudpan189-share/internal/bus"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,7 +15,7 @@ type scanTopResponse struct {
 
 func (s *service) ScanTop() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		if err := bus.PublishVirtualFileScanTop(ctx); err != nil {
+		if err := bus.PublishVirtualFileScanTop(ctx, models.ScanTriggerManual); err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{
 				"code": http.StatusInternalServerError,
 				"msg":  "发布扫描顶层文件任务失败：" + err.Error(),
@@ -1,27 +1,52 @@
 package storage
 
 import (
+	"database/sql"
 	"net/http"
+	"strings"
 
+	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+	"github.com/xxcheng123/cloudpan189-share/internal/consts"
+	"github.com/xxcheng123/cloudpan189-share/internal/duplicate"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/search"
+	"gorm.io/gorm"
 )
 
 type searchRequest struct {
-	Keyword     string `form:"keyword" binding:"required"`
-	PID         int64  `form:"pid"`    // 父级ID
-	Global      bool   `form:"global"` // 全局搜索（如果为true，忽略pid）
-	PageSize    int    `form:"pageSize" binding:"required,min=1,max=100"`
-	CurrentPage int    `form:"currentPage" binding:"required,min=1"`
+	Keyword        string `form:"keyword"`
+	PID            int64  `form:"pid"`                                                    // 父级ID
+	Global         bool   `form:"global"`                                                 // 全局搜索（如果为true，忽略pid）
+	Ext            string `form:"ext"`                                                    // 扩展名，多个用逗号分隔
+	Type           string `form:"type" binding:"omitempty,oneof=file folder"`             // 只看文件或文件夹
+	MinSize        int64  `form:"minSize" binding:"omitempty,min=0"`                      // 最小大小（字节）
+	MaxSize        int64  `form:"maxSize" binding:"omitempty,min=0"`                      // 最大大小（字节）
+	ModifiedAfter  string `form:"modifiedAfter" binding:"omitempty,datetime=2006-01-02"`  // 修改日期起
+	ModifiedBefore string `form:"modifiedBefore" binding:"omitempty,datetime=2006-01-02"` // 修改日期止
+	MountId        int64  `form:"mountId"`                                                // 只搜索该挂载点
+	Cursor         int64  `form:"cursor"`                                                 // 上一页返回的 nextCursor
+	PageSize       int    `form:"pageSize" binding:"required,min=1,max=100"`
+	CurrentPage    int    `form:"currentPage"` // 兼容旧的分页方式，传入 cursor 时忽略
 }
 
+// searchBatchSize 开启重复文件隐藏时每批读取的行数
+const searchBatchSize = 200
+
 type searchResponse struct {
-	Total       int64       `json:"total"`
+	Total       int64       `json:"total"` // 开启重复文件隐藏时包含被隐藏的副本，仅供参考
 	CurrentPage int         `json:"currentPage"`
 	PageSize    int         `json:"pageSize"`
+	NextCursor  int64       `json:"nextCursor"` // 为 0 时没有下一页
 	Data        []*FileItem `json:"data"`
 }
 
+type searchRow struct {
+	models.VirtualFile
+	FullPath sql.NullString `gorm:"column:full_path"`
+}
+
 func (s *service) Search() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var req searchRequest
@@ -35,18 +60,91 @@ func (s *service) Search() gin.HandlerFunc {
 			return
 		}
 
-		query := s.db.WithContext(ctx).Model(&models.VirtualFile{})
+		query := s.db.WithContext(ctx).Table("virtual_files AS f").
+			Joins("LEFT JOIN " + search.TableName + " AS s ON s.rowid = f.id")
+
 		if !req.Global {
-			query = query.Where("parent_id = ?", req.PID)
+			query = query.Where("f.parent_id = ?", req.PID)
+		}
+
+		if keyword := strings.TrimSpace(req.Keyword); keyword != "" {
+			if expr := search.MatchExpr(keyword); expr != "" {
+				query = query.Where("f.id IN (SELECT rowid FROM "+search.TableName+" WHERE "+search.TableName+" MATCH ?)", expr)
+			} else {
+				query = query.Where("f.name LIKE ?", "%"+keyword+"%")
+			}
+		}
+
+		if req.Ext != "" {
+			var (
+				conditions []string
+				args       []any
+			)
+
+			for _, ext := range strings.Split(req.Ext, ",") {
+				if ext = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(ext)), "."); ext != "" {
+					conditions = append(conditions, "LOWER(f.name) LIKE ?")
+					args = append(args, "%."+ext)
+				}
+			}
+
+			if len(conditions) > 0 {
+				query = query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+			}
+		}
+
+		switch req.Type {
+		case "file":
+			query = query.Where("f.is_folder = 0")
+		case "folder":
+			query = query.Where("f.is_folder = 1")
 		}
 
-		if req.Keyword != "" {
-			query = query.Where("name LIKE ?", "%"+req.Keyword+"%")
+		if req.MinSize > 0 {
+			query = query.Where("f.size >= ?", req.MinSize)
+		}
+
+		if req.MaxSize > 0 {
+			query = query.Where("f.size <= ?", req.MaxSize)
+		}
+
+		if req.ModifiedAfter != "" {
+			query = query.Where("f.modify_date >= ?", req.ModifiedAfter)
+		}
+
+		if req.ModifiedBefore != "" {
+			query = query.Where("f.modify_date <= ?", req.ModifiedBefore+" 23:59:59")
+		}
+
+		if req.MountId > 0 {
+			query = query.Where("s.top_id = ?", req.MountId)
+		}
+
+		// 与目录浏览一致，跳过因异常被隐藏的挂载点
+		query = query.Where("(s.top_id IS NULL OR s.top_id NOT IN (?))", s.db.Model(&models.VirtualFile{}).
+			Select("id").
+			Where("is_top = 1 AND json_extract(addition, '$."+consts.FileAdditionKeyHidden+"') = 1"))
+
+		// 按用户组过滤，只能搜到组内挂载点下的文件
+		var groupFileSet mapset.Set[int64]
+		if gid := ctx.GetInt64(consts.CtxKeyGroupId); gid != 0 {
+			var fileIds []int64
+			if err := s.db.WithContext(ctx).Model(&models.Group2File{}).Where("group_id = ?", gid).Pluck("file_id", &fileIds).Error; err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{
+					"code": http.StatusInternalServerError,
+					"msg":  "查询失败",
+				})
+
+				return
+			}
+
+			groupFileSet = mapset.NewSet(fileIds...)
+			query = query.Where("s.top_id IN ?", append(fileIds, 0))
 		}
 
 		var count int64
 
-		if err := query.Count(&count).Error; err != nil {
+		if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{
 				"code": http.StatusInternalServerError,
 				"msg":  "查询失败",
@@ -55,33 +153,109 @@ func (s *service) Search() gin.HandlerFunc {
 			return
 		}
 
-		if req.CurrentPage <= 0 {
-			req.CurrentPage = 1
+		// WebDAV 中隐藏的重复副本同样不出现在搜索结果中，避免绕过隐藏直接下载；保留的那一份仍可搜到。
+		// 隐藏在分页之前完成，按游标分批多取，直到凑满一页
+		hideDuplicates := duplicate.HideEnabled(models.DuplicateHideTargetWebDAV)
+
+		var (
+			cursor = req.Cursor
+			// skip 兼容旧分页方式时需要跳过的可见文件数
+			skip      int
+			batchSize = req.PageSize + 1
+		)
+
+		if req.Cursor == 0 && req.CurrentPage > 1 {
+			if hideDuplicates {
+				skip = (req.CurrentPage - 1) * req.PageSize
+			} else {
+				query = query.Offset((req.CurrentPage - 1) * req.PageSize)
+			}
 		}
 
-		if req.PageSize <= 0 {
-			req.PageSize = 10
+		if hideDuplicates {
+			batchSize = max(batchSize, searchBatchSize)
 		}
 
-		query = query.Offset((req.CurrentPage - 1) * req.PageSize).Limit(req.PageSize)
+		// 多取一条用于判断是否还有下一页
+		var rows = make([]*searchRow, 0, req.PageSize+1)
 
-		var list = make([]*models.VirtualFile, 0)
+		for len(rows) <= req.PageSize {
+			batchQuery := query.Session(&gorm.Session{})
+			if cursor > 0 {
+				batchQuery = batchQuery.Where("f.id < ?", cursor)
+			}
 
-		if err := query.Find(&list).Error; err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"code": http.StatusInternalServerError,
-				"msg":  "查询失败",
-			})
+			var batch = make([]*searchRow, 0)
 
-			return
+			if err := batchQuery.Select("f.*, s.full_path").
+				Order("f.id DESC").
+				Limit(batchSize).
+				Scan(&batch).Error; err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{
+					"code": http.StatusInternalServerError,
+					"msg":  "查询失败",
+				})
+
+				return
+			}
+
+			visible := batch
+
+			if hideDuplicates && len(batch) > 0 {
+				files := lo.Map(batch, func(row *searchRow, _ int) *models.VirtualFile {
+					return &row.VirtualFile
+				})
+
+				hidden, err := duplicate.FindHidden(ctx, s.db, files, groupFileSet)
+				if err != nil {
+					ctx.JSON(http.StatusInternalServerError, gin.H{
+						"code": http.StatusInternalServerError,
+						"msg":  "查询失败",
+					})
+
+					return
+				}
+
+				visible = lo.Filter(batch, func(row *searchRow, _ int) bool {
+					_, ok := hidden[row.ID]
+
+					return !ok
+				})
+			}
+
+			for _, row := range visible {
+				if skip > 0 {
+					skip--
+
+					continue
+				}
+
+				rows = append(rows, row)
+			}
+
+			if len(batch) < batchSize {
+				break
+			}
+
+			cursor = batch[len(batch)-1].ID
+		}
+
+		var nextCursor int64
+		if len(rows) > req.PageSize {
+			rows = rows[:req.PageSize]
+			nextCursor = rows[len(rows)-1].ID
 		}
 
-		var fileList = make([]*FileItem, 0)
-		for _, v := range list {
-			p, _ := s.getFullPath(ctx, v)
+		var fileList = make([]*FileItem, 0, len(rows))
+		for _, row := range rows {
+			p := row.FullPath.String
+			if !row.FullPath.Valid {
+				// 尚未建立索引的文件
+				p, _ = s.getFullPath(ctx, &row.VirtualFile)
+			}
 
 			fileList = append(fileList, &FileItem{
-				VirtualFile: v,
+				VirtualFile: &row.VirtualFile,
 				LocalPath:   p,
 			})
 		}
@@ -90,6 +264,7 @@ func (s *service) Search() gin.HandlerFunc {
 			Total:       count,
 			CurrentPage: req.CurrentPage,
 			PageSize:    req.PageSize,
+			NextCursor:  nextCursor,
 			Data:        fileList,
 		})
 	}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
 	"github.com/xxcheng123/cloudpan189-share/internal/consts"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 	"gorm.io/datatypes"
@@ -49,6 +50,8 @@ func (s *service) ToggleAutoScan() gin.HandlerFunc {
 			file.Addition = make(datatypes.JSONMap)
 		}
 
+		before := file.Addition[consts.FileAdditionKeyDisableAutoScan]
+
 		// 设置 disable_auto_scan 标志
 		file.Addition[consts.FileAdditionKeyDisableAutoScan] = req.DisableAutoScan
 
@@ -61,6 +64,15 @@ func (s *service) ToggleAutoScan() gin.HandlerFunc {
 			return
 		}
 
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditStorageAutoScan,
+			TargetType: audit.TargetStorage,
+			TargetId:   file.ID,
+			TargetName: file.Name,
+			Before:     before,
+			After:      req.DisableAutoScan,
+		})
+
 		var msg string
 		if req.DisableAutoScan {
 			msg = "已设置禁用自动扫描队列"
@@ -0,0 +1,29 @@
+package traffic
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	LimitList() gin.HandlerFunc
+	LimitSave() gin.HandlerFunc
+	LimitDelete() gin.HandlerFunc
+	UsageReport() gin.HandlerFunc
+	SourceReport() gin.HandlerFunc
+	Streams() gin.HandlerFunc
+}
+
+type service struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+// NewService 创建流量管理服务
+func NewService(db *gorm.DB, logger *zap.Logger) Service {
+	return &service{
+		db:     db,
+		logger: logger,
+	}
+}
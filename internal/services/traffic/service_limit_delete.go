@@ -0,0 +1,58 @@
+package traffic
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	trafficCore "github.com/xxcheng123/cloudpan189-share/internal/traffic"
+	"go.uber.org/zap"
+)
+
+type limitDeleteRequest struct {
+	ID int64 `json:"id" binding:"required,min=1"`
+}
+
+type limitDeleteResponse struct {
+	RowsAffected int64 `json:"rowsAffected"`
+}
+
+func (s *service) LimitDelete() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(limitDeleteRequest)
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		result := s.db.WithContext(ctx).Where("id = ?", req.ID).Delete(&models.TrafficLimit{})
+		if result.Error != nil {
+			s.logger.Error("traffic limit delete failure", zap.Error(result.Error))
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "流量限制删除失败",
+			})
+			return
+		}
+
+		if result.RowsAffected == 0 {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"code": http.StatusNotFound,
+				"msg":  "流量限制不存在",
+			})
+			return
+		}
+
+		if err := trafficCore.Reload(ctx); err != nil {
+			s.logger.Error("traffic limit reload failure", zap.Error(err))
+		}
+
+		ctx.JSON(http.StatusOK, &limitDeleteResponse{
+			RowsAffected: result.RowsAffected,
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package traffic
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+)
+
+type limitListRequest struct {
+	Scope string `form:"scope" binding:"omitempty,oneof=global group user"`
+}
+
+func (s *service) LimitList() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(limitListRequest)
+		if err := ctx.ShouldBindQuery(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		query := s.db.WithContext(ctx).Model(&models.TrafficLimit{})
+		if req.Scope != "" {
+			query = query.Where("scope = ?", req.Scope)
+		}
+
+		var list = make([]*models.TrafficLimit, 0)
+		if err := query.Order("scope ASC, target_id ASC").Find(&list).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, list)
+	}
+}
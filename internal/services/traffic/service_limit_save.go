@@ -0,0 +1,75 @@
+package traffic
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	trafficCore "github.com/xxcheng123/cloudpan189-share/internal/traffic"
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+)
+
+type limitSaveRequest struct {
+	Scope        string `json:"scope" binding:"required,oneof=global group user"`
+	TargetId     int64  `json:"targetId" binding:"min=0"`
+	RateLimit    int64  `json:"rateLimit" binding:"min=0"`
+	Burst        int64  `json:"burst" binding:"min=0"`
+	MaxStreams   int    `json:"maxStreams" binding:"min=0"`
+	MonthlyQuota int64  `json:"monthlyQuota" binding:"min=0"`
+}
+
+// LimitSave 按 scope + targetId 新增或更新限制规则
+func (s *service) LimitSave() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(limitSaveRequest)
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		if req.Scope == models.TrafficScopeGlobal {
+			req.TargetId = 0
+		} else if req.TargetId == 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  "请指定用户或用户组",
+			})
+			return
+		}
+
+		limit := &models.TrafficLimit{
+			Scope:        req.Scope,
+			TargetId:     req.TargetId,
+			RateLimit:    req.RateLimit,
+			Burst:        req.Burst,
+			MaxStreams:   req.MaxStreams,
+			MonthlyQuota: req.MonthlyQuota,
+		}
+
+		if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "scope"}, {Name: "target_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"rate_limit", "burst", "max_streams", "monthly_quota", "updated_at"}),
+		}).Create(limit).Error; err != nil {
+			s.logger.Error("traffic limit save failure", zap.Error(err))
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "流量限制保存失败",
+			})
+			return
+		}
+
+		if err := trafficCore.Reload(ctx); err != nil {
+			s.logger.Error("traffic limit reload failure", zap.Error(err))
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"code": http.StatusOK,
+			"msg":  "保存成功",
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package traffic
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+)
+
+type sourceReportRequest struct {
+	CurrentPage int    `form:"currentPage" binding:"omitempty"`
+	PageSize    int    `form:"pageSize" binding:"omitempty"`
+	FileId      int64  `form:"fileId" binding:"omitempty"`
+	StartDay    string `form:"startDay" binding:"omitempty,datetime=2006-01-02"`
+	EndDay      string `form:"endDay" binding:"omitempty,datetime=2006-01-02"`
+	MirrorOnly  bool   `form:"mirrorOnly" binding:"omitempty"` // 只看走了镜像回退的记录
+}
+
+type sourceReportResponse struct {
+	Total       int64                    `json:"total"`
+	CurrentPage int                      `json:"currentPage"`
+	PageSize    int                      `json:"pageSize"`
+	Data        []*models.DownloadSource `json:"data"`
+}
+
+// SourceReport 下载来源报表，记录每个文件的请求实际由哪个文件提供
+func (s *service) SourceReport() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(sourceReportRequest)
+		if err := ctx.ShouldBindQuery(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		if req.CurrentPage <= 0 {
+			req.CurrentPage = 1
+		}
+
+		if req.PageSize <= 0 {
+			req.PageSize = 10
+		}
+
+		query := s.db.WithContext(ctx).Model(&models.DownloadSource{})
+
+		if req.FileId > 0 {
+			query = query.Where("file_id = ? OR source_file_id = ?", req.FileId, req.FileId)
+		}
+
+		if req.StartDay != "" {
+			query = query.Where("day >= ?", req.StartDay)
+		}
+
+		if req.EndDay != "" {
+			query = query.Where("day <= ?", req.EndDay)
+		}
+
+		if req.MirrorOnly {
+			query = query.Where("file_id <> source_file_id")
+		}
+
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+			return
+		}
+
+		var list = make([]*models.DownloadSource, 0)
+		if err := query.Order("day DESC, requests DESC").
+			Offset((req.CurrentPage - 1) * req.PageSize).
+			Limit(req.PageSize).
+			Find(&list).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, &sourceReportResponse{
+			Total:       count,
+			CurrentPage: req.CurrentPage,
+			PageSize:    req.PageSize,
+			Data:        list,
+		})
+	}
+}
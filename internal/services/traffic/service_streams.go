@@ -0,0 +1,15 @@
+package traffic
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	trafficCore "github.com/xxcheng123/cloudpan189-share/internal/traffic"
+)
+
+// Streams 当前活跃下载流
+func (s *service) Streams() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, trafficCore.Stats())
+	}
+}
@@ -0,0 +1,129 @@
+package traffic
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"gorm.io/gorm"
+)
+
+type usageReportRequest struct {
+	CurrentPage int    `form:"currentPage" binding:"omitempty"`
+	PageSize    int    `form:"pageSize" binding:"omitempty"`
+	UserId      int64  `form:"userId" binding:"omitempty"`
+	FileId      int64  `form:"fileId" binding:"omitempty"`
+	StartDay    string `form:"startDay" binding:"omitempty,datetime=2006-01-02"`
+	EndDay      string `form:"endDay" binding:"omitempty,datetime=2006-01-02"`
+	GroupBy     string `form:"groupBy" binding:"omitempty,oneof=user file day"`
+}
+
+type usageItem struct {
+	UserId   int64  `json:"userId"`
+	FileId   int64  `json:"fileId"`
+	Day      string `json:"day"`
+	Bytes    int64  `json:"bytes"`
+	Requests int64  `json:"requests"`
+}
+
+type usageReportResponse struct {
+	Total       int64        `json:"total"`
+	CurrentPage int          `json:"currentPage"`
+	PageSize    int          `json:"pageSize"`
+	TotalBytes  int64        `json:"totalBytes"`
+	Data        []*usageItem `json:"data"`
+}
+
+// UsageReport 流量报表，可按用户、文件、天汇总
+func (s *service) UsageReport() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(usageReportRequest)
+		if err := ctx.ShouldBindQuery(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		if req.CurrentPage <= 0 {
+			req.CurrentPage = 1
+		}
+
+		if req.PageSize <= 0 {
+			req.PageSize = 10
+		}
+
+		query := s.db.WithContext(ctx).Model(&models.TrafficUsage{})
+
+		if req.UserId > 0 {
+			query = query.Where("user_id = ?", req.UserId)
+		}
+
+		if req.FileId > 0 {
+			query = query.Where("file_id = ?", req.FileId)
+		}
+
+		if req.StartDay != "" {
+			query = query.Where("day >= ?", req.StartDay)
+		}
+
+		if req.EndDay != "" {
+			query = query.Where("day <= ?", req.EndDay)
+		}
+
+		var totalBytes int64
+		if err := query.Session(&gorm.Session{}).Select("COALESCE(SUM(bytes), 0)").Scan(&totalBytes).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+			return
+		}
+
+		switch req.GroupBy {
+		case "user":
+			query = query.Select("user_id, SUM(bytes) AS bytes, SUM(requests) AS requests").Group("user_id")
+		case "file":
+			query = query.Select("file_id, SUM(bytes) AS bytes, SUM(requests) AS requests").Group("file_id")
+		case "day":
+			query = query.Select("day, SUM(bytes) AS bytes, SUM(requests) AS requests").Group("day")
+		default:
+			query = query.Select("user_id, file_id, day, bytes, requests")
+		}
+
+		var count int64
+		if err := s.db.WithContext(ctx).Table("(?) AS t", query.Session(&gorm.Session{})).Count(&count).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+			return
+		}
+
+		order := "bytes DESC"
+		if req.GroupBy == "day" || req.GroupBy == "" {
+			order = "day DESC, bytes DESC"
+		}
+
+		var list = make([]*usageItem, 0)
+		if err := query.Order(order).
+			Offset((req.CurrentPage - 1) * req.PageSize).
+			Limit(req.PageSize).
+			Scan(&list).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, &usageReportResponse{
+			Total:       count,
+			CurrentPage: req.CurrentPage,
+			PageSize:    req.PageSize,
+			TotalBytes:  totalBytes,
+			Data:        list,
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"golang.org/x/net/webdav"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/baseurl"
 	"github.com/xxcheng123/cloudpan189-share/internal/types"
 	"go.uber.org/zap"
 )
@@ -59,13 +60,16 @@ func (s *service) generatePropfindResponse(ctx *gin.Context, fileInfo *FileInfo)
 		currentPath += "/"
 	}
 
-	s.addPropResponse(&xmlResponse, fileInfo, currentPath)
+	// 请求路径已含 basePath，只需补上代理剥离的前缀
+	prefix := baseurl.ForwardedPrefix(ctx)
+
+	s.addPropResponse(&xmlResponse, prefix, fileInfo, currentPath)
 
 	// 如果是文件夹且depth不为0，添加子项
 	if fileInfo.IsFolder == 1 && depth != "0" && len(fileInfo.Children) > 0 {
 		for _, child := range fileInfo.Children {
 			childPath := s.buildChildPath(currentPath, child.Name, child.IsFolder == 1)
-			s.addPropResponse(&xmlResponse, child, childPath)
+			s.addPropResponse(&xmlResponse, prefix, child, childPath)
 		}
 	}
 
@@ -75,11 +79,11 @@ func (s *service) generatePropfindResponse(ctx *gin.Context, fileInfo *FileInfo)
 }
 
 // addPropResponse 添加单个文件/文件夹的属性响应
-func (s *service) addPropResponse(xmlResponse *strings.Builder, fileInfo *FileInfo, href string) {
+func (s *service) addPropResponse(xmlResponse *strings.Builder, prefix string, fileInfo *FileInfo, href string) {
 	xmlResponse.WriteString(`<D:response>`)
 
 	// 正确编码 href
-	encodedHref := s.encodeWebDAVPath(href)
+	encodedHref := s.encodeWebDAVPath(prefix, href)
 	xmlResponse.WriteString(fmt.Sprintf(`<D:href>%s</D:href>`, escapeXML(encodedHref)))
 
 	xmlResponse.WriteString(`<D:propstat>`)
@@ -137,8 +141,8 @@ func (s *service) addPropResponse(xmlResponse *strings.Builder, fileInfo *FileIn
 	xmlResponse.WriteString(`</D:response>`)
 }
 
-// encodeWebDAVPath 对 WebDAV 路径进行正确的 URL 编码
-func (s *service) encodeWebDAVPath(rawPath string) string {
+// encodeWebDAVPath 对 WebDAV 路径进行正确的 URL 编码，prefix 为对外的路径前缀
+func (s *service) encodeWebDAVPath(prefix, rawPath string) string {
 	// 分割路径为各个部分
 	parts := strings.Split(strings.Trim(rawPath, "/"), "/")
 
@@ -151,7 +155,7 @@ func (s *service) encodeWebDAVPath(rawPath string) string {
 	}
 
 	// 重新组合路径
-	encodedPath := "/" + strings.Join(encodedParts, "/")
+	encodedPath := prefix + "/" + strings.Join(encodedParts, "/")
 
 	// 如果原路径以 / 结尾（文件夹），保持这个特征
 	if strings.HasSuffix(rawPath, "/") && !strings.HasSuffix(encodedPath, "/") {
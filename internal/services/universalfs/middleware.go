@@ -104,6 +104,19 @@ func (s *service) BaseMiddleware() gin.HandlerFunc {
 					return
 				}
 
+				if isHiddenMount(tmpFile) {
+					s.logger.Warn("挂载点异常已隐藏", zap.Int64("fileId", tmpFile.ID), zap.String("filename", p))
+
+					ctx.JSON(http.StatusNotFound, types.ErrResponse{
+						Code:    http.StatusNotFound,
+						Message: "文件未找到",
+					})
+
+					ctx.Abort()
+
+					return
+				}
+
 				if tmpFile.IsTop == 1 && gid != 0 && !groupFileSet.Contains(tmpFile.ID) {
 					// 没有权限
 					s.logger.Warn("用户无权限访问文件", zap.Int64("gid", gid), zap.Int64("fileId", tmpFile.ID), zap.String("filename", p))
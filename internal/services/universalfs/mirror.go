@@ -0,0 +1,143 @@
+package universalfs
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/consts"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/utils"
+	"github.com/xxcheng123/cloudpan189-share/internal/shared"
+	"github.com/xxcheng123/cloudpan189-share/internal/traffic"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 单次回退最多尝试的镜像文件数
+const mirrorCandidateLimit = 20
+
+// getMirrorDownloadURL 主源获取失败后，从镜像挂载中查找相同 Hash+Size 的文件并获取下载链接。
+// 开启 mirror_auto_detect 时在所有挂载中查找，否则只在同一镜像组内查找。
+func (s *service) getMirrorDownloadURL(ctx context.Context, fileID int64) (string, int64, bool) {
+	file := new(models.VirtualFile)
+	if err := s.db.WithContext(ctx).Where("id", fileID).First(file).Error; err != nil {
+		return "", 0, false
+	}
+
+	if file.Hash == "" || file.Size <= 0 {
+		return "", 0, false
+	}
+
+	if file.OsType != models.OsTypeFile && file.OsType != models.OsTypeCloudFamilyFile {
+		return "", 0, false
+	}
+
+	var mirrorGroup string
+	if !shared.MirrorAutoDetect {
+		top, err := s.findTopFile(ctx, file)
+		if err != nil {
+			return "", 0, false
+		}
+
+		if mirrorGroup = utils.GetString(top.Addition, consts.FileAdditionKeyMirrorGroup); mirrorGroup == "" {
+			return "", 0, false
+		}
+	}
+
+	var candidates = make([]*models.VirtualFile, 0)
+	if err := s.db.WithContext(ctx).
+		Where("hash = ? AND size = ?", file.Hash, file.Size).
+		Where("id <> ?", file.ID).
+		Where("os_type IN ?", []string{models.OsTypeFile, models.OsTypeCloudFamilyFile}).
+		Limit(mirrorCandidateLimit).
+		Find(&candidates).Error; err != nil {
+		s.logger.Error("查询镜像文件失败", zap.Int64("fileId", fileID), zap.Error(err))
+
+		return "", 0, false
+	}
+
+	// 已被隐藏的异常挂载放到最后尝试
+	var ordered, fallback []int64
+	for _, candidate := range candidates {
+		top, err := s.findTopFile(ctx, candidate)
+		if err != nil {
+			continue
+		}
+
+		if mirrorGroup != "" && utils.GetString(top.Addition, consts.FileAdditionKeyMirrorGroup) != mirrorGroup {
+			continue
+		}
+
+		if isHiddenMount(top) {
+			fallback = append(fallback, candidate.ID)
+		} else {
+			ordered = append(ordered, candidate.ID)
+		}
+	}
+
+	for _, candidateId := range append(ordered, fallback...) {
+		u, _, err := s.getFileDownloadURL(ctx, candidateId)
+		if err != nil {
+			s.logger.Warn("镜像文件获取下载链接失败",
+				zap.Int64("fileId", fileID),
+				zap.Int64("mirrorFileId", candidateId),
+				zap.Error(err))
+
+			continue
+		}
+
+		s.logger.Info("主源失败，已从镜像获取下载链接",
+			zap.Int64("fileId", fileID),
+			zap.Int64("mirrorFileId", candidateId),
+			zap.String("mirrorGroup", mirrorGroup))
+
+		s.cache.Set(fmt.Sprintf("file::url::%d", fileID), u, time.Minute)
+		s.cache.Set(fmt.Sprintf("file::source::%d", fileID), candidateId, time.Minute)
+
+		return u, candidateId, true
+	}
+
+	return "", 0, false
+}
+
+func (s *service) findTopFile(ctx context.Context, file *models.VirtualFile) (*models.VirtualFile, error) {
+	for file.IsTop != 1 {
+		if file.ParentId == 0 {
+			return nil, gorm.ErrRecordNotFound
+		}
+
+		parent := new(models.VirtualFile)
+		if err := s.db.WithContext(ctx).Where("id", file.ParentId).First(parent).Error; err != nil {
+			return nil, err
+		}
+
+		file = parent
+	}
+
+	return file, nil
+}
+
+// cachedDownloadSource 返回缓存的下载链接对应的实际来源
+func (s *service) cachedDownloadSource(fileID int64) int64 {
+	if v, ok := s.cache.Get(fmt.Sprintf("file::source::%d", fileID)); ok {
+		if sourceId, ok := v.(int64); ok {
+			return sourceId
+		}
+	}
+
+	return fileID
+}
+
+// markDownloadSource 在响应头中标明实际来源，并按请求累加到下载来源统计中，由 traffic 批量写库
+func (s *service) markDownloadSource(ctx *gin.Context, fileID, sourceId int64) {
+	if sourceId == 0 {
+		sourceId = fileID
+	}
+
+	ctx.Header("X-Download-Source", strconv.FormatInt(sourceId, 10))
+
+	traffic.RecordSource(fileID, sourceId)
+}
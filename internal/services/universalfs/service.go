@@ -16,6 +16,7 @@ import (
 type Service interface {
 	Open(prefix string, format string) gin.HandlerFunc
 	FileDownload() gin.HandlerFunc
+	ServeFile() gin.HandlerFunc
 	DavMiddleware() gin.HandlerFunc
 	BaseMiddleware() gin.HandlerFunc
 	Delete() gin.HandlerFunc
@@ -18,15 +18,19 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
+	"github.com/samber/lo"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"github.com/xxcheng123/cloudpan189-interface/client"
 	"github.com/xxcheng123/cloudpan189-share/configs"
 	"github.com/xxcheng123/cloudpan189-share/internal/consts"
+	"github.com/xxcheng123/cloudpan189-share/internal/metrics"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/utils"
 	"github.com/xxcheng123/cloudpan189-share/internal/shared"
+	"github.com/xxcheng123/cloudpan189-share/internal/tokenpool"
+	"github.com/xxcheng123/cloudpan189-share/internal/traffic"
 	"github.com/xxcheng123/cloudpan189-share/internal/types"
 	"github.com/xxcheng123/multistreamer"
 )
@@ -36,12 +40,19 @@ type fileDownloadRequest struct {
 	TimeStamp int64  `form:"timestamp" binding:"required"`
 	Random    string `form:"random" binding:"required"`
 	Sign      string `form:"sign" binding:"required"`
+	UID       int64  `form:"uid"` // 生成链接的用户，用于流量统计，可为空
 }
 
+const (
+	ctxKeyDownloadFileId = "x_download_fid"
+	ctxKeyDownloadUserId = "x_download_uid" // 下载流量计入的用户
+)
+
 type DoResult struct {
 	Content  string
 	HttpCode int
 	Err      error
+	SourceId int64 // 实际提供下载的文件ID，走镜像回退时与请求的文件不同
 }
 
 // 全局HTTP客户端，复用连接
@@ -96,6 +107,11 @@ func (s *service) FileDownload() gin.HandlerFunc {
 			"sign":      []string{req.Sign},
 		}
 
+		// 兼容旧链接，只有带 uid 的链接才参与签名
+		if ctx.Query("uid") != "" {
+			values.Set("uid", strconv.FormatInt(req.UID, 10))
+		}
+
 		if !enc.Verify(values, key) {
 			s.logger.Warn("文件下载请求签名验证失败",
 				zap.Int64("fileId", req.ID),
@@ -108,36 +124,108 @@ func (s *service) FileDownload() gin.HandlerFunc {
 			return
 		}
 
-		if v, ok := s.cache.Get(fmt.Sprintf("file::url::%d", req.ID)); ok {
-			if downURL, ok := v.(string); ok {
-				ctx.Header("X-Download-Url-Cache", "true")
-				s.doResponse(ctx, downURL)
-				return
-			} else {
-				s.logger.Warn("缓存中的URL格式错误", zap.Int64("fileId", req.ID))
-				// 继续执行，重新获取下载链接
-			}
-		}
+		s.serveFile(ctx, req.ID, req.UID)
+	}
+}
 
-		file := &models.VirtualFile{}
-		if err := s.db.WithContext(ctx).Where("id = ?", req.ID).First(file).Error; err != nil {
-			s.logger.Error("查询文件信息失败", zap.Int64("fileId", req.ID), zap.Error(err))
-			ctx.JSON(http.StatusNotFound, types.ErrResponse{
-				Code:    http.StatusNotFound,
-				Message: "文件未找到",
-			})
+// ServeFile 输出前置中间件校验过的文件，用于分享链接等不走签名链接的入口，流量计入 user_id
+func (s *service) ServeFile() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		s.serveFile(ctx, ctx.GetInt64(consts.CtxKeyFileId), ctx.GetInt64("user_id"))
+	}
+}
+
+// serveFile 输出文件内容，经本服务传输时才按流量限制申请下载流，重定向不计流量
+func (s *service) serveFile(ctx *gin.Context, fileId, uid int64) {
+	ctx.Set(ctxKeyDownloadFileId, fileId)
+	ctx.Set(ctxKeyDownloadUserId, uid)
 
+	defer s.recordDownloadMetrics(ctx)
+
+	v, cached := s.cache.Get(fmt.Sprintf("file::url::%d", fileId))
+	metrics.RecordURLCache(cached)
+
+	if cached {
+		if downURL, ok := v.(string); ok {
+			ctx.Header("X-Download-Url-Cache", "true")
+			s.markDownloadSource(ctx, fileId, s.cachedDownloadSource(fileId))
+			s.doResponse(ctx, downURL)
 			return
+		} else {
+			s.logger.Warn("缓存中的URL格式错误", zap.Int64("fileId", fileId))
+			// 继续执行，重新获取下载链接
 		}
+	}
 
-		if file.OsType == models.OsTypeRealFile {
-			s.handleRealFileDownload(ctx, file, req.ID)
+	file := &models.VirtualFile{}
+	if err := s.db.WithContext(ctx).Where("id = ?", fileId).First(file).Error; err != nil {
+		s.logger.Error("查询文件信息失败", zap.Int64("fileId", fileId), zap.Error(err))
+		ctx.JSON(http.StatusNotFound, types.ErrResponse{
+			Code:    http.StatusNotFound,
+			Message: "文件未找到",
+		})
 
-			return
+		return
+	}
+
+	if file.OsType == models.OsTypeRealFile {
+		s.handleRealFileDownload(ctx, file, fileId)
+
+		return
+	}
+
+	s.handleCloudFileDownload(ctx, fileId)
+}
+
+// acquireStream 经本服务传输文件内容前申请下载流，并按限速包装响应写入器；被流量限制拒绝时已写入响应并返回 false
+func (s *service) acquireStream(ctx *gin.Context) (*traffic.Stream, bool) {
+	var (
+		fileId = ctx.GetInt64(ctxKeyDownloadFileId)
+		uid    = ctx.GetInt64(ctxKeyDownloadUserId)
+	)
+
+	stream, err := traffic.Acquire(ctx, uid, fileId)
+	if err != nil {
+		if errors.Is(err, traffic.ErrQuotaExceeded) || errors.Is(err, traffic.ErrTooManyStreams) {
+			s.logger.Warn("文件下载被流量限制拒绝",
+				zap.Int64("fileId", fileId),
+				zap.Int64("userId", uid),
+				zap.Error(err))
+			ctx.JSON(http.StatusTooManyRequests, types.ErrResponse{
+				Code:    http.StatusTooManyRequests,
+				Message: err.Error(),
+			})
+
+			return nil, false
 		}
 
-		s.handleCloudFileDownload(ctx, req.ID)
+		s.logger.Error("申请下载流失败", zap.Int64("fileId", fileId), zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, types.ErrResponse{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+
+		return nil, false
+	}
+
+	ctx.Writer = stream.Wrap(ctx.Request.Context(), ctx.Writer)
+
+	return stream, true
+}
+
+// recordDownloadMetrics 按传输方式统计下载次数和经本服务传输的字节数，未开始传输的请求不计
+func (s *service) recordDownloadMetrics(ctx *gin.Context) {
+	transferType := ctx.Writer.Header().Get("X-Transfer-Type")
+	if transferType == "" {
+		return
+	}
+
+	var written int64
+	if transferType != "redirect" && ctx.Writer.Size() > 0 {
+		written = int64(ctx.Writer.Size())
 	}
+
+	metrics.RecordDownload(transferType, written)
 }
 
 func (s *service) handleRealFileDownload(ctx *gin.Context, file *models.VirtualFile, fileID int64) {
@@ -182,8 +270,21 @@ func (s *service) handleRealFileDownload(ctx *gin.Context, file *models.VirtualF
 	}
 
 	filename := file.Name
-	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"; filename*=UTF-8''%s",
-		filename, url.QueryEscape(filename)))
+	disposition := "attachment"
+	if ctx.GetBool(consts.CtxKeyServeInline) {
+		disposition = "inline"
+	}
+
+	ctx.Header("X-Transfer-Type", "local_file")
+	ctx.Header("Content-Disposition", fmt.Sprintf("%s; filename=\"%s\"; filename*=UTF-8''%s",
+		disposition, filename, url.QueryEscape(filename)))
+
+	stream, ok := s.acquireStream(ctx)
+	if !ok {
+		return
+	}
+
+	defer stream.Close()
 
 	s.logger.Info("开始下载真实文件",
 		zap.Int64("fileId", fileID),
@@ -196,11 +297,20 @@ func (s *service) handleRealFileDownload(ctx *gin.Context, file *models.VirtualF
 func (s *service) handleCloudFileDownload(ctx *gin.Context, fileID int64) {
 	_result, err, _ := s.g.Do(fmt.Sprintf("file::url::%d", fileID), func() (interface{}, error) {
 		u, httpCode, err := s.getFileDownloadURL(ctx, fileID)
+		sourceId := fileID
+
+		// 主源失败时尝试从镜像中相同 Hash+Size 的文件获取
+		if err != nil && httpCode != http.StatusNotFound {
+			if mirrorURL, mirrorId, ok := s.getMirrorDownloadURL(ctx, fileID); ok {
+				u, httpCode, err, sourceId = mirrorURL, http.StatusFound, nil, mirrorId
+			}
+		}
 
 		return &DoResult{
 			Content:  u,
 			HttpCode: httpCode,
 			Err:      err,
+			SourceId: sourceId,
 		}, nil
 	})
 
@@ -237,6 +347,8 @@ func (s *service) handleCloudFileDownload(ctx *gin.Context, fileID int64) {
 		return
 	}
 
+	s.markDownloadSource(ctx, fileID, result.SourceId)
+
 	if result.HttpCode == http.StatusOK {
 		ctx.String(http.StatusOK, result.Content)
 
@@ -246,10 +358,11 @@ func (s *service) handleCloudFileDownload(ctx *gin.Context, fileID int64) {
 	s.doResponse(ctx, result.Content)
 }
 
+// doResponse 按设置选择传输方式；仅限在线预览的请求不重定向，避免把云盘直链交给访问者
 func (s *service) doResponse(ctx *gin.Context, url string) {
 	if shared.Setting.MultipleStream {
 		s.handleMultiStreamResponse(ctx, url)
-	} else if shared.Setting.LocalProxy {
+	} else if shared.Setting.LocalProxy || ctx.GetBool(consts.CtxKeyServeInline) {
 		s.handleLocalProxy(ctx, url)
 	} else {
 		ctx.Header("X-Transfer-Type", "redirect")
@@ -258,6 +371,13 @@ func (s *service) doResponse(ctx *gin.Context, url string) {
 }
 
 func (s *service) handleMultiStreamResponse(ctx *gin.Context, url string) {
+	stream, ok := s.acquireStream(ctx)
+	if !ok {
+		return
+	}
+
+	defer stream.Close()
+
 	ctx.Header("X-Transfer-Type", "multi_stream")
 	ctx.Header("X-Transfer-Chunk-Size", strconv.FormatInt(shared.MultipleStreamChunkSize, 10))
 	ctx.Header("X-Transfer-Chunk-Size-Format", utils.FormatBytes(shared.MultipleStreamChunkSize))
@@ -288,6 +408,7 @@ func (s *service) handleMultiStreamResponse(ctx *gin.Context, url string) {
 		ctx.Header(k, v[0])
 	}
 
+	s.forceInline(ctx)
 	ctx.Status(streamer.HTTPCode())
 
 	if err = streamer.Transfer(ctx, ctx.Writer); err != nil {
@@ -300,6 +421,13 @@ func (s *service) handleMultiStreamResponse(ctx *gin.Context, url string) {
 }
 
 func (s *service) handleLocalProxy(ctx *gin.Context, url string) {
+	stream, ok := s.acquireStream(ctx)
+	if !ok {
+		return
+	}
+
+	defer stream.Close()
+
 	start := time.Now()
 	ctx.Header("X-Transfer-Type", "local_proxy")
 
@@ -342,6 +470,7 @@ func (s *service) handleLocalProxy(ctx *gin.Context, url string) {
 	defer resp.Body.Close()
 
 	s.copyOptimizedResponseHeaders(resp.Header, ctx)
+	s.forceInline(ctx)
 	ctx.Status(resp.StatusCode)
 
 	if flusher, ok := ctx.Writer.(http.Flusher); ok {
@@ -381,6 +510,13 @@ func (s *service) handleLocalProxy(ctx *gin.Context, url string) {
 	}
 }
 
+// forceInline 仅限在线预览时覆盖上游返回的 Content-Disposition
+func (s *service) forceInline(ctx *gin.Context) {
+	if ctx.GetBool(consts.CtxKeyServeInline) {
+		ctx.Header("Content-Disposition", "inline")
+	}
+}
+
 func (s *service) copyOptimizedHeaders(src, dst http.Header) {
 	importantHeaders := []string{
 		"Range", "If-Range", "If-Modified-Since", "If-None-Match",
@@ -557,29 +693,11 @@ func (s *service) getFileDownloadURL(ctx context.Context, id int64) (string, int
 		}
 	}
 
-	cloudTokenId, err := s.findCloudTokenId(ctx, file)
+	poolId, tokenIds, strategy, err := s.findCloudTokenPool(ctx, file)
 	if err != nil {
 		return "", http.StatusBadRequest, err
 	}
 
-	ct := new(models.CloudToken)
-	if err := s.db.WithContext(ctx).Where("id", cloudTokenId).First(ct).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			s.logger.Error("云盘令牌未找到",
-				zap.Int64("fileId", id),
-				zap.Int64("cloudTokenId", cloudTokenId))
-
-			return "", http.StatusBadRequest, errors.New("绑定的令牌查找失败，可能被删除或隐藏")
-		}
-
-		s.logger.Error("查询云盘令牌失败",
-			zap.Int64("fileId", id),
-			zap.Int64("cloudTokenId", cloudTokenId),
-			zap.Error(err))
-
-		return "", http.StatusInternalServerError, err
-	}
-
 	fileId := utils.String(file.Addition[consts.FileAdditionKeyFileId])
 	s.logger.Info("开始获取云盘文件下载链接",
 		zap.Int64("fileId", id),
@@ -587,39 +705,55 @@ func (s *service) getFileDownloadURL(ctx context.Context, id int64) (string, int
 
 	var (
 		downloadURL string
+		lastErr     error
 	)
 
-	if file.OsType == models.OsTypeCloudFamilyFile {
-		result, err := client.New().WithToken(client.NewAuthToken(ct.AccessToken, ct.ExpiresIn)).
-			FamilyGetFileDownload(ctx, client.String(familyId), client.String(fileId))
+	// 按策略依次尝试令牌池中的令牌，令牌失效或被限流时换下一个
+	for _, cloudTokenId := range tokenpool.Select(poolId, tokenIds, strategy) {
+		ct := new(models.CloudToken)
+		if err := s.db.WithContext(ctx).Where("id", cloudTokenId).First(ct).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				s.logger.Error("云盘令牌未找到",
+					zap.Int64("fileId", id),
+					zap.Int64("cloudTokenId", cloudTokenId))
 
-		if err != nil {
-			s.logger.Error("获取云盘文件下载链接失败",
+				lastErr = errors.New("绑定的令牌查找失败，可能被删除或隐藏")
+
+				continue
+			}
+
+			s.logger.Error("查询云盘令牌失败",
 				zap.Int64("fileId", id),
-				zap.String("cloudFileId", fileId),
+				zap.Int64("cloudTokenId", cloudTokenId),
 				zap.Error(err))
+
 			return "", http.StatusInternalServerError, err
 		}
 
-		downloadURL = html.UnescapeString(result.FileDownloadUrl)
-	} else {
-		result, err := client.New().WithToken(client.NewAuthToken(ct.AccessToken, ct.ExpiresIn)).
-			GetFileDownload(ctx, client.String(fileId), func(req *client.GetFileDownloadRequest) {
-				if v, ok := file.Addition[consts.FileAdditionKeyShareId]; ok {
-					req.ShareId, _ = utils.Int64(v)
-				}
-			})
+		downloadURL, lastErr = s.getCloudFileDownloadURL(ctx, ct, file, familyId, fileId)
+		tokenpool.Report(cloudTokenId, lastErr)
 
-		if err != nil {
-			s.logger.Error("获取云盘文件下载链接失败",
+		if lastErr == nil {
+			s.logger.Info("令牌获取下载链接成功",
 				zap.Int64("fileId", id),
-				zap.String("cloudFileId", fileId),
-				zap.Error(err))
+				zap.Int64("cloudTokenId", cloudTokenId))
 
-			return "", http.StatusInternalServerError, err
+			break
+		}
+
+		s.logger.Error("获取云盘文件下载链接失败",
+			zap.Int64("fileId", id),
+			zap.Int64("cloudTokenId", cloudTokenId),
+			zap.String("cloudFileId", fileId),
+			zap.Error(lastErr))
+
+		if !tokenpool.Retryable(lastErr) {
+			return "", http.StatusInternalServerError, lastErr
 		}
+	}
 
-		downloadURL = result.FileDownloadUrl
+	if lastErr != nil {
+		return "", http.StatusInternalServerError, lastErr
 	}
 
 	resp, err := utils.NoFollowRedirectHttpClient.Get(downloadURL)
@@ -635,6 +769,7 @@ func (s *service) getFileDownloadURL(ctx context.Context, id int64) (string, int
 
 	finalUrl := resp.Request.URL.String()
 	s.cache.Set(fmt.Sprintf("file::url::%d", file.ID), finalUrl, time.Minute)
+	s.cache.Delete(fmt.Sprintf("file::source::%d", file.ID))
 
 	s.logger.Info("成功获取文件下载链接",
 		zap.Int64("fileId", id),
@@ -643,14 +778,45 @@ func (s *service) getFileDownloadURL(ctx context.Context, id int64) (string, int
 	return finalUrl, http.StatusFound, nil
 }
 
-func (s *service) findCloudTokenId(ctx context.Context, file *models.VirtualFile) (int64, error) {
-	var cloudTokenId int64
+func (s *service) getCloudFileDownloadURL(ctx context.Context, ct *models.CloudToken, file *models.VirtualFile, familyId, fileId string) (string, error) {
+	cli := metrics.NewClient().WithToken(client.NewAuthToken(ct.AccessToken, ct.ExpiresIn))
+
+	if file.OsType == models.OsTypeCloudFamilyFile {
+		result, err := cli.FamilyGetFileDownload(ctx, client.String(familyId), client.String(fileId))
+		if err != nil {
+			return "", err
+		}
+
+		return html.UnescapeString(result.FileDownloadUrl), nil
+	}
+
+	result, err := cli.GetFileDownload(ctx, client.String(fileId), func(req *client.GetFileDownloadRequest) {
+		if v, ok := file.Addition[consts.FileAdditionKeyShareId]; ok {
+			req.ShareId, _ = utils.Int64(v)
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return result.FileDownloadUrl, nil
+}
+
+// findCloudTokenPool 向上查找绑定令牌的节点，返回该节点ID、令牌池（首个为主令牌）及选择策略
+func (s *service) findCloudTokenPool(ctx context.Context, file *models.VirtualFile) (int64, []int64, string, error) {
 	scanFile := file
 
 	for {
 		if v, ok := scanFile.Addition[consts.FileAdditionKeyCloudToken]; ok {
-			cloudTokenId, _ = utils.Int64(v)
-			break
+			cloudTokenId, _ := utils.Int64(v)
+
+			tokenIds := []int64{cloudTokenId}
+			// 个人云、家庭云的令牌池不生效，其他账号的令牌看不到所有者的文件
+			if pool, ok := scanFile.Addition[consts.FileAdditionKeyCloudTokenPool]; ok && lo.Contains(models.TokenPoolOsTypes, scanFile.OsType) {
+				tokenIds = append(tokenIds, utils.Int64Slice(pool)...)
+			}
+
+			return scanFile.ID, tokenIds, utils.GetString(scanFile.Addition, consts.FileAdditionKeyCloudTokenStrategy), nil
 		}
 
 		if scanFile.ParentId == 0 {
@@ -658,7 +824,7 @@ func (s *service) findCloudTokenId(ctx context.Context, file *models.VirtualFile
 				zap.Int64("fileId", file.ID),
 				zap.String("fileName", file.Name))
 
-			return 0, errors.New("当前资源没有绑定用于获取播放链接的令牌")
+			return 0, nil, "", errors.New("当前资源没有绑定用于获取播放链接的令牌")
 		}
 
 		var parent = new(models.VirtualFile)
@@ -668,7 +834,7 @@ func (s *service) findCloudTokenId(ctx context.Context, file *models.VirtualFile
 					zap.Int64("fileId", file.ID),
 					zap.Int64("parentId", scanFile.ParentId))
 
-				return 0, errors.New("文件未找到")
+				return 0, nil, "", errors.New("文件未找到")
 			}
 
 			s.logger.Error("查询父级文件信息失败",
@@ -676,11 +842,9 @@ func (s *service) findCloudTokenId(ctx context.Context, file *models.VirtualFile
 				zap.Int64("parentId", scanFile.ParentId),
 				zap.Error(err))
 
-			return 0, errors.New("当前资源没有绑定用于获取播放链接的令牌")
+			return 0, nil, "", errors.New("当前资源没有绑定用于获取播放链接的令牌")
 		}
 
 		scanFile = parent
 	}
-
-	return cloudTokenId, nil
 }
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/xxcheng123/cloudpan189-share/internal/baseurl"
 	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/enc"
 
 	mapset "github.com/deckarep/golang-set/v2"
@@ -18,6 +19,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/xxcheng123/cloudpan189-share/internal/consts"
+	"github.com/xxcheng123/cloudpan189-share/internal/duplicate"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/utils"
 	"github.com/xxcheng123/cloudpan189-share/internal/shared"
@@ -99,7 +101,7 @@ func (s *service) Open(prefix string, format string) gin.HandlerFunc {
 				return
 			}
 		} else {
-			f.DownloadURL = s.generateDownloadURL(file.ID)
+			f.DownloadURL = DownloadURL(ctx, file.ID, ctx.GetInt64("user_id"))
 		}
 
 		s.responseByFormat(ctx, f, format)
@@ -138,8 +140,33 @@ func (s *service) loadFolderChildren(ctx *gin.Context, f *FileInfo, gid int64, g
 		return err
 	}
 
-	// 构建子项列表
+	// WebDAV 中隐藏跨挂载点的重复文件
+	var duplicates = make(map[int64]struct{})
+	if (format == "dav" || format == "strm_dav") && duplicate.HideEnabled(models.DuplicateHideTargetWebDAV) {
+		// 未分组用户可以看到所有挂载点，不限制保留副本的范围
+		var scope mapset.Set[int64]
+		if gid != 0 {
+			scope = groupFileSet
+		}
+
+		hidden, err := duplicate.FindHidden(ctx, s.db, list, scope)
+		if err != nil {
+			return err
+		}
+
+		duplicates = hidden
+	}
+
+	// 构建子项列表，跳过因异常被隐藏的挂载点
 	for _, v := range list {
+		if isHiddenMount(v) {
+			continue
+		}
+
+		if _, ok := duplicates[v.ID]; ok {
+			continue
+		}
+
 		f.Children = append(f.Children, &FileInfo{
 			VirtualFile: v,
 			Path:        path.Join(f.Path, v.Name),
@@ -209,13 +236,20 @@ func (s *service) responseByFormat(ctx *gin.Context, f *FileInfo, format string)
 	}
 }
 
-func (s *service) generateDownloadURL(fid int64) string {
-	values := enc.Enc(url.Values{
+// DownloadURL 生成带签名的下载地址，6 小时内有效，uid 不为 0 时流量和限额计入该用户
+func DownloadURL(ctx *gin.Context, fid, uid int64) string {
+	values := url.Values{
 		"id":     []string{fmt.Sprintf("%d", fid)},
 		"random": []string{uuid.NewString()},
-	}, shared.Setting.SaltKey)
+	}
+
+	if uid > 0 {
+		values.Set("uid", fmt.Sprintf("%d", uid))
+	}
+
+	values = enc.Enc(values, shared.Setting.SaltKey)
 
-	baseURL := shared.Setting.BaseURL
+	baseURL := baseurl.Resolve(ctx)
 
 	return fmt.Sprintf("%s/api/file_download?%s", baseURL, values.Encode())
 }
@@ -227,7 +261,18 @@ func (s *service) generateDownloadURLWithNeverExpire(fid int64) string {
 		"timestamp": []string{"-1"},
 	}, shared.Setting.SaltKey)
 
-	baseURL := shared.Setting.BaseURL
+	baseURL := baseurl.Static(shared.Setting.BaseURL)
 
 	return fmt.Sprintf("%s/api/file_download?%s", baseURL, values.Encode())
 }
+
+// isHiddenMount 挂载点异常时按设置被隐藏
+func isHiddenMount(file *models.VirtualFile) bool {
+	if file.IsTop != 1 {
+		return false
+	}
+
+	hidden, _ := utils.Bool(file.Addition[consts.FileAdditionKeyHidden])
+
+	return hidden
+}
@@ -0,0 +1,58 @@
+package user
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/patrickmn/go-cache"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+)
+
+// credentialCacheTTL 校验通过的 Basic 认证缓存时长，用户被修改后立即清空
+const credentialCacheTTL = time.Second * 30
+
+// credentialCache username -> *credential
+var credentialCache = cache.New(credentialCacheTTL, time.Minute)
+
+type credential struct {
+	password string // 与库中相同的摘要，不保存明文
+	user     *models.User
+}
+
+func cachedCredential(username, password string) (*models.User, bool) {
+	v, ok := credentialCache.Get(username)
+	if !ok {
+		return nil, false
+	}
+
+	c := v.(*credential)
+	if c.password != hash(password) {
+		return nil, false
+	}
+
+	return c.user, true
+}
+
+func cacheCredential(username, password string, user *models.User) {
+	credentialCache.SetDefault(username, &credential{
+		password: hash(password),
+		user:     user,
+	})
+}
+
+// FlushCredentialCache 用户的密码、状态、权限或用户组变化后调用，导入配置归档后同样需要调用
+func FlushCredentialCache() {
+	credentialCache.Flush()
+}
+
+// abortLocked 认证次数过多被锁定
+func abortLocked(ctx *gin.Context, wait time.Duration) {
+	ctx.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+
+	ctx.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"code": http.StatusTooManyRequests,
+		"msg":  "失败次数过多，请 " + strconv.Itoa(int(wait.Minutes())+1) + " 分钟后再试",
+	})
+}
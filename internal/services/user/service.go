@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
 	"github.com/xxcheng123/cloudpan189-share/internal/shared"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -20,11 +21,24 @@ type Service interface {
 	Login() gin.HandlerFunc
 	RefreshToken() gin.HandlerFunc
 	AuthMiddleware(permission uint8) gin.HandlerFunc
+	StreamAuthMiddleware(permission uint8) gin.HandlerFunc
+	StreamTicket() gin.HandlerFunc
 	BasicAuthMiddleware(permission uint8) gin.HandlerFunc
 	Info() gin.HandlerFunc
 	ModifyPass() gin.HandlerFunc
 	ModifyOwnPass() gin.HandlerFunc
 	BindGroup() gin.HandlerFunc
+	LoginTwoFactor() gin.HandlerFunc
+	LoginTwoFactorSetup() gin.HandlerFunc
+	TwoFactorSetup() gin.HandlerFunc
+	TwoFactorEnable() gin.HandlerFunc
+	TwoFactorDisable() gin.HandlerFunc
+	TwoFactorRecoveryCodes() gin.HandlerFunc
+	TwoFactorReset() gin.HandlerFunc
+	Logout() gin.HandlerFunc
+	Sessions() gin.HandlerFunc
+	SessionList() gin.HandlerFunc
+	SessionRevoke() gin.HandlerFunc
 }
 
 type service struct {
@@ -52,6 +66,7 @@ type Claims struct {
 	UserId      int64  `json:"user_id"`
 	Username    string `json:"username"`
 	UserVersion int    `json:"user_version"`
+	SessionId   int64  `json:"sid,omitempty"` // 访问和刷新Token所属的会话
 	jwt.RegisteredClaims
 }
 
@@ -62,11 +77,12 @@ const (
 )
 
 // generateAccessToken 生成访问Token
-func (s *service) generateAccessToken(userId int64, username string, userVersion int) (string, error) {
+func (s *service) generateAccessToken(userId int64, username string, userVersion int, sessionId int64) (string, error) {
 	claims := &Claims{
 		UserId:      userId,
 		Username:    username,
 		UserVersion: userVersion,
+		SessionId:   sessionId,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenExpire)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -80,19 +96,20 @@ func (s *service) generateAccessToken(userId int64, username string, userVersion
 	return token.SignedString([]byte(shared.Setting.SaltKey))
 }
 
-// generateRefreshToken 生成刷新Token
-func (s *service) generateRefreshToken(userId int64, username string, userVersion int) (string, error) {
+// generateRefreshToken 生成刷新Token，tokenId 与会话中保存的一致时才能使用
+func (s *service) generateRefreshToken(userId int64, username string, userVersion int, sessionId int64, tokenId string) (string, error) {
 	claims := &Claims{
 		UserId:      userId,
 		Username:    username,
 		UserVersion: userVersion,
+		SessionId:   sessionId,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(RefreshTokenExpire)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    "auth-service",
 			Subject:   "refresh-token",
-			ID:        fmt.Sprintf("%d", userId),
+			ID:        tokenId,
 		},
 	}
 
@@ -100,8 +117,23 @@ func (s *service) generateRefreshToken(userId int64, username string, userVersio
 	return token.SignedString([]byte(shared.Setting.SaltKey))
 }
 
+// generateTokens 为会话签发访问Token和刷新Token
+func (s *service) generateTokens(user *models.User, session *models.Session) (string, string, error) {
+	accessToken, err := s.generateAccessToken(user.ID, user.Username, user.Version, session.ID)
+	if err != nil {
+		return "", "", err
+	}
+
+	refreshToken, err := s.generateRefreshToken(user.ID, user.Username, user.Version, session.ID, session.TokenID)
+	if err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
+}
+
 // ParseAccessToken 解析访问Token
-func (s *service) ParseAccessToken(tokenString string) (int64, string, int, error) {
+func (s *service) ParseAccessToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -111,22 +143,22 @@ func (s *service) ParseAccessToken(tokenString string) (int64, string, int, erro
 	})
 
 	if err != nil {
-		return 0, "", 0, err
+		return nil, err
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
 		if claims.Subject != "access-token" {
-			return 0, "", 0, fmt.Errorf("invalid access token subject")
+			return nil, fmt.Errorf("invalid access token subject")
 		}
 
-		return claims.UserId, claims.Issuer, claims.UserVersion, nil
+		return claims, nil
 	}
 
-	return 0, "", 0, fmt.Errorf("invalid access token")
+	return nil, fmt.Errorf("invalid access token")
 }
 
 // parseRefreshToken 解析刷新Token
-func (s *service) parseRefreshToken(tokenString string) (int64, string, int, error) {
+func (s *service) parseRefreshToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -135,16 +167,16 @@ func (s *service) parseRefreshToken(tokenString string) (int64, string, int, err
 	})
 
 	if err != nil {
-		return 0, "", 0, err
+		return nil, err
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
 		if claims.Subject != "refresh-token" {
-			return 0, "", 0, fmt.Errorf("invalid refresh token subject")
+			return nil, fmt.Errorf("invalid refresh token subject")
 		}
 
-		return claims.UserId, claims.Issuer, claims.UserVersion, nil
+		return claims, nil
 	}
 
-	return 0, "", 0, fmt.Errorf("invalid refresh token")
+	return nil, fmt.Errorf("invalid refresh token")
 }
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 	"go.uber.org/zap"
 )
@@ -46,6 +47,16 @@ func (s *service) Add() gin.HandlerFunc {
 			return
 		}
 
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditUserAdd,
+			TargetType: audit.TargetUser,
+			TargetId:   u.ID,
+			TargetName: u.Username,
+			After: gin.H{
+				"isSuper": req.IsSuper,
+			},
+		})
+
 		ctx.JSON(http.StatusOK, &addResponse{
 			ID: u.ID,
 		})
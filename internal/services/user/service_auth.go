@@ -8,6 +8,7 @@ import (
 	"github.com/xxcheng123/cloudpan189-share/internal/consts"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/authguard"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 	"github.com/xxcheng123/cloudpan189-share/internal/shared"
 	"go.uber.org/zap"
@@ -40,7 +41,7 @@ func (s *service) AuthMiddleware(permission uint8) gin.HandlerFunc {
 			return
 		}
 
-		uid, username, version, err := s.ParseAccessToken(tokenParts[1])
+		claims, err := s.ParseAccessToken(tokenParts[1])
 		if err != nil {
 			s.logger.Warn("invalid token", zap.Error(err))
 			ctx.JSON(http.StatusUnauthorized, gin.H{
@@ -53,24 +54,27 @@ func (s *service) AuthMiddleware(permission uint8) gin.HandlerFunc {
 			return
 		}
 
-		// 验证用户是否存在且激活
-		var user = new(models.User)
-		if err = s.db.WithContext(ctx).Where("id", uid).Where("status", 1).First(user).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				s.logger.Warn("user not found or inactive", zap.Int64("user_id", uid))
-				ctx.JSON(http.StatusUnauthorized, gin.H{
-					"code": http.StatusUnauthorized,
-					"msg":  "用户不存在或已禁用",
-				})
+		s.authorize(ctx, claims, permission)
+	}
+}
 
-				ctx.Abort()
+// StreamAuthMiddleware 浏览器的 EventSource 无法设置 Authorization 头，可改用 ticket 参数中的短期凭证认证；其余校验与 AuthMiddleware 相同
+func (s *service) StreamAuthMiddleware(permission uint8) gin.HandlerFunc {
+	bearer := s.AuthMiddleware(permission)
 
-				return
-			}
-			s.logger.Error("database error during token check", zap.Error(err))
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"code": http.StatusInternalServerError,
-				"msg":  "Token验证失败",
+	return func(ctx *gin.Context) {
+		if ctx.GetHeader("Authorization") != "" {
+			bearer(ctx)
+
+			return
+		}
+
+		claims, err := s.parseStreamTicket(ctx.Query("ticket"))
+		if err != nil {
+			s.logger.Warn("invalid stream ticket", zap.Error(err))
+			ctx.JSON(http.StatusUnauthorized, gin.H{
+				"code": http.StatusUnauthorized,
+				"msg":  "无效的凭证",
 			})
 
 			ctx.Abort()
@@ -78,33 +82,66 @@ func (s *service) AuthMiddleware(permission uint8) gin.HandlerFunc {
 			return
 		}
 
-		if user.Version > version {
-			s.logger.Warn("user version mismatch",
-				zap.Int64("user_id", uid),
-				zap.Int("user_version", user.Version),
-				zap.Int("token_version", version))
+		s.authorize(ctx, claims, permission)
+	}
+}
 
+// authorize 校验用户状态、会话和权限，通过后写入上下文继续处理
+func (s *service) authorize(ctx *gin.Context, claims *Claims, permission uint8) {
+	var (
+		uid      = claims.UserId
+		username = claims.Username
+		version  = claims.UserVersion
+	)
+
+	// 验证用户是否存在且激活
+	var user = new(models.User)
+	if err := s.db.WithContext(ctx).Where("id", uid).Where("status", 1).First(user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logger.Warn("user not found or inactive", zap.Int64("user_id", uid))
 			ctx.JSON(http.StatusUnauthorized, gin.H{
 				"code": http.StatusUnauthorized,
-				"msg":  "用户信息已更新，请重新登录",
+				"msg":  "用户不存在或已禁用",
 			})
 
 			ctx.Abort()
 
 			return
 		}
+		s.logger.Error("database error during token check", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"code": http.StatusInternalServerError,
+			"msg":  "Token验证失败",
+		})
+
+		ctx.Abort()
+
+		return
+	}
+
+	if user.Version > version {
+		s.logger.Warn("user version mismatch",
+			zap.Int64("user_id", uid),
+			zap.Int("user_version", user.Version),
+			zap.Int("token_version", version))
+
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"code": http.StatusUnauthorized,
+			"msg":  "用户信息已更新，请重新登录",
+		})
+
+		ctx.Abort()
+
+		return
+	}
 
-		//检查权限够不够 (位计算)
-		if user.Permissions&permission == 0 {
-			s.logger.Warn("insufficient permissions",
-				zap.Int64("user_id", uid),
-				zap.String("username", username),
-				zap.Uint8("required_permissions", permission),
-				zap.Uint8("user_permissions", user.Permissions))
-
-			ctx.JSON(http.StatusForbidden, gin.H{
-				"code": http.StatusForbidden,
-				"msg":  "权限不足",
+	// 会话被注销后，已签发的访问Token随之失效
+	session, err := s.activeSession(ctx, claims.SessionId, uid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusUnauthorized, gin.H{
+				"code": http.StatusUnauthorized,
+				"msg":  "会话已失效，请重新登录",
 			})
 
 			ctx.Abort()
@@ -112,14 +149,45 @@ func (s *service) AuthMiddleware(permission uint8) gin.HandlerFunc {
 			return
 		}
 
-		ctx.Set("user_id", uid)
-		ctx.Set("username", username)
-		ctx.Set("permissions", user.Permissions)
-		ctx.Set("group_id", user.GroupID)
-		ctx.Set(consts.CtxKeyGroupId, user.GroupID)
+		s.logger.Error("database error during session check", zap.Error(err))
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"code": http.StatusInternalServerError,
+			"msg":  "Token验证失败",
+		})
+
+		ctx.Abort()
+
+		return
+	}
+
+	s.touchSession(ctx, session)
+
+	//检查权限够不够 (位计算)
+	if user.Permissions&permission == 0 {
+		s.logger.Warn("insufficient permissions",
+			zap.Int64("user_id", uid),
+			zap.String("username", username),
+			zap.Uint8("required_permissions", permission),
+			zap.Uint8("user_permissions", user.Permissions))
+
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"code": http.StatusForbidden,
+			"msg":  "权限不足",
+		})
 
-		ctx.Next()
+		ctx.Abort()
+
+		return
 	}
+
+	ctx.Set("user_id", uid)
+	ctx.Set("username", username)
+	ctx.Set("session_id", session.ID)
+	ctx.Set("permissions", user.Permissions)
+	ctx.Set("group_id", user.GroupID)
+	ctx.Set(consts.CtxKeyGroupId, user.GroupID)
+
+	ctx.Next()
 }
 
 func (s *service) BasicAuthMiddleware(permission uint8) gin.HandlerFunc {
@@ -144,10 +212,27 @@ func (s *service) BasicAuthMiddleware(permission uint8) gin.HandlerFunc {
 			return
 		}
 
+		// 最近校验通过的账号密码直接放行，WebDAV 客户端每个请求都会带上认证信息
+		user, ok := cachedCredential(username, password)
+		if ok {
+			s.setBasicAuthUser(ctx, user, permission)
+
+			return
+		}
+
+		if wait, locked := authguard.Check(ctx, username); locked {
+			ctx.Header("WWW-Authenticate", `Basic realm="Restricted"`)
+			abortLocked(ctx, wait)
+
+			return
+		}
+
 		// 验证用户是否存在且激活
-		var user = new(models.User)
+		user = new(models.User)
 		if err := s.db.WithContext(ctx).Where("username", username).Where("status", 1).First(user).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
+				authguard.Fail(ctx, models.LoginFailureSourceDav, username, authguard.ReasonUserNotFound)
+
 				ctx.JSON(http.StatusUnauthorized, gin.H{
 					"code": http.StatusUnauthorized,
 					"msg":  "用户不存在或已禁用",
@@ -170,6 +255,8 @@ func (s *service) BasicAuthMiddleware(permission uint8) gin.HandlerFunc {
 		}
 
 		if hash(password) != user.Password {
+			authguard.Fail(ctx, models.LoginFailureSourceDav, username, authguard.ReasonBadPassword)
+
 			ctx.JSON(http.StatusUnauthorized, gin.H{
 				"code": http.StatusUnauthorized,
 				"msg":  "密码错误",
@@ -180,29 +267,37 @@ func (s *service) BasicAuthMiddleware(permission uint8) gin.HandlerFunc {
 			return
 		}
 
-		//检查权限够不够 (位计算)
-		if user.Permissions&permission == 0 {
-			s.logger.Warn("insufficient permissions",
-				zap.Int64("user_id", user.ID),
-				zap.String("username", username),
-				zap.Uint8("required_permissions", permission),
-				zap.Uint8("user_permissions", user.Permissions))
-
-			ctx.JSON(http.StatusForbidden, gin.H{
-				"code": http.StatusForbidden,
-				"msg":  "权限不足",
-			})
+		authguard.Succeed(username)
+		cacheCredential(username, password, user)
 
-			ctx.Abort()
+		s.setBasicAuthUser(ctx, user, permission)
+	}
+}
 
-			return
-		}
+// setBasicAuthUser 检查权限并写入用户信息
+func (s *service) setBasicAuthUser(ctx *gin.Context, user *models.User, permission uint8) {
+	//检查权限够不够 (位计算)
+	if user.Permissions&permission == 0 {
+		s.logger.Warn("insufficient permissions",
+			zap.Int64("user_id", user.ID),
+			zap.String("username", user.Username),
+			zap.Uint8("required_permissions", permission),
+			zap.Uint8("user_permissions", user.Permissions))
 
-		ctx.Set("user_id", user.ID)
-		ctx.Set("username", username)
-		ctx.Set("permissions", user.Permissions)
-		ctx.Set("group_id", user.GroupID)
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"code": http.StatusForbidden,
+			"msg":  "权限不足",
+		})
 
-		ctx.Next()
+		ctx.Abort()
+
+		return
 	}
+
+	ctx.Set("user_id", user.ID)
+	ctx.Set("username", user.Username)
+	ctx.Set("permissions", user.Permissions)
+	ctx.Set("group_id", user.GroupID)
+
+	ctx.Next()
 }
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 	"go.uber.org/zap"
 )
@@ -66,6 +67,8 @@ func (s *service) BindGroup() gin.HandlerFunc {
 			groupName = "默认用户组"
 		}
 
+		beforeGroupID := user.GroupID
+
 		// 更新用户的 group_id
 		result := s.db.WithContext(ctx).Model(&user).Update("group_id", req.GroupID)
 		if result.Error != nil {
@@ -87,6 +90,20 @@ func (s *service) BindGroup() gin.HandlerFunc {
 			zap.String("group_name", groupName),
 			zap.Int64("rows_affected", result.RowsAffected))
 
+		FlushCredentialCache()
+
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditUserBindGroup,
+			TargetType: audit.TargetUser,
+			TargetId:   user.ID,
+			TargetName: user.Username,
+			Before:     beforeGroupID,
+			After: gin.H{
+				"groupId":   req.GroupID,
+				"groupName": groupName,
+			},
+		})
+
 		ctx.JSON(http.StatusOK, &bindGroupResponse{
 			UserID:       req.UserID,
 			GroupID:      req.GroupID,
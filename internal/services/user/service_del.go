@@ -4,7 +4,9 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
 )
 
 type delRequest struct {
@@ -36,6 +38,10 @@ func (s *service) Del() gin.HandlerFunc {
 			return
 		}
 
+		// 删除前的信息用于审计，不存在时 Find 不报错，交由删除结果处理
+		before := new(models.User)
+		s.db.WithContext(ctx).Where("id = ?", req.ID).Limit(1).Find(before)
+
 		result := s.db.WithContext(ctx).Where("id = ?", req.ID).Delete(&models.User{})
 		if result.Error != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{
@@ -46,6 +52,29 @@ func (s *service) Del() gin.HandlerFunc {
 			return
 		}
 
+		if result.RowsAffected > 0 {
+			FlushCredentialCache()
+
+			if err := s.db.WithContext(ctx).Where("user_id = ?", req.ID).Delete(new(models.Session)).Error; err != nil {
+				s.logger.Error("删除用户会话失败", zap.Int64("user_id", req.ID), zap.Error(err))
+			}
+
+			if err := s.db.WithContext(ctx).Where("user_id = ?", req.ID).Delete(new(models.ShareLink)).Error; err != nil {
+				s.logger.Error("删除用户分享链接失败", zap.Int64("user_id", req.ID), zap.Error(err))
+			}
+
+			audit.Record(ctx, audit.Entry{
+				Action:     models.AuditUserDelete,
+				TargetType: audit.TargetUser,
+				TargetId:   req.ID,
+				TargetName: before.Username,
+				Before: gin.H{
+					"permissions": before.Permissions,
+					"groupId":     before.GroupID,
+				},
+			})
+		}
+
 		ctx.JSON(http.StatusOK, &delResponse{
 			RowsAffected: result.RowsAffected,
 		})
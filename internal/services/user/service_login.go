@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/authguard"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -23,6 +24,16 @@ type loginResponse struct {
 	TokenType    string       `json:"tokenType"`
 	ExpiresIn    int64        `json:"expiresIn"`
 	User         *models.User `json:"user"`
+	// RecoveryCodes 登录时按要求绑定两步验证后返回的恢复码，只出现这一次
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
+
+// 需要两步验证时的登录响应，不含访问Token
+type twoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"twoFactorRequired"`
+	SetupRequired     bool   `json:"setupRequired"` // 管理员被要求启用但尚未绑定，需先绑定
+	ChallengeToken    string `json:"challengeToken"`
+	ExpiresIn         int64  `json:"expiresIn"`
 }
 
 // Login 用户登录
@@ -38,11 +49,19 @@ func (s *service) Login() gin.HandlerFunc {
 			return
 		}
 
+		if wait, locked := authguard.Check(ctx, req.Username); locked {
+			abortLocked(ctx, wait)
+
+			return
+		}
+
 		var user = new(models.User)
 		if err := s.db.WithContext(ctx).Where("username", req.Username).First(user).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				s.logger.Warn("login failed - user not found", zap.String("username", req.Username))
 
+				authguard.Fail(ctx, models.LoginFailureSourceLogin, req.Username, authguard.ReasonUserNotFound)
+
 				ctx.JSON(http.StatusUnauthorized, gin.H{
 					"code": http.StatusUnauthorized,
 					"msg":  "用户名或密码错误",
@@ -67,6 +86,8 @@ func (s *service) Login() gin.HandlerFunc {
 				zap.String("username", req.Username),
 				zap.Int64("user_id", user.ID))
 
+			authguard.Fail(ctx, models.LoginFailureSourceLogin, req.Username, authguard.ReasonBadPassword)
+
 			ctx.JSON(http.StatusUnauthorized, gin.H{
 				"code": http.StatusUnauthorized,
 				"msg":  "用户名或密码错误",
@@ -87,36 +108,67 @@ func (s *service) Login() gin.HandlerFunc {
 			return
 		}
 
-		// 生成访问Token
-		accessToken, err := s.generateAccessToken(user.ID, user.Username, user.Version)
-		if err != nil {
-			s.logger.Error("failed to generate access token", zap.Error(err))
+		if user.TOTPEnabled || requireTwoFactor(user) {
+			challengeToken, err := s.generateChallengeToken(user)
+			if err != nil {
+				s.logger.Error("failed to generate challenge token", zap.Error(err))
 
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"code": http.StatusInternalServerError,
-				"msg":  "Token生成失败",
-			})
+				ctx.JSON(http.StatusInternalServerError, gin.H{
+					"code": http.StatusInternalServerError,
+					"msg":  "Token生成失败",
+				})
 
-			return
-		}
+				return
+			}
 
-		// 生成刷新Token
-		refreshToken, err := s.generateRefreshToken(user.ID, user.Username, user.Version)
-		if err != nil {
-			s.logger.Error("failed to generate refresh token", zap.Error(err))
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"code": http.StatusInternalServerError,
-				"msg":  "Token生成失败",
+			ctx.JSON(http.StatusOK, &twoFactorChallengeResponse{
+				TwoFactorRequired: true,
+				SetupRequired:     !user.TOTPEnabled,
+				ChallengeToken:    challengeToken,
+				ExpiresIn:         int64(ChallengeTokenExpire.Seconds()),
 			})
+
 			return
 		}
 
-		ctx.JSON(http.StatusOK, &loginResponse{
-			AccessToken:  accessToken,
-			RefreshToken: refreshToken,
-			TokenType:    "Bearer",
-			ExpiresIn:    int64(AccessTokenExpire.Seconds()),
-			User:         user,
+		s.respondLogin(ctx, user, nil)
+	}
+}
+
+// respondLogin 认证全部通过后创建会话，签发访问和刷新Token
+func (s *service) respondLogin(ctx *gin.Context, user *models.User, recoveryCodes []string) {
+	authguard.Succeed(user.Username)
+
+	session, err := s.createSession(ctx, user)
+	if err != nil {
+		s.logger.Error("failed to create session", zap.Error(err))
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"code": http.StatusInternalServerError,
+			"msg":  "登录失败",
 		})
+
+		return
 	}
+
+	accessToken, refreshToken, err := s.generateTokens(user, session)
+	if err != nil {
+		s.logger.Error("failed to generate token", zap.Error(err))
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"code": http.StatusInternalServerError,
+			"msg":  "Token生成失败",
+		})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, &loginResponse{
+		AccessToken:   accessToken,
+		RefreshToken:  refreshToken,
+		TokenType:     "Bearer",
+		ExpiresIn:     int64(AccessTokenExpire.Seconds()),
+		User:          user,
+		RecoveryCodes: recoveryCodes,
+	})
 }
@@ -0,0 +1,129 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/xxcheng123/cloudpan189-share/internal/authguard"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+)
+
+type loginTwoFactorRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	Code           string `json:"code" binding:"required_without=RecoveryCode,omitempty,len=6"`
+	RecoveryCode   string `json:"recoveryCode" binding:"omitempty,max=32"`
+}
+
+// LoginTwoFactor 登录第二步：提交验证码或恢复码换取访问Token；
+// 被要求绑定的管理员在 LoginTwoFactorSetup 获取密钥后，用首个验证码在这里完成绑定并登录
+func (s *service) LoginTwoFactor() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(loginTwoFactorRequest)
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		user, ok := s.challengeUser(ctx, req.ChallengeToken)
+		if !ok {
+			return
+		}
+
+		if wait, locked := authguard.Check(ctx, user.Username); locked {
+			abortLocked(ctx, wait)
+
+			return
+		}
+
+		if !user.TOTPEnabled {
+			if !requireTwoFactor(user) || req.RecoveryCode != "" {
+				ctx.JSON(http.StatusBadRequest, gin.H{
+					"code": http.StatusBadRequest,
+					"msg":  "未启用两步验证",
+				})
+				return
+			}
+
+			codes, ok := s.enableTwoFactor(ctx, user, req.Code)
+			if !ok {
+				return
+			}
+
+			s.respondLogin(ctx, user, codes)
+
+			return
+		}
+
+		if err := s.verifySecondFactor(ctx, user, req.Code, req.RecoveryCode); err != nil {
+			if errors.Is(err, errInvalidCode) {
+				authguard.Fail(ctx, models.LoginFailureSourceLogin, user.Username, authguard.ReasonBadCode)
+			}
+
+			ctx.JSON(http.StatusUnauthorized, gin.H{
+				"code": http.StatusUnauthorized,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		s.respondLogin(ctx, user, nil)
+	}
+}
+
+type loginTwoFactorSetupRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+}
+
+// LoginTwoFactorSetup 被要求启用两步验证但尚未绑定的管理员，在登录过程中获取密钥
+func (s *service) LoginTwoFactorSetup() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(loginTwoFactorSetupRequest)
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		user, ok := s.challengeUser(ctx, req.ChallengeToken)
+		if !ok {
+			return
+		}
+
+		if user.TOTPEnabled || !requireTwoFactor(user) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  "无需绑定两步验证",
+			})
+			return
+		}
+
+		s.beginTwoFactorSetup(ctx, user)
+	}
+}
+
+// challengeUser 校验两步验证令牌，写入 user_id 供审计使用
+func (s *service) challengeUser(ctx *gin.Context, token string) (*models.User, bool) {
+	user, err := s.parseChallengeToken(ctx, token)
+	if err != nil {
+		s.logger.Warn("invalid challenge token", zap.Error(err))
+
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"code": http.StatusUnauthorized,
+			"msg":  "登录已过期，请重新输入密码",
+		})
+
+		return nil, false
+	}
+
+	ctx.Set("user_id", user.ID)
+	ctx.Set("username", user.Username)
+
+	return user, true
+}
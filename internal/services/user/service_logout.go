@@ -0,0 +1,31 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+)
+
+// Logout 退出登录，注销当前会话，其访问和刷新Token立即失效
+func (s *service) Logout() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		sid := ctx.GetInt64("session_id")
+
+		if _, err := revokeSessions(s.db.WithContext(ctx).Where("id = ?", sid), models.SessionRevokeLogout); err != nil {
+			s.logger.Error("logout failure", zap.Int64("session_id", sid), zap.Error(err))
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "退出登录失败",
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"code": http.StatusOK,
+			"msg":  "已退出登录",
+		})
+	}
+}
@@ -98,6 +98,9 @@ func (s *service) ModifyOwnPass() gin.HandlerFunc {
 			return
 		}
 
+		FlushCredentialCache()
+		s.revokeUserSessions(ctx, uid, models.SessionRevokeCredential)
+
 		ctx.JSON(http.StatusOK, &modifyOwnPassResponse{
 			RowsAffected: result.RowsAffected,
 		})
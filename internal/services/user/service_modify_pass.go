@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 	"go.uber.org/zap"
 )
@@ -60,6 +61,15 @@ func (s *service) ModifyPass() gin.HandlerFunc {
 			return
 		}
 
+		FlushCredentialCache()
+		s.revokeUserSessions(ctx, req.ID, models.SessionRevokeCredential)
+
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditUserModifyPass,
+			TargetType: audit.TargetUser,
+			TargetId:   req.ID,
+		})
+
 		ctx.JSON(http.StatusOK, &modifyPassResponse{
 			RowsAffected: result.RowsAffected,
 		})
@@ -36,7 +36,7 @@ func (s *service) RefreshToken() gin.HandlerFunc {
 		}
 
 		// 解析刷新Token
-		uid, _, version, err := s.parseRefreshToken(req.RefreshToken)
+		claims, err := s.parseRefreshToken(req.RefreshToken)
 		if err != nil {
 			s.logger.Warn("invalid refresh token", zap.Error(err))
 			ctx.JSON(http.StatusUnauthorized, gin.H{
@@ -47,6 +47,26 @@ func (s *service) RefreshToken() gin.HandlerFunc {
 			return
 		}
 
+		uid := claims.UserId
+
+		// 会话上线前签发的刷新Token没有会话，需重新登录
+		session, err := s.activeSession(ctx, claims.SessionId, uid)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, gin.H{
+				"code": http.StatusUnauthorized,
+				"msg":  "会话已失效，请重新登录",
+			})
+
+			return
+		}
+
+		// 刷新Token只能使用一次，已轮换的再次出现说明可能泄露，注销整个会话
+		if session.TokenID != claims.ID {
+			s.revokeReusedSession(ctx, session)
+
+			return
+		}
+
 		// 获取用户信息
 		var user = new(models.User)
 		if err := s.db.WithContext(ctx).First(user, uid).Error; err != nil {
@@ -69,7 +89,7 @@ func (s *service) RefreshToken() gin.HandlerFunc {
 			return
 		}
 
-		if user.Version > version {
+		if user.Version > claims.UserVersion {
 			s.logger.Warn("user version mismatch during token refresh", zap.Int64("user_id", uid))
 			ctx.JSON(http.StatusUnauthorized, gin.H{
 				"code": http.StatusUnauthorized,
@@ -79,10 +99,19 @@ func (s *service) RefreshToken() gin.HandlerFunc {
 			return
 		}
 
-		// 生成新的Token
-		accessToken, err := s.generateAccessToken(user.ID, user.Username, user.Version)
+		// 开启强制两步验证后，未绑定的管理员需重新登录完成绑定
+		if requireTwoFactor(user) && !user.TOTPEnabled {
+			ctx.JSON(http.StatusUnauthorized, gin.H{
+				"code": http.StatusUnauthorized,
+				"msg":  "请重新登录并绑定两步验证",
+			})
+
+			return
+		}
+
+		rotated, err := s.rotateSession(ctx, session, claims.ID)
 		if err != nil {
-			s.logger.Error("failed to generate access token", zap.Error(err))
+			s.logger.Error("failed to rotate session", zap.Error(err))
 			ctx.JSON(http.StatusInternalServerError, gin.H{
 				"code": http.StatusInternalServerError,
 				"msg":  "Token生成失败",
@@ -91,9 +120,17 @@ func (s *service) RefreshToken() gin.HandlerFunc {
 			return
 		}
 
-		newRefreshToken, err := s.generateRefreshToken(user.ID, user.Username, user.Version)
+		// 同一刷新Token被并发使用，只有一个请求能完成轮换
+		if !rotated {
+			s.revokeReusedSession(ctx, session)
+
+			return
+		}
+
+		// 生成新的Token
+		accessToken, newRefreshToken, err := s.generateTokens(user, session)
 		if err != nil {
-			s.logger.Error("failed to generate refresh token", zap.Error(err))
+			s.logger.Error("failed to generate token", zap.Error(err))
 			ctx.JSON(http.StatusInternalServerError, gin.H{
 				"code": http.StatusInternalServerError,
 				"msg":  "Token生成失败",
@@ -113,3 +150,20 @@ func (s *service) RefreshToken() gin.HandlerFunc {
 		})
 	}
 }
+
+// revokeReusedSession 检测到已作废的刷新Token被使用时注销会话，持有最新Token的一方也需重新登录
+func (s *service) revokeReusedSession(ctx *gin.Context, session *models.Session) {
+	if _, err := revokeSessions(s.db.WithContext(ctx).Where("id = ?", session.ID), models.SessionRevokeReuse); err != nil {
+		s.logger.Error("failed to revoke session", zap.Int64("session_id", session.ID), zap.Error(err))
+	}
+
+	s.logger.Warn("refresh token reuse detected, session revoked",
+		zap.Int64("user_id", session.UserID),
+		zap.Int64("session_id", session.ID),
+		zap.String("ip", ctx.ClientIP()))
+
+	ctx.JSON(http.StatusUnauthorized, gin.H{
+		"code": http.StatusUnauthorized,
+		"msg":  "刷新Token已被使用，会话已注销，请重新登录",
+	})
+}
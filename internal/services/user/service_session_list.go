@@ -0,0 +1,117 @@
+package user
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+)
+
+type sessionListRequest struct {
+	UserID      int64 `form:"userId" binding:"omitempty,min=1"`
+	OnlyActive  bool  `form:"onlyActive" binding:"omitempty"` // 只看未注销且未过期的会话
+	CurrentPage int   `form:"currentPage" binding:"omitempty"`
+	PageSize    int   `form:"pageSize" binding:"omitempty,max=100"`
+}
+
+type sessionListItem struct {
+	*models.Session
+	Username string `json:"username"`
+}
+
+type sessionListResponse struct {
+	Total       int64              `json:"total"`
+	CurrentPage int                `json:"currentPage"`
+	PageSize    int                `json:"pageSize"`
+	Data        []*sessionListItem `json:"data"`
+}
+
+// SessionList 管理员查看所有用户的会话，按最近活跃时间倒序
+func (s *service) SessionList() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(sessionListRequest)
+		if err := ctx.ShouldBindQuery(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		if req.CurrentPage <= 0 {
+			req.CurrentPage = 1
+		}
+
+		if req.PageSize <= 0 {
+			req.PageSize = 10
+		}
+
+		query := s.db.WithContext(ctx).Model(&models.Session{})
+
+		if req.UserID > 0 {
+			query = query.Where("user_id = ?", req.UserID)
+		}
+
+		if req.OnlyActive {
+			query = query.Where("revoked_at IS NULL AND expires_at > ?", time.Now())
+		}
+
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+			return
+		}
+
+		var list = make([]*models.Session, 0)
+		if err := query.Order("last_seen_at DESC").
+			Offset((req.CurrentPage - 1) * req.PageSize).
+			Limit(req.PageSize).
+			Find(&list).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+			return
+		}
+
+		// 补充用户名
+		var users = make([]*models.User, 0)
+		if len(list) > 0 {
+			userIds := lo.Uniq(lo.Map(list, func(item *models.Session, _ int) int64 {
+				return item.UserID
+			}))
+
+			if err := s.db.WithContext(ctx).Select("id", "username").Where("id IN ?", userIds).Find(&users).Error; err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{
+					"code": http.StatusInternalServerError,
+					"msg":  "查询用户失败",
+				})
+				return
+			}
+		}
+
+		names := lo.SliceToMap(users, func(user *models.User) (int64, string) {
+			return user.ID, user.Username
+		})
+
+		data := make([]*sessionListItem, 0, len(list))
+		for _, item := range list {
+			data = append(data, &sessionListItem{
+				Session:  item,
+				Username: names[item.UserID],
+			})
+		}
+
+		ctx.JSON(http.StatusOK, &sessionListResponse{
+			Total:       count,
+			CurrentPage: req.CurrentPage,
+			PageSize:    req.PageSize,
+			Data:        data,
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+)
+
+type sessionRevokeRequest struct {
+	ID int64 `json:"id" binding:"required,min=1"`
+}
+
+// SessionRevoke 注销会话，普通用户只能注销自己的，管理员可注销任意用户的
+func (s *service) SessionRevoke() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(sessionRevokeRequest)
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		var (
+			uid            = ctx.GetInt64("user_id")
+			permissions, _ = ctx.Value("permissions").(uint8)
+			isAdmin        = permissions&models.PermissionAdmin != 0
+		)
+
+		var session = new(models.Session)
+		if err := s.db.WithContext(ctx).Where("id = ?", req.ID).First(session).Error; err != nil || (session.UserID != uid && !isAdmin) {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"code": http.StatusNotFound,
+				"msg":  "会话不存在",
+			})
+			return
+		}
+
+		rows, err := revokeSessions(s.db.WithContext(ctx).Where("id = ?", session.ID), models.SessionRevokeManual)
+		if err != nil {
+			s.logger.Error("session revoke failure", zap.Int64("session_id", session.ID), zap.Error(err))
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "注销会话失败",
+			})
+			return
+		}
+
+		if rows > 0 && session.UserID != uid {
+			var user = new(models.User)
+			s.db.WithContext(ctx).Select("id", "username").Where("id = ?", session.UserID).Limit(1).Find(user)
+
+			audit.Record(ctx, audit.Entry{
+				Action:     models.AuditSessionRevoke,
+				TargetType: audit.TargetSession,
+				TargetId:   session.ID,
+				TargetName: user.Username,
+				Before: gin.H{
+					"device": session.Device,
+					"ip":     session.IP,
+				},
+			})
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"code": http.StatusOK,
+			"msg":  "已注销",
+		})
+	}
+}
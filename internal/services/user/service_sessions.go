@@ -0,0 +1,49 @@
+package user
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+)
+
+type sessionResponse struct {
+	*models.Session
+	Current bool `json:"current"` // 是否为发起请求的会话
+}
+
+// Sessions 当前用户未注销且未过期的会话
+func (s *service) Sessions() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var (
+			uid = ctx.GetInt64("user_id")
+			sid = ctx.GetInt64("session_id")
+		)
+
+		var list = make([]*models.Session, 0)
+		if err := s.db.WithContext(ctx).
+			Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", uid, time.Now()).
+			Order("last_seen_at DESC").
+			Find(&list).Error; err != nil {
+			s.logger.Error("session query failure", zap.Error(err))
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+			return
+		}
+
+		data := make([]*sessionResponse, 0, len(list))
+		for _, item := range list {
+			data = append(data, &sessionResponse{
+				Session: item,
+				Current: item.ID == sid,
+			})
+		}
+
+		ctx.JSON(http.StatusOK, data)
+	}
+}
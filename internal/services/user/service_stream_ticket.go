@@ -0,0 +1,92 @@
+package user
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/shared"
+	"go.uber.org/zap"
+)
+
+// StreamTicketExpire 实时事件凭证的有效期，只在建立连接时校验，断线重连需重新获取
+const StreamTicketExpire = time.Minute
+
+type streamTicketResponse struct {
+	Ticket    string `json:"ticket"`
+	ExpiresIn int64  `json:"expiresIn"` // 秒
+}
+
+// StreamTicket 签发建立 SSE 连接用的短期凭证，放在 URL 的 ticket 参数中
+func (s *service) StreamTicket() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var user = new(models.User)
+		if err := s.db.WithContext(ctx).Where("id", ctx.GetInt64("user_id")).First(user).Error; err != nil {
+			s.logger.Error("查询用户失败", zap.Int64("user_id", ctx.GetInt64("user_id")), zap.Error(err))
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询用户失败",
+			})
+
+			return
+		}
+
+		claims := &Claims{
+			UserId:      user.ID,
+			Username:    user.Username,
+			UserVersion: user.Version,
+			SessionId:   ctx.GetInt64("session_id"),
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(StreamTicketExpire)),
+				IssuedAt:  jwt.NewNumericDate(time.Now()),
+				NotBefore: jwt.NewNumericDate(time.Now()),
+				Issuer:    "auth-service",
+				Subject:   "stream-ticket",
+			},
+		}
+
+		ticket, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(shared.Setting.SaltKey))
+		if err != nil {
+			s.logger.Error("签发实时事件凭证失败", zap.Error(err))
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "签发凭证失败",
+			})
+
+			return
+		}
+
+		ctx.JSON(http.StatusOK, &streamTicketResponse{
+			Ticket:    ticket,
+			ExpiresIn: int64(StreamTicketExpire.Seconds()),
+		})
+	}
+}
+
+// parseStreamTicket 解析实时事件凭证，访问Token和刷新Token不能用于此处
+func (s *service) parseStreamTicket(tokenString string) (*Claims, error) {
+	if tokenString == "" {
+		return nil, fmt.Errorf("missing stream ticket")
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return []byte(shared.Setting.SaltKey), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid || claims.Subject != "stream-ticket" {
+		return nil, fmt.Errorf("invalid stream ticket")
+	}
+
+	return claims, nil
+}
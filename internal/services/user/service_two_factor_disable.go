@@ -0,0 +1,114 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+)
+
+type twoFactorDisableRequest struct {
+	Password     string `json:"password" binding:"required,min=6,max=20"`
+	Code         string `json:"code" binding:"required_without=RecoveryCode,omitempty,len=6"`
+	RecoveryCode string `json:"recoveryCode" binding:"omitempty,max=32"`
+}
+
+// TwoFactorDisable 关闭当前用户的两步验证，需要密码和验证码（或恢复码）
+func (s *service) TwoFactorDisable() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(twoFactorDisableRequest)
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		user, ok := s.currentUser(ctx)
+		if !ok {
+			return
+		}
+
+		if !user.TOTPEnabled {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  "未启用两步验证",
+			})
+			return
+		}
+
+		if requireTwoFactor(user) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  "系统要求管理员启用两步验证，不能关闭",
+			})
+			return
+		}
+
+		if user.Password != hash(req.Password) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  "密码错误",
+			})
+			return
+		}
+
+		if err := s.verifySecondFactor(ctx, user, req.Code, req.RecoveryCode); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		if err := s.clearTwoFactor(ctx, user.ID, false); err != nil {
+			s.logger.Error("disable two factor failure", zap.Error(err))
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "关闭两步验证失败",
+			})
+			return
+		}
+
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditUserTwoFactorOff,
+			TargetType: audit.TargetUser,
+			TargetId:   user.ID,
+			TargetName: user.Username,
+		})
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"code": http.StatusOK,
+			"msg":  "已关闭两步验证",
+		})
+	}
+}
+
+// clearTwoFactor 清除密钥和恢复码；logout 为 true 时同时使已签发的登录令牌失效
+func (s *service) clearTwoFactor(ctx *gin.Context, uid int64, logout bool) error {
+	updates := map[string]any{
+		"totp_enabled":   false,
+		"totp_secret":    "",
+		"recovery_codes": "",
+	}
+
+	if logout {
+		updates["version"] = s.db.Raw("version + 1")
+	}
+
+	if err := s.db.WithContext(ctx).Model(new(models.User)).Where("id = ?", uid).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	FlushCredentialCache()
+
+	if logout {
+		s.revokeUserSessions(ctx, uid, models.SessionRevokeCredential)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+)
+
+type twoFactorEnableRequest struct {
+	Code string `json:"code" binding:"required,len=6"`
+}
+
+type twoFactorEnableResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"` // 只返回这一次
+}
+
+// TwoFactorEnable 用验证器应用生成的验证码确认密钥，通过后启用两步验证并返回恢复码
+func (s *service) TwoFactorEnable() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(twoFactorEnableRequest)
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		user, ok := s.currentUser(ctx)
+		if !ok {
+			return
+		}
+
+		codes, ok := s.enableTwoFactor(ctx, user, req.Code)
+		if !ok {
+			return
+		}
+
+		ctx.JSON(http.StatusOK, &twoFactorEnableResponse{
+			RecoveryCodes: codes,
+		})
+	}
+}
+
+// enableTwoFactor 校验待验证的密钥并启用，失败时已写入响应
+func (s *service) enableTwoFactor(ctx *gin.Context, user *models.User, code string) ([]string, bool) {
+	if user.TOTPEnabled {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": http.StatusBadRequest,
+			"msg":  "已启用两步验证",
+		})
+		return nil, false
+	}
+
+	if user.TOTPSecret == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": http.StatusBadRequest,
+			"msg":  "请先获取密钥",
+		})
+		return nil, false
+	}
+
+	if err := s.verifyTOTP(ctx, user, code); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"code": http.StatusBadRequest,
+			"msg":  err.Error(),
+		})
+		return nil, false
+	}
+
+	codes, digests := generateRecoveryCodes()
+
+	if err := s.db.WithContext(ctx).Model(new(models.User)).
+		Where("id = ?", user.ID).
+		Updates(map[string]any{
+			"totp_enabled":   true,
+			"recovery_codes": digests,
+		}).Error; err != nil {
+		s.logger.Error("enable two factor failure", zap.Error(err))
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"code": http.StatusInternalServerError,
+			"msg":  "启用两步验证失败",
+		})
+		return nil, false
+	}
+
+	user.TOTPEnabled = true
+	user.RecoveryCodes = digests
+
+	FlushCredentialCache()
+
+	audit.Record(ctx, audit.Entry{
+		Action:     models.AuditUserTwoFactorOn,
+		TargetType: audit.TargetUser,
+		TargetId:   user.ID,
+		TargetName: user.Username,
+	})
+
+	return codes, true
+}
@@ -0,0 +1,70 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+)
+
+type twoFactorRecoveryCodesRequest struct {
+	Code string `json:"code" binding:"required,len=6"`
+}
+
+type twoFactorRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// TwoFactorRecoveryCodes 重新生成恢复码，旧的恢复码全部作废
+func (s *service) TwoFactorRecoveryCodes() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(twoFactorRecoveryCodesRequest)
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		user, ok := s.currentUser(ctx)
+		if !ok {
+			return
+		}
+
+		if !user.TOTPEnabled {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  "未启用两步验证",
+			})
+			return
+		}
+
+		if err := s.verifyTOTP(ctx, user, req.Code); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		codes, digests := generateRecoveryCodes()
+
+		if err := s.db.WithContext(ctx).Model(new(models.User)).
+			Where("id = ?", user.ID).
+			Update("recovery_codes", digests).Error; err != nil {
+			s.logger.Error("recovery codes save failure", zap.Error(err))
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "恢复码生成失败",
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, &twoFactorRecoveryCodesResponse{
+			RecoveryCodes: codes,
+		})
+	}
+}
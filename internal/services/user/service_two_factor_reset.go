@@ -0,0 +1,62 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+)
+
+type twoFactorResetRequest struct {
+	ID int64 `json:"id" binding:"required,min=1"`
+}
+
+// TwoFactorReset 管理员为丢失验证器的用户解除两步验证，该用户已登录的会话随之失效
+func (s *service) TwoFactorReset() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(twoFactorResetRequest)
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		var user = new(models.User)
+		if err := s.db.WithContext(ctx).Where("id = ?", req.ID).First(user).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"code": http.StatusNotFound,
+				"msg":  "用户不存在",
+			})
+			return
+		}
+
+		if err := s.clearTwoFactor(ctx, user.ID, true); err != nil {
+			s.logger.Error("reset two factor failure", zap.Error(err))
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "解除两步验证失败",
+			})
+			return
+		}
+
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditUserTwoFactorReset,
+			TargetType: audit.TargetUser,
+			TargetId:   user.ID,
+			TargetName: user.Username,
+			Before: gin.H{
+				"totpEnabled": user.TOTPEnabled,
+			},
+		})
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"code": http.StatusOK,
+			"msg":  "已解除两步验证",
+		})
+	}
+}
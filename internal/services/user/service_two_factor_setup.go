@@ -0,0 +1,66 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/totp"
+	"go.uber.org/zap"
+)
+
+type twoFactorSetupResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"` // otpauth 地址，前端生成二维码供验证器应用扫描
+}
+
+// TwoFactorSetup 为当前用户生成待验证的密钥，调用 TwoFactorEnable 验证通过后才启用
+func (s *service) TwoFactorSetup() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := s.currentUser(ctx)
+		if !ok {
+			return
+		}
+
+		if user.TOTPEnabled {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  "已启用两步验证，如需更换请先关闭",
+			})
+			return
+		}
+
+		s.beginTwoFactorSetup(ctx, user)
+	}
+}
+
+// beginTwoFactorSetup 生成并保存待验证的密钥，重复调用会替换之前未验证的密钥
+func (s *service) beginTwoFactorSetup(ctx *gin.Context, user *models.User) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		s.logger.Error("failed to generate totp secret", zap.Error(err))
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"code": http.StatusInternalServerError,
+			"msg":  "密钥生成失败",
+		})
+		return
+	}
+
+	if err = s.db.WithContext(ctx).Model(new(models.User)).
+		Where("id = ? AND totp_enabled = ?", user.ID, false).
+		Update("totp_secret", secret).Error; err != nil {
+		s.logger.Error("totp secret save failure", zap.Error(err))
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"code": http.StatusInternalServerError,
+			"msg":  "密钥保存失败",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, &twoFactorSetupResponse{
+		Secret: secret,
+		URI:    totp.URI(totpIssuer, user.Username, secret),
+	})
+}
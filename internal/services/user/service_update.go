@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 )
 
@@ -47,6 +48,9 @@ func (s *service) Update() gin.HandlerFunc {
 			return
 		}
 
+		before := new(models.User)
+		s.db.WithContext(ctx).Where("id = ?", req.ID).Limit(1).Find(before)
+
 		result := s.db.WithContext(ctx).Model(new(models.User)).Where("id = ?", req.ID).Updates(mp)
 		if result.Error != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{
@@ -57,6 +61,25 @@ func (s *service) Update() gin.HandlerFunc {
 			return
 		}
 
+		// 密码只记录是否修改
+		after := gin.H{"passwordChanged": req.Password != nil}
+		if req.Permissions != nil {
+			after["permissions"] = *req.Permissions
+		}
+
+		FlushCredentialCache()
+
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditUserUpdate,
+			TargetType: audit.TargetUser,
+			TargetId:   req.ID,
+			TargetName: before.Username,
+			Before: gin.H{
+				"permissions": before.Permissions,
+			},
+			After: after,
+		})
+
 		ctx.JSON(http.StatusOK, &updateResponse{
 			RowsAffected: result.RowsAffected,
 		})
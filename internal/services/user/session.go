@@ -0,0 +1,198 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// sessionRetention 已过期或已注销的会话保留时长，便于查看
+	sessionRetention = time.Hour * 24 * 30
+	// lastSeenInterval 访问时更新最近活跃时间的最小间隔，避免每个请求都写库
+	lastSeenInterval = time.Minute
+)
+
+// newTokenID 随机的刷新Token ID
+func newTokenID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// createSession 登录成功后创建会话，并清理该用户早已失效的会话
+func (s *service) createSession(ctx *gin.Context, user *models.User) (*models.Session, error) {
+	now := time.Now()
+	userAgent := truncate(ctx.Request.UserAgent(), 512)
+
+	session := &models.Session{
+		UserID:     user.ID,
+		TokenID:    newTokenID(),
+		Device:     deviceName(userAgent),
+		UserAgent:  userAgent,
+		IP:         ctx.ClientIP(),
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(RefreshTokenExpire),
+	}
+
+	if err := s.db.WithContext(ctx).Create(session).Error; err != nil {
+		return nil, err
+	}
+
+	deadline := now.Add(-sessionRetention)
+	if err := s.db.WithContext(ctx).
+		Where("user_id = ? AND (expires_at < ? OR revoked_at < ?)", user.ID, deadline, deadline).
+		Delete(new(models.Session)).Error; err != nil {
+		s.logger.Warn("清理过期会话失败", zap.Int64("user_id", user.ID), zap.Error(err))
+	}
+
+	return session, nil
+}
+
+// rotateSession 使用刷新Token后换发新的Token ID，旧的随之作废；返回 false 表示 tokenId 已不是当前值
+func (s *service) rotateSession(ctx *gin.Context, session *models.Session, tokenId string) (bool, error) {
+	var (
+		now       = time.Now()
+		newId     = newTokenID()
+		ip        = ctx.ClientIP()
+		userAgent = truncate(ctx.Request.UserAgent(), 512)
+	)
+
+	// 以旧的 Token ID 为条件，并发刷新时只有一个请求成功
+	result := s.db.WithContext(ctx).Model(new(models.Session)).
+		Where("id = ? AND token_id = ? AND revoked_at IS NULL", session.ID, tokenId).
+		Updates(map[string]any{
+			"token_id":     newId,
+			"device":       deviceName(userAgent),
+			"ip":           ip,
+			"user_agent":   userAgent,
+			"last_seen_at": now,
+			"expires_at":   now.Add(RefreshTokenExpire),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	session.TokenID = newId
+	session.IP = ip
+	session.Device = deviceName(userAgent)
+	session.UserAgent = userAgent
+	session.LastSeenAt = now
+	session.ExpiresAt = now.Add(RefreshTokenExpire)
+
+	return true, nil
+}
+
+// activeSession 查询未注销且未过期的会话
+func (s *service) activeSession(ctx context.Context, id, uid int64) (*models.Session, error) {
+	var session = new(models.Session)
+
+	err := s.db.WithContext(ctx).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", id, uid, time.Now()).
+		First(session).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// touchSession 更新最近活跃时间和 IP，间隔不足 lastSeenInterval 时跳过
+func (s *service) touchSession(ctx *gin.Context, session *models.Session) {
+	if time.Since(session.LastSeenAt) < lastSeenInterval {
+		return
+	}
+
+	err := s.db.WithContext(ctx).Model(new(models.Session)).
+		Where("id = ?", session.ID).
+		Updates(map[string]any{
+			"last_seen_at": time.Now(),
+			"ip":           ctx.ClientIP(),
+		}).Error
+	if err != nil {
+		s.logger.Warn("更新会话活跃时间失败", zap.Int64("session_id", session.ID), zap.Error(err))
+	}
+}
+
+// revokeSessions 注销满足条件的未注销会话，返回注销数量
+func revokeSessions(query *gorm.DB, reason models.SessionRevokeReason) (int64, error) {
+	result := query.Model(new(models.Session)).
+		Where("revoked_at IS NULL").
+		Updates(map[string]any{
+			"revoked_at":    time.Now(),
+			"revoke_reason": reason,
+		})
+
+	return result.RowsAffected, result.Error
+}
+
+// revokeUserSessions 注销用户的全部会话，用于修改密码等使已签发Token全部失效的场景
+func (s *service) revokeUserSessions(ctx context.Context, uid int64, reason models.SessionRevokeReason) {
+	if _, err := revokeSessions(s.db.WithContext(ctx).Where("user_id = ?", uid), reason); err != nil {
+		s.logger.Error("注销用户会话失败", zap.Int64("user_id", uid), zap.Error(err))
+	}
+}
+
+// deviceName 从 User-Agent 粗略识别浏览器和系统，仅用于会话列表展示
+func deviceName(userAgent string) string {
+	var browser, system string
+
+	switch {
+	case strings.Contains(userAgent, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(userAgent, "OPR/"):
+		browser = "Opera"
+	case strings.Contains(userAgent, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(userAgent, "Chrome/"):
+		browser = "Chrome"
+	case strings.Contains(userAgent, "Safari/"):
+		browser = "Safari"
+	case strings.Contains(userAgent, "curl/"):
+		browser = "curl"
+	}
+
+	switch {
+	case strings.Contains(userAgent, "Android"):
+		system = "Android"
+	case strings.Contains(userAgent, "iPhone"), strings.Contains(userAgent, "iPad"):
+		system = "iOS"
+	case strings.Contains(userAgent, "Windows"):
+		system = "Windows"
+	case strings.Contains(userAgent, "Mac OS X"):
+		system = "macOS"
+	case strings.Contains(userAgent, "Linux"):
+		system = "Linux"
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " / " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	default:
+		return "未知设备"
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	return strings.ToValidUTF8(s[:n], "")
+}
@@ -0,0 +1,208 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/totp"
+	"github.com/xxcheng123/cloudpan189-share/internal/shared"
+	"go.uber.org/zap"
+)
+
+const (
+	// ChallengeTokenExpire 密码验证通过后，完成第二步验证的时限
+	ChallengeTokenExpire = time.Minute * 5
+
+	recoveryCodeCount = 10
+	totpIssuer        = "cloudpan189-share"
+)
+
+var (
+	errInvalidCode = errors.New("验证码错误")
+	errCodeReused  = errors.New("验证码已使用，请等待下一个验证码")
+)
+
+// requireTwoFactor 按设置管理员必须启用两步验证
+func requireTwoFactor(user *models.User) bool {
+	return shared.AdminRequireTwoFactor && user.Permissions&models.PermissionAdmin != 0
+}
+
+// generateChallengeToken 密码验证通过、尚未完成第二步时签发，只能用于两步验证接口
+func (s *service) generateChallengeToken(user *models.User) (string, error) {
+	claims := &Claims{
+		UserId:      user.ID,
+		Username:    user.Username,
+		UserVersion: user.Version,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ChallengeTokenExpire)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    "auth-service",
+			Subject:   "two-factor-challenge",
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(shared.Setting.SaltKey))
+}
+
+// parseChallengeToken 解析两步验证令牌，并确认用户仍然有效、密码未被修改
+func (s *service) parseChallengeToken(ctx context.Context, tokenString string) (*models.User, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return []byte(shared.Setting.SaltKey), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid || claims.Subject != "two-factor-challenge" {
+		return nil, fmt.Errorf("invalid challenge token")
+	}
+
+	var user = new(models.User)
+	if err = s.db.WithContext(ctx).Where("id", claims.UserId).Where("status", 1).First(user).Error; err != nil {
+		return nil, err
+	}
+
+	if user.Version > claims.UserVersion {
+		return nil, fmt.Errorf("user version changed")
+	}
+
+	return user, nil
+}
+
+// verifyTOTP 校验验证码并记录时间步，同一时间步的验证码只能用一次
+func (s *service) verifyTOTP(ctx context.Context, user *models.User, code string) error {
+	step, ok := totp.Validate(user.TOTPSecret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return errInvalidCode
+	}
+
+	result := s.db.WithContext(ctx).Model(new(models.User)).
+		Where("id = ? AND totp_last_step < ?", user.ID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errCodeReused
+	}
+
+	user.TOTPLastStep = step
+
+	return nil
+}
+
+// useRecoveryCode 校验恢复码，通过后作废
+func (s *service) useRecoveryCode(ctx context.Context, user *models.User, code string) error {
+	digest := hashRecoveryCode(code)
+	codes := strings.Split(user.RecoveryCodes, ",")
+
+	remain := make([]string, 0, len(codes))
+	for _, item := range codes {
+		if item != "" && item != digest {
+			remain = append(remain, item)
+		}
+	}
+
+	if len(remain) == len(codes) || digest == "" {
+		return errInvalidCode
+	}
+
+	// 带上原值作为条件，同一恢复码并发使用时只有一次成功
+	result := s.db.WithContext(ctx).Model(new(models.User)).
+		Where("id = ? AND recovery_codes = ?", user.ID, user.RecoveryCodes).
+		Update("recovery_codes", strings.Join(remain, ","))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errInvalidCode
+	}
+
+	user.RecoveryCodes = strings.Join(remain, ",")
+
+	return nil
+}
+
+// verifySecondFactor 验证码和恢复码二选一
+func (s *service) verifySecondFactor(ctx context.Context, user *models.User, code, recoveryCode string) error {
+	if recoveryCode != "" {
+		return s.useRecoveryCode(ctx, user, recoveryCode)
+	}
+
+	return s.verifyTOTP(ctx, user, code)
+}
+
+// generateRecoveryCodes 生成一组恢复码，返回明文和用于保存的摘要
+func generateRecoveryCodes() ([]string, string) {
+	const letters = "abcdefghjkmnpqrstuvwxyz23456789"
+
+	var (
+		codes   = make([]string, 0, recoveryCodeCount)
+		digests = make([]string, 0, recoveryCodeCount)
+	)
+
+	for range recoveryCodeCount {
+		b := make([]byte, 10)
+		_, _ = rand.Read(b)
+
+		for i := range b {
+			b[i] = letters[int(b[i])%len(letters)]
+		}
+
+		code := string(b[:5]) + "-" + string(b[5:])
+		codes = append(codes, code)
+		digests = append(digests, hashRecoveryCode(code))
+	}
+
+	return codes, strings.Join(digests, ",")
+}
+
+// hashRecoveryCode 忽略大小写、空格和连字符
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	if code == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(code))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// currentUser 认证中间件写入的当前用户
+func (s *service) currentUser(ctx *gin.Context) (*models.User, bool) {
+	uid := ctx.GetInt64("user_id")
+
+	var user = new(models.User)
+	if err := s.db.WithContext(ctx).Where("id = ?", uid).First(user).Error; err != nil {
+		s.logger.Error("user query failure", zap.Int64("user_id", uid), zap.Error(err))
+
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"code": http.StatusNotFound,
+			"msg":  "用户不存在",
+		})
+
+		return nil, false
+	}
+
+	return user, true
+}
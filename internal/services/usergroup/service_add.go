@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 	"go.uber.org/zap"
 )
@@ -41,6 +42,13 @@ func (s *service) Add() gin.HandlerFunc {
 			return
 		}
 
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditUserGroupAdd,
+			TargetType: audit.TargetUserGroup,
+			TargetId:   group.ID,
+			TargetName: group.Name,
+		})
+
 		ctx.JSON(http.StatusOK, &addResponse{
 			ID: group.ID,
 		})
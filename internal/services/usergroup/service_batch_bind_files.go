@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 	"go.uber.org/zap"
 )
@@ -52,6 +53,19 @@ func (s *service) BatchBindFiles() gin.HandlerFunc {
 			return
 		}
 
+		var beforeFileIDs []int64
+		s.db.WithContext(ctx).Model(&models.Group2File{}).Where("group_id = ?", req.GroupID).Pluck("file_id", &beforeFileIDs)
+
+		record := func(fileIDs []int64) {
+			audit.Record(ctx, audit.Entry{
+				Action:     models.AuditUserGroupBindFiles,
+				TargetType: audit.TargetUserGroup,
+				TargetId:   req.GroupID,
+				Before:     beforeFileIDs,
+				After:      fileIDs,
+			})
+		}
+
 		// 先删除该组的所有旧文件绑定关系
 		deleteResult := s.db.WithContext(ctx).Where("group_id = ?", req.GroupID).Delete(&models.Group2File{})
 		if deleteResult.Error != nil {
@@ -68,6 +82,8 @@ func (s *service) BatchBindFiles() gin.HandlerFunc {
 
 		// 如果文件ID列表为空，只删除不创建新绑定
 		if len(req.FileIDs) == 0 {
+			record([]int64{})
+
 			ctx.JSON(http.StatusOK, &batchBindFilesResponse{
 				GroupID:      req.GroupID,
 				BindCount:    0,
@@ -88,6 +104,8 @@ func (s *service) BatchBindFiles() gin.HandlerFunc {
 
 		// 如果去重后为空，只删除不创建新绑定
 		if len(uniqueFileIDs) == 0 {
+			record([]int64{})
+
 			ctx.JSON(http.StatusOK, &batchBindFilesResponse{
 				GroupID:      req.GroupID,
 				BindCount:    0,
@@ -124,6 +142,8 @@ func (s *service) BatchBindFiles() gin.HandlerFunc {
 			zap.Int("bind_count", len(uniqueFileIDs)),
 			zap.Int64("deleted_count", deletedCount))
 
+		record(uniqueFileIDs)
+
 		ctx.JSON(http.StatusOK, &batchBindFilesResponse{
 			GroupID:      req.GroupID,
 			BindCount:    len(uniqueFileIDs),
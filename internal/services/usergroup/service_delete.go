@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 	"go.uber.org/zap"
 )
@@ -50,6 +51,12 @@ func (s *service) Delete() gin.HandlerFunc {
 			return
 		}
 
+		before := new(models.UserGroup)
+		s.db.WithContext(ctx).Where("id = ?", req.ID).Limit(1).Find(before)
+
+		var beforeFileIDs []int64
+		s.db.WithContext(ctx).Model(&models.Group2File{}).Where("group_id = ?", req.ID).Pluck("file_id", &beforeFileIDs)
+
 		// 先删除该组的所有文件绑定关系
 		if err := s.db.WithContext(ctx).Where("group_id = ?", req.ID).Delete(&models.Group2File{}).Error; err != nil {
 			s.logger.Error("delete group file bindings failure", zap.Error(err))
@@ -82,6 +89,16 @@ func (s *service) Delete() gin.HandlerFunc {
 			return
 		}
 
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditUserGroupDelete,
+			TargetType: audit.TargetUserGroup,
+			TargetId:   req.ID,
+			TargetName: before.Name,
+			Before: gin.H{
+				"fileIds": beforeFileIDs,
+			},
+		})
+
 		ctx.JSON(http.StatusOK, &deleteResponse{
 			RowsAffected: result.RowsAffected,
 		})
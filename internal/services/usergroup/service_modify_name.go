@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/audit"
 	"github.com/xxcheng123/cloudpan189-share/internal/models"
 	"go.uber.org/zap"
 )
@@ -50,6 +51,9 @@ func (s *service) ModifyName() gin.HandlerFunc {
 			return
 		}
 
+		before := new(models.UserGroup)
+		s.db.WithContext(ctx).Where("id = ?", req.ID).Limit(1).Find(before)
+
 		// 执行更新操作
 		result := s.db.WithContext(ctx).Model(&models.UserGroup{}).
 			Where("id = ?", req.ID).
@@ -74,6 +78,15 @@ func (s *service) ModifyName() gin.HandlerFunc {
 			return
 		}
 
+		audit.Record(ctx, audit.Entry{
+			Action:     models.AuditUserGroupModifyName,
+			TargetType: audit.TargetUserGroup,
+			TargetId:   req.ID,
+			TargetName: req.Name,
+			Before:     before.Name,
+			After:      req.Name,
+		})
+
 		ctx.JSON(http.StatusOK, &modifyNameResponse{
 			RowsAffected: result.RowsAffected,
 		})
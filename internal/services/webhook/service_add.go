@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+)
+
+type addRequest struct {
+	Name           string   `json:"name" binding:"required,min=1,max=255"`
+	URL            string   `json:"url" binding:"required,url,max=1024"`
+	Secret         string   `json:"secret" binding:"max=255"`
+	Enabled        bool     `json:"enabled"`
+	Actions        []string `json:"actions" binding:"dive,oneof=create update delete"`
+	MountIds       []int64  `json:"mountIds"`
+	Exts           []string `json:"exts"`
+	MinSize        int64    `json:"minSize" binding:"min=0"`
+	IncludeFolders bool     `json:"includeFolders"`
+}
+
+type addResponse struct {
+	ID int64 `json:"id"`
+}
+
+func (s *service) Add() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(addRequest)
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		m := &models.Webhook{
+			Name:           req.Name,
+			URL:            req.URL,
+			Secret:         req.Secret,
+			Enabled:        req.Enabled,
+			Actions:        datatypes.NewJSONSlice(req.Actions),
+			MountIds:       datatypes.NewJSONSlice(req.MountIds),
+			Exts:           datatypes.NewJSONSlice(req.Exts),
+			MinSize:        req.MinSize,
+			IncludeFolders: req.IncludeFolders,
+		}
+
+		if err := s.db.WithContext(ctx).Create(m).Error; err != nil {
+			s.logger.Error("webhook create failure", zap.Error(err))
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "Webhook 创建失败",
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, &addResponse{
+			ID: m.ID,
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package webhook
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+)
+
+func (s *service) List() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var list = make([]*models.Webhook, 0)
+		if err := s.db.WithContext(ctx).Order("id ASC").Find(&list).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "查询失败",
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, list)
+	}
+}
@@ -0,0 +1,66 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/xxcheng123/cloudpan189-share/internal/changefeed"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+)
+
+type testRequest struct {
+	ID int64 `json:"id" binding:"required,min=1"`
+}
+
+// Test 向指定 Webhook 发送一条测试变更，不重试
+func (s *service) Test() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(testRequest)
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		m := new(models.Webhook)
+		if err := s.db.WithContext(ctx).Where("id = ?", req.ID).First(m).Error; err != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"code": http.StatusNotFound,
+				"msg":  "Webhook 不存在",
+			})
+			return
+		}
+
+		now := time.Now()
+
+		if _, err := changefeed.Deliver(ctx, m, &changefeed.Payload{
+			Delivery:  uuid.NewString(),
+			Action:    models.FileEventCreate,
+			Timestamp: now.Unix(),
+			Events: []*models.FileEvent{
+				{
+					Action:    models.FileEventCreate,
+					Name:      "test.mkv",
+					Path:      "/test/test.mkv",
+					CreatedAt: now,
+				},
+			},
+		}); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  fmt.Sprintf("发送失败：%s", err.Error()),
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"code": http.StatusOK,
+			"msg":  "发送成功",
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+)
+
+type updateRequest struct {
+	ID int64 `json:"id" binding:"required,min=1"`
+	addRequest
+}
+
+type updateResponse struct {
+	RowsAffected int64 `json:"rowsAffected"`
+}
+
+func (s *service) Update() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		req := new(updateRequest)
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"code": http.StatusBadRequest,
+				"msg":  err.Error(),
+			})
+			return
+		}
+
+		result := s.db.WithContext(ctx).Model(&models.Webhook{}).Where("id = ?", req.ID).Updates(map[string]interface{}{
+			"name":            req.Name,
+			"url":             req.URL,
+			"secret":          req.Secret,
+			"enabled":         req.Enabled,
+			"actions":         datatypes.NewJSONSlice(req.Actions),
+			"mount_ids":       datatypes.NewJSONSlice(req.MountIds),
+			"exts":            datatypes.NewJSONSlice(req.Exts),
+			"min_size":        req.MinSize,
+			"include_folders": req.IncludeFolders,
+		})
+		if result.Error != nil {
+			s.logger.Error("webhook update failure", zap.Error(result.Error))
+
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"code": http.StatusInternalServerError,
+				"msg":  "Webhook 修改失败",
+			})
+			return
+		}
+
+		if result.RowsAffected == 0 {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"code": http.StatusNotFound,
+				"msg":  "Webhook 不存在",
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, &updateResponse{
+			RowsAffected: result.RowsAffected,
+		})
+	}
+}
@@ -11,4 +11,11 @@ var (
 	StrmSupportFileExtList    []string = models.DefaultStrmSupportFileExtList
 	LinkFileAutoDelete        bool     = models.DefaultLinkFileAutoDelete
 	StrmBaseURL               string   = models.DefaultStrmBaseURL
+	TokenHealthCheckMinutes   int      = models.DefaultTokenHealthCheckMinutes
+	TokenExpireWarnDays       int      = models.DefaultTokenExpireWarnDays
+	MountUnhealthyActions     []string = models.DefaultMountUnhealthyActions
+	MirrorAutoDetect          bool     = models.DefaultMirrorAutoDetect
+	DuplicateHideTargets      []string = models.DefaultDuplicateHideTargets
+	FileEventRetentionDays    int      = models.DefaultFileEventRetentionDays
+	AdminRequireTwoFactor     bool     = models.DefaultAdminRequireTwoFactor
 )
@@ -0,0 +1,124 @@
+// Package stream 进程内的实时事件分发，供 SSE 推送任务、扫描进度和令牌登录结果
+package stream
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 事件主题
+const (
+	TopicBus   = "bus"   // 任务生命周期
+	TopicScan  = "scan"  // 扫描进度
+	TopicToken = "token" // 云盘令牌登录结果
+)
+
+var Topics = []string{TopicBus, TopicScan, TopicToken}
+
+// 订阅者缓冲区，写满后丢弃新消息，避免慢连接拖住发布方
+const subscriberBuffer = 64
+
+// Message 推送给订阅者的消息
+type Message struct {
+	ID    int64  `json:"id"`
+	Topic string `json:"topic"`
+	Type  string `json:"type"`
+	Time  int64  `json:"time"` // 毫秒
+	Data  any    `json:"data"`
+}
+
+// TokenLogin 令牌登录结果
+type TokenLogin struct {
+	TokenId   int64  `json:"tokenId"`
+	Name      string `json:"name"`
+	LoginType int8   `json:"loginType"`
+	Auto      bool   `json:"auto"` // 是否为定时自动登录
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+}
+
+type subscriber struct {
+	topics map[string]struct{}
+	ch     chan *Message
+}
+
+var (
+	mu          sync.RWMutex
+	subscribers = make(map[*subscriber]struct{})
+	counter     atomic.Int64
+
+	done      = make(chan struct{})
+	closeOnce sync.Once
+)
+
+// Done 服务关闭时关闭，长连接据此主动结束
+func Done() <-chan struct{} {
+	return done
+}
+
+// Close 通知所有长连接结束，用于优雅退出
+func Close() {
+	closeOnce.Do(func() {
+		close(done)
+	})
+}
+
+// Publish 向订阅了 topic 的连接广播消息，没有订阅者时直接返回
+func Publish(topic, typ string, data any) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if len(subscribers) == 0 {
+		return
+	}
+
+	msg := &Message{
+		ID:    counter.Add(1),
+		Topic: topic,
+		Type:  typ,
+		Time:  time.Now().UnixMilli(),
+		Data:  data,
+	}
+
+	for sub := range subscribers {
+		if _, ok := sub.topics[topic]; !ok {
+			continue
+		}
+
+		select {
+		case sub.ch <- msg:
+		default:
+		}
+	}
+}
+
+// Subscribe 订阅指定主题，topics 为空时订阅全部，返回的 cancel 需在连接断开后调用
+func Subscribe(topics []string) (<-chan *Message, func()) {
+	if len(topics) == 0 {
+		topics = Topics
+	}
+
+	sub := &subscriber{
+		topics: make(map[string]struct{}, len(topics)),
+		ch:     make(chan *Message, subscriberBuffer),
+	}
+
+	for _, topic := range topics {
+		sub.topics[topic] = struct{}{}
+	}
+
+	mu.Lock()
+	subscribers[sub] = struct{}{}
+	mu.Unlock()
+
+	var once sync.Once
+
+	return sub.ch, func() {
+		once.Do(func() {
+			mu.Lock()
+			delete(subscribers, sub)
+			mu.Unlock()
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package tokenpool
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/xxcheng123/cloudpan189-interface/client"
+)
+
+type Strategy = string
+
+const (
+	StrategyRoundRobin Strategy = "round_robin"
+	StrategyLRU        Strategy = "lru"
+)
+
+type ErrorKind = string
+
+const (
+	ErrorKindNone      ErrorKind = ""
+	ErrorKindExpired   ErrorKind = "expired"
+	ErrorKindThrottled ErrorKind = "throttled"
+	ErrorKindOther     ErrorKind = "other"
+)
+
+const (
+	expiredCooldown   = time.Minute * 10
+	throttledCooldown = time.Minute
+)
+
+// 天翼云盘返回的令牌失效错误码
+var expiredCodes = []string{
+	"InvalidSessionKey",
+	"InvalidAccessToken",
+	"InvalidToken",
+	"UserInvalidOpenToken",
+	"AccessTokenInvalid",
+	"TokenExpired",
+}
+
+// 天翼云盘返回的限流错误码
+var throttledCodes = []string{
+	"RequestTooFrequent",
+	"ErrorFrequently",
+	"FlowLimit",
+	"UserDayFlowOverLimited",
+	"DailyDownloadTimesLimit",
+	"ShareDumpFileOverload",
+}
+
+// Health 令牌健康状态，仅保存在内存中，重启后清零
+type Health struct {
+	SuccessCount   int64     `json:"successCount"`
+	FailureCount   int64     `json:"failureCount"`
+	ExpiredCount   int64     `json:"expiredCount"`
+	ThrottledCount int64     `json:"throttledCount"`
+	LastUsedAt     time.Time `json:"lastUsedAt"`
+	LastError      string    `json:"lastError"`
+	LastErrorAt    time.Time `json:"lastErrorAt"`
+	CooldownUntil  time.Time `json:"cooldownUntil"`
+}
+
+type pool struct {
+	mu     sync.Mutex
+	health map[int64]*Health
+	cursor map[int64]int
+}
+
+var defaultPool = &pool{
+	health: make(map[int64]*Health),
+	cursor: make(map[int64]int),
+}
+
+// Classify 根据云盘返回的错误判断是否令牌失效或被限流
+func Classify(err error) ErrorKind {
+	if err == nil {
+		return ErrorKindNone
+	}
+
+	var respErr *client.RespErr
+	if !errors.As(err, &respErr) {
+		return ErrorKindOther
+	}
+
+	codes := []string{respErr.Code, respErr.ErrorCode, respErr.Error_}
+	if v, ok := respErr.ResCode.(string); ok {
+		codes = append(codes, v)
+	}
+
+	for _, code := range codes {
+		if code == "" {
+			continue
+		}
+
+		for _, c := range expiredCodes {
+			if strings.EqualFold(code, c) {
+				return ErrorKindExpired
+			}
+		}
+
+		for _, c := range throttledCodes {
+			if strings.EqualFold(code, c) {
+				return ErrorKindThrottled
+			}
+		}
+	}
+
+	return ErrorKindOther
+}
+
+// Retryable 该错误是否应换一个令牌重试
+func Retryable(err error) bool {
+	kind := Classify(err)
+
+	return kind == ErrorKindExpired || kind == ErrorKindThrottled
+}
+
+// Select 按策略对候选令牌排序，冷却中的令牌排在最后；poolId 一般为挂载点ID
+func Select(poolId int64, ids []int64, strategy Strategy) []int64 {
+	ids = unique(ids)
+	if len(ids) <= 1 {
+		return ids
+	}
+
+	p := defaultPool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ordered := make([]int64, 0, len(ids))
+
+	switch strategy {
+	case StrategyLRU:
+		ordered = append(ordered, ids...)
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return p.lastUsedAt(ordered[i]).Before(p.lastUsedAt(ordered[j]))
+		})
+	default:
+		start := p.cursor[poolId] % len(ids)
+		p.cursor[poolId] = start + 1
+
+		ordered = append(ordered, ids[start:]...)
+		ordered = append(ordered, ids[:start]...)
+	}
+
+	now := time.Now()
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return !p.coolingDown(ordered[i], now) && p.coolingDown(ordered[j], now)
+	})
+
+	return ordered
+}
+
+// Report 记录一次令牌调用结果
+func Report(id int64, err error) {
+	p := defaultPool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	h := p.get(id)
+	now := time.Now()
+	h.LastUsedAt = now
+
+	if err == nil {
+		h.SuccessCount++
+		h.CooldownUntil = time.Time{}
+
+		return
+	}
+
+	h.FailureCount++
+	h.LastError = err.Error()
+	h.LastErrorAt = now
+
+	switch Classify(err) {
+	case ErrorKindExpired:
+		h.ExpiredCount++
+		h.CooldownUntil = now.Add(expiredCooldown)
+	case ErrorKindThrottled:
+		h.ThrottledCount++
+		h.CooldownUntil = now.Add(throttledCooldown)
+	}
+}
+
+// Reset 清除令牌的冷却状态，重新登录后调用
+func Reset(id int64) {
+	p := defaultPool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if h, ok := p.health[id]; ok {
+		h.CooldownUntil = time.Time{}
+	}
+}
+
+// GetHealth 获取令牌健康状态的副本
+func GetHealth(id int64) Health {
+	p := defaultPool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if h, ok := p.health[id]; ok {
+		return *h
+	}
+
+	return Health{}
+}
+
+func (p *pool) get(id int64) *Health {
+	h, ok := p.health[id]
+	if !ok {
+		h = new(Health)
+		p.health[id] = h
+	}
+
+	return h
+}
+
+func (p *pool) lastUsedAt(id int64) time.Time {
+	if h, ok := p.health[id]; ok {
+		return h.LastUsedAt
+	}
+
+	return time.Time{}
+}
+
+func (p *pool) coolingDown(id int64, now time.Time) bool {
+	if h, ok := p.health[id]; ok {
+		return h.CooldownUntil.After(now)
+	}
+
+	return false
+}
+
+func unique(ids []int64) []int64 {
+	seen := make(map[int64]struct{}, len(ids))
+	result := make([]int64, 0, len(ids))
+
+	for _, id := range ids {
+		if id <= 0 {
+			continue
+		}
+
+		if _, ok := seen[id]; ok {
+			continue
+		}
+
+		seen[id] = struct{}{}
+		result = append(result, id)
+	}
+
+	return result
+}
@@ -0,0 +1,136 @@
+package tokenpool
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/xxcheng123/cloudpan189-interface/client"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorKind
+	}{
+		{"nil", nil, ErrorKindNone},
+		{"plain error", errors.New("dial tcp: timeout"), ErrorKindOther},
+		{"expired code", &client.RespErr{Code: "InvalidSessionKey"}, ErrorKindExpired},
+		{"expired error code ignores case", &client.RespErr{ErrorCode: "invalidaccesstoken"}, ErrorKindExpired},
+		{"expired string res_code", &client.RespErr{ResCode: "UserInvalidOpenToken"}, ErrorKindExpired},
+		{"throttled error field", &client.RespErr{Error_: "RequestTooFrequent"}, ErrorKindThrottled},
+		{"throttled daily limit", &client.RespErr{Code: "DailyDownloadTimesLimit"}, ErrorKindThrottled},
+		{"numeric res_code", &client.RespErr{ResCode: 1, ResMessage: "InvalidSessionKey"}, ErrorKindOther},
+		{"other business error", &client.RespErr{Code: "FileNotFound"}, ErrorKindOther},
+		{"wrapped", errors.Wrap(&client.RespErr{Code: "TokenExpired"}, "get download url"), ErrorKindExpired},
+		{"fmt wrapped", fmt.Errorf("list: %w", &client.RespErr{Code: "FlowLimit"}), ErrorKindThrottled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Errorf("Classify() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("eof"), false},
+		{&client.RespErr{Code: "InvalidToken"}, true},
+		{&client.RespErr{Code: "ShareDumpFileOverload"}, true},
+		{&client.RespErr{Code: "FileNotFound"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := Retryable(tt.err); got != tt.want {
+			t.Errorf("Retryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestSelectRoundRobin(t *testing.T) {
+	const poolId = 1001
+
+	ids := []int64{101, 102, 103}
+
+	want := [][]int64{
+		{101, 102, 103},
+		{102, 103, 101},
+		{103, 101, 102},
+		{101, 102, 103},
+	}
+
+	for i, w := range want {
+		if got := Select(poolId, ids, StrategyRoundRobin); !reflect.DeepEqual(got, w) {
+			t.Errorf("round %d: Select() = %v, want %v", i, got, w)
+		}
+	}
+}
+
+func TestSelectFiltersAndDeduplicates(t *testing.T) {
+	tests := []struct {
+		name string
+		ids  []int64
+		want []int64
+	}{
+		{"empty", nil, []int64{}},
+		{"invalid ids dropped", []int64{0, -1, 201}, []int64{201}},
+		{"duplicates dropped", []int64{202, 202}, []int64{202}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Select(2001, tt.ids, StrategyRoundRobin); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Select() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectLRU(t *testing.T) {
+	Report(303, nil)
+	time.Sleep(time.Millisecond)
+	Report(301, nil)
+
+	// 从未使用过的 302 最先，其后按上次使用时间
+	want := []int64{302, 303, 301}
+	if got := Select(3001, []int64{301, 302, 303}, StrategyLRU); !reflect.DeepEqual(got, want) {
+		t.Errorf("Select() = %v, want %v", got, want)
+	}
+}
+
+func TestSelectCoolingDownLast(t *testing.T) {
+	Report(401, &client.RespErr{Code: "InvalidSessionKey"})
+	Report(402, &client.RespErr{Code: "RequestTooFrequent"})
+	Report(403, errors.New("connection reset"))
+
+	want := []int64{403, 401, 402}
+	if got := Select(4001, []int64{401, 402, 403}, StrategyLRU); !reflect.DeepEqual(got, want) {
+		t.Errorf("Select() = %v, want %v", got, want)
+	}
+
+	h := GetHealth(401)
+	if h.ExpiredCount != 1 || h.FailureCount != 1 || !h.CooldownUntil.After(time.Now()) {
+		t.Errorf("expired token health = %+v", h)
+	}
+
+	if h = GetHealth(403); !h.CooldownUntil.IsZero() {
+		t.Errorf("other errors should not cool down, got %v", h.CooldownUntil)
+	}
+
+	Reset(401)
+	Report(402, nil)
+
+	if got := Select(4002, []int64{401, 402}, StrategyRoundRobin); !reflect.DeepEqual(got, []int64{401, 402}) {
+		t.Errorf("after reset Select() = %v, want [401 402]", got)
+	}
+}
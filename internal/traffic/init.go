@@ -0,0 +1,38 @@
+package traffic
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/xxcheng123/cloudpan189-share/configs"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/ratelimit"
+	"go.uber.org/zap"
+)
+
+var onceLoad sync.Once
+
+var singletonManager *manager
+
+func Init() {
+	onceLoad.Do(func() {
+		singletonManager = &manager{
+			db:      configs.DB(),
+			logger:  configs.Logger().With(zap.String("module", "traffic")),
+			cache:   cache.New(time.Minute, time.Minute*10),
+			limits:  make(map[scopeKey]*models.TrafficLimit),
+			buckets: make(map[scopeKey]*ratelimit.Bucket),
+			streams: make(map[scopeKey]int),
+			pending: make(map[usageKey]*usageDelta),
+			sources: make(map[sourceKey]int64),
+		}
+
+		if err := singletonManager.reload(context.Background()); err != nil {
+			singletonManager.logger.Error("加载流量限制规则失败", zap.Error(err))
+		}
+
+		go singletonManager.flushLoop()
+	})
+}
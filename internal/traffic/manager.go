@@ -0,0 +1,393 @@
+package traffic
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	"github.com/xxcheng123/cloudpan189-share/internal/models"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/ratelimit"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// flushInterval 流量批量写库的间隔，配额检查因此最多滞后这么久
+const flushInterval = time.Second * 10
+
+var (
+	ErrQuotaExceeded  = errors.New("本月流量配额已用完")
+	ErrTooManyStreams = errors.New("并发下载数已达上限")
+)
+
+type manager struct {
+	db     *gorm.DB
+	logger *zap.Logger
+	cache  *cache.Cache
+
+	mu      sync.Mutex
+	limits  map[scopeKey]*models.TrafficLimit
+	buckets map[scopeKey]*ratelimit.Bucket
+	streams map[scopeKey]int
+
+	// pending 尚未写库的流量，按 flushInterval 批量写入，避免每个下载请求都写一次库
+	pendingMu sync.Mutex
+	pending   map[usageKey]*usageDelta
+	// sources 尚未写库的下载来源请求数，与流量一起批量写入
+	sources map[sourceKey]int64
+}
+
+type usageKey struct {
+	UserId int64
+	FileId int64
+	Day    string
+}
+
+type sourceKey struct {
+	FileId       int64
+	SourceFileId int64
+	Day          string
+}
+
+type usageDelta struct {
+	Bytes    int64
+	Requests int64
+}
+
+type scopeKey struct {
+	Scope    models.TrafficScope
+	TargetId int64
+}
+
+func (k scopeKey) String() string {
+	return fmt.Sprintf("%s:%d", k.Scope, k.TargetId)
+}
+
+// StreamStat 当前活跃流统计
+type StreamStat struct {
+	Scope    models.TrafficScope `json:"scope"`
+	TargetId int64               `json:"targetId"`
+	Streams  int                 `json:"streams"`
+}
+
+// Reload 重新加载限制规则，规则变更后调用
+func Reload(ctx context.Context) error {
+	if singletonManager == nil {
+		return nil
+	}
+
+	return singletonManager.reload(ctx)
+}
+
+// Acquire 为一次下载申请流，超出配额或并发数时返回错误；未初始化时不做任何限制
+func Acquire(ctx context.Context, userId, fileId int64) (*Stream, error) {
+	if singletonManager == nil {
+		return &Stream{}, nil
+	}
+
+	return singletonManager.acquire(ctx, userId, fileId)
+}
+
+// Flush 立即写入尚未落库的流量，退出前调用
+func Flush(ctx context.Context) {
+	if singletonManager == nil {
+		return
+	}
+
+	singletonManager.flush(ctx)
+}
+
+// RecordSource 累加一次下载请求的实际来源，由 flush 批量落库；未初始化时不记录
+func RecordSource(fileId, sourceFileId int64) {
+	if singletonManager == nil {
+		return
+	}
+
+	singletonManager.recordSource(fileId, sourceFileId)
+}
+
+// Stats 当前各范围的活跃流数量
+func Stats() []StreamStat {
+	if singletonManager == nil {
+		return []StreamStat{}
+	}
+
+	singletonManager.mu.Lock()
+	defer singletonManager.mu.Unlock()
+
+	stats := make([]StreamStat, 0, len(singletonManager.streams))
+	for sk, count := range singletonManager.streams {
+		stats = append(stats, StreamStat{
+			Scope:    sk.Scope,
+			TargetId: sk.TargetId,
+			Streams:  count,
+		})
+	}
+
+	return stats
+}
+
+func (m *manager) reload(ctx context.Context) error {
+	list := make([]*models.TrafficLimit, 0)
+	if err := m.db.WithContext(ctx).Find(&list).Error; err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.limits = make(map[scopeKey]*models.TrafficLimit, len(list))
+	for _, l := range list {
+		m.limits[scopeKey{Scope: l.Scope, TargetId: l.TargetId}] = l
+	}
+
+	// 已存在的令牌桶就地调整速率，保证正在进行的下载立即生效
+	for key, b := range m.buckets {
+		if l, ok := m.limits[key]; ok && l.RateLimit > 0 {
+			b.SetRate(l.RateLimit, l.Burst)
+		} else {
+			b.SetRate(0, 0)
+			delete(m.buckets, key)
+		}
+	}
+
+	m.cache.Flush()
+
+	return nil
+}
+
+func (m *manager) acquire(ctx context.Context, userId, fileId int64) (*Stream, error) {
+	scopes := []scopeKey{{Scope: models.TrafficScopeGlobal}}
+
+	if userId > 0 {
+		groupId, err := m.getUserGroupId(ctx, userId)
+		if err != nil {
+			return nil, err
+		}
+
+		scopes = append(scopes,
+			scopeKey{Scope: models.TrafficScopeGroup, TargetId: groupId},
+			scopeKey{Scope: models.TrafficScopeUser, TargetId: userId},
+		)
+	}
+
+	for _, sk := range scopes {
+		m.mu.Lock()
+		l := m.limits[sk]
+		m.mu.Unlock()
+
+		if l == nil || l.MonthlyQuota <= 0 {
+			continue
+		}
+
+		used, err := m.monthlyUsage(ctx, sk)
+		if err != nil {
+			return nil, err
+		}
+
+		if used >= l.MonthlyQuota {
+			return nil, errors.Wrapf(ErrQuotaExceeded, "%s", sk)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, sk := range scopes {
+		if l := m.limits[sk]; l != nil && l.MaxStreams > 0 && m.streams[sk] >= l.MaxStreams {
+			return nil, errors.Wrapf(ErrTooManyStreams, "%s", sk)
+		}
+	}
+
+	stream := &Stream{
+		m:      m,
+		userId: userId,
+		fileId: fileId,
+	}
+
+	for _, sk := range scopes {
+		m.streams[sk]++
+		stream.keys = append(stream.keys, sk)
+
+		l := m.limits[sk]
+		if l == nil || l.RateLimit <= 0 {
+			continue
+		}
+
+		b, ok := m.buckets[sk]
+		if !ok {
+			b = ratelimit.NewBucket(l.RateLimit, l.Burst)
+			m.buckets[sk] = b
+		}
+
+		stream.buckets = append(stream.buckets, b)
+	}
+
+	return stream, nil
+}
+
+func (m *manager) release(keys []scopeKey) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, sk := range keys {
+		if m.streams[sk] <= 1 {
+			delete(m.streams, sk)
+		} else {
+			m.streams[sk]--
+		}
+	}
+}
+
+// record 累加 用户/文件/天 的流量，先写入内存，由 flush 批量落库
+func (m *manager) record(userId, fileId, bytes int64) {
+	key := usageKey{
+		UserId: userId,
+		FileId: fileId,
+		Day:    time.Now().Format(time.DateOnly),
+	}
+
+	m.pendingMu.Lock()
+	defer m.pendingMu.Unlock()
+
+	delta, ok := m.pending[key]
+	if !ok {
+		delta = new(usageDelta)
+		m.pending[key] = delta
+	}
+
+	delta.Bytes += bytes
+	delta.Requests++
+}
+
+// recordSource 累加 文件/来源/天 的请求数，先写入内存，由 flush 批量落库
+func (m *manager) recordSource(fileId, sourceFileId int64) {
+	key := sourceKey{
+		FileId:       fileId,
+		SourceFileId: sourceFileId,
+		Day:          time.Now().Format(time.DateOnly),
+	}
+
+	m.pendingMu.Lock()
+	defer m.pendingMu.Unlock()
+
+	m.sources[key]++
+}
+
+func (m *manager) flushLoop() {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		m.flush(context.Background())
+	}
+}
+
+// flush 将内存中的流量和下载来源写入数据库
+func (m *manager) flush(ctx context.Context) {
+	m.pendingMu.Lock()
+	pending, sources := m.pending, m.sources
+	m.pending = make(map[usageKey]*usageDelta)
+	m.sources = make(map[sourceKey]int64)
+	m.pendingMu.Unlock()
+
+	for key, delta := range pending {
+		usage := &models.TrafficUsage{
+			UserId:   key.UserId,
+			FileId:   key.FileId,
+			Day:      key.Day,
+			Bytes:    delta.Bytes,
+			Requests: delta.Requests,
+		}
+
+		if err := m.db.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "file_id"}, {Name: "day"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"bytes":      gorm.Expr("bytes + ?", delta.Bytes),
+				"requests":   gorm.Expr("requests + ?", delta.Requests),
+				"updated_at": time.Now(),
+			}),
+		}).Create(usage).Error; err != nil {
+			m.logger.Error("记录流量失败",
+				zap.Int64("userId", key.UserId),
+				zap.Int64("fileId", key.FileId),
+				zap.Int64("bytes", delta.Bytes),
+				zap.Error(err))
+		}
+	}
+
+	for key, requests := range sources {
+		record := &models.DownloadSource{
+			FileId:       key.FileId,
+			SourceFileId: key.SourceFileId,
+			Day:          key.Day,
+			Requests:     requests,
+		}
+
+		if err := m.db.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "file_id"}, {Name: "source_file_id"}, {Name: "day"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"requests":   gorm.Expr("requests + ?", requests),
+				"updated_at": time.Now(),
+			}),
+		}).Create(record).Error; err != nil {
+			m.logger.Error("记录下载来源失败",
+				zap.Int64("fileId", key.FileId),
+				zap.Int64("sourceFileId", key.SourceFileId),
+				zap.Int64("requests", requests),
+				zap.Error(err))
+		}
+	}
+}
+
+func (m *manager) monthlyUsage(ctx context.Context, sk scopeKey) (int64, error) {
+	monthStart := time.Now().Format("2006-01") + "-01"
+	cacheKey := fmt.Sprintf("usage::%s::%s", sk, monthStart)
+
+	if v, ok := m.cache.Get(cacheKey); ok {
+		if used, ok := v.(int64); ok {
+			return used, nil
+		}
+	}
+
+	var used int64
+
+	query := m.db.WithContext(ctx).Model(&models.TrafficUsage{}).Where("day >= ?", monthStart)
+
+	switch sk.Scope {
+	case models.TrafficScopeUser:
+		query = query.Where("user_id = ?", sk.TargetId)
+	case models.TrafficScopeGroup:
+		query = query.Where("user_id IN (?)", m.db.Model(&models.User{}).Select("id").Where("group_id = ?", sk.TargetId))
+	}
+
+	if err := query.Select("COALESCE(SUM(bytes), 0)").Scan(&used).Error; err != nil {
+		return 0, err
+	}
+
+	m.cache.Set(cacheKey, used, time.Second*30)
+
+	return used, nil
+}
+
+func (m *manager) getUserGroupId(ctx context.Context, userId int64) (int64, error) {
+	cacheKey := fmt.Sprintf("user::group::%d", userId)
+
+	if v, ok := m.cache.Get(cacheKey); ok {
+		if groupId, ok := v.(int64); ok {
+			return groupId, nil
+		}
+	}
+
+	user := new(models.User)
+	if err := m.db.WithContext(ctx).Select("id", "group_id").Where("id = ?", userId).First(user).Error; err != nil {
+		return 0, err
+	}
+
+	m.cache.Set(cacheKey, user.GroupID, time.Minute)
+
+	return user.GroupID, nil
+}
@@ -0,0 +1,110 @@
+package traffic
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xxcheng123/cloudpan189-share/internal/pkgs/ratelimit"
+)
+
+// Stream 一次下载占用的流，负责限速和流量统计
+type Stream struct {
+	m       *manager
+	userId  int64
+	fileId  int64
+	keys    []scopeKey
+	buckets []*ratelimit.Bucket
+	written int64
+	once    sync.Once
+}
+
+// Wrap 包装响应写入器，写入时按令牌桶限速并累计字节数
+func (s *Stream) Wrap(ctx context.Context, w gin.ResponseWriter) gin.ResponseWriter {
+	if s.m == nil {
+		return w
+	}
+
+	return &responseWriter{
+		ResponseWriter: w,
+		ctx:            ctx,
+		stream:         s,
+	}
+}
+
+// Written 已写入的字节数
+func (s *Stream) Written() int64 {
+	return atomic.LoadInt64(&s.written)
+}
+
+// Close 释放并发占用并记录流量，可重复调用
+func (s *Stream) Close() {
+	if s.m == nil {
+		return
+	}
+
+	s.once.Do(func() {
+		s.m.release(s.keys)
+		s.m.record(s.userId, s.fileId, s.Written())
+	})
+}
+
+// chunkSize 单次写入的最大字节数，不超过所有令牌桶中最小的容量
+func (s *Stream) chunkSize(n int) int {
+	for _, b := range s.buckets {
+		if b.Unlimited() {
+			continue
+		}
+
+		if burst := b.Burst(); burst > 0 && burst < n {
+			n = burst
+		}
+	}
+
+	return n
+}
+
+func (s *Stream) wait(ctx context.Context, n int) error {
+	for _, b := range s.buckets {
+		if err := b.WaitN(ctx, n); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type responseWriter struct {
+	gin.ResponseWriter
+	ctx    context.Context
+	stream *Stream
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	var total int
+
+	for len(p) > 0 {
+		n := w.stream.chunkSize(len(p))
+
+		if err := w.stream.wait(w.ctx, n); err != nil {
+			return total, err
+		}
+
+		nw, err := w.ResponseWriter.Write(p[:n])
+		total += nw
+		atomic.AddInt64(&w.stream.written, int64(nw))
+
+		if err != nil {
+			return total, err
+		}
+
+		p = p[n:]
+	}
+
+	return total, nil
+}
+
+func (w *responseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}